@@ -14,6 +14,7 @@ import (
 	"tg-cloud-drive-api/internal/api"
 	"tg-cloud-drive-api/internal/config"
 	"tg-cloud-drive-api/internal/db"
+	"tg-cloud-drive-api/internal/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -62,7 +63,33 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	shutdownTracing := tracing.Init(tracing.Config{
+		Endpoint:    cfg.TracingEndpoint,
+		Headers:     cfg.TracingHeaders,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+		Logger:      logger,
+	})
+	defer func() {
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Warn("链路追踪导出未完成", slog.String("error", err.Error()))
+		}
+	}()
+	if cfg.TracingEndpoint != "" {
+		logger.Info("链路追踪已启用", slog.String("endpoint", cfg.TracingEndpoint), slog.Float64("sample_ratio", cfg.TracingSampleRatio))
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		logger.Error("数据库连接配置无效", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if cfg.TracingEndpoint != "" {
+		poolCfg.ConnConfig.Tracer = db.QueryTracer{}
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
 		logger.Error("数据库连接失败", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -103,13 +130,23 @@ func main() {
 
 	logger.Info("后端启动", slog.String("addr", server.Addr))
 
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
 		<-ctx.Done()
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		logger.Info("收到退出信号，开始优雅关闭", slog.Duration("drain_timeout", cfg.ShutdownDrainTimeout))
+
+		// 先停止领取新任务并结束 SSE 推送，再等待进行中的请求（如分片上传）完成，最后收尾后台任务。
+		app.BeginShutdown()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
 		defer shutdownCancel()
 
-		if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.Canceled) {
-			logger.Error("优雅关闭失败", slog.String("error", err.Error()))
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("等待进行中的请求超时，强制断开连接", slog.String("error", err.Error()))
+			_ = server.Close()
+		}
+		if err := app.Shutdown(shutdownCtx); err != nil {
+			logger.Warn("后台任务未在期限内结束，已取消", slog.String("error", err.Error()))
 		}
 	}()
 
@@ -117,6 +154,7 @@ func main() {
 		logger.Error("服务异常退出", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	<-shutdownDone
 
 	logger.Info("后端已退出")
 }
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/telegram"
+)
+
+const (
+	adminNotifyKindTorrentAwaitingSelection = "torrent_awaiting_selection"
+	adminNotifyKindTransferFailed           = "transfer_failed"
+	adminNotifyKindLowDisk                  = "low_disk"
+)
+
+const (
+	adminNotifyFlushInterval   = 30 * time.Second
+	adminNotifyCooldown        = 30 * time.Minute
+	adminNotifyQueueLimit      = 200
+	adminNotifyLinesPerMessage = 20
+	adminNotifyLineMaxBytes    = 300
+	adminNotifySendTimeout     = 30 * time.Second
+	notifyChatIDsMax           = 20
+)
+
+type adminNotification struct {
+	Kind string
+	// Key 用于冷却去重：同一个 Key 在 adminNotifyCooldown 内只通知一次。
+	Key  string
+	Text string
+}
+
+// adminNotifier 缓冲待发送的管理员通知，由后台循环按周期合并成一条消息发送，避免短时间内刷屏或触发限流。
+type adminNotifier struct {
+	mu         sync.Mutex
+	pending    []adminNotification
+	dropped    int
+	lastQueued map[string]time.Time
+}
+
+func newAdminNotifier() *adminNotifier {
+	return &adminNotifier{lastQueued: map[string]time.Time{}}
+}
+
+// enqueue 加入一条通知；冷却期内的重复 Key 会被忽略，队列满时只计数不保存。
+func (n *adminNotifier) enqueue(item adminNotification, now time.Time) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if last, ok := n.lastQueued[item.Key]; ok && now.Sub(last) < adminNotifyCooldown {
+		return false
+	}
+	n.lastQueued[item.Key] = now
+	if len(n.lastQueued) > adminNotifyQueueLimit*4 {
+		for key, at := range n.lastQueued {
+			if now.Sub(at) >= adminNotifyCooldown {
+				delete(n.lastQueued, key)
+			}
+		}
+	}
+	if len(n.pending) >= adminNotifyQueueLimit {
+		n.dropped++
+		return false
+	}
+	n.pending = append(n.pending, item)
+	return true
+}
+
+func (n *adminNotifier) drain() ([]adminNotification, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	items, dropped := n.pending, n.dropped
+	n.pending = nil
+	n.dropped = 0
+	return items, dropped
+}
+
+func (s *Server) notifyAdmins(kind string, key string, text string) {
+	s.adminNotifier.enqueue(adminNotification{
+		Kind: kind,
+		Key:  kind + ":" + key,
+		Text: truncateAuditText(strings.TrimSpace(text), adminNotifyLineMaxBytes),
+	}, time.Now())
+}
+
+func (s *Server) notifyTransferFailed(item transferJobViewDTO) {
+	reason := "未知错误"
+	if item.LastError != nil && strings.TrimSpace(*item.LastError) != "" {
+		reason = strings.TrimSpace(*item.LastError)
+	}
+	s.notifyAdmins(adminNotifyKindTransferFailed, item.ID, fmt.Sprintf("传输任务「%s」失败：%s", item.Name, reason))
+}
+
+// notifyLowDisk 在预留空间检查失败时提醒管理员；同一路径在冷却期内只提醒一次。
+func (s *Server) notifyLowDisk(path string, reservedBytes int64) {
+	text := fmt.Sprintf("磁盘空间不足：%s 可用空间低于预留值 %s", path, formatNotifyBytes(reservedBytes))
+	if freeBytes, err := getAvailableDiskBytes(path); err == nil {
+		text = fmt.Sprintf("磁盘空间不足：%s 剩余 %s，预留 %s", path, formatNotifyBytes(freeBytes), formatNotifyBytes(reservedBytes))
+	}
+	s.notifyAdmins(adminNotifyKindLowDisk, path, text)
+}
+
+func formatNotifyBytes(v int64) string {
+	const unit = 1024
+	if v < unit {
+		return fmt.Sprintf("%d B", v)
+	}
+	value := float64(v)
+	for _, suffix := range []string{"KB", "MB", "GB", "TB"} {
+		value /= unit
+		if value < unit || suffix == "TB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", v)
+}
+
+func adminNotifyKindEnabled(kind string, settings store.RuntimeSettings) bool {
+	switch kind {
+	case adminNotifyKindTorrentAwaitingSelection:
+		return settings.NotifyTorrentAwaitingSelection
+	case adminNotifyKindTransferFailed:
+		return settings.NotifyTransferFailed
+	case adminNotifyKindLowDisk:
+		return settings.NotifyLowDisk
+	default:
+		return false
+	}
+}
+
+func adminNotifyKindLabel(kind string) string {
+	switch kind {
+	case adminNotifyKindTorrentAwaitingSelection:
+		return "待选择文件"
+	case adminNotifyKindTransferFailed:
+		return "传输失败"
+	case adminNotifyKindLowDisk:
+		return "磁盘空间"
+	default:
+		return kind
+	}
+}
+
+// formatAdminNotificationMessage 把一批通知合并为一条纯文本消息，超出行数的部分只给出数量。
+func formatAdminNotificationMessage(items []adminNotification, dropped int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("TG Cloud Drive 通知（%d 条）\n", len(items)+dropped))
+	for i, item := range items {
+		if i >= adminNotifyLinesPerMessage {
+			break
+		}
+		b.WriteString(fmt.Sprintf("\n• [%s] %s", adminNotifyKindLabel(item.Kind), item.Text))
+	}
+	hidden := dropped
+	if len(items) > adminNotifyLinesPerMessage {
+		hidden += len(items) - adminNotifyLinesPerMessage
+	}
+	if hidden > 0 {
+		b.WriteString(fmt.Sprintf("\n\n另有 %d 条通知未显示。", hidden))
+	}
+	return b.String()
+}
+
+func (s *Server) startAdminNotifyLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopAdminNotify)
+			if !s.waitLoopInterval(adminNotifyFlushInterval) {
+				return
+			}
+			s.flushAdminNotifications(context.Background())
+		}
+	})
+}
+
+// flushAdminNotifications 取出缓冲的通知，按当前设置过滤后合并发送给每个通知会话。
+func (s *Server) flushAdminNotifications(ctx context.Context) {
+	items, dropped := s.adminNotifier.drain()
+	if len(items) == 0 && dropped == 0 {
+		return
+	}
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("get runtime settings for admin notifications failed", "error", err.Error())
+		}
+		return
+	}
+	if len(settings.NotifyChatIDs) == 0 {
+		return
+	}
+	enabled := make([]adminNotification, 0, len(items))
+	for _, item := range items {
+		if adminNotifyKindEnabled(item.Kind, settings) {
+			enabled = append(enabled, item)
+		}
+	}
+	if len(enabled) == 0 {
+		return
+	}
+	tgClient, err := s.requireTelegramClient()
+	if err != nil {
+		return
+	}
+
+	text := formatAdminNotificationMessage(enabled, dropped)
+	for _, chatID := range settings.NotifyChatIDs {
+		sendCtx, cancel := context.WithTimeout(ctx, adminNotifySendTimeout)
+		_, err := retryTelegramMessageSend(sendCtx, func() (telegram.Message, error) {
+			return tgClient.SendMessage(sendCtx, chatID, text, telegram.SendMessageOptions{})
+		})
+		cancel()
+		if err != nil {
+			s.logger.Warn("send admin notification failed", "error", err.Error(), "chat_id", chatID, "count", len(enabled))
+		}
+	}
+}
+
+// validateNotifyChatIDs 校验通知会话：数字 ID（可为负数的群组/频道 ID）或 @username。
+func validateNotifyChatIDs(ids []string) error {
+	if len(ids) > notifyChatIDsMax {
+		return fmt.Errorf("通知会话最多 %d 个", notifyChatIDsMax)
+	}
+	for _, raw := range ids {
+		id := strings.TrimSpace(raw)
+		if id == "" {
+			continue
+		}
+		if !isValidNotifyChatID(id) {
+			return fmt.Errorf("通知会话 ID 非法：%s（应为数字 ID 或 @用户名）", id)
+		}
+	}
+	return nil
+}
+
+func isValidNotifyChatID(id string) bool {
+	if username, ok := strings.CutPrefix(id, "@"); ok {
+		if len(username) < 5 || len(username) > 32 {
+			return false
+		}
+		for _, r := range username {
+			if !(r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+				return false
+			}
+		}
+		return true
+	}
+	digits := strings.TrimPrefix(id, "-")
+	if digits == "" || len(digits) > 20 {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
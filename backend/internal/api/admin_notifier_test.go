@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminNotifierEnqueueCooldownAndLimit(t *testing.T) {
+	t.Parallel()
+
+	n := newAdminNotifier()
+	now := time.Unix(1700000000, 0)
+	item := adminNotification{Kind: adminNotifyKindLowDisk, Key: "low_disk:/tmp", Text: "磁盘空间不足"}
+	if !n.enqueue(item, now) {
+		t.Fatalf("first enqueue should be accepted")
+	}
+	if n.enqueue(item, now.Add(time.Minute)) {
+		t.Fatalf("duplicate key within cooldown should be ignored")
+	}
+	if !n.enqueue(item, now.Add(adminNotifyCooldown)) {
+		t.Fatalf("duplicate key after cooldown should be accepted")
+	}
+
+	for i := 0; i < adminNotifyQueueLimit+5; i++ {
+		n.enqueue(adminNotification{Kind: adminNotifyKindTransferFailed, Key: fmt.Sprintf("transfer_failed:%d", i)}, now)
+	}
+	items, dropped := n.drain()
+	if len(items) != adminNotifyQueueLimit || dropped != 7 {
+		t.Fatalf("drain() = %d items, %d dropped; want %d, 7", len(items), dropped, adminNotifyQueueLimit)
+	}
+	if items, dropped := n.drain(); len(items) != 0 || dropped != 0 {
+		t.Fatalf("second drain should be empty, got %d/%d", len(items), dropped)
+	}
+}
+
+func TestFormatAdminNotificationMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		count      int
+		dropped    int
+		wantLines  int
+		wantHidden string
+	}{
+		{name: "single", count: 1, wantLines: 1},
+		{name: "truncated", count: adminNotifyLinesPerMessage + 3, wantLines: adminNotifyLinesPerMessage, wantHidden: "另有 3 条"},
+		{name: "dropped", count: 2, dropped: 4, wantLines: 2, wantHidden: "另有 4 条"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			items := make([]adminNotification, 0, tc.count)
+			for i := 0; i < tc.count; i++ {
+				items = append(items, adminNotification{Kind: adminNotifyKindTransferFailed, Text: fmt.Sprintf("任务 %d 失败", i)})
+			}
+			got := formatAdminNotificationMessage(items, tc.dropped)
+			if lines := strings.Count(got, "• [传输失败]"); lines != tc.wantLines {
+				t.Fatalf("lines = %d, want %d\n%s", lines, tc.wantLines, got)
+			}
+			if !strings.Contains(got, fmt.Sprintf("（%d 条）", tc.count+tc.dropped)) {
+				t.Fatalf("missing total in header:\n%s", got)
+			}
+			if tc.wantHidden == "" && strings.Contains(got, "另有") {
+				t.Fatalf("unexpected hidden note:\n%s", got)
+			}
+			if tc.wantHidden != "" && !strings.Contains(got, tc.wantHidden) {
+				t.Fatalf("missing %q:\n%s", tc.wantHidden, got)
+			}
+		})
+	}
+}
+
+func TestValidateNotifyChatIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ids     []string
+		wantErr bool
+	}{
+		{name: "empty", ids: nil},
+		{name: "user and channel ids", ids: []string{"123456789", "-1001234567890", " "}},
+		{name: "username", ids: []string{"@tgcd_admin"}},
+		{name: "short username", ids: []string{"@abc"}, wantErr: true},
+		{name: "not numeric", ids: []string{"12ab"}, wantErr: true},
+		{name: "too many", ids: strings.Split(strings.Repeat("1,", notifyChatIDsMax), ","), wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if err := validateNotifyChatIDs(tc.ids); (err != nil) != tc.wantErr {
+				t.Fatalf("validateNotifyChatIDs(%v) err = %v, wantErr %v", tc.ids, err, tc.wantErr)
+			}
+		})
+	}
+}
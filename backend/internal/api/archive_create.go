@@ -0,0 +1,255 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"syscall"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	archiveFormatZip    = "zip"
+	archiveFormatTarZst = "tar.zst"
+)
+
+var errArchiveZstdUnavailable = errors.New("未找到 zstd 命令，无法生成 tar.zst")
+
+// 已压缩的格式再做 Deflate 收益很小，直接存储
+var archiveStoredExtensions = map[string]struct{}{
+	".zip": {}, ".gz": {}, ".tgz": {}, ".bz2": {}, ".xz": {}, ".zst": {}, ".7z": {}, ".rar": {},
+	".jpg": {}, ".jpeg": {}, ".png": {}, ".webp": {}, ".heic": {}, ".avif": {},
+	".mp4": {}, ".mkv": {}, ".mov": {}, ".webm": {}, ".mp3": {}, ".aac": {}, ".m4a": {}, ".flac": {}, ".ogg": {},
+}
+
+type archiveSourceFile struct {
+	item store.Item
+	path string
+}
+
+type archiveSourceTree struct {
+	dirs  []store.Item
+	files []archiveSourceFile
+	// dirPaths 与 dirs 一一对应
+	dirPaths  []string
+	totalSize int64
+}
+
+func parseArchiveFormat(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", archiveFormatZip:
+		return archiveFormatZip, true
+	case archiveFormatTarZst, "tzst":
+		return archiveFormatTarZst, true
+	default:
+		return "", false
+	}
+}
+
+func archiveOutputName(folderName string, format string) string {
+	name := strings.TrimSpace(folderName)
+	if name == "" {
+		name = "archive"
+	}
+	return name + "." + format
+}
+
+func archiveOutputMimeType(format string) string {
+	if format == archiveFormatTarZst {
+		return "application/zstd"
+	}
+	return "application/zip"
+}
+
+// collectArchiveSourceTree 以根目录名作为压缩包内的顶层目录；
+// 普通目录打包时跳过其中的密码箱项目。
+func collectArchiveSourceTree(root store.Item, items []store.Item) archiveSourceTree {
+	tree := archiveSourceTree{}
+	rootPrefix := strings.TrimSuffix(root.Path, "/") + "/"
+	for _, item := range items {
+		if item.ID == root.ID {
+			continue
+		}
+		if item.InVault && !root.InVault {
+			continue
+		}
+		rel, ok := strings.CutPrefix(item.Path, rootPrefix)
+		if !ok || rel == "" {
+			continue
+		}
+		entryPath, ok := normalizeArchiveEntryPath(path.Join(root.Name, rel))
+		if !ok {
+			continue
+		}
+		if item.Type == store.ItemTypeFolder {
+			tree.dirs = append(tree.dirs, item)
+			tree.dirPaths = append(tree.dirPaths, entryPath)
+			continue
+		}
+		tree.files = append(tree.files, archiveSourceFile{item: item, path: entryPath})
+		tree.totalSize += item.Size
+	}
+	return tree
+}
+
+func zipMethodForArchiveEntry(name string) uint16 {
+	if _, ok := archiveStoredExtensions[strings.ToLower(path.Ext(name))]; ok {
+		return zip.Store
+	}
+	return zip.Deflate
+}
+
+// writeFolderArchive 逐个读取源文件分块写入压缩流；onFile 在每个文件写完后回调。
+func (s *Server) writeFolderArchive(
+	ctx context.Context,
+	w io.Writer,
+	format string,
+	tree archiveSourceTree,
+	onFile func(done int),
+) error {
+	switch format {
+	case archiveFormatZip:
+		return s.writeFolderZip(ctx, w, tree, onFile)
+	case archiveFormatTarZst:
+		return s.writeFolderTarZst(ctx, w, tree, onFile)
+	default:
+		return errArchiveUnsupported
+	}
+}
+
+func (s *Server) writeFolderZip(ctx context.Context, w io.Writer, tree archiveSourceTree, onFile func(done int)) error {
+	zw := zip.NewWriter(w)
+	for i, dir := range tree.dirs {
+		header := &zip.FileHeader{Name: tree.dirPaths[i] + "/", Method: zip.Store, Modified: dir.UpdatedAt}
+		if _, err := zw.CreateHeader(header); err != nil {
+			return err
+		}
+	}
+	for i, file := range tree.files {
+		header := &zip.FileHeader{
+			Name:     file.path,
+			Method:   zipMethodForArchiveEntry(file.path),
+			Modified: file.item.UpdatedAt,
+		}
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if err := s.copyArchiveSourceFile(ctx, entry, file.item); err != nil {
+			return err
+		}
+		if onFile != nil {
+			onFile(i + 1)
+		}
+	}
+	return zw.Close()
+}
+
+func (s *Server) writeFolderTarZst(ctx context.Context, w io.Writer, tree archiveSourceTree, onFile func(done int)) error {
+	binary := strings.TrimSpace(s.cfg.ZstdBinary)
+	if binary == "" {
+		binary = "zstd"
+	}
+	resolved, err := exec.LookPath(binary)
+	if err != nil {
+		return errArchiveZstdUnavailable
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, resolved, "-q", "-c", "-T0")
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	// 直接写子进程的 stdin 管道：zstd 因下游失败或 ctx 取消而退出后，写入立即返回 EPIPE，
+	// 不会像经 io.Pipe 中转那样在无人读取时永久阻塞。
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	writeErr := s.writeFolderTar(ctx, stdin, tree, onFile)
+	_ = stdin.Close()
+	waitErr := cmd.Wait()
+	if writeErr != nil && (waitErr == nil || !isBrokenPipeError(writeErr)) {
+		return writeErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("zstd 压缩失败: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// isBrokenPipeError 表示写入端因子进程已退出而失败，此时子进程的退出原因更有意义。
+func isBrokenPipeError(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, os.ErrClosed)
+}
+
+func (s *Server) writeFolderTar(ctx context.Context, w io.Writer, tree archiveSourceTree, onFile func(done int)) error {
+	tw := tar.NewWriter(w)
+	for i, dir := range tree.dirs {
+		header := &tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     tree.dirPaths[i] + "/",
+			Mode:     0o755,
+			ModTime:  dir.UpdatedAt,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+	}
+	for i, file := range tree.files {
+		header := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.path,
+			Mode:     0o644,
+			Size:     file.item.Size,
+			ModTime:  file.item.UpdatedAt,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := s.copyArchiveSourceFile(ctx, tw, file.item); err != nil {
+			return err
+		}
+		if onFile != nil {
+			onFile(i + 1)
+		}
+	}
+	return tw.Close()
+}
+
+func (s *Server) copyArchiveSourceFile(ctx context.Context, w io.Writer, item store.Item) error {
+	if item.Size <= 0 {
+		return nil
+	}
+	chunks, err := store.New(s.db).ListChunks(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("%s: 文件分块不存在", item.Name)
+	}
+	reader := s.openItemChunkReader(ctx, chunks, 0, item.Size-1)
+	defer reader.Close()
+	written, err := io.Copy(w, reader)
+	if err != nil {
+		return fmt.Errorf("%s: %w", item.Name, err)
+	}
+	if written != item.Size {
+		return fmt.Errorf("%s: 读取大小不一致", item.Name)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// runArchiveCreateJob 一边生成压缩流一边按分块上传，磁盘上最多只暂存一个分块。
+func (s *Server) runArchiveCreateJob(
+	ctx context.Context,
+	job store.TransferJob,
+	target store.Item,
+	format string,
+	tree archiveSourceTree,
+) {
+	defer s.finishArchiveJob(job.ID)
+
+	st := store.New(s.db)
+	fileCount := len(tree.files)
+	var reservedBytes int64
+	if settings, err := s.getRuntimeSettings(ctx); err == nil {
+		reservedBytes = settings.ReservedDiskBytes
+	}
+
+	pr, pw := io.Pipe()
+	writerDone := make(chan error, 1)
+	go func() {
+		err := s.writeFolderArchive(ctx, pw, format, tree, func(done int) {
+			s.updateArchiveJobProgress(st, job.ID, done, 0, 0, store.TransferJobStatusRunning, nil)
+		})
+		_ = pw.CloseWithError(err)
+		writerDone <- err
+	}()
+
+	uploaded, totalBytes, uploadErr := s.uploadChunksFromStream(
+		ctx,
+		st,
+		target.ID,
+		target.Name,
+		pr,
+		s.cfg.ChunkSizeBytes,
+		reservedBytes,
+		time.Now(),
+	)
+	// 上传失败时让生成端尽快退出
+	_ = pr.CloseWithError(errors.Join(uploadErr, io.ErrClosedPipe))
+	writeErr := <-writerDone
+
+	err := uploadErr
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil && totalBytes <= 0 {
+		err = errors.New("压缩结果为空")
+	}
+	if err == nil {
+		err = st.UpdateItemSize(ctx, target.ID, totalBytes, time.Now())
+	}
+	if err != nil {
+		s.discardArchiveCreateTarget(target, uploaded)
+		status := store.TransferJobStatusError
+		canceled := 0
+		failed := fileCount
+		if ctx.Err() != nil {
+			status = store.TransferJobStatusCanceled
+			canceled, failed = fileCount, 0
+		} else {
+			s.logger.Warn("create folder archive failed", "error", err.Error(), "job_id", job.ID.String())
+		}
+		msg := err.Error()
+		s.updateArchiveJobProgress(st, job.ID, 0, failed, canceled, status, &msg)
+		return
+	}
+	s.updateArchiveJobProgress(st, job.ID, fileCount, 0, 0, store.TransferJobStatusCompleted, nil)
+}
+
+// discardArchiveCreateTarget 任务可能已被取消，清理时不复用任务 ctx。
+func (s *Server) discardArchiveCreateTarget(target store.Item, uploaded []store.Chunk) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, chunk := range uploaded {
+		_ = s.deleteMessageWithRetry(ctx, chunk.TGChatID, chunk.TGMessageID)
+	}
+	if err := store.New(s.db).DeleteItemsByPathPrefix(ctx, target.Path); err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.logger.Warn("delete archive target failed", "error", err.Error(), "item_id", target.ID.String())
+	}
+}
+
+func buildArchiveCreateTransferJob(folder store.Item, target store.Item, fileCount int, totalSize int64, now time.Time) store.TransferJob {
+	jobID := uuid.New()
+	targetID := target.ID
+	return store.TransferJob{
+		ID:           jobID,
+		Direction:    store.TransferDirectionUpload,
+		SourceKind:   store.TransferSourceKindArchiveCreate,
+		SourceRef:    folder.ID.String() + ":" + jobID.String(),
+		UnitKind:     store.TransferUnitKindFile,
+		Name:         strings.TrimSpace(target.Name),
+		TargetItemID: &targetID,
+		TotalSize:    totalSize,
+		ItemCount:    fileCount,
+		Status:       store.TransferJobStatusRunning,
+		StartedAt:    now,
+		FinishedAt:   now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestParseArchiveFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{raw: "", want: archiveFormatZip, wantOK: true},
+		{raw: "ZIP", want: archiveFormatZip, wantOK: true},
+		{raw: "tar.zst", want: archiveFormatTarZst, wantOK: true},
+		{raw: "tzst", want: archiveFormatTarZst, wantOK: true},
+		{raw: "rar", wantOK: false},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseArchiveFormat(tc.raw)
+			if ok != tc.wantOK || got != tc.want {
+				t.Fatalf("parseArchiveFormat(%q) = %q, %v; want %q, %v", tc.raw, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func buildArchiveTestTree() (store.Item, []store.Item) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	root := store.Item{ID: uuid.New(), Type: store.ItemTypeFolder, Name: "项目", Path: "/docs/项目", UpdatedAt: now}
+	return root, []store.Item{
+		root,
+		{ID: uuid.New(), Type: store.ItemTypeFolder, Name: "sub", Path: "/docs/项目/sub", UpdatedAt: now},
+		{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "a.txt", Path: "/docs/项目/sub/a.txt", UpdatedAt: now},
+		{ID: uuid.New(), Type: store.ItemTypeVideo, Name: "b.mp4", Path: "/docs/项目/b.mp4", UpdatedAt: now},
+		{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "secret.txt", Path: "/docs/项目/secret.txt", InVault: true, UpdatedAt: now, Size: 9},
+		{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "other.txt", Path: "/docs/项目2/other.txt", UpdatedAt: now, Size: 3},
+	}
+}
+
+func TestCollectArchiveSourceTree(t *testing.T) {
+	t.Parallel()
+
+	root, items := buildArchiveTestTree()
+	tree := collectArchiveSourceTree(root, items)
+	if len(tree.dirs) != 1 || tree.dirPaths[0] != "项目/sub" {
+		t.Fatalf("dirs = %v", tree.dirPaths)
+	}
+	if len(tree.files) != 2 || tree.files[0].path != "项目/sub/a.txt" || tree.files[1].path != "项目/b.mp4" {
+		t.Fatalf("files = %+v", tree.files)
+	}
+	if tree.totalSize != 0 {
+		t.Fatalf("totalSize = %d, vault and sibling files should be skipped", tree.totalSize)
+	}
+
+	root.InVault = true
+	if tree := collectArchiveSourceTree(root, items); len(tree.files) != 3 {
+		t.Fatalf("vault folder should include vault files, got %d", len(tree.files))
+	}
+}
+
+func TestWriteFolderArchiveLayout(t *testing.T) {
+	t.Parallel()
+
+	root, items := buildArchiveTestTree()
+	tree := collectArchiveSourceTree(root, items)
+	s := &Server{}
+
+	var zipBuf bytes.Buffer
+	done := 0
+	if err := s.writeFolderArchive(context.Background(), &zipBuf, archiveFormatZip, tree, func(n int) { done = n }); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+	if done != 2 {
+		t.Fatalf("onFile reported %d files, want 2", done)
+	}
+	reader, err := zip.NewReader(bytes.NewReader(zipBuf.Bytes()), int64(zipBuf.Len()))
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	names := make([]string, 0, len(reader.File))
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	if len(names) != 3 || names[0] != "项目/sub/" || names[1] != "项目/sub/a.txt" || names[2] != "项目/b.mp4" {
+		t.Fatalf("zip entries = %v", names)
+	}
+	if reader.File[1].Method != zip.Deflate || reader.File[2].Method != zip.Store {
+		t.Fatalf("zip methods = %d, %d", reader.File[1].Method, reader.File[2].Method)
+	}
+
+	var tarBuf bytes.Buffer
+	if err := s.writeFolderTar(context.Background(), &tarBuf, tree, nil); err != nil {
+		t.Fatalf("write tar: %v", err)
+	}
+	tr := tar.NewReader(&tarBuf)
+	var tarNames []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read tar: %v", err)
+		}
+		tarNames = append(tarNames, header.Name)
+	}
+	if len(tarNames) != 3 || tarNames[0] != "项目/sub/" || tarNames[2] != "项目/b.mp4" {
+		t.Fatalf("tar entries = %v", tarNames)
+	}
+}
+
+type failingArchiveWriter struct{}
+
+func (failingArchiveWriter) Write([]byte) (int, error) {
+	return 0, errors.New("upload failed")
+}
+
+// 下游写入失败后压缩进程退出，tar 写入必须随之返回而不是阻塞在管道上。
+func TestWriteFolderTarZstReturnsWhenDownstreamFails(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	// 用 cat 代替 zstd，使数据量足以塞满管道缓冲
+	script := filepath.Join(t.TempDir(), "fake-zstd")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec cat\n"), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	s := &Server{}
+	s.cfg.ZstdBinary = script
+
+	var tree archiveSourceTree
+	for i := 0; i < 4000; i++ {
+		tree.dirs = append(tree.dirs, store.Item{ID: uuid.New(), UpdatedAt: time.Unix(1700000000, 0)})
+		tree.dirPaths = append(tree.dirPaths, fmt.Sprintf("root/dir-%04d", i))
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.writeFolderTarZst(context.Background(), failingArchiveWriter{}, tree, nil)
+	}()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatalf("writeFolderTarZst should fail when the downstream writer fails")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("writeFolderTarZst blocked after the downstream writer failed")
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func (s *Server) runArchiveExtractJob(ctx context.Context, job store.TransferJob, archiveItem store.Item, root store.Item) {
+	defer s.finishArchiveJob(job.ID)
+
+	st := store.New(s.db)
+	completed, failed, canceled := 0, 0, 0
+	var lastErr error
+
+	archive, err := s.openItemZipArchive(ctx, archiveItem)
+	if err != nil {
+		lastErr = err
+	}
+	var entries []archiveEntry
+	if archive.reader != nil {
+		entries = listZipArchiveEntries(archive.reader)
+	}
+
+	folders := map[string]*uuid.UUID{"": &root.ID}
+	for _, entry := range entries {
+		if entry.dto.IsDir {
+			continue
+		}
+		if ctx.Err() != nil {
+			canceled++
+			continue
+		}
+		if err := s.extractArchiveEntry(ctx, st, archive, entry, root, folders, archiveItem.InVault); err != nil {
+			if ctx.Err() != nil {
+				canceled++
+				continue
+			}
+			failed++
+			lastErr = fmt.Errorf("%s: %w", entry.dto.Path, err)
+			s.logger.Warn("extract archive entry failed", "error", err.Error(), "job_id", job.ID.String(), "entry", entry.dto.Path)
+		} else {
+			completed++
+		}
+		s.updateArchiveJobProgress(st, job.ID, completed, failed, canceled, store.TransferJobStatusRunning, nil)
+	}
+
+	status := store.TransferJobStatusCompleted
+	switch {
+	case ctx.Err() != nil:
+		status = store.TransferJobStatusCanceled
+	case lastErr != nil:
+		status = store.TransferJobStatusError
+	}
+	var lastError *string
+	if lastErr != nil {
+		msg := lastErr.Error()
+		lastError = &msg
+	}
+	s.updateArchiveJobProgress(st, job.ID, completed, failed, canceled, status, lastError)
+}
+
+// extractArchiveEntry 将单个条目解压到临时文件并校验 CRC32，再复用本地文件上传流程写入网盘。
+func (s *Server) extractArchiveEntry(
+	ctx context.Context,
+	st *store.Store,
+	archive itemZipArchive,
+	entry archiveEntry,
+	root store.Item,
+	folders map[string]*uuid.UUID,
+	inVault bool,
+) error {
+	if !entry.dto.Supported {
+		return errArchiveEntryUnsupported
+	}
+	if entry.dto.Size == 0 {
+		return errors.New("空文件无法上传到 Telegram")
+	}
+	parentID, err := s.ensureArchiveExtractFolder(ctx, st, root, path.Dir(entry.dto.Path), folders, inVault)
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		return err
+	}
+	tmpPath, err := s.writeArchiveEntryToTempFile(ctx, archive, entry, settings.ReservedDiskBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	if err := s.acquireUploadSlot(ctx, settings.UploadConcurrency); err != nil {
+		return err
+	}
+	item, _, err := s.uploadLocalFileAsItem(ctx, parentID, entry.dto.Name, tmpPath, entry.dto.Size)
+	s.releaseUpload()
+	if err != nil {
+		return err
+	}
+	if inVault {
+		if _, err := st.UpdateItemsVaultByPathPrefix(ctx, item.Path, true, time.Now()); err != nil {
+			return err
+		}
+		return nil
+	}
+	s.emitItemWebhookEvent(webhookEventItemCreated, item, "archive_extract")
+	return nil
+}
+
+func (s *Server) ensureArchiveExtractFolder(
+	ctx context.Context,
+	st *store.Store,
+	root store.Item,
+	dir string,
+	folders map[string]*uuid.UUID,
+	inVault bool,
+) (*uuid.UUID, error) {
+	if dir == "." {
+		dir = ""
+	}
+	if cached, ok := folders[dir]; ok {
+		return cached, nil
+	}
+	parentID, err := s.ensureArchiveExtractFolder(ctx, st, root, parentArchiveDir(dir), folders, inVault)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := st.CreateFolder(ctx, parentID, path.Base(dir), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	if inVault {
+		if _, err := st.UpdateItemsVaultByPathPrefix(ctx, folder.Path, true, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	id := folder.ID
+	folders[dir] = &id
+	return &id, nil
+}
+
+func parentArchiveDir(dir string) string {
+	parent := path.Dir(dir)
+	if parent == "." || parent == "/" {
+		return ""
+	}
+	return parent
+}
+
+// writeArchiveEntryToTempFile 解压条目到临时文件。写入前按条目声明的大小检查预留磁盘空间，
+// 写入时最多读取声明大小 + 1 字节：压缩数据解出的内容超出声明大小（如压缩炸弹）时立即失败。
+func (s *Server) writeArchiveEntryToTempFile(
+	ctx context.Context,
+	archive itemZipArchive,
+	entry archiveEntry,
+	reservedDiskBytes int64,
+) (string, error) {
+	if err := ensureDiskSpaceAvailableAt(os.TempDir(), reservedDiskBytes, entry.dto.Size); err != nil {
+		if errors.Is(err, errInsufficientTempSpace) {
+			s.notifyLowDisk(os.TempDir(), reservedDiskBytes)
+			return "", errors.New("服务器可用磁盘不足，无法解压该文件")
+		}
+		return "", err
+	}
+	stream, err := s.openZipEntryStream(ctx, archive, entry.file)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	// 保留扩展名，上传时依据临时文件路径推断 MIME
+	ext := strings.ToLower(path.Ext(entry.dto.Name))
+	tmpFile, err := os.CreateTemp("", "tgcd-archive-entry-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	copyErr := copyArchiveEntryData(tmpFile, stream, entry.dto.Size, entry.file.CRC32)
+	if closeErr := tmpFile.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		_ = os.Remove(tmpPath)
+		return "", copyErr
+	}
+	return tmpPath, nil
+}
+
+// copyArchiveEntryData 最多写入 size 字节，超出时不再继续读取；写完后校验大小与 CRC（crc 为 0 时跳过）。
+func copyArchiveEntryData(dst io.Writer, stream io.Reader, size int64, crc uint32) error {
+	hash := crc32.NewIEEE()
+	written, err := io.Copy(io.MultiWriter(dst, hash), io.LimitReader(stream, size+1))
+	if err != nil {
+		return err
+	}
+	if written > size {
+		return fmt.Errorf("解压内容超过声明大小 %d 字节，压缩包可能已损坏", size)
+	}
+	if written != size {
+		return fmt.Errorf("解压大小不一致: 期望 %d 字节，实际 %d 字节", size, written)
+	}
+	if crc != 0 && hash.Sum32() != crc {
+		return errors.New("CRC 校验失败，压缩包可能已损坏")
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	archiveJobInterruptedMessage        = "服务重启，压缩/解压任务已中断"
+	torrentPublishJobInterruptedMessage = "服务重启，torrent 生成任务已中断"
+)
+
+const (
+	// 执行中的任务按 archiveJobHeartbeatInterval 刷新 updated_at 并读取其他副本记录的取消请求；
+	// 超过 archiveJobStaleAfter 未刷新视为执行进程已退出。
+	archiveJobHeartbeatInterval = 30 * time.Second
+	archiveJobStaleAfter        = 5 * time.Minute
+	archiveJobReapInterval      = 2 * time.Minute
+)
+
+// 压缩、解压与 torrent 生成任务都在进程内执行，这里统一登记取消函数。
+// 登记表只在执行任务的副本上有效，其他副本经 RequestTransferJobCancel 把取消请求记录在任务行上，由心跳转交。
+type archiveJobHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *Server) registerArchiveJob(jobID uuid.UUID, cancel context.CancelFunc) {
+	s.archiveJobsMu.Lock()
+	defer s.archiveJobsMu.Unlock()
+	if s.archiveJobs == nil {
+		s.archiveJobs = map[uuid.UUID]*archiveJobHandle{}
+	}
+	handle := &archiveJobHandle{cancel: cancel, done: make(chan struct{})}
+	s.archiveJobs[jobID] = handle
+	if s.db != nil {
+		go s.runArchiveJobHeartbeat(jobID, handle)
+	}
+}
+
+// runArchiveJobHeartbeat 在任务结束前定期刷新心跳，避免被其他副本当作中断任务回收；
+// 发现其他副本记录的取消请求时取消本地任务。
+func (s *Server) runArchiveJobHeartbeat(jobID uuid.UUID, handle *archiveJobHandle) {
+	ticker := time.NewTicker(archiveJobHeartbeatInterval)
+	defer ticker.Stop()
+	st := store.New(s.db)
+	for {
+		select {
+		case <-handle.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			req, err := st.TouchRunningTransferJob(ctx, jobID, time.Now())
+			cancel()
+			if err != nil {
+				s.logger.Warn("touch archive job heartbeat failed", "error", err.Error(), "job_id", jobID.String())
+				continue
+			}
+			if req.Requested {
+				s.logger.Info("archive job cancel requested by another replica", "job_id", jobID.String(), "delete", req.Delete)
+				handle.cancel()
+			}
+		}
+	}
+}
+
+func (s *Server) finishArchiveJob(jobID uuid.UUID) {
+	s.archiveJobsMu.Lock()
+	handle := s.archiveJobs[jobID]
+	delete(s.archiveJobs, jobID)
+	s.archiveJobsMu.Unlock()
+	if handle != nil {
+		handle.cancel()
+		close(handle.done)
+	}
+	s.deleteArchiveJobIfRequested(jobID)
+}
+
+// hasArchiveJob 表示任务正在当前副本执行。
+func (s *Server) hasArchiveJob(jobID uuid.UUID) bool {
+	s.archiveJobsMu.Lock()
+	defer s.archiveJobsMu.Unlock()
+	return s.archiveJobs[jobID] != nil
+}
+
+// cancelArchiveJob 取消任务并等待其退出，避免清理目标后仍有内容写入。
+func (s *Server) cancelArchiveJob(ctx context.Context, jobID uuid.UUID) {
+	s.archiveJobsMu.Lock()
+	handle := s.archiveJobs[jobID]
+	s.archiveJobsMu.Unlock()
+	if handle == nil {
+		return
+	}
+	handle.cancel()
+	select {
+	case <-handle.done:
+	case <-ctx.Done():
+	}
+}
+
+// requestArchiveJobCancel 取消任务：在当前副本执行时直接取消，否则把请求记录在任务行上交给执行副本。
+func (s *Server) requestArchiveJobCancel(ctx context.Context, jobID uuid.UUID) {
+	if s.hasArchiveJob(jobID) {
+		s.cancelArchiveJob(ctx, jobID)
+		return
+	}
+	if s.db == nil {
+		return
+	}
+	if err := store.New(s.db).RequestTransferJobCancel(ctx, jobID, false, time.Now()); err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.logger.Warn("request archive job cancel failed", "error", err.Error(), "job_id", jobID.String())
+	}
+}
+
+// deleteArchiveJobIfRequested 处理其他副本在任务运行期间记录的删除请求：任务结束后清理产物并删除任务记录。
+func (s *Server) deleteArchiveJobIfRequested(jobID uuid.UUID) {
+	if s.db == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	st := store.New(s.db)
+	req, err := st.GetTransferJobCancelRequest(ctx, jobID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("get archive job cancel request failed", "error", err.Error(), "job_id", jobID.String())
+		}
+		return
+	}
+	if !req.Delete {
+		return
+	}
+	job, err := st.GetTransferJobByID(ctx, jobID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("get archive job failed", "error", err.Error(), "job_id", jobID.String())
+		}
+		return
+	}
+	if _, err := s.deleteTransferJobWithCleanup(ctx, st, job); err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.logger.Error("delete archive job on request failed", "error", err.Error(), "job_id", jobID.String())
+	}
+}
+
+func (s *Server) startArchiveJobReaperLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopArchiveJobReaper)
+			if s.isLeader() {
+				s.failInterruptedArchiveJobs(s.backgroundCtx)
+			}
+			if !s.waitLoopInterval(archiveJobReapInterval) {
+				return
+			}
+		}
+	})
+}
+
+// failInterruptedArchiveJobs 回收执行进程已退出（心跳超时）的进程内任务；这类任务无法继续，直接标记失败。
+func (s *Server) failInterruptedArchiveJobs(ctx context.Context) {
+	if s.db == nil {
+		return
+	}
+	st := store.New(s.db)
+	now := time.Now()
+	for kind, message := range map[store.TransferSourceKind]string{
+		store.TransferSourceKindArchiveExtract: archiveJobInterruptedMessage,
+		store.TransferSourceKindArchiveCreate:  archiveJobInterruptedMessage,
+		store.TransferSourceKindTorrentPublish: torrentPublishJobInterruptedMessage,
+	} {
+		count, err := st.FailRunningTransferJobsBySourceKind(ctx, kind, message, now.Add(-archiveJobStaleAfter), now)
+		if err != nil {
+			s.logger.Warn("mark interrupted archive jobs failed", "error", err.Error(), "source_kind", string(kind))
+			continue
+		}
+		if count > 0 {
+			s.logger.Info("marked interrupted archive jobs", "count", count, "source_kind", string(kind))
+		}
+	}
+}
+
+func (s *Server) updateArchiveJobProgress(
+	st *store.Store,
+	jobID uuid.UUID,
+	completed int,
+	failed int,
+	canceled int,
+	status store.TransferJobStatus,
+	lastError *string,
+) {
+	// 任务被取消后仍需落库，这里不复用任务 ctx
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := st.UpdateTransferJobProgress(ctx, jobID, completed, failed, canceled, status, lastError, time.Now()); err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("update archive job failed", "error", err.Error(), "job_id", jobID.String())
+		}
+		return
+	}
+	s.syncTransferJobByID(ctx, jobID)
+}
+
+func buildArchiveTransferJobView(job store.TransferJob, phase string) transferJobViewDTO {
+	dto := toTransferJobViewDTO(job)
+	done := int64(job.CompletedCount + job.ErrorCount + job.CanceledCount)
+	dto.Progress = progressFromCounts(done, int64(max(job.ItemCount, 1)), "items", job.Status == store.TransferJobStatusCompleted)
+	if job.Status == store.TransferJobStatusRunning {
+		dto.Phase = phase
+	}
+	return dto
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	archiveReadBlockSize   = 256 * 1024
+	archiveReadCacheBlocks = 32
+	archiveListMaxEntries  = 20000
+	archiveZipFlagEncrypt  = 0x1
+)
+
+var (
+	errArchiveUnsupported      = errors.New("暂不支持该压缩格式，目前仅支持 zip")
+	errArchiveEntryUnsupported = errors.New("该条目已加密或使用了不支持的压缩算法")
+)
+
+// itemRangeReaderAt 以 io.ReaderAt 形式按需读取文件分块，并缓存最近访问的块，
+// 使 archive/zip 读取中央目录时只产生少量 Range 请求。
+type itemRangeReaderAt struct {
+	s      *Server
+	ctx    context.Context
+	chunks []store.Chunk
+	size   int64
+
+	mu     sync.Mutex
+	blocks map[int64][]byte
+	order  []int64
+}
+
+func (s *Server) newItemRangeReaderAt(ctx context.Context, chunks []store.Chunk, size int64) *itemRangeReaderAt {
+	return &itemRangeReaderAt{s: s, ctx: ctx, chunks: chunks, size: size, blocks: map[int64][]byte{}}
+}
+
+func (r *itemRangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("读取偏移非法")
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && off+int64(n) < r.size {
+		pos := off + int64(n)
+		blockStart := pos - pos%archiveReadBlockSize
+		block, err := r.block(blockStart)
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], block[pos-blockStart:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *itemRangeReaderAt) block(start int64) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if block, ok := r.blocks[start]; ok {
+		return block, nil
+	}
+
+	end := minInt64(start+archiveReadBlockSize, r.size) - 1
+	var buf bytes.Buffer
+	buf.Grow(int(end - start + 1))
+	if err := r.s.copyItemChunkRange(r.ctx, &buf, r.chunks, start, end); err != nil {
+		return nil, err
+	}
+	if len(r.order) >= archiveReadCacheBlocks {
+		delete(r.blocks, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.blocks[start] = buf.Bytes()
+	r.order = append(r.order, start)
+	return buf.Bytes(), nil
+}
+
+type itemZipArchive struct {
+	reader *zip.Reader
+	chunks []store.Chunk
+	size   int64
+}
+
+// openItemZipArchive 只读取文件尾部的中央目录，不下载完整压缩包。
+func (s *Server) openItemZipArchive(ctx context.Context, item store.Item) (itemZipArchive, error) {
+	chunks, err := store.New(s.db).ListChunks(ctx, item.ID)
+	if err != nil {
+		return itemZipArchive{}, err
+	}
+	size := resolveItemContentSize(item, chunks)
+	if size <= 0 {
+		return itemZipArchive{}, errArchiveUnsupported
+	}
+	reader, err := zip.NewReader(s.newItemRangeReaderAt(ctx, chunks, size), size)
+	if err != nil {
+		if errors.Is(err, zip.ErrFormat) || errors.Is(err, zip.ErrAlgorithm) {
+			return itemZipArchive{}, errArchiveUnsupported
+		}
+		return itemZipArchive{}, err
+	}
+	return itemZipArchive{reader: reader, chunks: chunks, size: size}, nil
+}
+
+type archiveEntryDTO struct {
+	Path           string     `json:"path"`
+	Name           string     `json:"name"`
+	IsDir          bool       `json:"isDir"`
+	Size           int64      `json:"size"`
+	CompressedSize int64      `json:"compressedSize"`
+	ModifiedAt     *time.Time `json:"modifiedAt,omitempty"`
+	Encrypted      bool       `json:"encrypted"`
+	Supported      bool       `json:"supported"`
+}
+
+type archiveEntry struct {
+	file *zip.File
+	dto  archiveEntryDTO
+}
+
+// listZipArchiveEntries 规范化条目路径并丢弃可能越界（zip slip）的条目。
+func listZipArchiveEntries(reader *zip.Reader) []archiveEntry {
+	entries := make([]archiveEntry, 0, len(reader.File))
+	for _, file := range reader.File {
+		name := decodeZipEntryName(file)
+		entryPath, ok := normalizeArchiveEntryPath(name)
+		if !ok {
+			continue
+		}
+		isDir := strings.HasSuffix(strings.ReplaceAll(name, "\\", "/"), "/") || file.FileInfo().IsDir()
+		dto := archiveEntryDTO{
+			Path:           entryPath,
+			Name:           path.Base(entryPath),
+			IsDir:          isDir,
+			Encrypted:      file.Flags&archiveZipFlagEncrypt != 0,
+			CompressedSize: int64(file.CompressedSize64),
+		}
+		if !isDir {
+			dto.Size = int64(file.UncompressedSize64)
+			dto.Supported = isSupportedZipEntry(file)
+		}
+		if modified := file.Modified; !modified.IsZero() && modified.Year() > 1980 {
+			dto.ModifiedAt = &modified
+		}
+		entries = append(entries, archiveEntry{file: file, dto: dto})
+	}
+	return entries
+}
+
+func findZipArchiveEntry(entries []archiveEntry, entryPath string) (archiveEntry, bool) {
+	normalized, ok := normalizeArchiveEntryPath(entryPath)
+	if !ok {
+		return archiveEntry{}, false
+	}
+	for _, entry := range entries {
+		if entry.dto.Path == normalized {
+			return entry, true
+		}
+	}
+	return archiveEntry{}, false
+}
+
+// decodeZipEntryName 处理未设置 UTF-8 标志的旧压缩包：Windows 中文环境下生成的文件名通常是 GBK 编码。
+func decodeZipEntryName(file *zip.File) string {
+	if !file.NonUTF8 || utf8.ValidString(file.Name) {
+		return file.Name
+	}
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().String(file.Name)
+	if err != nil {
+		return strings.ToValidUTF8(file.Name, "_")
+	}
+	return decoded
+}
+
+// normalizeArchiveEntryPath 统一分隔符并拒绝绝对路径与 ".." 跳出。
+func normalizeArchiveEntryPath(name string) (string, bool) {
+	name = strings.ReplaceAll(strings.TrimSpace(name), "\\", "/")
+	if name == "" || strings.HasPrefix(name, "/") {
+		return "", false
+	}
+	for _, part := range strings.Split(strings.TrimSuffix(name, "/"), "/") {
+		if part == ".." {
+			return "", false
+		}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == "" {
+		return "", false
+	}
+	return cleaned, true
+}
+
+func isSupportedZipEntry(file *zip.File) bool {
+	if file.Flags&archiveZipFlagEncrypt != 0 {
+		return false
+	}
+	return file.Method == zip.Store || file.Method == zip.Deflate
+}
+
+// openZipEntryStream 直接按数据区间流式读取条目并解压，避免逐块随机读取。
+func (s *Server) openZipEntryStream(ctx context.Context, archive itemZipArchive, file *zip.File) (io.ReadCloser, error) {
+	if !isSupportedZipEntry(file) {
+		return nil, errArchiveEntryUnsupported
+	}
+	if file.CompressedSize64 == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	offset, err := file.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+	end := offset + int64(file.CompressedSize64) - 1
+	if end >= archive.size {
+		return nil, fmt.Errorf("压缩包条目越界: %s", file.Name)
+	}
+	raw := s.openItemChunkReader(ctx, archive.chunks, offset, end)
+	if file.Method == zip.Store {
+		return raw, nil
+	}
+	return &zipEntryReadCloser{Reader: flate.NewReader(raw), raw: raw}, nil
+}
+
+type zipEntryReadCloser struct {
+	io.Reader
+	raw io.Closer
+}
+
+func (r *zipEntryReadCloser) Close() error {
+	if closer, ok := r.Reader.(io.Closer); ok {
+		_ = closer.Close()
+	}
+	return r.raw.Close()
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestNormalizeArchiveEntryPath(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{raw: "docs/readme.txt", want: "docs/readme.txt", wantOK: true},
+		{raw: "docs\\sub\\a.txt", want: "docs/sub/a.txt", wantOK: true},
+		{raw: "docs/", want: "docs", wantOK: true},
+		{raw: "./a//b.txt", want: "a/b.txt", wantOK: true},
+		{raw: "../etc/passwd", wantOK: false},
+		{raw: "a/../../b", wantOK: false},
+		{raw: "/abs/path", wantOK: false},
+		{raw: "\\abs\\path", wantOK: false},
+		{raw: "", wantOK: false},
+		{raw: "./", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			got, ok := normalizeArchiveEntryPath(tc.raw)
+			if ok != tc.wantOK || got != tc.want {
+				t.Fatalf("normalizeArchiveEntryPath(%q) = %q, %v; want %q, %v", tc.raw, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestListZipArchiveEntries(t *testing.T) {
+	t.Parallel()
+
+	gbkName, err := simplifiedchinese.GBK.NewEncoder().String("中文/说明.txt")
+	if err != nil {
+		t.Fatalf("encode gbk: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.CreateHeader(&zip.FileHeader{Name: "photos/", Method: zip.Store}); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "photos/a.txt", Method: zip.Deflate})
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	_, _ = w.Write(bytes.Repeat([]byte("hello "), 100))
+	w, err = zw.CreateHeader(&zip.FileHeader{Name: gbkName, Method: zip.Store, NonUTF8: true})
+	if err != nil {
+		t.Fatalf("create gbk file: %v", err)
+	}
+	_, _ = w.Write([]byte("gbk"))
+	if _, err := zw.CreateHeader(&zip.FileHeader{Name: "../evil.txt", Method: zip.Store}); err != nil {
+		t.Fatalf("create evil file: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	entries := listZipArchiveEntries(reader)
+	if len(entries) != 3 {
+		t.Fatalf("listZipArchiveEntries() returned %d entries, want 3 (zip slip dropped)", len(entries))
+	}
+	if !entries[0].dto.IsDir || entries[0].dto.Path != "photos" {
+		t.Fatalf("entries[0] = %+v", entries[0].dto)
+	}
+	if entries[1].dto.Path != "photos/a.txt" || entries[1].dto.Size != 600 || !entries[1].dto.Supported {
+		t.Fatalf("entries[1] = %+v", entries[1].dto)
+	}
+	if entries[2].dto.Path != "中文/说明.txt" || entries[2].dto.Name != "说明.txt" {
+		t.Fatalf("entries[2] = %+v", entries[2].dto)
+	}
+
+	if count, total := summarizeArchiveFiles(entries); count != 2 || total != 603 {
+		t.Fatalf("summarizeArchiveFiles() = %d, %d", count, total)
+	}
+	entry, ok := findZipArchiveEntry(entries, "photos\\a.txt")
+	if !ok {
+		t.Fatal("findZipArchiveEntry() did not match backslash path")
+	}
+	rc, err := entry.file.Open()
+	if err != nil {
+		t.Fatalf("open entry: %v", err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if len(data) != 600 {
+		t.Fatalf("entry content length = %d", len(data))
+	}
+}
+
+func TestArchiveExtractFolderName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"photos.zip":       "photos",
+		"backup.tar.gz":    "backup",
+		"v1.2.release.zip": "v1.2.release",
+		".zip":             ".zip_extracted",
+		"noext":            "noext",
+	}
+	for raw, want := range cases {
+		if got := archiveExtractFolderName(raw); got != want {
+			t.Fatalf("archiveExtractFolderName(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
+
+type countingArchiveWriter struct{ n int64 }
+
+func (w *countingArchiveWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// 解出的内容超过条目声明的大小时，最多多写 1 字节即失败，不会把整个压缩炸弹写到磁盘。
+func TestCopyArchiveEntryDataStopsAtDeclaredSize(t *testing.T) {
+	t.Parallel()
+
+	var compressed bytes.Buffer
+	fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		t.Fatalf("flate writer: %v", err)
+	}
+	if _, err := fw.Write(make([]byte, 8<<20)); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("close flate: %v", err)
+	}
+
+	const declared = 1024
+	dst := &countingArchiveWriter{}
+	err = copyArchiveEntryData(dst, flate.NewReader(&compressed), declared, 0)
+	if err == nil || !strings.Contains(err.Error(), "超过声明大小") {
+		t.Fatalf("copyArchiveEntryData err = %v, want oversize error", err)
+	}
+	if dst.n > declared+1 {
+		t.Fatalf("written = %d bytes, want at most %d", dst.n, declared+1)
+	}
+
+	data := []byte("hello archive")
+	if err := copyArchiveEntryData(io.Discard, bytes.NewReader(data), int64(len(data)), crc32.ChecksumIEEE(data)); err != nil {
+		t.Fatalf("exact entry: %v", err)
+	}
+	if err := copyArchiveEntryData(io.Discard, bytes.NewReader(data), int64(len(data)), 1); err == nil {
+		t.Fatalf("CRC mismatch should fail")
+	}
+	if err := copyArchiveEntryData(io.Discard, bytes.NewReader(data), int64(len(data))+5, 0); err == nil {
+		t.Fatalf("short entry should fail")
+	}
+}
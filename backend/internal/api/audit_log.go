@@ -0,0 +1,435 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	auditActionLogin           = "auth.login"
+	auditActionLogout          = "auth.logout"
+	auditActionVaultUnlock     = "vault.unlock"
+	auditActionVaultLock       = "vault.lock"
+	auditActionItemCreate      = "item.create"
+	auditActionItemRename      = "item.rename"
+	auditActionItemMove        = "item.move"
+	auditActionItemCopy        = "item.copy"
+	auditActionItemDelete      = "item.delete"
+	auditActionShareCreate     = "share.create"
+	auditActionShareRevoke     = "share.revoke"
+	auditActionSettingsUpdate  = "settings.update"
+	auditActionAccessSwitch    = "settings.access_switch"
+	auditActionTorrentCreate   = "torrent.create"
+	auditActionTorrentDelete   = "torrent.delete"
+	auditActionTorrentDispatch = "torrent.dispatch"
+	auditActionTorrentRetry    = "torrent.retry"
+	auditActionTorrentPause    = "torrent.pause"
+	auditActionTorrentResume   = "torrent.resume"
+	auditActionTorrentPriority = "torrent.priority"
+	auditActionTorrentComplete = "torrent.completed"
+	auditActionTorrentFailed   = "torrent.failed"
+	auditActionWebhookCreate   = "webhook.create"
+	auditActionWebhookUpdate   = "webhook.update"
+	auditActionWebhookDelete   = "webhook.delete"
+)
+
+const (
+	auditActorAdmin     = "admin"
+	auditActorAnonymous = "anonymous"
+	auditActorSystem    = "system"
+	auditResultSuccess  = "success"
+	auditResultFailure  = "failure"
+	auditRedactedValue  = "[REDACTED]"
+)
+
+const (
+	auditWriteTimeout            = 5 * time.Second
+	auditUserAgentMaxBytes       = 512
+	auditLogRetentionInterval    = 6 * time.Hour
+	auditLogRetentionTimeout     = 5 * time.Minute
+	auditLogRetentionDaysDefault = 180
+	auditLogRetentionDaysMax     = 3650
+)
+
+type auditRequestInfo struct {
+	Actor     string
+	IP        string
+	UserAgent string
+}
+
+type auditRequestInfoKey struct{}
+
+// auditEntry 描述一条待写入的审计记录；Actor 为空时使用请求上下文中的身份，后台任务默认为 system。
+type auditEntry struct {
+	Action   string
+	Actor    string
+	Failed   bool
+	ItemID   *uuid.UUID
+	ItemPath string
+	TargetID string
+	Detail   map[string]any
+}
+
+// auditContextMiddleware 在请求上下文中记录操作者、来源 IP 与 UA，供 handler 及其派生的后台任务写审计日志。
+func (s *Server) auditContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := auditActorAnonymous
+		if s.cfg.AllowDevNoAuth || s.isAuthed(r) {
+			actor = auditActorAdmin
+		}
+		info := auditRequestInfo{
+			Actor:     actor,
+			IP:        requestClientIP(r),
+			UserAgent: truncateAuditText(r.UserAgent(), auditUserAgentMaxBytes),
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), auditRequestInfoKey{}, info)))
+	})
+}
+
+func auditRequestInfoFromContext(ctx context.Context) (auditRequestInfo, bool) {
+	info, ok := ctx.Value(auditRequestInfoKey{}).(auditRequestInfo)
+	return info, ok
+}
+
+// requestClientIP 取客户端地址。只有直连地址是本机或内网（即 nginx 等反向代理）时才信任转发头：
+// 优先取代理设置的 X-Real-IP，其次取 X-Forwarded-For 的最后一跳；
+// X-Forwarded-For 靠前的地址由客户端自行提供，可以伪造，不予采用。
+func requestClientIP(r *http.Request) string {
+	remote := strings.TrimSpace(r.RemoteAddr)
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !isTrustedProxyAddr(remote) {
+		return remote
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		hops := strings.Split(forwarded, ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); net.ParseIP(ip) != nil {
+			return ip
+		}
+	}
+	return remote
+}
+
+func isTrustedProxyAddr(addr string) bool {
+	ip := net.ParseIP(addr)
+	return ip != nil && (ip.IsLoopback() || ip.IsPrivate())
+}
+
+// recordAudit 同步写入审计记录；写入失败只记日志，不影响业务结果。请求已取消时仍会写入。
+func (s *Server) recordAudit(ctx context.Context, entry auditEntry) {
+	if s.db == nil {
+		return
+	}
+	info, ok := auditRequestInfoFromContext(ctx)
+	if !ok {
+		info.Actor = auditActorSystem
+	}
+	if entry.Actor != "" {
+		info.Actor = entry.Actor
+	}
+	result := auditResultSuccess
+	if entry.Failed {
+		result = auditResultFailure
+	}
+	row := store.AuditLogEntry{
+		OccurredAt: time.Now(),
+		Actor:      info.Actor,
+		Action:     entry.Action,
+		Result:     result,
+		IP:         info.IP,
+		UserAgent:  info.UserAgent,
+		ItemID:     entry.ItemID,
+		Detail:     redactAuditDetail(entry.Detail),
+	}
+	if entry.ItemPath != "" {
+		itemPath := entry.ItemPath
+		row.ItemPath = &itemPath
+	}
+	if entry.TargetID != "" {
+		targetID := entry.TargetID
+		row.TargetID = &targetID
+	}
+
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), auditWriteTimeout)
+	defer cancel()
+	if err := store.New(s.db).InsertAuditLog(writeCtx, row); err != nil {
+		s.logger.Warn("write audit log failed", "error", err.Error(), "action", entry.Action)
+	}
+}
+
+// recordItemAudit 为单个文件/文件夹记录审计日志。
+func (s *Server) recordItemAudit(ctx context.Context, action string, item store.Item, detail map[string]any) {
+	id := item.ID
+	s.recordAudit(ctx, auditEntry{
+		Action:   action,
+		ItemID:   &id,
+		ItemPath: item.Path,
+		Detail:   detail,
+	})
+}
+
+// recordItemMoveRenameAudit 对比修改前后的条目，重命名与移动分别记录一条。
+func (s *Server) recordItemMoveRenameAudit(ctx context.Context, before store.Item, after store.Item) {
+	detail := map[string]any{"from": before.Path, "to": after.Path}
+	if before.Name != after.Name {
+		s.recordItemAudit(ctx, auditActionItemRename, after, detail)
+	}
+	if !sameParentID(before.ParentID, after.ParentID) {
+		s.recordItemAudit(ctx, auditActionItemMove, after, detail)
+	}
+}
+
+func sameParentID(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// recordTorrentAudit 记录种子任务生命周期事件；failure 非空时记为失败。
+func (s *Server) recordTorrentAudit(ctx context.Context, action string, task store.TorrentTask, failure error, detail map[string]any) {
+	if detail == nil {
+		detail = map[string]any{}
+	}
+	detail["torrentName"] = task.TorrentName
+	detail["infoHash"] = task.InfoHash
+	if failure != nil {
+		detail["error"] = failure.Error()
+	}
+	s.recordAudit(ctx, auditEntry{
+		Action:   action,
+		Failed:   failure != nil,
+		TargetID: task.ID.String(),
+		Detail:   detail,
+	})
+}
+
+// auditDetailFromRequest 将请求体结构转换为审计详情（仅保留已提交的字段），敏感字段由 redactAuditDetail 统一脱敏。
+// 总是返回非 nil 的 map，调用方可以继续追加字段。
+func auditDetailFromRequest(v any) map[string]any {
+	out := map[string]any{}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return out
+	}
+	if err := json.Unmarshal(raw, &out); err != nil || out == nil {
+		return map[string]any{}
+	}
+	return pruneNilAuditValues(out)
+}
+
+func pruneNilAuditValues(in map[string]any) map[string]any {
+	for key, value := range in {
+		switch typed := value.(type) {
+		case nil:
+			delete(in, key)
+		case map[string]any:
+			if pruned := pruneNilAuditValues(typed); len(pruned) == 0 {
+				delete(in, key)
+			}
+		}
+	}
+	return in
+}
+
+// redactAuditDetail 递归替换密码、令牌等敏感字段的值；字段本身保留，以便看出“改过什么”。
+func redactAuditDetail(in map[string]any) map[string]any {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(in))
+	for key, value := range in {
+		if isSensitiveAuditKey(key) {
+			out[key] = auditRedactedValue
+			continue
+		}
+		switch typed := value.(type) {
+		case map[string]any:
+			out[key] = redactAuditDetail(typed)
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+func isSensitiveAuditKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, marker := range []string{"password", "token", "secret", "hash"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateAuditText(v string, limit int) string {
+	if len(v) <= limit {
+		return v
+	}
+	cut := limit
+	for cut > 0 && v[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return v[:cut]
+}
+
+func (s *Server) startAuditLogRetentionLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopAuditLogRetention)
+			if s.isLeader() {
+				s.runAuditLogRetentionPass(s.backgroundCtx)
+			}
+			if !s.waitLoopInterval(auditLogRetentionInterval) {
+				return
+			}
+		}
+	})
+}
+
+// runAuditLogRetentionPass 按运行设置删除过期审计记录；保留天数为 0 表示永久保留。
+func (s *Server) runAuditLogRetentionPass(ctx context.Context) {
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("get runtime settings for audit log retention failed", "error", err.Error())
+		}
+		return
+	}
+	if settings.AuditLogRetentionDays <= 0 {
+		return
+	}
+	cutoff := time.Now().AddDate(0, 0, -settings.AuditLogRetentionDays)
+	purgeCtx, cancel := context.WithTimeout(ctx, auditLogRetentionTimeout)
+	defer cancel()
+	deleted, err := store.New(s.db).PurgeAuditLogBefore(purgeCtx, cutoff)
+	if err != nil {
+		s.logger.Warn("purge audit log failed", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("audit log purged", "deleted", deleted, "retention_days", settings.AuditLogRetentionDays)
+	}
+}
+
+type auditLogEntryDTO struct {
+	ID         int64          `json:"id"`
+	OccurredAt time.Time      `json:"occurredAt"`
+	Actor      string         `json:"actor"`
+	Action     string         `json:"action"`
+	Result     string         `json:"result"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"userAgent"`
+	ItemID     *string        `json:"itemId"`
+	ItemPath   *string        `json:"itemPath"`
+	TargetID   *string        `json:"targetId"`
+	Detail     map[string]any `json:"detail"`
+}
+
+func toAuditLogEntryDTO(e store.AuditLogEntry) auditLogEntryDTO {
+	dto := auditLogEntryDTO{
+		ID:         e.ID,
+		OccurredAt: e.OccurredAt,
+		Actor:      e.Actor,
+		Action:     e.Action,
+		Result:     e.Result,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		ItemPath:   e.ItemPath,
+		TargetID:   e.TargetID,
+		Detail:     e.Detail,
+	}
+	if e.ItemID != nil {
+		id := e.ItemID.String()
+		dto.ItemID = &id
+	}
+	if dto.Detail == nil {
+		dto.Detail = map[string]any{}
+	}
+	return dto
+}
+
+func parseAuditLogListParams(q map[string][]string) (store.AuditLogListParams, error) {
+	get := func(key string) string {
+		if values := q[key]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+	params := store.AuditLogListParams{
+		Actor:      get("actor"),
+		PathPrefix: get("path"),
+		Query:      get("q"),
+		Page:       intFromQuery(get("page"), 1),
+		PageSize:   intFromQuery(get("pageSize"), 50),
+	}
+	for _, raw := range q["action"] {
+		for _, action := range strings.Split(raw, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				params.Actions = append(params.Actions, action)
+			}
+		}
+	}
+	switch result := get("result"); result {
+	case "", auditResultSuccess, auditResultFailure:
+		params.Result = result
+	default:
+		return store.AuditLogListParams{}, errors.New("result 仅支持 success/failure")
+	}
+	if raw := get("itemId"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return store.AuditLogListParams{}, errors.New("itemId 非法")
+		}
+		params.ItemID = &id
+	}
+	for key, target := range map[string]**time.Time{"since": &params.Since, "until": &params.Until} {
+		raw := get(key)
+		if raw == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return store.AuditLogListParams{}, errors.New(key + " 需为 RFC3339 时间")
+		}
+		*target = &ts
+	}
+	return params, nil
+}
+
+// handleListAuditLog 按时间倒序分页查询审计日志。
+// 支持 action（逗号分隔，"item.*" 按前缀匹配）、actor、result、itemId、path（含子路径）、q、since/until 过滤。
+func (s *Server) handleListAuditLog(w http.ResponseWriter, r *http.Request) {
+	params, err := parseAuditLogListParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	entries, total, err := store.New(s.db).ListAuditLog(r.Context(), params)
+	if err != nil {
+		s.logger.Error("list audit log failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	out := make([]auditLogEntryDTO, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, toAuditLogEntryDTO(entry))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":      out,
+		"pagination": newPaginationResponse(params.Page, params.PageSize, total),
+	})
+}
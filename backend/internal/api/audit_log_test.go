@@ -0,0 +1,157 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestRedactAuditDetail(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   map[string]any
+		want map[string]any
+	}{
+		{name: "empty", in: nil, want: nil},
+		{
+			name: "runtime settings patch",
+			in: map[string]any{
+				"uploadConcurrency":  float64(4),
+				"vaultPassword":      "hunter2",
+				"adminPassword":      "root",
+				"torrentQbtPassword": "qbt",
+			},
+			want: map[string]any{
+				"uploadConcurrency":  float64(4),
+				"vaultPassword":      auditRedactedValue,
+				"adminPassword":      auditRedactedValue,
+				"torrentQbtPassword": auditRedactedValue,
+			},
+		},
+		{
+			name: "nested service access",
+			in: map[string]any{
+				"accessMethod": "self_hosted_bot_api",
+				"nested":       map[string]any{"tgBotToken": "123:abc", "tgApiHash": "deadbeef", "tgApiId": float64(1)},
+			},
+			want: map[string]any{
+				"accessMethod": "self_hosted_bot_api",
+				"nested":       map[string]any{"tgBotToken": auditRedactedValue, "tgApiHash": auditRedactedValue, "tgApiId": float64(1)},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := redactAuditDetail(tc.in); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("redactAuditDetail() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuditDetailFromRequestKeepsSubmittedFields(t *testing.T) {
+	t.Parallel()
+
+	concurrency := 3
+	password := "secret"
+	got := redactAuditDetail(auditDetailFromRequest(&runtimeSettingsPatchRequest{
+		UploadConcurrency: &concurrency,
+		VaultPassword:     &password,
+	}))
+	want := map[string]any{
+		"uploadConcurrency": float64(3),
+		"vaultPassword":     auditRedactedValue,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("detail = %#v, want %#v", got, want)
+	}
+	if detail := auditDetailFromRequest(nil); detail == nil {
+		t.Fatalf("auditDetailFromRequest(nil) should return an empty map")
+	}
+}
+
+func TestRequestClientIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		forwarded  string
+		realIP     string
+		remoteAddr string
+		want       string
+	}{
+		{name: "real ip preferred", forwarded: "192.0.2.99, 203.0.113.7", realIP: "203.0.113.7", remoteAddr: "172.18.0.3:5000", want: "203.0.113.7"},
+		{name: "forwarded last hop", forwarded: "192.0.2.99, 203.0.113.7", remoteAddr: "127.0.0.1:5000", want: "203.0.113.7"},
+		{name: "invalid real ip", forwarded: "203.0.113.7", realIP: "unknown", remoteAddr: "10.0.0.2:5000", want: "203.0.113.7"},
+		{name: "untrusted remote ignores headers", forwarded: "192.0.2.99", realIP: "192.0.2.99", remoteAddr: "198.51.100.4:5000", want: "198.51.100.4"},
+		{name: "remote addr", remoteAddr: "192.0.2.10:43210", want: "192.0.2.10"},
+		{name: "ipv6 remote addr", remoteAddr: "[2001:db8::1]:443", want: "2001:db8::1"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/api/items", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if tc.realIP != "" {
+				r.Header.Set("X-Real-IP", tc.realIP)
+			}
+			if got := requestClientIP(r); got != tc.want {
+				t.Fatalf("requestClientIP() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseAuditLogListParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		query       string
+		wantErr     bool
+		wantActions []string
+		wantSince   bool
+	}{
+		{name: "defaults", query: ""},
+		{name: "actions", query: "action=item.*,auth.login&action=share.create", wantActions: []string{"item.*", "auth.login", "share.create"}},
+		{name: "since", query: "since=2026-01-02T03:04:05Z", wantSince: true},
+		{name: "bad since", query: "since=yesterday", wantErr: true},
+		{name: "bad result", query: "result=maybe", wantErr: true},
+		{name: "bad item id", query: "itemId=abc", wantErr: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			values, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("parse query: %v", err)
+			}
+			params, err := parseAuditLogListParams(values)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseAuditLogListParams() err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(params.Actions, tc.wantActions) {
+				t.Fatalf("actions = %#v, want %#v", params.Actions, tc.wantActions)
+			}
+			if (params.Since != nil) != tc.wantSince {
+				t.Fatalf("since = %v, want set=%v", params.Since, tc.wantSince)
+			}
+			if params.Page != 1 || params.PageSize != 50 {
+				t.Fatalf("page = %d/%d, want 1/50", params.Page, params.PageSize)
+			}
+		})
+	}
+}
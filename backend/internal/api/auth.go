@@ -24,6 +24,7 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if s.cfg.AllowDevNoAuth {
 		// 开发模式可跳过鉴权，但仍提供 cookie，便于前端逻辑统一
 		s.setAuthCookie(w, time.Now())
+		s.recordAudit(r.Context(), auditEntry{Action: auditActionLogin, Actor: auditActorAdmin, Detail: map[string]any{"devNoAuth": true}})
 		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 		return
 	}
@@ -41,11 +42,13 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !s.verifyAdminPassword(req.Password) {
+		s.recordAudit(r.Context(), auditEntry{Action: auditActionLogin, Actor: auditActorAnonymous, Failed: true})
 		writeError(w, http.StatusUnauthorized, "unauthorized", "密码错误")
 		return
 	}
 
 	s.setAuthCookie(w, time.Now())
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionLogin, Actor: auditActorAdmin})
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
@@ -60,6 +63,7 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		Secure:   s.cfg.CookieSecure,
 	}
 	http.SetCookie(w, c)
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionLogout})
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
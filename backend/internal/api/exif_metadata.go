@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagMake               = 0x010F
+	exifTagModel              = 0x0110
+	exifTagDateTime           = 0x0132
+	exifTagExifIFDPointer     = 0x8769
+	exifTagGPSIFDPointer      = 0x8825
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+	exifTagGPSLatitudeRef     = 0x0001
+	exifTagGPSLatitude        = 0x0002
+	exifTagGPSLongitudeRef    = 0x0003
+	exifTagGPSLongitude       = 0x0004
+
+	exifTypeASCII    = 2
+	exifTypeRational = 5
+	exifIFDMaxCount  = 512
+	exifDateLayout   = "2006:01:02 15:04:05"
+)
+
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+type jpegExifMetadata struct {
+	Orientation  int
+	CameraMake   string
+	CameraModel  string
+	TakenAt      *time.Time
+	GPSLatitude  *float64
+	GPSLongitude *float64
+}
+
+type exifIFDEntry struct {
+	typ   uint16
+	count int
+	pos   int
+}
+
+// parseJPEGExifMetadata 读取相机型号、拍摄时间与 GPS 坐标；字段缺失时保持零值。
+func parseJPEGExifMetadata(data []byte) jpegExifMetadata {
+	out := jpegExifMetadata{Orientation: parseJPEGExifOrientation(data)}
+	start, end, ok := findJPEGExifSegment(data)
+	if !ok {
+		return out
+	}
+	tiff := data[start+10 : end]
+	order := tiffByteOrder(tiff)
+	if order == nil || len(tiff) < 8 || order.Uint16(tiff[2:4]) != 42 {
+		return out
+	}
+
+	ifd0 := readExifIFD(tiff, order, int(order.Uint32(tiff[4:8])))
+	out.CameraMake = exifASCII(tiff, ifd0[exifTagMake])
+	out.CameraModel = exifASCII(tiff, ifd0[exifTagModel])
+
+	taken := exifASCII(tiff, ifd0[exifTagDateTime])
+	offset := ""
+	if ptr, ok := exifPointer(tiff, order, ifd0[exifTagExifIFDPointer]); ok {
+		exifIFD := readExifIFD(tiff, order, ptr)
+		if original := exifASCII(tiff, exifIFD[exifTagDateTimeOriginal]); original != "" {
+			taken = original
+		}
+		offset = exifASCII(tiff, exifIFD[exifTagOffsetTimeOriginal])
+	}
+	out.TakenAt = parseExifDateTime(taken, offset)
+
+	if ptr, ok := exifPointer(tiff, order, ifd0[exifTagGPSIFDPointer]); ok {
+		gps := readExifIFD(tiff, order, ptr)
+		out.GPSLatitude = exifGPSCoordinate(tiff, order, gps[exifTagGPSLatitude], exifASCII(tiff, gps[exifTagGPSLatitudeRef]), "S", 90)
+		out.GPSLongitude = exifGPSCoordinate(tiff, order, gps[exifTagGPSLongitude], exifASCII(tiff, gps[exifTagGPSLongitudeRef]), "W", 180)
+	}
+	return out
+}
+
+func readExifIFD(tiff []byte, order binary.ByteOrder, offset int) map[uint16]exifIFDEntry {
+	out := map[uint16]exifIFDEntry{}
+	if offset < 8 || offset+2 > len(tiff) {
+		return out
+	}
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	if count > exifIFDMaxCount {
+		return out
+	}
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		typ := order.Uint16(tiff[entry+2 : entry+4])
+		size, ok := exifTypeSizes[typ]
+		if !ok {
+			continue
+		}
+		valueCount := int(order.Uint32(tiff[entry+4 : entry+8]))
+		if valueCount <= 0 || valueCount > len(tiff) {
+			continue
+		}
+		pos := entry + 8
+		if size*valueCount > 4 {
+			pos = int(order.Uint32(tiff[entry+8 : entry+12]))
+		}
+		if pos < 0 || pos+size*valueCount > len(tiff) {
+			continue
+		}
+		out[order.Uint16(tiff[entry:entry+2])] = exifIFDEntry{typ: typ, count: valueCount, pos: pos}
+	}
+	return out
+}
+
+func exifASCII(tiff []byte, entry exifIFDEntry) string {
+	if entry.typ != exifTypeASCII || entry.count == 0 {
+		return ""
+	}
+	raw := string(tiff[entry.pos : entry.pos+entry.count])
+	if idx := strings.IndexByte(raw, 0); idx >= 0 {
+		raw = raw[:idx]
+	}
+	return strings.TrimSpace(raw)
+}
+
+func exifPointer(tiff []byte, order binary.ByteOrder, entry exifIFDEntry) (int, bool) {
+	if entry.count != 1 || exifTypeSizes[entry.typ] != 4 {
+		return 0, false
+	}
+	return int(order.Uint32(tiff[entry.pos : entry.pos+4])), true
+}
+
+func exifRational(tiff []byte, order binary.ByteOrder, entry exifIFDEntry, index int) (float64, bool) {
+	if entry.typ != exifTypeRational || index >= entry.count {
+		return 0, false
+	}
+	pos := entry.pos + index*8
+	num := order.Uint32(tiff[pos : pos+4])
+	den := order.Uint32(tiff[pos+4 : pos+8])
+	if den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// exifGPSCoordinate 把度/分/秒三个有理数换算为十进制度数，南纬与西经取负。
+func exifGPSCoordinate(tiff []byte, order binary.ByteOrder, entry exifIFDEntry, ref string, negativeRef string, limit float64) *float64 {
+	if entry.count < 3 {
+		return nil
+	}
+	deg, ok1 := exifRational(tiff, order, entry, 0)
+	minutes, ok2 := exifRational(tiff, order, entry, 1)
+	sec, ok3 := exifRational(tiff, order, entry, 2)
+	if !ok1 || !ok2 || !ok3 {
+		return nil
+	}
+	value := deg + minutes/60 + sec/3600
+	if strings.EqualFold(ref, negativeRef) {
+		value = -value
+	}
+	if value > limit || value < -limit {
+		return nil
+	}
+	return &value
+}
+
+// parseExifDateTime 解析 "2006:01:02 15:04:05"；缺少时区偏移时按 UTC 记录。
+func parseExifDateTime(raw string, offset string) *time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "0000") {
+		return nil
+	}
+	loc := time.UTC
+	if offset = strings.TrimSpace(offset); offset != "" {
+		if parsed, err := time.Parse("-07:00", offset); err == nil {
+			_, seconds := parsed.Zone()
+			loc = time.FixedZone(offset, seconds)
+		}
+	}
+	parsed, err := time.ParseInLocation(exifDateLayout, raw, loc)
+	if err != nil {
+		return nil
+	}
+	utc := parsed.UTC()
+	return &utc
+}
@@ -35,33 +35,13 @@ func (s *Server) handleDeleteItemPermanently(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	refs, err := st.ListChunkDeleteRefsByPathPrefix(r.Context(), it.Path)
+	cleanupResult, err := s.deleteItemPermanently(r.Context(), st, it)
 	if err != nil {
-		if errors.Is(err, store.ErrBadInput) {
-			writeError(w, http.StatusBadRequest, "bad_request", "路径非法")
-			return
-		}
-		s.logger.Error("list delete refs failed", "error", err.Error())
-		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		writeItemActionError(w, err)
 		return
 	}
-
-	// 先尽力删除 Telegram 消息；删除失败记录到表中，但不阻塞本地永久删除（宽松模式）。
-	cleanupResult := s.cleanupTelegramMessages(r.Context(), it, refs)
 	failedDeletes := cleanupResult.failures
 
-	if len(failedDeletes) > 0 {
-		if err := st.UpsertTelegramDeleteFailures(r.Context(), failedDeletes); err != nil {
-			s.logger.Error("record telegram delete failures failed", "error", err.Error(), "count", len(failedDeletes))
-		}
-	}
-
-	if err := st.DeleteItemsByPathPrefix(r.Context(), it.Path); err != nil {
-		s.logger.Error("delete items failed", "error", err.Error())
-		writeError(w, http.StatusInternalServerError, "internal_error", "删除失败")
-		return
-	}
-
 	// 构建失败详情（用于前端展示）
 	failedDetails := make([]map[string]any, 0, len(failedDeletes))
 	for _, f := range failedDeletes {
@@ -132,179 +112,146 @@ func (s *Server) handleCopyItem(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if destParent != nil {
-		parent, err := st.GetItem(r.Context(), *destParent)
-		if err != nil {
-			if errors.Is(err, store.ErrNotFound) {
-				writeError(w, http.StatusBadRequest, "bad_request", "目标目录不存在")
-				return
-			}
+	if err := validateItemCopyDestination(r.Context(), st, destParent); err != nil {
+		if !isItemActionError(err) {
 			s.logger.Error("get dest parent failed", "error", err.Error())
-			writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
-			return
-		}
-		if parent.Type != store.ItemTypeFolder {
-			writeError(w, http.StatusBadRequest, "bad_request", "目标目录不可用")
-			return
 		}
+		writeItemActionError(w, err)
+		return
 	}
 
-	now := time.Now()
-
-	if src.Type != store.ItemTypeFolder {
-		newItem, err := st.CreateFileItem(r.Context(), destParent, src.Type, nameWithCopySuffix(src.Name), src.Size, src.MimeType, now)
-		if err != nil {
-			if errors.Is(err, store.ErrBadInput) {
-				writeError(w, http.StatusBadRequest, "bad_request", "目标目录不可用")
-				return
-			}
-			if errors.Is(err, store.ErrConflict) {
-				writeError(w, http.StatusConflict, "conflict", "同一目录下已存在同名文件或文件夹")
-				return
-			}
-			s.logger.Error("create file item failed", "error", err.Error())
-			writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-			return
-		}
+	copied, err := s.copyItemTree(r.Context(), st, src, destParent, time.Now())
+	if err != nil {
+		writeItemActionError(w, err)
+		return
+	}
+	s.recordItemAudit(r.Context(), auditActionItemCopy, copied, map[string]any{"from": src.Path})
+	s.emitItemWebhookEvent(webhookEventItemCreated, copied, "copy")
+	writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(copied)})
+}
 
-		// 拷贝分块：使用 file_id 重新 sendDocument，不重新上传字节
-		srcChunks, err := st.ListChunks(r.Context(), src.ID)
-		if err != nil {
-			_ = st.DeleteItemsByPathPrefix(r.Context(), newItem.Path)
-			s.logger.Error("list chunks failed", "error", err.Error())
-			writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-			return
+// deleteItemPermanently 尽力删除 Telegram 消息后移除本地子树；删除失败记录到表中，不阻塞本地删除（宽松模式）。
+func (s *Server) deleteItemPermanently(ctx context.Context, st *store.Store, it store.Item) (telegramCleanupResult, error) {
+	refs, err := st.ListChunkDeleteRefsByPathPrefix(ctx, it.Path)
+	if err != nil {
+		if errors.Is(err, store.ErrBadInput) {
+			return telegramCleanupResult{}, newItemActionError(http.StatusBadRequest, "bad_request", "路径非法")
 		}
+		s.logger.Error("list delete refs failed", "error", err.Error())
+		return telegramCleanupResult{}, newItemActionError(http.StatusInternalServerError, "internal_error", "查询失败")
+	}
 
-		var createdRefs []store.ChunkDeleteRef
-		cleanup := func(ctx context.Context) {
-			for _, ref := range createdRefs {
-				_ = s.deleteMessageWithRetry(ctx, ref.TGChatID, ref.TGMessageID)
-			}
-			_ = st.DeleteItemsByPathPrefix(ctx, newItem.Path)
+	cleanupResult := s.cleanupTelegramMessages(ctx, it, refs)
+	if len(cleanupResult.failures) > 0 {
+		if err := st.UpsertTelegramDeleteFailures(ctx, cleanupResult.failures); err != nil {
+			s.logger.Error("record telegram delete failures failed", "error", err.Error(), "count", len(cleanupResult.failures))
 		}
+	}
 
-		for _, c := range srcChunks {
-			caption := fmt.Sprintf("tgcd-copy:%s:%d", newItem.ID.String(), c.ChunkIndex)
-			msg, err := s.sendDocumentByFileIDWithRetry(r.Context(), s.cfg.TGStorageChatID, c.TGFileID, caption)
-			if err != nil {
-				s.logger.Error("sendDocument(file_id) failed", "error", err.Error())
-				cleanup(r.Context())
-				writeError(w, http.StatusBadGateway, "bad_gateway", "复制到 Telegram 失败")
-				return
-			}
-			resolvedDoc, docErr := s.resolveMessageDocument(r.Context(), msg)
-			if docErr != nil {
-				s.logger.Error(
-					"sendDocument(file_id) missing file_id",
-					"src_item_id", src.ID.String(),
-					"chunk_index", c.ChunkIndex,
-					"message_id", msg.MessageID,
-					"error", docErr.Error(),
-				)
-				if msg.MessageID > 0 {
-					_ = s.deleteMessageWithRetry(r.Context(), s.cfg.TGStorageChatID, msg.MessageID)
-				}
-				cleanup(r.Context())
-				writeError(w, http.StatusBadGateway, "bad_gateway", "复制结果异常（缺少文件标识）")
-				return
-			}
+	auditDetail := map[string]any{
+		"type":             string(it.Type),
+		"telegramDeleted":  cleanupResult.stats.Deleted,
+		"telegramReplaced": cleanupResult.stats.Replaced,
+		"telegramFailed":   cleanupResult.stats.Failed,
+	}
+	if err := st.DeleteItemsByPathPrefix(ctx, it.Path); err != nil {
+		s.logger.Error("delete items failed", "error", err.Error())
+		auditDetail["error"] = err.Error()
+		s.recordAudit(ctx, auditEntry{Action: auditActionItemDelete, Failed: true, ItemID: &it.ID, ItemPath: it.Path, Detail: auditDetail})
+		return cleanupResult, newItemActionError(http.StatusInternalServerError, "internal_error", "删除失败")
+	}
+	s.recordItemAudit(ctx, auditActionItemDelete, it, auditDetail)
+	s.emitItemWebhookEvent(webhookEventItemDeleted, it, "")
+	return cleanupResult, nil
+}
 
-			createdRefs = append(createdRefs, store.ChunkDeleteRef{TGChatID: s.cfg.TGStorageChatID, TGMessageID: msg.MessageID})
+func validateItemCopyDestination(ctx context.Context, st *store.Store, destParent *uuid.UUID) error {
+	if destParent == nil {
+		return nil
+	}
+	parent, err := st.GetItem(ctx, *destParent)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return newItemActionError(http.StatusBadRequest, "bad_request", "目标目录不存在")
+		}
+		return err
+	}
+	if parent.Type != store.ItemTypeFolder {
+		return newItemActionError(http.StatusBadRequest, "bad_request", "目标目录不可用")
+	}
+	return nil
+}
 
-			chunkSize := c.ChunkSize
-			if resolvedDoc.FileSize > 0 && resolvedDoc.FileSize < int64(^uint(0)>>1) {
-				chunkSize = int(resolvedDoc.FileSize)
-			}
-			if err := st.InsertChunk(r.Context(), store.Chunk{
-				ID:             uuid.New(),
-				ItemID:         newItem.ID,
-				ChunkIndex:     c.ChunkIndex,
-				ChunkSize:      chunkSize,
-				TGChatID:       s.cfg.TGStorageChatID,
-				TGMessageID:    msg.MessageID,
-				TGFileID:       resolvedDoc.FileID,
-				TGFileUniqueID: resolvedDoc.FileUniqueID,
-				CreatedAt:      now,
-			}); err != nil {
-				s.logger.Error("insert chunk failed", "error", err.Error())
-				cleanup(r.Context())
-				writeError(w, http.StatusInternalServerError, "internal_error", "写入分块元数据失败")
-				return
-			}
+// copyItemTree 复制文件或整个目录；分块通过 file_id 重新 sendDocument，不重新上传字节。失败时回滚已创建的消息与条目。
+func (s *Server) copyItemTree(
+	ctx context.Context,
+	st *store.Store,
+	src store.Item,
+	destParent *uuid.UUID,
+	now time.Time,
+) (store.Item, error) {
+	if src.Type != store.ItemTypeFolder {
+		newItem, err := st.CreateFileItem(ctx, destParent, src.Type, nameWithCopySuffix(src.Name), src.Size, src.MimeType, now)
+		if err != nil {
+			return store.Item{}, s.mapItemCopyCreateError(err, "create file item failed")
 		}
 
-		writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(newItem)})
-		return
+		var createdRefs []store.ChunkDeleteRef
+		if err := s.copyItemChunks(ctx, st, src.ID, newItem.ID, now, &createdRefs); err != nil {
+			s.rollbackItemCopy(ctx, st, newItem.Path, createdRefs)
+			return store.Item{}, err
+		}
+		return newItem, nil
 	}
 
 	// 文件夹复制：先读取子树快照，再创建新根目录，避免“复制到自身/子目录”时把新目录也扫进来。
-	subtree, err := st.ListSubtreeItems(r.Context(), src.Path)
+	subtree, err := st.ListSubtreeItems(ctx, src.Path)
 	if err != nil {
 		if errors.Is(err, store.ErrBadInput) {
-			writeError(w, http.StatusBadRequest, "bad_request", "路径非法")
-			return
+			return store.Item{}, newItemActionError(http.StatusBadRequest, "bad_request", "路径非法")
 		}
 		s.logger.Error("list subtree failed", "error", err.Error())
-		writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-		return
+		return store.Item{}, newItemActionError(http.StatusInternalServerError, "internal_error", "复制失败")
 	}
 
-	newRoot, err := st.CreateFolder(r.Context(), destParent, nameWithCopySuffix(src.Name), now)
+	newRoot, err := st.CreateFolder(ctx, destParent, nameWithCopySuffix(src.Name), now)
 	if err != nil {
-		if errors.Is(err, store.ErrBadInput) {
-			writeError(w, http.StatusBadRequest, "bad_request", "目标目录不可用")
-			return
-		}
-		if errors.Is(err, store.ErrConflict) {
-			writeError(w, http.StatusConflict, "conflict", "同一目录下已存在同名文件或文件夹")
-			return
-		}
-		s.logger.Error("create folder failed", "error", err.Error())
-		writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-		return
+		return store.Item{}, s.mapItemCopyCreateError(err, "create folder failed")
 	}
 
 	var createdRefs []store.ChunkDeleteRef
-	cleanupTree := func(ctx context.Context) {
-		for _, ref := range createdRefs {
-			_ = s.deleteMessageWithRetry(ctx, ref.TGChatID, ref.TGMessageID)
-		}
-		_ = st.DeleteItemsByPathPrefix(ctx, newRoot.Path)
-	}
-
 	idMap := map[uuid.UUID]uuid.UUID{
 		src.ID: newRoot.ID,
 	}
-
 	for _, node := range subtree {
 		if node.ID == src.ID {
 			continue
 		}
+		if err := ctx.Err(); err != nil {
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, err
+		}
 		if node.ParentID == nil {
-			cleanupTree(r.Context())
-			writeError(w, http.StatusInternalServerError, "internal_error", "目录结构异常")
-			return
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, newItemActionError(http.StatusInternalServerError, "internal_error", "目录结构异常")
 		}
 
 		mappedParent, ok := idMap[*node.ParentID]
 		if !ok {
-			cleanupTree(r.Context())
-			writeError(w, http.StatusInternalServerError, "internal_error", "目录结构异常")
-			return
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, newItemActionError(http.StatusInternalServerError, "internal_error", "目录结构异常")
 		}
 
 		rel := strings.TrimPrefix(node.Path, src.Path)
 		if rel == "" || !strings.HasPrefix(rel, "/") {
-			cleanupTree(r.Context())
-			writeError(w, http.StatusInternalServerError, "internal_error", "路径计算失败")
-			return
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, newItemActionError(http.StatusInternalServerError, "internal_error", "路径计算失败")
 		}
 		newPath := strings.TrimRight(newRoot.Path, "/") + rel
 
 		newID := uuid.New()
 		parentID := mappedParent
-		if err := st.InsertItemRaw(r.Context(), store.InsertItemRawInput{
+		if err := st.InsertItemRaw(ctx, store.InsertItemRawInput{
 			ID:        newID,
 			Type:      node.Type,
 			Name:      node.Name,
@@ -316,75 +263,100 @@ func (s *Server) handleCopyItem(w http.ResponseWriter, r *http.Request) {
 			UpdatedAt: now,
 		}); err != nil {
 			s.logger.Error("insert item raw failed", "error", err.Error())
-			cleanupTree(r.Context())
-			writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-			return
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, newItemActionError(http.StatusInternalServerError, "internal_error", "复制失败")
 		}
 		idMap[node.ID] = newID
 
 		if node.Type == store.ItemTypeFolder {
 			continue
 		}
+		if err := s.copyItemChunks(ctx, st, node.ID, newID, now, &createdRefs); err != nil {
+			s.rollbackItemCopy(ctx, st, newRoot.Path, createdRefs)
+			return store.Item{}, err
+		}
+	}
+	return newRoot, nil
+}
 
-		srcChunks, err := st.ListChunks(r.Context(), node.ID)
+func (s *Server) copyItemChunks(
+	ctx context.Context,
+	st *store.Store,
+	srcID uuid.UUID,
+	newID uuid.UUID,
+	now time.Time,
+	createdRefs *[]store.ChunkDeleteRef,
+) error {
+	srcChunks, err := st.ListChunks(ctx, srcID)
+	if err != nil {
+		s.logger.Error("list chunks failed", "error", err.Error())
+		return newItemActionError(http.StatusInternalServerError, "internal_error", "复制失败")
+	}
+
+	for _, c := range srcChunks {
+		caption := fmt.Sprintf("tgcd-copy:%s:%d", newID.String(), c.ChunkIndex)
+		msg, err := s.sendDocumentByFileIDWithRetry(ctx, s.cfg.TGStorageChatID, c.TGFileID, caption)
 		if err != nil {
-			s.logger.Error("list chunks failed", "error", err.Error())
-			cleanupTree(r.Context())
-			writeError(w, http.StatusInternalServerError, "internal_error", "复制失败")
-			return
+			s.logger.Error("sendDocument(file_id) failed", "error", err.Error())
+			return newItemActionError(http.StatusBadGateway, "bad_gateway", "复制到 Telegram 失败")
 		}
-		for _, c := range srcChunks {
-			caption := fmt.Sprintf("tgcd-copy:%s:%d", newID.String(), c.ChunkIndex)
-			msg, err := s.sendDocumentByFileIDWithRetry(r.Context(), s.cfg.TGStorageChatID, c.TGFileID, caption)
-			if err != nil {
-				s.logger.Error("sendDocument(file_id) failed", "error", err.Error())
-				cleanupTree(r.Context())
-				writeError(w, http.StatusBadGateway, "bad_gateway", "复制到 Telegram 失败")
-				return
-			}
-			resolvedDoc, docErr := s.resolveMessageDocument(r.Context(), msg)
-			if docErr != nil {
-				s.logger.Error(
-					"sendDocument(file_id) missing file_id",
-					"src_item_id", node.ID.String(),
-					"chunk_index", c.ChunkIndex,
-					"message_id", msg.MessageID,
-					"error", docErr.Error(),
-				)
-				if msg.MessageID > 0 {
-					_ = s.deleteMessageWithRetry(r.Context(), s.cfg.TGStorageChatID, msg.MessageID)
-				}
-				cleanupTree(r.Context())
-				writeError(w, http.StatusBadGateway, "bad_gateway", "复制结果异常（缺少文件标识）")
-				return
+		resolvedDoc, docErr := s.resolveMessageDocument(ctx, msg)
+		if docErr != nil {
+			s.logger.Error(
+				"sendDocument(file_id) missing file_id",
+				"src_item_id", srcID.String(),
+				"chunk_index", c.ChunkIndex,
+				"message_id", msg.MessageID,
+				"error", docErr.Error(),
+			)
+			if msg.MessageID > 0 {
+				_ = s.deleteMessageWithRetry(ctx, s.cfg.TGStorageChatID, msg.MessageID)
 			}
+			return newItemActionError(http.StatusBadGateway, "bad_gateway", "复制结果异常（缺少文件标识）")
+		}
 
-			createdRefs = append(createdRefs, store.ChunkDeleteRef{TGChatID: s.cfg.TGStorageChatID, TGMessageID: msg.MessageID})
+		*createdRefs = append(*createdRefs, store.ChunkDeleteRef{TGChatID: s.cfg.TGStorageChatID, TGMessageID: msg.MessageID})
 
-			chunkSize := c.ChunkSize
-			if resolvedDoc.FileSize > 0 && resolvedDoc.FileSize < int64(^uint(0)>>1) {
-				chunkSize = int(resolvedDoc.FileSize)
-			}
-			if err := st.InsertChunk(r.Context(), store.Chunk{
-				ID:             uuid.New(),
-				ItemID:         newID,
-				ChunkIndex:     c.ChunkIndex,
-				ChunkSize:      chunkSize,
-				TGChatID:       s.cfg.TGStorageChatID,
-				TGMessageID:    msg.MessageID,
-				TGFileID:       resolvedDoc.FileID,
-				TGFileUniqueID: resolvedDoc.FileUniqueID,
-				CreatedAt:      now,
-			}); err != nil {
-				s.logger.Error("insert chunk failed", "error", err.Error())
-				cleanupTree(r.Context())
-				writeError(w, http.StatusInternalServerError, "internal_error", "写入分块元数据失败")
-				return
-			}
+		chunkSize := c.ChunkSize
+		if resolvedDoc.FileSize > 0 && resolvedDoc.FileSize < int64(^uint(0)>>1) {
+			chunkSize = int(resolvedDoc.FileSize)
+		}
+		if err := st.InsertChunk(ctx, store.Chunk{
+			ID:             uuid.New(),
+			ItemID:         newID,
+			ChunkIndex:     c.ChunkIndex,
+			ChunkSize:      chunkSize,
+			TGChatID:       s.cfg.TGStorageChatID,
+			TGMessageID:    msg.MessageID,
+			TGFileID:       resolvedDoc.FileID,
+			TGFileUniqueID: resolvedDoc.FileUniqueID,
+			CreatedAt:      now,
+		}); err != nil {
+			s.logger.Error("insert chunk failed", "error", err.Error())
+			return newItemActionError(http.StatusInternalServerError, "internal_error", "写入分块元数据失败")
 		}
 	}
+	return nil
+}
+
+// rollbackItemCopy 不使用调用方 ctx，保证批量任务被取消后仍能清理已复制的一半内容。
+func (s *Server) rollbackItemCopy(ctx context.Context, st *store.Store, newPath string, createdRefs []store.ChunkDeleteRef) {
+	cleanupCtx := context.WithoutCancel(ctx)
+	for _, ref := range createdRefs {
+		_ = s.deleteMessageWithRetry(cleanupCtx, ref.TGChatID, ref.TGMessageID)
+	}
+	_ = st.DeleteItemsByPathPrefix(cleanupCtx, newPath)
+}
 
-	writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(newRoot)})
+func (s *Server) mapItemCopyCreateError(err error, logMessage string) error {
+	if errors.Is(err, store.ErrBadInput) {
+		return newItemActionError(http.StatusBadRequest, "bad_request", "目标目录不可用")
+	}
+	if errors.Is(err, store.ErrConflict) {
+		return newItemActionError(http.StatusConflict, "conflict", "同一目录下已存在同名文件或文件夹")
+	}
+	s.logger.Error(logMessage, "error", err.Error())
+	return newItemActionError(http.StatusInternalServerError, "internal_error", "复制失败")
 }
 
 func nameWithCopySuffix(original string) string {
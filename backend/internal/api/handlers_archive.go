@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+// handleListArchiveEntries 通过 Range 读取 zip 中央目录列出条目，不下载整个压缩包。
+func (s *Server) handleListArchiveEntries(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadArchiveItem(w, r)
+	if !ok {
+		return
+	}
+	archive, err := s.openItemZipArchive(r.Context(), item)
+	if err != nil {
+		s.writeArchiveError(w, item, "open archive failed", err)
+		return
+	}
+
+	entries := listZipArchiveEntries(archive.reader)
+	truncated := len(entries) > archiveListMaxEntries
+	if truncated {
+		entries = entries[:archiveListMaxEntries]
+	}
+	out := make([]archiveEntryDTO, 0, len(entries))
+	var totalSize int64
+	fileCount := 0
+	for _, entry := range entries {
+		out = append(out, entry.dto)
+		if !entry.dto.IsDir {
+			fileCount++
+			totalSize += entry.dto.Size
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"format":    "zip",
+		"entries":   out,
+		"fileCount": fileCount,
+		"totalSize": totalSize,
+		"truncated": truncated,
+		"comment":   archive.reader.Comment,
+	})
+}
+
+// handleArchiveEntryContent 流式返回单个条目，只读取该条目的压缩数据区间。
+func (s *Server) handleArchiveEntryContent(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadArchiveItem(w, r)
+	if !ok {
+		return
+	}
+	entryPath := strings.TrimSpace(r.URL.Query().Get("path"))
+	if entryPath == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "缺少 path 参数")
+		return
+	}
+	archive, err := s.openItemZipArchive(r.Context(), item)
+	if err != nil {
+		s.writeArchiveError(w, item, "open archive failed", err)
+		return
+	}
+	entry, ok := findZipArchiveEntry(listZipArchiveEntries(archive.reader), entryPath)
+	if !ok || entry.dto.IsDir {
+		writeError(w, http.StatusNotFound, "not_found", "压缩包内文件不存在")
+		return
+	}
+	if !entry.dto.Supported {
+		writeError(w, http.StatusBadRequest, "bad_request", errArchiveEntryUnsupported.Error())
+		return
+	}
+
+	stream, err := s.openZipEntryStream(r.Context(), archive, entry.file)
+	if err != nil {
+		s.writeArchiveError(w, item, "open archive entry failed", err)
+		return
+	}
+	defer stream.Close()
+
+	mimeType := inferMimeTypeByFileName(entry.dto.Name)
+	if mimeType == "" {
+		mimeType = binaryMimeType
+	}
+	download := strings.TrimSpace(r.URL.Query().Get("download")) == "1"
+	inline := shouldInlinePreviewDownload(store.GuessItemType(entry.dto.Name, mimeType), mimeType, download)
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(entry.dto.Size, 10))
+	w.Header().Set("Content-Disposition", contentDisposition(entry.dto.Name, inline))
+	w.Header().Set("Cache-Control", "private, max-age=0")
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, stream); err != nil && !errors.Is(err, context.Canceled) {
+		s.logger.Warn("stream archive entry failed", "error", err.Error(), "item_id", item.ID.String(), "entry", entry.dto.Path)
+	}
+}
+
+// handleExtractArchive 把压缩包解压到网盘目录，作为传输任务在后台执行。
+func (s *Server) handleExtractArchive(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadArchiveItem(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		DestinationParentRaw *json.RawMessage `json:"destinationParentId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	destParent, destSpecified, err := parseItemBatchDestination(req.DestinationParentRaw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if !destSpecified {
+		destParent = item.ParentID
+	}
+
+	st := store.New(s.db)
+	if err := validateItemCopyDestination(r.Context(), st, destParent); err != nil {
+		if !isItemActionError(err) {
+			s.logger.Error("get dest parent failed", "error", err.Error())
+		}
+		writeItemActionError(w, err)
+		return
+	}
+
+	archive, err := s.openItemZipArchive(r.Context(), item)
+	if err != nil {
+		s.writeArchiveError(w, item, "open archive failed", err)
+		return
+	}
+	entries := listZipArchiveEntries(archive.reader)
+	fileCount, totalSize := summarizeArchiveFiles(entries)
+	if fileCount == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "压缩包内没有可解压的文件")
+		return
+	}
+
+	now := time.Now()
+	root, err := st.CreateFolder(r.Context(), destParent, archiveExtractFolderName(item.Name), now)
+	if err != nil {
+		s.logger.Error("create archive extract folder failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建解压目录失败")
+		return
+	}
+	if item.InVault {
+		// 密码箱中的压缩包解压后仍留在密码箱内
+		if _, err := st.UpdateItemsVaultByPathPrefix(r.Context(), root.Path, true, now); err != nil {
+			s.logger.Warn("mark archive extract folder vault failed", "error", err.Error(), "item_id", root.ID.String())
+		}
+	}
+
+	job := buildArchiveExtractTransferJob(item, root, fileCount, totalSize, now)
+	if err := st.CreateTransferJob(r.Context(), job); err != nil {
+		s.logger.Error("create archive extract transfer job failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建解压任务失败")
+		return
+	}
+
+	// 后台任务不随请求取消，但沿用请求的链路标识。
+	ctx, cancel := context.WithCancel(tracing.Detach(r.Context()))
+	s.registerArchiveJob(job.ID, cancel)
+	s.publishRunningTransferJob(r.Context(), job)
+	s.goBackground(func() { s.runArchiveExtractJob(ctx, job, item, root) })
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"transferId": job.ID.String(),
+		"folder":     toItemDTO(root),
+	})
+}
+
+// handleCreateFolderArchive 将文件夹打包为 zip 或 tar.zst 并作为新文件保存到网盘，后台执行。
+func (s *Server) handleCreateFolderArchive(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	var req struct {
+		Format               string           `json:"format"`
+		DestinationParentRaw *json.RawMessage `json:"destinationParentId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	format, ok := parseArchiveFormat(req.Format)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "bad_request", "format 仅支持 zip 或 tar.zst")
+		return
+	}
+
+	st := store.New(s.db)
+	folder, err := st.GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件夹不存在")
+			return
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if folder.Type != store.ItemTypeFolder {
+		writeError(w, http.StatusBadRequest, "bad_request", "只能打包文件夹")
+		return
+	}
+	if folder.InVault && !s.requireVaultUnlocked(w, r) {
+		return
+	}
+
+	destParent, destSpecified, err := parseItemBatchDestination(req.DestinationParentRaw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if !destSpecified {
+		destParent = folder.ParentID
+	}
+	if err := validateItemCopyDestination(r.Context(), st, destParent); err != nil {
+		if !isItemActionError(err) {
+			s.logger.Error("get dest parent failed", "error", err.Error())
+		}
+		writeItemActionError(w, err)
+		return
+	}
+
+	items, err := st.ListSubtreeItems(r.Context(), folder.Path)
+	if err != nil {
+		s.logger.Error("list subtree items failed", "error", err.Error(), "item_id", folder.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取文件夹失败")
+		return
+	}
+	tree := collectArchiveSourceTree(folder, items)
+	if len(tree.files) == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "文件夹内没有可打包的文件")
+		return
+	}
+
+	now := time.Now()
+	name := archiveOutputName(folder.Name, format)
+	mimeType := archiveOutputMimeType(format)
+	target, err := st.CreateFileItem(r.Context(), destParent, store.GuessItemType(name, mimeType), name, 0, &mimeType, now)
+	if err != nil {
+		s.logger.Error("create archive item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建压缩文件失败")
+		return
+	}
+	if folder.InVault {
+		if _, err := st.UpdateItemsVaultByPathPrefix(r.Context(), target.Path, true, now); err != nil {
+			s.logger.Warn("mark archive item vault failed", "error", err.Error(), "item_id", target.ID.String())
+		}
+	}
+
+	job := buildArchiveCreateTransferJob(folder, target, len(tree.files), tree.totalSize, now)
+	if err := st.CreateTransferJob(r.Context(), job); err != nil {
+		_ = st.DeleteItemsByPathPrefix(r.Context(), target.Path)
+		s.logger.Error("create archive transfer job failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建压缩任务失败")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(tracing.Detach(r.Context()))
+	s.registerArchiveJob(job.ID, cancel)
+	s.publishRunningTransferJob(r.Context(), job)
+	s.goBackground(func() { s.runArchiveCreateJob(ctx, job, target, format, tree) })
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"transferId": job.ID.String(),
+		"item":       toItemDTO(target),
+	})
+}
+
+func (s *Server) loadArchiveItem(w http.ResponseWriter, r *http.Request) (store.Item, bool) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return store.Item{}, false
+	}
+	item, err := store.New(s.db).GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return store.Item{}, false
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return store.Item{}, false
+	}
+	if item.Type == store.ItemTypeFolder {
+		writeError(w, http.StatusBadRequest, "bad_request", "文件夹不是压缩包")
+		return store.Item{}, false
+	}
+	if item.InVault {
+		if !s.requireVaultUnlocked(w, r) {
+			return store.Item{}, false
+		}
+	}
+	return item, true
+}
+
+func (s *Server) writeArchiveError(w http.ResponseWriter, item store.Item, logMsg string, err error) {
+	switch {
+	case errors.Is(err, errArchiveUnsupported), errors.Is(err, errArchiveEntryUnsupported):
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+	case errors.Is(err, context.Canceled):
+		return
+	default:
+		s.logger.Warn(logMsg, "error", err.Error(), "item_id", item.ID.String())
+		writeError(w, http.StatusBadGateway, "bad_gateway", "读取压缩包失败")
+	}
+}
+
+func summarizeArchiveFiles(entries []archiveEntry) (int, int64) {
+	count := 0
+	var total int64
+	for _, entry := range entries {
+		if entry.dto.IsDir {
+			continue
+		}
+		count++
+		total += entry.dto.Size
+	}
+	return count, total
+}
+
+func archiveExtractFolderName(archiveName string) string {
+	name := strings.TrimSpace(archiveName)
+	base := strings.TrimSuffix(name, path.Ext(name))
+	if strings.HasSuffix(strings.ToLower(base), ".tar") {
+		base = strings.TrimSuffix(base, path.Ext(base))
+	}
+	if strings.TrimSpace(base) == "" {
+		return name + "_extracted"
+	}
+	return base
+}
+
+func buildArchiveExtractTransferJob(archive store.Item, root store.Item, fileCount int, totalSize int64, now time.Time) store.TransferJob {
+	jobID := uuid.New()
+	rootID := root.ID
+	return store.TransferJob{
+		ID:           jobID,
+		Direction:    store.TransferDirectionUpload,
+		SourceKind:   store.TransferSourceKindArchiveExtract,
+		SourceRef:    archive.ID.String() + ":" + jobID.String(),
+		UnitKind:     store.TransferUnitKindFolder,
+		Name:         strings.TrimSpace(archive.Name),
+		TargetItemID: &rootID,
+		TotalSize:    totalSize,
+		ItemCount:    fileCount,
+		Status:       store.TransferJobStatusRunning,
+		StartedAt:    now,
+		FinishedAt:   now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
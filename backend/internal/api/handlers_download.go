@@ -135,6 +135,9 @@ func (s *Server) handleSharedDownload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_ = st.TouchItem(r.Context(), it.ID, time.Now())
+	if r.Method == http.MethodGet && isInitialRangeRequest(r.Header.Get("Range")) {
+		s.emitShareAccessedWebhookEvent(r, it, "download")
+	}
 	_ = s.serveChunkedDownload(w, r, it, chunks)
 }
 
@@ -357,7 +360,7 @@ func (s *Server) serveChunkedDownload(w http.ResponseWriter, r *http.Request, it
 			req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", subStart, subEnd))
 		}
 
-		resp, err := fileHTTP.Do(req)
+		resp, err := doTelegramFileRequest(fileHTTP, req)
 		if err != nil {
 			s.logger.Error("download chunk failed", "error", err.Error())
 			if !wroteHeader {
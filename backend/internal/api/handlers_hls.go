@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	hlsPlaylistContentType = "application/vnd.apple.mpegurl"
+	hlsSegmentContentType  = "video/mp2t"
+)
+
+func (s *Server) handleItemHLSMaster(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadHLSItem(w, r)
+	if !ok {
+		return
+	}
+	probe, err := s.resolveHLSProbe(r.Context(), item)
+	if err != nil {
+		s.writeHLSError(w, item, "probe hls source failed", err)
+		return
+	}
+	writeHLSPlaylist(w, buildHLSMasterPlaylist(probe))
+}
+
+func (s *Server) handleItemHLSPlaylist(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadHLSItem(w, r)
+	if !ok {
+		return
+	}
+	probe, err := s.resolveHLSProbe(r.Context(), item)
+	if err != nil {
+		s.writeHLSError(w, item, "probe hls source failed", err)
+		return
+	}
+	if _, ok := findHLSRendition(probe, chi.URLParam(r, "rendition")); !ok {
+		writeError(w, http.StatusNotFound, "not_found", "清晰度不存在")
+		return
+	}
+	writeHLSPlaylist(w, buildHLSMediaPlaylist(probe))
+}
+
+func (s *Server) handleItemHLSSegment(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadHLSItem(w, r)
+	if !ok {
+		return
+	}
+	probe, err := s.resolveHLSProbe(r.Context(), item)
+	if err != nil {
+		s.writeHLSError(w, item, "probe hls source failed", err)
+		return
+	}
+	rendition, ok := findHLSRendition(probe, chi.URLParam(r, "rendition"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "清晰度不存在")
+		return
+	}
+	index, ok := parseHLSSegmentName(chi.URLParam(r, "segment"), probe)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "分段不存在")
+		return
+	}
+
+	segmentDir := filepath.Join(s.hlsItemCacheDir(item), rendition.Name)
+	if err := os.MkdirAll(segmentDir, 0o755); err != nil {
+		s.logger.Error("create hls cache dir failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "转码缓存初始化失败")
+		return
+	}
+	segmentPath := filepath.Join(segmentDir, strconv.Itoa(index)+".ts")
+	if s.tryServeHLSSegment(w, r, segmentPath) {
+		return
+	}
+
+	leader, wait := s.beginHLSGeneration(segmentPath)
+	if !leader {
+		select {
+		case <-wait:
+			if s.tryServeHLSSegment(w, r, segmentPath) {
+				return
+			}
+			writeError(w, http.StatusBadGateway, "bad_gateway", "视频转码失败")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	defer s.finishHLSGeneration(segmentPath)
+
+	settings, err := s.getRuntimeSettings(r.Context())
+	if err != nil {
+		s.logger.Error("get runtime settings failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取运行配置失败")
+		return
+	}
+	if err := s.acquireHLSTranscodeSlot(r.Context(), settings.HLSTranscodeConcurrency); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "转码队列繁忙，请稍后重试")
+		return
+	}
+	defer s.releaseHLSTranscode()
+
+	if err := s.generateHLSSegment(r.Context(), item, probe, rendition, index, segmentPath); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		s.writeHLSError(w, item, "transcode hls segment failed", err)
+		return
+	}
+
+	go s.cleanupHLSCache(settings.HLSCacheMaxBytes)
+
+	if s.tryServeHLSSegment(w, r, segmentPath) {
+		return
+	}
+	writeError(w, http.StatusBadGateway, "bad_gateway", "视频分段读取失败")
+}
+
+func (s *Server) loadHLSItem(w http.ResponseWriter, r *http.Request) (store.Item, bool) {
+	return s.loadVideoItem(w, r, "仅视频文件支持 HLS 播放")
+}
+
+// loadVideoItem 读取路径中的视频文件并校验密码箱状态，非视频时返回 unsupportedMsg。
+func (s *Server) loadVideoItem(w http.ResponseWriter, r *http.Request, unsupportedMsg string) (store.Item, bool) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return store.Item{}, false
+	}
+
+	item, err := store.New(s.db).GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return store.Item{}, false
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return store.Item{}, false
+	}
+	if item.Type != store.ItemTypeVideo {
+		writeError(w, http.StatusBadRequest, "bad_request", unsupportedMsg)
+		return store.Item{}, false
+	}
+	if item.InVault {
+		if !s.requireVaultUnlocked(w, r) {
+			return store.Item{}, false
+		}
+	}
+	return item, true
+}
+
+// resolveHLSProbe 读取缓存的探测结果；缺失时通过回环地址执行 ffprobe 并写入缓存。
+func (s *Server) resolveHLSProbe(ctx context.Context, item store.Item) (hlsProbe, error) {
+	itemDir := s.hlsItemCacheDir(item)
+	probePath := filepath.Join(itemDir, hlsProbeFileName)
+	if probe, ok := readHLSProbeFile(probePath); ok {
+		return probe, nil
+	}
+
+	leader, wait := s.beginHLSGeneration(probePath)
+	if !leader {
+		select {
+		case <-wait:
+			if probe, ok := readHLSProbeFile(probePath); ok {
+				return probe, nil
+			}
+			return hlsProbe{}, errors.New("视频探测失败")
+		case <-ctx.Done():
+			return hlsProbe{}, ctx.Err()
+		}
+	}
+	defer s.finishHLSGeneration(probePath)
+
+	sourceURL, release, err := s.openHLSSourceURL(item.ID)
+	if err != nil {
+		return hlsProbe{}, err
+	}
+	defer release()
+
+	probe, err := s.probeHLSSource(ctx, sourceURL)
+	if err != nil {
+		return hlsProbe{}, err
+	}
+	if err := os.MkdirAll(itemDir, 0o755); err != nil {
+		return hlsProbe{}, err
+	}
+	if err := writeHLSProbeFile(probePath, probe); err != nil {
+		return hlsProbe{}, err
+	}
+	return probe, nil
+}
+
+func (s *Server) generateHLSSegment(
+	ctx context.Context,
+	item store.Item,
+	probe hlsProbe,
+	rendition hlsRendition,
+	index int,
+	segmentPath string,
+) error {
+	sourceURL, release, err := s.openHLSSourceURL(item.ID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := strconv.Itoa(index * hlsSegmentSeconds)
+	args := []string{
+		"-ss", start,
+		"-i", sourceURL,
+		"-t", strconv.FormatFloat(probe.segmentDuration(index), 'f', 3, 64),
+		"-map", "0:v:0",
+		"-map", "0:a:0?",
+		"-sn", "-dn",
+		"-vf", rendition.scaleFilter(probe) + ",format=yuv420p",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-b:v", fmt.Sprintf("%dk", rendition.VideoBitrateK),
+		"-maxrate", fmt.Sprintf("%dk", rendition.VideoBitrateK*107/100),
+		"-bufsize", fmt.Sprintf("%dk", rendition.VideoBitrateK*2),
+		"-force_key_frames", "expr:gte(t,0)",
+		"-c:a", "aac",
+		"-ac", "2",
+		"-b:a", fmt.Sprintf("%dk", rendition.AudioBitrateK),
+		"-output_ts_offset", start,
+		"-muxdelay", "0",
+		"-f", "mpegts",
+	}
+	return s.runFFmpegToFile(ctx, segmentPath, "tgcd-hls-*.ts", args...)
+}
+
+func (s *Server) tryServeHLSSegment(w http.ResponseWriter, r *http.Request, segmentPath string) bool {
+	info, err := os.Stat(segmentPath)
+	if err != nil || info.IsDir() || info.Size() <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(segmentPath, now, now)
+
+	w.Header().Set("Content-Type", hlsSegmentContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeFile(w, r, segmentPath)
+	return true
+}
+
+func writeHLSPlaylist(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", hlsPlaylistContentType)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body))
+}
+
+func (s *Server) writeHLSError(w http.ResponseWriter, item store.Item, logMsg string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		writeError(w, http.StatusGatewayTimeout, "timeout", "视频处理超时")
+		return
+	}
+	if isMediaToolMissingError(err) {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "ffmpeg 未安装，无法转码播放")
+		return
+	}
+	s.logger.Warn(logMsg, "error", err.Error(), "item_id", item.ID.String())
+	writeError(w, http.StatusBadGateway, "bad_gateway", "视频转码失败")
+}
+
+// isMediaToolMissingError 判断错误是否由 ffmpeg/ffprobe 未安装导致。
+func isMediaToolMissingError(err error) bool {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) && strings.Contains(strings.ToLower(execErr.Error()), "executable file not found") {
+		return true
+	}
+	return strings.Contains(err.Error(), "未找到 ffprobe")
+}
+
+func (s *Server) hlsCacheDir() string {
+	if strings.TrimSpace(s.cfg.HLSCacheDir) != "" {
+		return strings.TrimSpace(s.cfg.HLSCacheDir)
+	}
+	return filepath.Join(os.TempDir(), "tgcd-hls-cache")
+}
+
+// hlsItemCacheDir 与缩略图一样以更新时间区分版本，文件被覆盖后旧分段自然失效。
+func (s *Server) hlsItemCacheDir(item store.Item) string {
+	return filepath.Join(s.hlsCacheDir(), s.thumbnailCacheKey(item))
+}
+
+func (s *Server) beginHLSGeneration(key string) (bool, <-chan struct{}) {
+	s.hlsGenMu.Lock()
+	defer s.hlsGenMu.Unlock()
+
+	if wait, ok := s.hlsGenerating[key]; ok {
+		return false, wait
+	}
+
+	wait := make(chan struct{})
+	s.hlsGenerating[key] = wait
+	return true, wait
+}
+
+func (s *Server) finishHLSGeneration(key string) {
+	s.hlsGenMu.Lock()
+	wait, ok := s.hlsGenerating[key]
+	if ok {
+		delete(s.hlsGenerating, key)
+	}
+	s.hlsGenMu.Unlock()
+	if ok {
+		close(wait)
+	}
+}
@@ -31,5 +31,15 @@ func (s *Server) handleGetItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(it)})
+	var metadata *itemMetadataDTO
+	if isMediaMetadataItem(it) {
+		meta, err := st.GetItemMetadata(r.Context(), it.ID)
+		if err == nil {
+			metadata = toItemMetadataDTO(meta)
+		} else if !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("get item metadata failed", "error", err.Error(), "item_id", it.ID.String())
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(it), "metadata": metadata})
 }
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	imageRenditionMaxSide = 4096
+
+	imageRenditionFitContain = "contain"
+	imageRenditionFitCover   = "cover"
+	imageRenditionFitFill    = "fill"
+
+	imageRenditionFormatJPEG = "jpeg"
+	imageRenditionFormatPNG  = "png"
+	imageRenditionFormatWebP = "webp"
+
+	imageRenditionWebPQuality = "80"
+)
+
+type imageRenditionOptions struct {
+	Width     int
+	Height    int
+	Fit       string
+	Format    string
+	StripExif bool
+}
+
+// handleItemImage 输出缩放/转码后的图片，结果与缩略图共用缓存目录和容量上限。
+func (s *Server) handleItemImage(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	opts, err := parseImageRenditionOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	item, err := st.GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if item.Type != store.ItemTypeImage {
+		writeError(w, http.StatusBadRequest, "bad_request", "仅图片文件支持缩放转码")
+		return
+	}
+	if item.InVault {
+		if !s.requireVaultUnlocked(w, r) {
+			return
+		}
+	}
+
+	s.serveImageRendition(w, r, item, opts)
+}
+
+// handleSharedImage 通过分享码访问缩放后的图片，规则与分享下载一致。
+func (s *Server) handleSharedImage(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(chi.URLParam(r, "code"))
+	if code == "" {
+		writeError(w, http.StatusNotFound, "not_found", "链接无效")
+		return
+	}
+	opts, err := parseImageRenditionOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	item, err := st.GetItemByShareCode(r.Context(), code)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+			return
+		}
+		s.logger.Error("get item by share code failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if item.InVault {
+		writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+		return
+	}
+	if item.Type != store.ItemTypeImage {
+		writeError(w, http.StatusBadRequest, "bad_request", "仅图片文件支持缩放转码")
+		return
+	}
+
+	s.emitShareAccessedWebhookEvent(r, item, "image")
+	s.serveImageRendition(w, r, item, opts)
+}
+
+func (s *Server) serveImageRendition(
+	w http.ResponseWriter,
+	r *http.Request,
+	item store.Item,
+	opts imageRenditionOptions,
+) {
+	settings, err := s.getRuntimeSettings(r.Context())
+	if err != nil {
+		s.logger.Error("get runtime settings failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取运行配置失败")
+		return
+	}
+
+	cacheTTL := thumbnailCacheTTLFromHours(settings.ThumbnailCacheTTLHours)
+	cacheDir := s.thumbnailCacheDir()
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		s.logger.Error("resolve thumbnail cache path failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "缩略图缓存初始化失败")
+		return
+	}
+	cacheName := opts.cacheFileName(s.thumbnailCacheKey(item))
+	cachePath := filepath.Join(cacheDir, cacheName)
+
+	serve := func() bool {
+		return s.tryServeThumbnailFromCache(w, r, cachePath, cacheTTL)
+	}
+	if serve() {
+		return
+	}
+
+	leader, wait := s.beginThumbnailGeneration(cacheName)
+	if !leader {
+		select {
+		case <-wait:
+			if serve() {
+				return
+			}
+			writeError(w, http.StatusBadGateway, "bad_gateway", "图片处理失败")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	defer s.finishThumbnailGeneration(cacheName)
+
+	if err := s.acquireThumbnailGenerateSlot(r.Context(), settings.ThumbnailGenerateConcurrency); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "图片处理队列繁忙，请稍后重试")
+		return
+	}
+	defer s.releaseThumbnailGenerate()
+
+	if err := s.generateImageRendition(r.Context(), item, opts, cachePath); err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) && strings.Contains(strings.ToLower(execErr.Error()), "executable file not found") {
+			writeError(w, http.StatusServiceUnavailable, "service_unavailable", "ffmpeg 未安装，无法处理该图片格式")
+			return
+		}
+		if errors.Is(err, errThumbnailSourceTooLarge) {
+			writeError(w, http.StatusBadRequest, "bad_request", "文件过大，暂不支持缩放转码")
+			return
+		}
+		s.logger.Warn(
+			"generate image rendition failed",
+			"error", err.Error(),
+			"item_id", item.ID.String(),
+			"format", opts.Format,
+		)
+		writeError(w, http.StatusBadGateway, "bad_gateway", "图片处理失败")
+		return
+	}
+
+	go s.cleanupThumbnailCache(cacheTTL, settings.ThumbnailCacheMaxBytes)
+
+	if serve() {
+		return
+	}
+	writeError(w, http.StatusBadGateway, "bad_gateway", "图片读取失败")
+}
+
+func (s *Server) generateImageRendition(ctx context.Context, item store.Item, opts imageRenditionOptions, cachePath string) error {
+	inputPath, err := s.downloadItemFullToTempFile(ctx, item, thumbnailFullInputMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputPath)
+
+	src, orientation, head, err := decodeImageFile(inputPath)
+	if err != nil {
+		// 标准库无法解码（webp/heic 等）或尺寸过大时，先用 ffmpeg 转成受限尺寸的 PNG
+		converted, convErr := s.convertImageToPNGWithFFmpeg(ctx, inputPath, cachePath)
+		if convErr != nil {
+			return convErr
+		}
+		defer os.Remove(converted)
+		src, orientation, head, err = decodeImageFile(converted)
+		if err != nil {
+			return err
+		}
+	}
+
+	rendered := renderImageRendition(src, orientation, opts)
+	switch opts.Format {
+	case imageRenditionFormatPNG:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, rendered); err != nil {
+			return err
+		}
+		return writeRenditionFile(buf.Bytes(), cachePath)
+	case imageRenditionFormatWebP:
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, rendered); err != nil {
+			return err
+		}
+		pngPath := cachePath + ".src.png.tmp"
+		if err := os.WriteFile(pngPath, buf.Bytes(), 0o640); err != nil {
+			return err
+		}
+		defer os.Remove(pngPath)
+		return s.runFFmpegToFile(ctx, cachePath, "tgcd-img-*.webp", "-i", pngPath, "-c:v", "libwebp", "-quality", imageRenditionWebPQuality)
+	default:
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, rendered, &jpeg.Options{Quality: thumbnailImageJPEGQuality}); err != nil {
+			return err
+		}
+		data := buf.Bytes()
+		if !opts.StripExif {
+			data = insertJPEGExifSegment(data, extractJPEGExifSegment(head))
+		}
+		return writeRenditionFile(data, cachePath)
+	}
+}
+
+func (s *Server) convertImageToPNGWithFFmpeg(ctx context.Context, inputPath string, cachePath string) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), "tgcd-img-src-*.png.tmp")
+	if err != nil {
+		return "", err
+	}
+	outPath := tmp.Name()
+	_ = tmp.Close()
+
+	filter := fmt.Sprintf(
+		"scale=w=min(%d\\,iw):h=min(%d\\,ih):force_original_aspect_ratio=decrease",
+		imageRenditionMaxSide,
+		imageRenditionMaxSide,
+	)
+	if err := s.runFFmpegToFile(ctx, outPath, "tgcd-img-src-*.png", "-i", inputPath, "-frames:v", "1", "-vf", filter); err != nil {
+		_ = os.Remove(outPath)
+		return "", err
+	}
+	return outPath, nil
+}
+
+func writeRenditionFile(data []byte, cachePath string) error {
+	tmpPath := cachePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o640); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := commitThumbnailFile(tmpPath, cachePath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// insertJPEGExifSegment 把 APP1 Exif 段插入到 SOI 之后。
+func insertJPEGExifSegment(data []byte, segment []byte) []byte {
+	if len(segment) == 0 || len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	out := make([]byte, 0, len(data)+len(segment))
+	out = append(out, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func parseImageRenditionOptions(q url.Values) (imageRenditionOptions, error) {
+	opts := imageRenditionOptions{Fit: imageRenditionFitContain, Format: imageRenditionFormatJPEG, StripExif: true}
+	var err error
+	if opts.Width, err = parseImageRenditionSide(q.Get("w"), "w"); err != nil {
+		return imageRenditionOptions{}, err
+	}
+	if opts.Height, err = parseImageRenditionSide(q.Get("h"), "h"); err != nil {
+		return imageRenditionOptions{}, err
+	}
+
+	switch fit := strings.ToLower(strings.TrimSpace(q.Get("fit"))); fit {
+	case "":
+	case imageRenditionFitContain, imageRenditionFitCover, imageRenditionFitFill:
+		opts.Fit = fit
+	default:
+		return imageRenditionOptions{}, errors.New("fit 仅支持 contain/cover/fill")
+	}
+
+	switch format := strings.ToLower(strings.TrimSpace(q.Get("format"))); format {
+	case "", imageRenditionFormatJPEG, "jpg":
+	case imageRenditionFormatPNG, imageRenditionFormatWebP:
+		opts.Format = format
+	default:
+		return imageRenditionOptions{}, errors.New("format 仅支持 jpeg/png/webp")
+	}
+
+	if raw := strings.TrimSpace(q.Get("strip")); raw != "" {
+		strip, err := strconv.ParseBool(raw)
+		if err != nil {
+			return imageRenditionOptions{}, errors.New("strip 非法")
+		}
+		opts.StripExif = strip
+	}
+	return opts, nil
+}
+
+func parseImageRenditionSide(raw string, name string) (int, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(trimmed)
+	if err != nil || value < 0 || value > imageRenditionMaxSide {
+		return 0, fmt.Errorf("%s 范围应为 0~%d", name, imageRenditionMaxSide)
+	}
+	return value, nil
+}
+
+// cacheFileName 只有 JPEG 输出才会保留 Exif，其余格式忽略 strip 参数以免产生重复缓存。
+func (o imageRenditionOptions) cacheFileName(itemKey string) string {
+	exifFlag := "s"
+	if o.Format == imageRenditionFormatJPEG && !o.StripExif {
+		exifFlag = "k"
+	}
+	ext := "jpg"
+	if o.Format != imageRenditionFormatJPEG {
+		ext = o.Format
+	}
+	return fmt.Sprintf("%s-img-%dx%d-%s-%s.%s", itemKey, o.Width, o.Height, o.Fit, exifFlag, ext)
+}
+
+// imageRenditionLayout 计算缩放尺寸与最终裁剪尺寸（均在校正方向后的坐标系中），不放大原图。
+func imageRenditionLayout(srcW int, srcH int, opts imageRenditionOptions) (int, int, int, int) {
+	if srcW <= 0 || srcH <= 0 {
+		return 1, 1, 1, 1
+	}
+	fw, fh := float64(srcW), float64(srcH)
+	boxW, boxH := float64(opts.Width), float64(opts.Height)
+	if opts.Width > 0 && opts.Height > 0 {
+		switch opts.Fit {
+		case imageRenditionFitFill:
+			w, h := minInt(opts.Width, srcW), minInt(opts.Height, srcH)
+			return w, h, w, h
+		case imageRenditionFitCover:
+			scale := math.Min(math.Max(boxW/fw, boxH/fh), 1)
+			sw, sh := roundImageSide(fw*scale), roundImageSide(fh*scale)
+			return sw, sh, minInt(opts.Width, sw), minInt(opts.Height, sh)
+		}
+	}
+
+	if opts.Width <= 0 {
+		boxW = imageRenditionMaxSide
+	}
+	if opts.Height <= 0 {
+		boxH = imageRenditionMaxSide
+	}
+	scale := math.Min(math.Min(boxW/fw, boxH/fh), 1)
+	sw, sh := roundImageSide(fw*scale), roundImageSide(fh*scale)
+	return sw, sh, sw, sh
+}
+
+func roundImageSide(value float64) int {
+	side := int(math.Round(value))
+	if side < 1 {
+		return 1
+	}
+	return side
+}
+
+// renderImageRendition 缩放在原始方向下进行，校正方向后再居中裁剪。
+func renderImageRendition(src image.Image, orientation int, opts imageRenditionOptions) *image.RGBA {
+	bounds := src.Bounds()
+	ow, oh := bounds.Dx(), bounds.Dy()
+	swapped := orientation >= 5 && orientation <= exifOrientationMaxSupported
+	if swapped {
+		ow, oh = oh, ow
+	}
+	scaleW, scaleH, cropW, cropH := imageRenditionLayout(ow, oh, opts)
+
+	resizeW, resizeH := scaleW, scaleH
+	if swapped {
+		resizeW, resizeH = scaleH, scaleW
+	}
+	flatten := opts.Format == imageRenditionFormatJPEG
+	oriented := applyExifOrientation(resizeImageSampled(src, resizeW, resizeH, flatten), orientation)
+	if cropW == scaleW && cropH == scaleH {
+		return oriented
+	}
+
+	offsetX := (scaleW - cropW) / 2
+	offsetY := (scaleH - cropH) / 2
+	cropped := image.NewRGBA(image.Rect(0, 0, cropW, cropH))
+	for y := 0; y < cropH; y++ {
+		srcStart := oriented.PixOffset(offsetX, offsetY+y)
+		dstStart := cropped.PixOffset(0, y)
+		copy(cropped.Pix[dstStart:dstStart+cropW*4], oriented.Pix[srcStart:srcStart+cropW*4])
+	}
+	return cropped
+}
+
+func isThumbnailCacheFileName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".png", ".webp":
+		return true
+	default:
+		return false
+	}
+}
+
+func thumbnailCacheContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		return "image/png"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "image/jpeg"
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"net/url"
+	"testing"
+)
+
+func TestParseImageRenditionOptions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		query   string
+		want    imageRenditionOptions
+		wantErr bool
+	}{
+		{name: "默认值", query: "", want: imageRenditionOptions{Fit: "contain", Format: "jpeg", StripExif: true}},
+		{name: "完整参数", query: "w=320&h=200&fit=COVER&format=webp&strip=false", want: imageRenditionOptions{Width: 320, Height: 200, Fit: "cover", Format: "webp"}},
+		{name: "jpg 别名", query: "w=100&format=jpg", want: imageRenditionOptions{Width: 100, Fit: "contain", Format: "jpeg", StripExif: true}},
+		{name: "宽度超限", query: "w=5000", wantErr: true},
+		{name: "高度为负", query: "h=-1", wantErr: true},
+		{name: "未知 fit", query: "fit=stretch", wantErr: true},
+		{name: "未知格式", query: "format=gif", wantErr: true},
+		{name: "strip 非法", query: "strip=maybe", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("parse query failed: %v", err)
+			}
+			got, err := parseImageRenditionOptions(q)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("parseImageRenditionOptions() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImageRenditionOptions() error = %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("parseImageRenditionOptions() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestImageRenditionLayout(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name                           string
+		srcW, srcH                     int
+		opts                           imageRenditionOptions
+		wantSW, wantSH, wantCW, wantCH int
+	}{
+		{name: "仅宽度", srcW: 4000, srcH: 3000, opts: imageRenditionOptions{Width: 400, Fit: "contain"}, wantSW: 400, wantSH: 300, wantCW: 400, wantCH: 300},
+		{name: "contain 受高度限制", srcW: 4000, srcH: 3000, opts: imageRenditionOptions{Width: 400, Height: 150, Fit: "contain"}, wantSW: 200, wantSH: 150, wantCW: 200, wantCH: 150},
+		{name: "cover 居中裁剪", srcW: 4000, srcH: 3000, opts: imageRenditionOptions{Width: 300, Height: 300, Fit: "cover"}, wantSW: 400, wantSH: 300, wantCW: 300, wantCH: 300},
+		{name: "fill 拉伸", srcW: 4000, srcH: 3000, opts: imageRenditionOptions{Width: 300, Height: 100, Fit: "fill"}, wantSW: 300, wantSH: 100, wantCW: 300, wantCH: 100},
+		{name: "不放大", srcW: 200, srcH: 100, opts: imageRenditionOptions{Width: 800, Height: 800, Fit: "contain"}, wantSW: 200, wantSH: 100, wantCW: 200, wantCH: 100},
+		{name: "未指定尺寸保持原图", srcW: 640, srcH: 480, opts: imageRenditionOptions{Fit: "contain"}, wantSW: 640, wantSH: 480, wantCW: 640, wantCH: 480},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sw, sh, cw, ch := imageRenditionLayout(tc.srcW, tc.srcH, tc.opts)
+			if sw != tc.wantSW || sh != tc.wantSH || cw != tc.wantCW || ch != tc.wantCH {
+				t.Fatalf(
+					"imageRenditionLayout() = %dx%d crop %dx%d, want %dx%d crop %dx%d",
+					sw, sh, cw, ch, tc.wantSW, tc.wantSH, tc.wantCW, tc.wantCH,
+				)
+			}
+		})
+	}
+}
+
+func TestRenderImageRenditionAppliesOrientationBeforeCrop(t *testing.T) {
+	t.Parallel()
+
+	// 原图 4x2，方向 6（顺时针 90°）后应视为 2x4
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: 0xff, A: 0xff})
+		}
+	}
+	got := renderImageRendition(src, 6, imageRenditionOptions{Width: 2, Height: 2, Fit: "cover", Format: "png"})
+	if got.Bounds().Dx() != 2 || got.Bounds().Dy() != 2 {
+		t.Fatalf("renderImageRendition() size = %v, want 2x2", got.Bounds())
+	}
+
+	got = renderImageRendition(src, 6, imageRenditionOptions{Width: 1, Fit: "contain", Format: "png"})
+	if got.Bounds().Dx() != 1 || got.Bounds().Dy() != 2 {
+		t.Fatalf("renderImageRendition() size = %v, want 1x2", got.Bounds())
+	}
+}
+
+func TestInsertJPEGExifSegmentResetsOrientation(t *testing.T) {
+	t.Parallel()
+
+	head := buildTestExifJPEGHeader(binary.BigEndian, 6)
+	segment := extractJPEGExifSegment(head)
+	if len(segment) == 0 {
+		t.Fatalf("extractJPEGExifSegment() = empty")
+	}
+
+	out := insertJPEGExifSegment([]byte{0xFF, 0xD8, 0xFF, 0xDA}, segment)
+	if !bytes.HasPrefix(out, []byte{0xFF, 0xD8, 0xFF, 0xE1}) {
+		t.Fatalf("insertJPEGExifSegment() should place APP1 after SOI")
+	}
+	if got := parseJPEGExifOrientation(out); got != 1 {
+		t.Fatalf("orientation after insert = %d, want 1", got)
+	}
+	if got := insertJPEGExifSegment([]byte("plain"), segment); string(got) != "plain" {
+		t.Fatalf("insertJPEGExifSegment(non-jpeg) modified data")
+	}
+}
+
+func TestImageRenditionCacheFileName(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		opts imageRenditionOptions
+		want string
+	}{
+		{name: "jpeg 去除 Exif", opts: imageRenditionOptions{Width: 100, Fit: "contain", Format: "jpeg", StripExif: true}, want: "k1-img-100x0-contain-s.jpg"},
+		{name: "jpeg 保留 Exif", opts: imageRenditionOptions{Width: 100, Fit: "contain", Format: "jpeg"}, want: "k1-img-100x0-contain-k.jpg"},
+		{name: "png 忽略 strip", opts: imageRenditionOptions{Height: 50, Fit: "cover", Format: "png"}, want: "k1-img-0x50-cover-s.png"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := tc.opts.cacheFileName("k1")
+			if got != tc.want {
+				t.Fatalf("cacheFileName() = %q, want %q", got, tc.want)
+			}
+			if !isThumbnailCacheFileName(got) {
+				t.Fatalf("isThumbnailCacheFileName(%q) = false", got)
+			}
+		})
+	}
+
+	if isThumbnailCacheFileName("k1.jpg.tmp") {
+		t.Fatalf("isThumbnailCacheFileName(tmp) = true, want false")
+	}
+	if got := thumbnailCacheContentType("a.webp"); got != "image/webp" {
+		t.Fatalf("thumbnailCacheContentType(webp) = %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+type itemMetadataDTO struct {
+	Status          string     `json:"status"`
+	Error           *string    `json:"error,omitempty"`
+	DurationSeconds *float64   `json:"durationSeconds"`
+	Width           *int       `json:"width"`
+	Height          *int       `json:"height"`
+	Rotation        *int       `json:"rotation"`
+	VideoCodec      *string    `json:"videoCodec"`
+	AudioCodec      *string    `json:"audioCodec"`
+	BitRate         *int64     `json:"bitRate"`
+	AudioTitle      *string    `json:"audioTitle"`
+	AudioArtist     *string    `json:"audioArtist"`
+	AudioAlbum      *string    `json:"audioAlbum"`
+	CameraMake      *string    `json:"cameraMake"`
+	CameraModel     *string    `json:"cameraModel"`
+	TakenAt         *time.Time `json:"takenAt"`
+	GPSLatitude     *float64   `json:"gpsLatitude"`
+	GPSLongitude    *float64   `json:"gpsLongitude"`
+	ExtractedAt     time.Time  `json:"extractedAt"`
+}
+
+func toItemMetadataDTO(meta store.ItemMetadata) *itemMetadataDTO {
+	return &itemMetadataDTO{
+		Status:          string(meta.Status),
+		Error:           meta.Error,
+		DurationSeconds: meta.DurationSeconds,
+		Width:           meta.Width,
+		Height:          meta.Height,
+		Rotation:        meta.Rotation,
+		VideoCodec:      meta.VideoCodec,
+		AudioCodec:      meta.AudioCodec,
+		BitRate:         meta.BitRate,
+		AudioTitle:      meta.AudioTitle,
+		AudioArtist:     meta.AudioArtist,
+		AudioAlbum:      meta.AudioAlbum,
+		CameraMake:      meta.CameraMake,
+		CameraModel:     meta.CameraModel,
+		TakenAt:         meta.TakenAt,
+		GPSLatitude:     meta.GPSLatitude,
+		GPSLongitude:    meta.GPSLongitude,
+		ExtractedAt:     meta.ExtractedAt,
+	}
+}
+
+// parseListMetadataFilter 解析列表接口的元数据过滤参数，返回中文错误说明。
+func parseListMetadataFilter(q url.Values) (store.MetadataFilter, error) {
+	var filter store.MetadataFilter
+	var err error
+	if filter.MinDurationSeconds, err = parseOptionalFloatQuery(q, "minDuration"); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	if filter.MaxDurationSeconds, err = parseOptionalFloatQuery(q, "maxDuration"); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	if filter.MinWidth, err = parseOptionalIntQuery(q, "minWidth"); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	if filter.MinHeight, err = parseOptionalIntQuery(q, "minHeight"); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	filter.VideoCodec = strings.TrimSpace(q.Get("videoCodec"))
+	filter.Camera = strings.TrimSpace(q.Get("camera"))
+	if filter.TakenFrom, err = parseOptionalDateQuery(q, "takenFrom", false); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	if filter.TakenTo, err = parseOptionalDateQuery(q, "takenTo", true); err != nil {
+		return store.MetadataFilter{}, err
+	}
+	if raw := strings.TrimSpace(q.Get("hasGps")); raw != "" {
+		hasGPS, parseErr := strconv.ParseBool(raw)
+		if parseErr != nil {
+			return store.MetadataFilter{}, errors.New("hasGps 非法")
+		}
+		filter.HasGPS = &hasGPS
+	}
+	return filter, nil
+}
+
+func parseOptionalFloatQuery(q url.Values, name string) (*float64, error) {
+	raw := strings.TrimSpace(q.Get(name))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return nil, errors.New(name + " 非法")
+	}
+	return &value, nil
+}
+
+func parseOptionalIntQuery(q url.Values, name string) (*int, error) {
+	raw := strings.TrimSpace(q.Get(name))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return nil, errors.New(name + " 非法")
+	}
+	return &value, nil
+}
+
+// parseOptionalDateQuery 支持 RFC3339 或 YYYY-MM-DD；日期作为结束时间时包含当天。
+func parseOptionalDateQuery(q url.Values, name string, endOfDay bool) (*time.Time, error) {
+	raw := strings.TrimSpace(q.Get(name))
+	if raw == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, errors.New(name + " 非法，应为 RFC3339 或 YYYY-MM-DD")
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return &parsed, nil
+}
@@ -46,6 +46,12 @@ func (s *Server) handleListItems(w http.ResponseWriter, r *http.Request) {
 		sortOrder = store.SortOrderAsc
 	}
 
+	metadataFilter, err := parseListMetadataFilter(q)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
 	page := intFromQuery(q.Get("page"), 1)
 	pageSize := intFromQuery(q.Get("pageSize"), 50)
 	var vaultStatus *vaultStatusResponse
@@ -73,6 +79,7 @@ func (s *Server) handleListItems(w http.ResponseWriter, r *http.Request) {
 		SortOrder: sortOrder,
 		Page:      page,
 		PageSize:  pageSize,
+		Metadata:  metadataFilter,
 	})
 	if err != nil {
 		if errors.Is(err, store.ErrBadInput) {
@@ -164,6 +171,8 @@ func (s *Server) handleCreateFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordItemAudit(r.Context(), auditActionItemCreate, folder, map[string]any{"type": string(folder.Type)})
+	s.emitItemWebhookEvent(webhookEventItemCreated, folder, "folder")
 	writeJSON(w, http.StatusOK, map[string]any{"item": toItemDTO(folder)})
 }
 
@@ -212,7 +221,11 @@ func (s *Server) handlePatchItem(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		before, beforeErr := st.GetItem(r.Context(), id)
 		updated, err = st.PatchItemMoveRename(r.Context(), id, input, now)
+		if err == nil && beforeErr == nil {
+			s.recordItemMoveRenameAudit(r.Context(), before, updated)
+		}
 		if err != nil {
 			switch {
 			case errors.Is(err, store.ErrNotFound):
@@ -301,6 +314,7 @@ func (s *Server) handleShareItem(w http.ResponseWriter, r *http.Request) {
 		}
 		break
 	}
+	s.recordItemAudit(r.Context(), auditActionShareCreate, it, map[string]any{"shareCode": code})
 
 	base := publicBaseURL(r, s.cfg.BaseURL, s.cfg.PublicURLHeader)
 	shareURL := strings.TrimRight(base, "/") + "/d/" + code
@@ -327,6 +341,9 @@ func (s *Server) handleUnshareItem(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal_error", "取消分享失败")
 		return
 	}
+	if it, err := st.GetItem(r.Context(), id); err == nil {
+		s.recordItemAudit(r.Context(), auditActionShareRevoke, it, nil)
+	}
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
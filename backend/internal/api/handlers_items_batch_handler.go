@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// handleCreateItemBatch 创建批量移动/复制/删除/收藏任务，后台执行，进度经 /api/transfers/stream 推送。
+func (s *Server) handleCreateItemBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	action, err := normalizeItemBatchAction(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	ids, err := parseUniqueBatchVaultIDs(req.ItemIDs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if len(ids) > itemBatchMaxTargets {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("单次最多处理 %d 个项目", itemBatchMaxTargets))
+		return
+	}
+	destParent, destSpecified, err := parseItemBatchDestination(req.DestinationParentRaw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if action == itemBatchActionMove && !destSpecified {
+		writeError(w, http.StatusBadRequest, "bad_request", "缺少 destinationParentId 字段")
+		return
+	}
+
+	st := store.New(s.db)
+	if action == itemBatchActionMove || action == itemBatchActionCopy {
+		if err := validateItemCopyDestination(r.Context(), st, destParent); err != nil {
+			if !isItemActionError(err) {
+				s.logger.Error("get dest parent failed", "error", err.Error())
+			}
+			writeItemActionError(w, err)
+			return
+		}
+	}
+
+	items, err := loadItemBatchItems(r.Context(), st, ids)
+	if err != nil {
+		s.logger.Error("load item batch targets failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询文件失败")
+		return
+	}
+
+	enabled := req.Enabled != nil && *req.Enabled
+	dto := s.startItemBatchJob(r.Context(), action, destParent, destSpecified, enabled, ids, items)
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": dto})
+}
+
+// startItemBatchJob 登记并在后台启动批量任务，返回包含逐项结果的初始快照。
+// 任务沿用请求上下文中的值（审计身份、链路），但不随请求结束而取消。
+func (s *Server) startItemBatchJob(
+	reqCtx context.Context,
+	action string,
+	destParent *uuid.UUID,
+	destSpecified bool,
+	enabled bool,
+	ids []uuid.UUID,
+	items map[uuid.UUID]store.Item,
+) itemBatchJobDTO {
+	targets, results := buildItemBatchTargets(action, ids, items)
+	job := newItemBatchJob(action, destParent, destSpecified, enabled, targets, results, time.Now())
+	ctx, cancel := context.WithCancel(context.WithoutCancel(reqCtx))
+	job.cancel = cancel
+	s.registerItemBatchJob(job)
+
+	dto := job.snapshot(true)
+	s.publishTransferEvent(transferStreamEvent{Type: itemBatchStreamEventUpsert, Batch: &dto})
+	s.goBackground(func() { s.runItemBatchJob(ctx, job) })
+	return dto
+}
+
+func (s *Server) handleGetItemBatch(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookupItemBatchJobParam(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot(true)})
+}
+
+func (s *Server) handleCancelItemBatch(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookupItemBatchJobParam(w, r)
+	if !ok {
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, "conflict", "任务已结束")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot(false)})
+}
+
+// lookupItemBatchJobParam 批量任务只保存在创建它的副本内存中，本副本找不到时返回 409：任务可能在其他副本上运行。
+func (s *Server) lookupItemBatchJobParam(w http.ResponseWriter, r *http.Request) (*itemBatchJob, bool) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return nil, false
+	}
+	job, ok := s.getItemBatchJob(id)
+	if !ok {
+		writeError(w, http.StatusConflict, "conflict", "批量任务不在当前副本或已过期")
+		return nil, false
+	}
+	return job, true
+}
+
+func normalizeItemBatchAction(req batchItemsRequest) (string, error) {
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	switch action {
+	case itemBatchActionMove, itemBatchActionCopy, itemBatchActionDelete:
+	case itemBatchActionStar:
+		if req.Enabled == nil {
+			return "", errors.New("缺少 enabled 字段")
+		}
+	case "":
+		return "", errors.New("缺少 action 字段")
+	default:
+		return "", errors.New("action 仅支持 move/copy/delete/star")
+	}
+	if len(req.ItemIDs) == 0 {
+		return "", errors.New("缺少 itemIds")
+	}
+	return action, nil
+}
+
+// parseItemBatchDestination 解析 destinationParentId：缺省=未指定；null=根目录；uuid=目标目录。
+func parseItemBatchDestination(raw *json.RawMessage) (*uuid.UUID, bool, error) {
+	if raw == nil {
+		return nil, false, nil
+	}
+	trimmed := bytes.TrimSpace(*raw)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, true, nil
+	}
+	var sID string
+	if err := json.Unmarshal(trimmed, &sID); err != nil {
+		return nil, false, errors.New("destinationParentId 非法")
+	}
+	parsed, err := uuid.Parse(strings.TrimSpace(sID))
+	if err != nil {
+		return nil, false, errors.New("destinationParentId 非法")
+	}
+	return &parsed, true, nil
+}
+
+func loadItemBatchItems(ctx context.Context, st *store.Store, ids []uuid.UUID) (map[uuid.UUID]store.Item, error) {
+	items := make(map[uuid.UUID]store.Item, len(ids))
+	for _, id := range ids {
+		item, err := st.GetItem(ctx, id)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		items[id] = item
+	}
+	return items, nil
+}
+
+func (s *Server) registerItemBatchJob(job *itemBatchJob) {
+	cutoff := time.Now().Add(-itemBatchJobRetention)
+	s.itemBatchMu.Lock()
+	defer s.itemBatchMu.Unlock()
+	for id, existing := range s.itemBatchJobs {
+		if existing.finishedBefore(cutoff) {
+			delete(s.itemBatchJobs, id)
+		}
+	}
+	s.itemBatchJobs[job.id] = job
+}
+
+func (s *Server) getItemBatchJob(id uuid.UUID) (*itemBatchJob, bool) {
+	s.itemBatchMu.Lock()
+	defer s.itemBatchMu.Unlock()
+	job, ok := s.itemBatchJobs[id]
+	return job, ok
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func (s *Server) runItemBatchJob(ctx context.Context, job *itemBatchJob) {
+	defer job.cancel()
+
+	st := store.New(s.db)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < s.resolveItemBatchParallelism(ctx, job.action); i++ {
+		wg.Add(1)
+		go s.runItemBatchWorker(ctx, st, job, jobs, &wg)
+	}
+	enqueueItemBatchTargets(ctx, jobs, job.targets)
+	wg.Wait()
+
+	dto := job.finish(time.Now())
+	s.publishTransferEvent(transferStreamEvent{Type: itemBatchStreamEventDone, Batch: &dto})
+	s.logger.Info(
+		"item batch finished",
+		"job_id", dto.ID,
+		"action", dto.Action,
+		"status", dto.Status,
+		"succeeded", dto.Summary.SucceededTargets,
+		"failed", dto.Summary.FailedTargets,
+		"canceled", dto.Summary.CanceledTargets,
+	)
+}
+
+func enqueueItemBatchTargets(ctx context.Context, jobs chan<- int, targets []itemBatchTarget) {
+	defer close(jobs)
+	for idx, target := range targets {
+		if target.item == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case jobs <- idx:
+		}
+	}
+}
+
+func (s *Server) runItemBatchWorker(
+	ctx context.Context,
+	st *store.Store,
+	job *itemBatchJob,
+	jobs <-chan int,
+	wg *sync.WaitGroup,
+) {
+	defer wg.Done()
+	for idx := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		item := *job.targets[idx].item
+		job.markRunning(idx)
+		result := s.processItemBatchTarget(ctx, st, job, item)
+		dto := job.recordResult(idx, result)
+		s.publishTransferEvent(transferStreamEvent{Type: itemBatchStreamEventUpsert, Batch: &dto})
+	}
+}
+
+// processItemBatchTarget 执行单个目标；只有复制支持中途取消，移动/删除/收藏一旦开始就执行完毕，避免留下半完成状态。
+func (s *Server) processItemBatchTarget(
+	ctx context.Context,
+	st *store.Store,
+	job *itemBatchJob,
+	item store.Item,
+) itemBatchResultDTO {
+	now := time.Now()
+	switch job.action {
+	case itemBatchActionMove:
+		dest := job.destParent
+		updated, err := st.PatchItemMoveRename(context.WithoutCancel(ctx), item.ID, store.PatchItemInput{ParentID: &dest}, now)
+		if err != nil {
+			return failedItemBatchResult(item, "move", describeItemBatchMoveError(err))
+		}
+		s.recordItemMoveRenameAudit(ctx, item, updated)
+		return succeededItemBatchResult(updated)
+	case itemBatchActionCopy:
+		dest := item.ParentID
+		if job.destSpecified {
+			dest = job.destParent
+		}
+		copied, err := s.copyItemTree(ctx, st, item, dest, now)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return canceledItemBatchResult(item)
+			}
+			return failedItemBatchResult(item, "copy", describeItemBatchError(err, "复制失败"))
+		}
+		s.recordItemAudit(ctx, auditActionItemCopy, copied, map[string]any{"from": item.Path})
+		s.emitItemWebhookEvent(webhookEventItemCreated, copied, "copy")
+		return succeededItemBatchResult(copied)
+	case itemBatchActionDelete:
+		cleanup, err := s.deleteItemPermanently(context.WithoutCancel(ctx), st, item)
+		job.addTelegramCleanup(cleanup.stats.Deleted+cleanup.stats.Replaced, cleanup.stats.Failed)
+		if err != nil {
+			return failedItemBatchResult(item, "delete", describeItemBatchError(err, "删除失败"))
+		}
+		result := succeededItemBatchResult(item)
+		result.Item = nil
+		return result
+	case itemBatchActionStar:
+		updated, err := st.UpdateItemStarred(context.WithoutCancel(ctx), item.ID, job.enabled, now)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return failedItemBatchResult(item, "star", "文件不存在")
+			}
+			s.logger.Error("update item starred failed", "error", err.Error(), "item_id", item.ID.String())
+			return failedItemBatchResult(item, "star", "更新收藏状态失败")
+		}
+		return succeededItemBatchResult(updated)
+	default:
+		return failedItemBatchResult(item, "action", "不支持的操作")
+	}
+}
+
+func describeItemBatchMoveError(err error) string {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return "文件不存在"
+	case errors.Is(err, store.ErrBadInput):
+		return "参数非法（请确认目标目录存在且可用）"
+	case errors.Is(err, store.ErrForbidden):
+		return "不能移动到自身或子目录中"
+	case errors.Is(err, store.ErrConflict):
+		return "同一目录下已存在同名文件或文件夹"
+	default:
+		return "更新失败"
+	}
+}
+
+func describeItemBatchError(err error, fallback string) string {
+	if isItemActionError(err) {
+		return err.Error()
+	}
+	return fallback
+}
+
+func succeededItemBatchResult(item store.Item) itemBatchResultDTO {
+	dto := toItemDTO(item)
+	return itemBatchResultDTO{
+		ItemID: item.ID.String(),
+		Name:   item.Name,
+		Type:   string(item.Type),
+		Status: itemBatchResultSucceeded,
+		Stage:  "done",
+		Item:   &dto,
+	}
+}
+
+func failedItemBatchResult(item store.Item, stage string, message string) itemBatchResultDTO {
+	return itemBatchResultDTO{
+		ItemID: item.ID.String(),
+		Name:   item.Name,
+		Type:   string(item.Type),
+		Status: itemBatchResultFailed,
+		Stage:  stage,
+		Error:  message,
+	}
+}
+
+func canceledItemBatchResult(item store.Item) itemBatchResultDTO {
+	return itemBatchResultDTO{
+		ItemID: item.ID.String(),
+		Name:   item.Name,
+		Type:   string(item.Type),
+		Status: itemBatchResultCanceled,
+		Stage:  "canceled",
+		Error:  "任务已取消",
+	}
+}
+
+// buildItemBatchTargets 生成目标与初始结果：不存在的条目直接记为失败；
+// 移动/复制/删除时，已被所选上级目录覆盖的子项记为跳过，避免重复处理。
+func buildItemBatchTargets(action string, ids []uuid.UUID, items map[uuid.UUID]store.Item) ([]itemBatchTarget, []itemBatchResultDTO) {
+	targets := make([]itemBatchTarget, 0, len(ids))
+	results := make([]itemBatchResultDTO, 0, len(ids))
+	selectedFolders := make([]string, 0)
+	if action != itemBatchActionStar {
+		for _, id := range ids {
+			if item, ok := items[id]; ok && item.Type == store.ItemTypeFolder {
+				selectedFolders = append(selectedFolders, item.Path)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		item, ok := items[id]
+		if !ok {
+			targets = append(targets, itemBatchTarget{id: id})
+			results = append(results, itemBatchResultDTO{
+				ItemID: id.String(),
+				Name:   id.String(),
+				Status: itemBatchResultFailed,
+				Stage:  "lookup",
+				Error:  "文件不存在",
+			})
+			continue
+		}
+		if isPathUnderAnyFolder(item.Path, selectedFolders) {
+			targets = append(targets, itemBatchTarget{id: id})
+			results = append(results, itemBatchResultDTO{
+				ItemID: id.String(),
+				Name:   item.Name,
+				Type:   string(item.Type),
+				Status: itemBatchResultSkipped,
+				Stage:  "nested",
+				Error:  itemBatchNestedTargetMessage,
+			})
+			continue
+		}
+		itemCopy := item
+		targets = append(targets, itemBatchTarget{id: id, item: &itemCopy})
+		results = append(results, itemBatchResultDTO{
+			ItemID: id.String(),
+			Name:   item.Name,
+			Type:   string(item.Type),
+			Status: itemBatchResultPending,
+		})
+	}
+	return targets, results
+}
+
+func isPathUnderAnyFolder(path string, folders []string) bool {
+	for _, folder := range folders {
+		prefix := folder
+		if prefix != "/" {
+			prefix += "/"
+		}
+		if path != folder && len(path) > len(prefix) && path[:len(prefix)] == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) resolveItemBatchParallelism(ctx context.Context, action string) int {
+	maxValue := itemBatchParallelismMax
+	if action == itemBatchActionCopy {
+		maxValue = itemBatchCopyParallelismMax
+	}
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		s.logger.Warn("load runtime settings for item batch failed", "error", err.Error())
+		return clampInt(itemBatchParallelismDefault, itemBatchParallelismMin, maxValue)
+	}
+	return clampInt(settings.UploadConcurrency, itemBatchParallelismMin, maxValue)
+}
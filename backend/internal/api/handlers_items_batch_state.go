@@ -0,0 +1,173 @@
+package api
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newItemBatchJob(
+	action string,
+	destParent *uuid.UUID,
+	destSpecified bool,
+	enabled bool,
+	targets []itemBatchTarget,
+	results []itemBatchResultDTO,
+	now time.Time,
+) *itemBatchJob {
+	job := &itemBatchJob{
+		id:            uuid.New(),
+		action:        action,
+		destParent:    destParent,
+		destSpecified: destSpecified,
+		enabled:       enabled,
+		status:        itemBatchStatusRunning,
+		targets:       targets,
+		results:       results,
+		createdAt:     now,
+	}
+	job.summary.TotalTargets = len(results)
+	for _, result := range results {
+		job.countResultLocked(result.Status)
+	}
+	job.refreshPercentLocked()
+	return job
+}
+
+func (j *itemBatchJob) markRunning(idx int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.results[idx].Status = itemBatchResultRunning
+}
+
+func (j *itemBatchJob) recordResult(idx int, result itemBatchResultDTO) itemBatchJobDTO {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.results[idx] = result
+	j.countResultLocked(result.Status)
+	j.refreshPercentLocked()
+	dto := j.snapshotLocked(false)
+	dto.Result = &result
+	return dto
+}
+
+func (j *itemBatchJob) addTelegramCleanup(deleted int, failed int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.summary.TelegramDeleteDeleted += deleted
+	j.summary.TelegramDeleteFailed += failed
+}
+
+// finish 将未处理的目标标记为已取消并计算最终状态。
+func (j *itemBatchJob) finish(now time.Time) itemBatchJobDTO {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for idx := range j.results {
+		switch j.results[idx].Status {
+		case itemBatchResultPending, itemBatchResultRunning:
+			j.results[idx].Status = itemBatchResultCanceled
+			j.results[idx].Stage = "canceled"
+			j.results[idx].Error = "任务已取消"
+			j.countResultLocked(itemBatchResultCanceled)
+		}
+	}
+	j.refreshPercentLocked()
+	j.status = resolveItemBatchFinalStatus(j.summary, j.cancelRequested)
+	finishedAt := now
+	j.finishedAt = &finishedAt
+	return j.snapshotLocked(false)
+}
+
+// requestCancel 返回 false 表示任务已结束，无需取消。
+func (j *itemBatchJob) requestCancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != itemBatchStatusRunning {
+		return false
+	}
+	j.cancelRequested = true
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return true
+}
+
+func (j *itemBatchJob) finishedBefore(cutoff time.Time) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.finishedAt != nil && j.finishedAt.Before(cutoff)
+}
+
+func (j *itemBatchJob) snapshot(includeResults bool) itemBatchJobDTO {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.snapshotLocked(includeResults)
+}
+
+func (j *itemBatchJob) snapshotLocked(includeResults bool) itemBatchJobDTO {
+	dto := itemBatchJobDTO{
+		ID:              j.id.String(),
+		Action:          j.action,
+		Status:          j.status,
+		CancelRequested: j.cancelRequested,
+		Summary:         j.summary,
+		Failures:        make([]itemBatchResultDTO, 0),
+		CreatedAt:       j.createdAt,
+	}
+	if j.destSpecified {
+		value := ""
+		if j.destParent != nil {
+			value = j.destParent.String()
+		}
+		dto.DestinationParentID = &value
+	}
+	if j.action == itemBatchActionStar {
+		dto.Enabled = boolPointer(j.enabled)
+	}
+	if j.finishedAt != nil {
+		finishedAt := *j.finishedAt
+		dto.FinishedAt = &finishedAt
+	}
+	for _, result := range j.results {
+		if result.Status == itemBatchResultFailed {
+			dto.Failures = append(dto.Failures, result)
+		}
+	}
+	if includeResults {
+		dto.Results = append([]itemBatchResultDTO(nil), j.results...)
+	}
+	return dto
+}
+
+func (j *itemBatchJob) countResultLocked(status string) {
+	switch status {
+	case itemBatchResultSucceeded:
+		j.summary.SucceededTargets++
+	case itemBatchResultFailed:
+		j.summary.FailedTargets++
+	case itemBatchResultSkipped:
+		j.summary.SkippedTargets++
+	case itemBatchResultCanceled:
+		j.summary.CanceledTargets++
+	default:
+		return
+	}
+	j.summary.DoneTargets++
+}
+
+func (j *itemBatchJob) refreshPercentLocked() {
+	j.summary.Percent = vaultBatchOverallPercent(j.summary.DoneTargets, j.summary.TotalTargets)
+}
+
+func resolveItemBatchFinalStatus(summary itemBatchSummary, cancelRequested bool) string {
+	if cancelRequested && summary.CanceledTargets > 0 {
+		return itemBatchStatusCanceled
+	}
+	if summary.FailedTargets == 0 {
+		return itemBatchStatusCompleted
+	}
+	if summary.SucceededTargets > 0 {
+		return itemBatchStatusPartial
+	}
+	return itemBatchStatusFailed
+}
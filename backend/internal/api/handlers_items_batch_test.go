@@ -0,0 +1,192 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestNormalizeItemBatchAction(t *testing.T) {
+	t.Parallel()
+
+	enabled := true
+	ids := []string{"313cd29a-f536-4706-9c92-58a0a93cf5bc"}
+	cases := []struct {
+		name    string
+		req     batchItemsRequest
+		want    string
+		wantErr bool
+	}{
+		{name: "移动", req: batchItemsRequest{Action: " Move ", ItemIDs: ids}, want: itemBatchActionMove},
+		{name: "收藏需要 enabled", req: batchItemsRequest{Action: "star", ItemIDs: ids}, wantErr: true},
+		{name: "收藏", req: batchItemsRequest{Action: "star", ItemIDs: ids, Enabled: &enabled}, want: itemBatchActionStar},
+		{name: "缺少 action", req: batchItemsRequest{ItemIDs: ids}, wantErr: true},
+		{name: "未知 action", req: batchItemsRequest{Action: "rename", ItemIDs: ids}, wantErr: true},
+		{name: "缺少 itemIds", req: batchItemsRequest{Action: "delete"}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := normalizeItemBatchAction(tc.req)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("normalizeItemBatchAction() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("normalizeItemBatchAction() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseItemBatchDestination(t *testing.T) {
+	t.Parallel()
+
+	raw := func(value string) *json.RawMessage {
+		msg := json.RawMessage(value)
+		return &msg
+	}
+	id := uuid.MustParse("8f848a2e-a84a-4f13-a5db-6e854753f8d8")
+	cases := []struct {
+		name          string
+		raw           *json.RawMessage
+		wantID        *uuid.UUID
+		wantSpecified bool
+		wantErr       bool
+	}{
+		{name: "缺省", raw: nil},
+		{name: "根目录", raw: raw("null"), wantSpecified: true},
+		{name: "目标目录", raw: raw(`"` + id.String() + `"`), wantID: &id, wantSpecified: true},
+		{name: "非法 id", raw: raw(`"abc"`), wantErr: true},
+		{name: "非字符串", raw: raw(`123`), wantErr: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			gotID, gotSpecified, err := parseItemBatchDestination(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseItemBatchDestination() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if gotSpecified != tc.wantSpecified {
+				t.Fatalf("parseItemBatchDestination() specified = %v, want %v", gotSpecified, tc.wantSpecified)
+			}
+			if (gotID == nil) != (tc.wantID == nil) || (gotID != nil && *gotID != *tc.wantID) {
+				t.Fatalf("parseItemBatchDestination() id = %v, want %v", gotID, tc.wantID)
+			}
+		})
+	}
+}
+
+func TestBuildItemBatchTargets(t *testing.T) {
+	t.Parallel()
+
+	folder := store.Item{ID: uuid.New(), Type: store.ItemTypeFolder, Name: "docs", Path: "/docs"}
+	child := store.Item{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "a.txt", Path: "/docs/a.txt"}
+	sibling := store.Item{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "docs2.txt", Path: "/docs2.txt"}
+	missing := uuid.New()
+	ids := []uuid.UUID{folder.ID, child.ID, sibling.ID, missing}
+	items := map[uuid.UUID]store.Item{folder.ID: folder, child.ID: child, sibling.ID: sibling}
+
+	targets, results := buildItemBatchTargets(itemBatchActionDelete, ids, items)
+	wantStatus := []string{itemBatchResultPending, itemBatchResultSkipped, itemBatchResultPending, itemBatchResultFailed}
+	for idx, want := range wantStatus {
+		if results[idx].Status != want {
+			t.Fatalf("results[%d].Status = %q, want %q", idx, results[idx].Status, want)
+		}
+		if (targets[idx].item != nil) != (want == itemBatchResultPending) {
+			t.Fatalf("targets[%d].item presence mismatch", idx)
+		}
+	}
+
+	_, starResults := buildItemBatchTargets(itemBatchActionStar, ids, items)
+	if starResults[1].Status != itemBatchResultPending {
+		t.Fatalf("star nested status = %q, want %q", starResults[1].Status, itemBatchResultPending)
+	}
+}
+
+func TestItemBatchJobLifecycle(t *testing.T) {
+	t.Parallel()
+
+	item := store.Item{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "a.txt", Path: "/a.txt"}
+	other := store.Item{ID: uuid.New(), Type: store.ItemTypeDocument, Name: "b.txt", Path: "/b.txt"}
+	ids := []uuid.UUID{item.ID, other.ID, uuid.New()}
+	targets, results := buildItemBatchTargets(itemBatchActionMove, ids, map[uuid.UUID]store.Item{
+		item.ID:  item,
+		other.ID: other,
+	})
+	job := newItemBatchJob(itemBatchActionMove, nil, true, false, targets, results, time.Now())
+	if job.summary.FailedTargets != 1 || job.summary.DoneTargets != 1 {
+		t.Fatalf("initial summary = %+v, want 1 failed/1 done", job.summary)
+	}
+
+	dto := job.recordResult(0, succeededItemBatchResult(item))
+	if dto.Summary.SucceededTargets != 1 || dto.Result == nil || dto.Result.ItemID != item.ID.String() {
+		t.Fatalf("recordResult() summary = %+v, result = %+v", dto.Summary, dto.Result)
+	}
+
+	final := job.finish(time.Now())
+	if final.Status != itemBatchStatusPartial {
+		t.Fatalf("finish() status = %q, want %q", final.Status, itemBatchStatusPartial)
+	}
+	if final.Summary.CanceledTargets != 1 || final.Summary.Percent != 100 {
+		t.Fatalf("finish() summary = %+v, want 1 canceled and 100%%", final.Summary)
+	}
+	if job.requestCancel() {
+		t.Fatalf("requestCancel() on finished job = true, want false")
+	}
+}
+
+func TestResolveItemBatchFinalStatus(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		summary  itemBatchSummary
+		canceled bool
+		want     string
+	}{
+		{name: "全部成功", summary: itemBatchSummary{SucceededTargets: 2}, want: itemBatchStatusCompleted},
+		{name: "部分失败", summary: itemBatchSummary{SucceededTargets: 1, FailedTargets: 1}, want: itemBatchStatusPartial},
+		{name: "全部失败", summary: itemBatchSummary{FailedTargets: 2}, want: itemBatchStatusFailed},
+		{name: "已取消", summary: itemBatchSummary{SucceededTargets: 1, CanceledTargets: 1}, canceled: true, want: itemBatchStatusCanceled},
+		{name: "取消时已全部完成", summary: itemBatchSummary{SucceededTargets: 2}, canceled: true, want: itemBatchStatusCompleted},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := resolveItemBatchFinalStatus(tc.summary, tc.canceled); got != tc.want {
+				t.Fatalf("resolveItemBatchFinalStatus() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCancelItemBatchOnOtherReplicaReturnsConflict(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", uuid.NewString())
+	r := httptest.NewRequest(http.MethodPost, "/api/items/batch/x/cancel", nil)
+	r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+	w := httptest.NewRecorder()
+	srv.handleCancelItemBatch(w, r)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
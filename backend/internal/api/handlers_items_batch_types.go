@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	itemBatchActionMove   = "move"
+	itemBatchActionCopy   = "copy"
+	itemBatchActionDelete = "delete"
+	itemBatchActionStar   = "star"
+
+	itemBatchStatusRunning   = "running"
+	itemBatchStatusCompleted = "completed"
+	itemBatchStatusPartial   = "partial"
+	itemBatchStatusFailed    = "failed"
+	itemBatchStatusCanceled  = "canceled"
+
+	itemBatchResultPending   = "pending"
+	itemBatchResultRunning   = "running"
+	itemBatchResultSucceeded = "succeeded"
+	itemBatchResultFailed    = "failed"
+	itemBatchResultSkipped   = "skipped"
+	itemBatchResultCanceled  = "canceled"
+
+	itemBatchStreamEventUpsert = "item_batch_upsert"
+	itemBatchStreamEventDone   = "item_batch_done"
+
+	itemBatchMaxTargets          = 1000
+	itemBatchJobRetention        = 30 * time.Minute
+	itemBatchParallelismMin      = 1
+	itemBatchParallelismMax      = 4
+	itemBatchParallelismDefault  = 2
+	itemBatchCopyParallelismMax  = 2
+	itemBatchNestedTargetMessage = "已随所选上级目录一并处理"
+)
+
+type batchItemsRequest struct {
+	Action               string           `json:"action"`
+	ItemIDs              []string         `json:"itemIds"`
+	DestinationParentRaw *json.RawMessage `json:"destinationParentId"`
+	Enabled              *bool            `json:"enabled"`
+}
+
+type itemBatchResultDTO struct {
+	ItemID string   `json:"itemId"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type,omitempty"`
+	Status string   `json:"status"`
+	Stage  string   `json:"stage,omitempty"`
+	Error  string   `json:"error,omitempty"`
+	Item   *ItemDTO `json:"item,omitempty"`
+}
+
+type itemBatchSummary struct {
+	TotalTargets          int     `json:"totalTargets"`
+	DoneTargets           int     `json:"doneTargets"`
+	SucceededTargets      int     `json:"succeededTargets"`
+	FailedTargets         int     `json:"failedTargets"`
+	SkippedTargets        int     `json:"skippedTargets"`
+	CanceledTargets       int     `json:"canceledTargets"`
+	TelegramDeleteFailed  int     `json:"telegramDeleteFailed"`
+	TelegramDeleteDeleted int     `json:"telegramDeleteDeleted"`
+	Percent               float64 `json:"percent"`
+}
+
+type itemBatchJobDTO struct {
+	ID                  string               `json:"id"`
+	Action              string               `json:"action"`
+	Status              string               `json:"status"`
+	DestinationParentID *string              `json:"destinationParentId,omitempty"`
+	Enabled             *bool                `json:"enabled,omitempty"`
+	CancelRequested     bool                 `json:"cancelRequested"`
+	Summary             itemBatchSummary     `json:"summary"`
+	Result              *itemBatchResultDTO  `json:"result,omitempty"`
+	Failures            []itemBatchResultDTO `json:"failures"`
+	Results             []itemBatchResultDTO `json:"results,omitempty"`
+	CreatedAt           time.Time            `json:"createdAt"`
+	FinishedAt          *time.Time           `json:"finishedAt,omitempty"`
+}
+
+// itemBatchTarget 为批量任务中的一个目标；item 为空表示查询阶段已失败或已跳过。
+type itemBatchTarget struct {
+	id   uuid.UUID
+	item *store.Item
+}
+
+type itemBatchJob struct {
+	mu              sync.Mutex
+	id              uuid.UUID
+	action          string
+	destParent      *uuid.UUID
+	destSpecified   bool
+	enabled         bool
+	status          string
+	cancelRequested bool
+	targets         []itemBatchTarget
+	results         []itemBatchResultDTO
+	summary         itemBatchSummary
+	cancel          context.CancelFunc
+	createdAt       time.Time
+	finishedAt      *time.Time
+}
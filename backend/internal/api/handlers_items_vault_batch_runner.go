@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/tracing"
 )
 
 func (s *Server) runBatchVaultSync(
@@ -18,6 +19,15 @@ func (s *Server) runBatchVaultSync(
 	totalTargets int,
 	reporter vaultBatchProgressReporter,
 ) (vaultBatchSummary, error) {
+	// 工作协程沿用请求 ctx，各目标的 span 都挂在这一批次之下。
+	ctx, span := tracing.Start(
+		ctx,
+		tracing.KindInternal,
+		"vault.batch",
+		tracing.Bool("vault.enabled", enabled),
+		tracing.Int("vault.targets", totalTargets),
+	)
+	defer span.End()
 	state := newVaultBatchState(enabled, totalTargets, initialFailures, reporter)
 	if err := state.emitInit(); err != nil {
 		return state.snapshot(), err
@@ -78,7 +88,18 @@ func (s *Server) runBatchVaultWorker(
 			cancel()
 			return
 		}
-		result := s.processBatchVaultTarget(ctx, st, item, enabled, now, state)
+		targetCtx, span := tracing.Start(
+			ctx,
+			tracing.KindInternal,
+			"vault.batch.target",
+			tracing.String("item.id", item.ID.String()),
+			tracing.String("item.type", string(item.Type)),
+		)
+		result := s.processBatchVaultTarget(targetCtx, st, item, enabled, now, state)
+		if !result.success {
+			span.SetError(result.message)
+		}
+		span.End()
 		if err := state.recordResult(result); err != nil {
 			cancel()
 			return
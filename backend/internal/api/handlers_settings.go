@@ -14,23 +14,33 @@ import (
 )
 
 type runtimeSettingsDTO struct {
-	UploadConcurrency                int    `json:"uploadConcurrency"`
-	DownloadConcurrency              int    `json:"downloadConcurrency"`
-	TelegramDeleteConcurrency        int    `json:"telegramDeleteConcurrency"`
-	ReservedDiskBytes                int64  `json:"reservedDiskBytes"`
-	UploadSessionTTLHours            int    `json:"uploadSessionTtlHours"`
-	UploadSessionCleanupIntervalMins int    `json:"uploadSessionCleanupIntervalMinutes"`
-	ThumbnailCacheMaxBytes           int64  `json:"thumbnailCacheMaxBytes"`
-	ThumbnailCacheTTLHours           int    `json:"thumbnailCacheTtlHours"`
-	ThumbnailGenerateConcurrency     int    `json:"thumbnailGenerateConcurrency"`
-	VaultSessionTTLMins              int    `json:"vaultSessionTtlMinutes"`
-	VaultPasswordEnabled             bool   `json:"vaultPasswordEnabled"`
-	TorrentQBTPasswordConfigured     bool   `json:"torrentQbtPasswordConfigured"`
-	TorrentSourceDeleteMode          string `json:"torrentSourceDeleteMode"`
-	TorrentSourceDeleteFixedMinutes  int    `json:"torrentSourceDeleteFixedMinutes"`
-	TorrentSourceDeleteRandomMinMins int    `json:"torrentSourceDeleteRandomMinMinutes"`
-	TorrentSourceDeleteRandomMaxMins int    `json:"torrentSourceDeleteRandomMaxMinutes"`
-	ChunkSizeBytes                   int64  `json:"chunkSizeBytes"`
+	UploadConcurrency                 int      `json:"uploadConcurrency"`
+	DownloadConcurrency               int      `json:"downloadConcurrency"`
+	TelegramDeleteConcurrency         int      `json:"telegramDeleteConcurrency"`
+	ReservedDiskBytes                 int64    `json:"reservedDiskBytes"`
+	UploadSessionTTLHours             int      `json:"uploadSessionTtlHours"`
+	UploadSessionCleanupIntervalMins  int      `json:"uploadSessionCleanupIntervalMinutes"`
+	ThumbnailCacheMaxBytes            int64    `json:"thumbnailCacheMaxBytes"`
+	ThumbnailCacheTTLHours            int      `json:"thumbnailCacheTtlHours"`
+	ThumbnailGenerateConcurrency      int      `json:"thumbnailGenerateConcurrency"`
+	VaultSessionTTLMins               int      `json:"vaultSessionTtlMinutes"`
+	VaultPasswordEnabled              bool     `json:"vaultPasswordEnabled"`
+	TorrentQBTPasswordConfigured      bool     `json:"torrentQbtPasswordConfigured"`
+	TorrentSourceDeleteMode           string   `json:"torrentSourceDeleteMode"`
+	TorrentSourceDeleteFixedMinutes   int      `json:"torrentSourceDeleteFixedMinutes"`
+	TorrentSourceDeleteRandomMinMins  int      `json:"torrentSourceDeleteRandomMinMinutes"`
+	TorrentSourceDeleteRandomMaxMins  int      `json:"torrentSourceDeleteRandomMaxMinutes"`
+	TorrentPreserveDirectoryStructure bool     `json:"torrentPreserveDirectoryStructure"`
+	TorrentSeedRatioTarget            float64  `json:"torrentSeedRatioTarget"`
+	TorrentSeedMinMinutes             int      `json:"torrentSeedMinMinutes"`
+	HLSTranscodeConcurrency           int      `json:"hlsTranscodeConcurrency"`
+	HLSCacheMaxBytes                  int64    `json:"hlsCacheMaxBytes"`
+	AuditLogRetentionDays             int      `json:"auditLogRetentionDays"`
+	NotifyChatIDs                     []string `json:"notifyChatIds"`
+	NotifyTorrentAwaitingSelection    bool     `json:"notifyTorrentAwaitingSelection"`
+	NotifyTransferFailed              bool     `json:"notifyTransferFailed"`
+	NotifyLowDisk                     bool     `json:"notifyLowDisk"`
+	ChunkSizeBytes                    int64    `json:"chunkSizeBytes"`
 }
 
 type serviceAccessDTO struct {
@@ -56,23 +66,33 @@ type serviceSwitchResultDTO struct {
 }
 
 type runtimeSettingsPatchRequest struct {
-	UploadConcurrency                *int    `json:"uploadConcurrency"`
-	DownloadConcurrency              *int    `json:"downloadConcurrency"`
-	TelegramDeleteConcurrency        *int    `json:"telegramDeleteConcurrency"`
-	ReservedDiskBytes                *int64  `json:"reservedDiskBytes"`
-	UploadSessionTTLHours            *int    `json:"uploadSessionTtlHours"`
-	UploadSessionCleanupIntervalMins *int    `json:"uploadSessionCleanupIntervalMinutes"`
-	ThumbnailCacheMaxBytes           *int64  `json:"thumbnailCacheMaxBytes"`
-	ThumbnailCacheTTLHours           *int    `json:"thumbnailCacheTtlHours"`
-	ThumbnailGenerateConcurrency     *int    `json:"thumbnailGenerateConcurrency"`
-	VaultSessionTTLMins              *int    `json:"vaultSessionTtlMinutes"`
-	VaultPassword                    *string `json:"vaultPassword"`
-	AdminPassword                    *string `json:"adminPassword"`
-	TorrentQBTPassword               *string `json:"torrentQbtPassword"`
-	TorrentSourceDeleteMode          *string `json:"torrentSourceDeleteMode"`
-	TorrentSourceDeleteFixedMinutes  *int    `json:"torrentSourceDeleteFixedMinutes"`
-	TorrentSourceDeleteRandomMinMins *int    `json:"torrentSourceDeleteRandomMinMinutes"`
-	TorrentSourceDeleteRandomMaxMins *int    `json:"torrentSourceDeleteRandomMaxMinutes"`
+	UploadConcurrency                 *int      `json:"uploadConcurrency"`
+	DownloadConcurrency               *int      `json:"downloadConcurrency"`
+	TelegramDeleteConcurrency         *int      `json:"telegramDeleteConcurrency"`
+	ReservedDiskBytes                 *int64    `json:"reservedDiskBytes"`
+	UploadSessionTTLHours             *int      `json:"uploadSessionTtlHours"`
+	UploadSessionCleanupIntervalMins  *int      `json:"uploadSessionCleanupIntervalMinutes"`
+	ThumbnailCacheMaxBytes            *int64    `json:"thumbnailCacheMaxBytes"`
+	ThumbnailCacheTTLHours            *int      `json:"thumbnailCacheTtlHours"`
+	ThumbnailGenerateConcurrency      *int      `json:"thumbnailGenerateConcurrency"`
+	VaultSessionTTLMins               *int      `json:"vaultSessionTtlMinutes"`
+	VaultPassword                     *string   `json:"vaultPassword"`
+	AdminPassword                     *string   `json:"adminPassword"`
+	TorrentQBTPassword                *string   `json:"torrentQbtPassword"`
+	TorrentSourceDeleteMode           *string   `json:"torrentSourceDeleteMode"`
+	TorrentSourceDeleteFixedMinutes   *int      `json:"torrentSourceDeleteFixedMinutes"`
+	TorrentSourceDeleteRandomMinMins  *int      `json:"torrentSourceDeleteRandomMinMinutes"`
+	TorrentSourceDeleteRandomMaxMins  *int      `json:"torrentSourceDeleteRandomMaxMinutes"`
+	TorrentPreserveDirectoryStructure *bool     `json:"torrentPreserveDirectoryStructure"`
+	TorrentSeedRatioTarget            *float64  `json:"torrentSeedRatioTarget"`
+	TorrentSeedMinMinutes             *int      `json:"torrentSeedMinMinutes"`
+	HLSTranscodeConcurrency           *int      `json:"hlsTranscodeConcurrency"`
+	HLSCacheMaxBytes                  *int64    `json:"hlsCacheMaxBytes"`
+	AuditLogRetentionDays             *int      `json:"auditLogRetentionDays"`
+	NotifyChatIDs                     *[]string `json:"notifyChatIds"`
+	NotifyTorrentAwaitingSelection    *bool     `json:"notifyTorrentAwaitingSelection"`
+	NotifyTransferFailed              *bool     `json:"notifyTransferFailed"`
+	NotifyLowDisk                     *bool     `json:"notifyLowDisk"`
 }
 
 type serviceAccessPatchRequest struct {
@@ -108,7 +128,17 @@ func hasRuntimePatchChanges(req *runtimeSettingsPatchRequest) bool {
 		req.TorrentSourceDeleteMode != nil ||
 		req.TorrentSourceDeleteFixedMinutes != nil ||
 		req.TorrentSourceDeleteRandomMinMins != nil ||
-		req.TorrentSourceDeleteRandomMaxMins != nil
+		req.TorrentSourceDeleteRandomMaxMins != nil ||
+		req.TorrentPreserveDirectoryStructure != nil ||
+		req.TorrentSeedRatioTarget != nil ||
+		req.TorrentSeedMinMinutes != nil ||
+		req.HLSTranscodeConcurrency != nil ||
+		req.HLSCacheMaxBytes != nil ||
+		req.AuditLogRetentionDays != nil ||
+		req.NotifyChatIDs != nil ||
+		req.NotifyTorrentAwaitingSelection != nil ||
+		req.NotifyTransferFailed != nil ||
+		req.NotifyLowDisk != nil
 }
 
 func hasServicePatchChanges(req *serviceAccessPatchRequest) bool {
@@ -236,6 +266,31 @@ func (s *Server) validateAndPatchRuntimeSettings(
 		(*req.VaultSessionTTLMins < 1 || *req.VaultSessionTTLMins > 1440) {
 		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "密码箱密码有效期范围应为 1~1440 分钟", errors.New("invalid vault session ttl")
 	}
+	if req.TorrentSeedRatioTarget != nil &&
+		(*req.TorrentSeedRatioTarget < 0 || *req.TorrentSeedRatioTarget > torrentSeedRatioTargetMax) {
+		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "做种分享率目标范围应为 0~100", errors.New("invalid torrent seed ratio target")
+	}
+	if req.TorrentSeedMinMinutes != nil &&
+		(*req.TorrentSeedMinMinutes < 0 || *req.TorrentSeedMinMinutes > torrentSeedMinMinutesMax) {
+		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "最短做种时长范围应为 0~43200 分钟", errors.New("invalid torrent seed min minutes")
+	}
+	if req.HLSTranscodeConcurrency != nil &&
+		(*req.HLSTranscodeConcurrency < 1 || *req.HLSTranscodeConcurrency > 4) {
+		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "HLS 转码并发范围应为 1~4", errors.New("invalid hls transcode concurrency")
+	}
+	if req.HLSCacheMaxBytes != nil &&
+		(*req.HLSCacheMaxBytes < 256*1024*1024 || *req.HLSCacheMaxBytes > 200*1024*1024*1024) {
+		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "HLS 缓存上限范围应为 256MB~200GB", errors.New("invalid hls cache max bytes")
+	}
+	if req.AuditLogRetentionDays != nil &&
+		(*req.AuditLogRetentionDays < 0 || *req.AuditLogRetentionDays > auditLogRetentionDaysMax) {
+		return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", "审计日志保留天数范围应为 0~3650（0 表示永久保留）", errors.New("invalid audit log retention days")
+	}
+	if req.NotifyChatIDs != nil {
+		if err := validateNotifyChatIDs(*req.NotifyChatIDs); err != nil {
+			return store.RuntimeSettings{}, http.StatusBadRequest, "bad_request", err.Error(), err
+		}
+	}
 
 	current, err := s.getRuntimeSettings(ctx)
 	if err != nil {
@@ -304,22 +359,32 @@ func (s *Server) validateAndPatchRuntimeSettings(
 	}
 
 	next, err := store.New(s.db).UpdateRuntimeSettings(ctx, store.RuntimeSettingsPatch{
-		UploadConcurrency:                req.UploadConcurrency,
-		DownloadConcurrency:              req.DownloadConcurrency,
-		TelegramDeleteConcurrency:        req.TelegramDeleteConcurrency,
-		ReservedDiskBytes:                req.ReservedDiskBytes,
-		UploadSessionTTLHours:            req.UploadSessionTTLHours,
-		UploadSessionCleanupIntervalMins: req.UploadSessionCleanupIntervalMins,
-		ThumbnailCacheMaxBytes:           req.ThumbnailCacheMaxBytes,
-		ThumbnailCacheTTLHours:           req.ThumbnailCacheTTLHours,
-		ThumbnailGenerateConcurrency:     req.ThumbnailGenerateConcurrency,
-		VaultSessionTTLMins:              req.VaultSessionTTLMins,
-		VaultPasswordHash:                vaultPasswordHash,
-		TorrentQBTPassword:               nextQBTPassword,
-		TorrentSourceDeleteMode:          &nextDeleteMode,
-		TorrentSourceDeleteFixedMinutes:  &nextFixedMins,
-		TorrentSourceDeleteRandomMinMins: &nextRandomMinMins,
-		TorrentSourceDeleteRandomMaxMins: &nextRandomMaxMins,
+		UploadConcurrency:                 req.UploadConcurrency,
+		DownloadConcurrency:               req.DownloadConcurrency,
+		TelegramDeleteConcurrency:         req.TelegramDeleteConcurrency,
+		ReservedDiskBytes:                 req.ReservedDiskBytes,
+		UploadSessionTTLHours:             req.UploadSessionTTLHours,
+		UploadSessionCleanupIntervalMins:  req.UploadSessionCleanupIntervalMins,
+		ThumbnailCacheMaxBytes:            req.ThumbnailCacheMaxBytes,
+		ThumbnailCacheTTLHours:            req.ThumbnailCacheTTLHours,
+		ThumbnailGenerateConcurrency:      req.ThumbnailGenerateConcurrency,
+		VaultSessionTTLMins:               req.VaultSessionTTLMins,
+		VaultPasswordHash:                 vaultPasswordHash,
+		TorrentQBTPassword:                nextQBTPassword,
+		TorrentSourceDeleteMode:           &nextDeleteMode,
+		TorrentSourceDeleteFixedMinutes:   &nextFixedMins,
+		TorrentSourceDeleteRandomMinMins:  &nextRandomMinMins,
+		TorrentSourceDeleteRandomMaxMins:  &nextRandomMaxMins,
+		TorrentPreserveDirectoryStructure: req.TorrentPreserveDirectoryStructure,
+		TorrentSeedRatioTarget:            req.TorrentSeedRatioTarget,
+		TorrentSeedMinMinutes:             req.TorrentSeedMinMinutes,
+		HLSTranscodeConcurrency:           req.HLSTranscodeConcurrency,
+		HLSCacheMaxBytes:                  req.HLSCacheMaxBytes,
+		AuditLogRetentionDays:             req.AuditLogRetentionDays,
+		NotifyChatIDs:                     req.NotifyChatIDs,
+		NotifyTorrentAwaitingSelection:    req.NotifyTorrentAwaitingSelection,
+		NotifyTransferFailed:              req.NotifyTransferFailed,
+		NotifyLowDisk:                     req.NotifyLowDisk,
 	}, s.defaultRuntimeSettings())
 	if err != nil {
 		s.logger.Error("update runtime settings failed", "error", err.Error())
@@ -512,6 +577,7 @@ func (s *Server) applyServiceAccessPatch(
 		updated.AccessMethod,
 		updated.TGAPIBaseURL,
 		5*time.Minute,
+		s.telegramClientOptions()...,
 	)
 	if err := nextClient.SelfCheck(ctx, updated.TGStorageChatID); err != nil {
 		s.logger.Error("verify switched service access failed", "error", err.Error())
@@ -549,6 +615,7 @@ func (s *Server) applyServiceAccessPatch(
 			rollbackCfg.AccessMethod,
 			rollbackCfg.TGAPIBaseURL,
 			5*time.Minute,
+			s.telegramClientOptions()...,
 		)
 		s.applySystemConfig(rollbackCfg, rollbackClient)
 
@@ -583,20 +650,38 @@ func (s *Server) handlePatchSettings(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if hasRuntimeChanges {
+		auditDetail := auditDetailFromRequest(req.Runtime)
 		if _, status, code, message, err := s.validateAndPatchRuntimeSettings(r.Context(), req.Runtime); err != nil {
+			auditDetail["error"] = message
+			s.recordAudit(r.Context(), auditEntry{Action: auditActionSettingsUpdate, Failed: true, Detail: auditDetail})
 			writeError(w, status, code, message)
 			return
 		}
+		s.recordAudit(r.Context(), auditEntry{Action: auditActionSettingsUpdate, Detail: auditDetail})
 	}
 
 	st := store.New(s.db)
 	var switchResult *serviceSwitchResultDTO
 	if hasServiceChanges {
-		_, switchResultValue, status, payload, err := s.applyServiceAccessPatch(r.Context(), st, req.ServiceAccess)
+		auditDetail := auditDetailFromRequest(req.ServiceAccess)
+		if current, err := st.GetSystemConfig(r.Context()); err == nil {
+			auditDetail["from"] = current.AccessMethod
+		}
+		updated, switchResultValue, status, payload, err := s.applyServiceAccessPatch(r.Context(), st, req.ServiceAccess)
 		if err != nil {
+			if message, ok := payload["message"].(string); ok {
+				auditDetail["error"] = message
+			}
+			if rolledBack, ok := payload["rolledBack"].(bool); ok {
+				auditDetail["rolledBack"] = rolledBack
+			}
+			s.recordAudit(r.Context(), auditEntry{Action: auditActionAccessSwitch, Failed: true, Detail: auditDetail})
 			writeJSON(w, status, payload)
 			return
 		}
+		auditDetail["to"] = updated.AccessMethod
+		auditDetail["unchanged"] = switchResultValue != nil && switchResultValue.Unchanged
+		s.recordAudit(r.Context(), auditEntry{Action: auditActionAccessSwitch, Detail: auditDetail})
 		switchResult = switchResultValue
 	}
 
@@ -632,23 +717,33 @@ func toRuntimeSettingsDTO(s store.RuntimeSettings, chunkSizeBytes int64) runtime
 	}
 
 	return runtimeSettingsDTO{
-		UploadConcurrency:                s.UploadConcurrency,
-		DownloadConcurrency:              s.DownloadConcurrency,
-		TelegramDeleteConcurrency:        s.TelegramDeleteConcurrency,
-		ReservedDiskBytes:                s.ReservedDiskBytes,
-		UploadSessionTTLHours:            s.UploadSessionTTLHours,
-		UploadSessionCleanupIntervalMins: s.UploadSessionCleanupIntervalMins,
-		ThumbnailCacheMaxBytes:           s.ThumbnailCacheMaxBytes,
-		ThumbnailCacheTTLHours:           s.ThumbnailCacheTTLHours,
-		ThumbnailGenerateConcurrency:     s.ThumbnailGenerateConcurrency,
-		VaultSessionTTLMins:              s.VaultSessionTTLMins,
-		VaultPasswordEnabled:             strings.TrimSpace(s.VaultPasswordHash) != "",
-		TorrentQBTPasswordConfigured:     strings.TrimSpace(s.TorrentQBTPassword) != "",
-		TorrentSourceDeleteMode:          deleteMode,
-		TorrentSourceDeleteFixedMinutes:  s.TorrentSourceDeleteFixedMinutes,
-		TorrentSourceDeleteRandomMinMins: s.TorrentSourceDeleteRandomMinMins,
-		TorrentSourceDeleteRandomMaxMins: s.TorrentSourceDeleteRandomMaxMins,
-		ChunkSizeBytes:                   chunkSizeBytes,
+		UploadConcurrency:                 s.UploadConcurrency,
+		DownloadConcurrency:               s.DownloadConcurrency,
+		TelegramDeleteConcurrency:         s.TelegramDeleteConcurrency,
+		ReservedDiskBytes:                 s.ReservedDiskBytes,
+		UploadSessionTTLHours:             s.UploadSessionTTLHours,
+		UploadSessionCleanupIntervalMins:  s.UploadSessionCleanupIntervalMins,
+		ThumbnailCacheMaxBytes:            s.ThumbnailCacheMaxBytes,
+		ThumbnailCacheTTLHours:            s.ThumbnailCacheTTLHours,
+		ThumbnailGenerateConcurrency:      s.ThumbnailGenerateConcurrency,
+		VaultSessionTTLMins:               s.VaultSessionTTLMins,
+		VaultPasswordEnabled:              strings.TrimSpace(s.VaultPasswordHash) != "",
+		TorrentQBTPasswordConfigured:      strings.TrimSpace(s.TorrentQBTPassword) != "",
+		TorrentSourceDeleteMode:           deleteMode,
+		TorrentSourceDeleteFixedMinutes:   s.TorrentSourceDeleteFixedMinutes,
+		TorrentSourceDeleteRandomMinMins:  s.TorrentSourceDeleteRandomMinMins,
+		TorrentSourceDeleteRandomMaxMins:  s.TorrentSourceDeleteRandomMaxMins,
+		TorrentPreserveDirectoryStructure: s.TorrentPreserveDirectoryStructure,
+		TorrentSeedRatioTarget:            s.TorrentSeedRatioTarget,
+		TorrentSeedMinMinutes:             s.TorrentSeedMinMinutes,
+		HLSTranscodeConcurrency:           s.HLSTranscodeConcurrency,
+		HLSCacheMaxBytes:                  s.HLSCacheMaxBytes,
+		AuditLogRetentionDays:             s.AuditLogRetentionDays,
+		NotifyChatIDs:                     s.NotifyChatIDs,
+		NotifyTorrentAwaitingSelection:    s.NotifyTorrentAwaitingSelection,
+		NotifyTransferFailed:              s.NotifyTransferFailed,
+		NotifyLowDisk:                     s.NotifyLowDisk,
+		ChunkSizeBytes:                    chunkSizeBytes,
 	}
 }
 
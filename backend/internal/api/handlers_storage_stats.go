@@ -15,6 +15,8 @@ type storageStatsDTO struct {
 	TotalBytes int64                          `json:"totalBytes"`
 	TotalFiles int64                          `json:"totalFiles"`
 	ByType     map[string]storageTypeStatsDTO `json:"byType"`
+	// UnresolvedDeleteFailures 为仍留在频道中、等待重试或人工处理的 Telegram 消息数
+	UnresolvedDeleteFailures int64 `json:"unresolvedDeleteFailures"`
 }
 
 func (s *Server) handleGetStorageStats(w http.ResponseWriter, r *http.Request) {
@@ -24,9 +26,17 @@ func (s *Server) handleGetStorageStats(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal_error", "读取存储统计失败")
 		return
 	}
+	unresolved, err := store.New(s.db).CountUnresolvedTelegramDeleteFailures(r.Context())
+	if err != nil {
+		s.logger.Error("count telegram delete failures failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取存储统计失败")
+		return
+	}
 
+	dto := toStorageStatsDTO(stats)
+	dto.UnresolvedDeleteFailures = unresolved
 	writeJSON(w, http.StatusOK, map[string]any{
-		"stats": toStorageStatsDTO(stats),
+		"stats": dto,
 	})
 }
 
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	subtitleContentType        = "text/vtt; charset=utf-8"
+	subtitleCacheSubDir        = "subtitles"
+	subtitleStreamsFileName    = "subtitles.json"
+	subtitleSidecarMaxBytes    = 16 * 1024 * 1024
+	subtitleSidecarListLimit   = 200
+	subtitleExtractTimeout     = 15 * time.Minute
+	subtitleSidecarReadTimeout = 2 * time.Minute
+)
+
+// handleListItemSubtitles 列出视频可用的字幕：同目录外挂字幕（按主文件名匹配）与 ffprobe 探测到的内嵌文本字幕。
+func (s *Server) handleListItemSubtitles(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadVideoItem(w, r, "仅视频文件支持字幕")
+	if !ok {
+		return
+	}
+
+	siblings, err := store.New(s.db).ListSiblingFilesByExtensions(
+		r.Context(),
+		item.ParentID,
+		item.ID,
+		subtitleSidecarExtensions,
+		subtitleSidecarListLimit,
+	)
+	if err != nil {
+		s.logger.Error("list subtitle sidecars failed", "error", err.Error(), "item_id", item.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询字幕失败")
+		return
+	}
+	tracks := buildSidecarSubtitleTracks(item, siblings)
+
+	// 内嵌字幕探测失败不影响外挂字幕的返回
+	embeddedAvailable := true
+	streams, err := s.resolveEmbeddedSubtitleStreams(r.Context(), item)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		embeddedAvailable = false
+		s.logger.Warn("probe embedded subtitles failed", "error", err.Error(), "item_id", item.ID.String())
+	}
+	tracks = append(tracks, buildEmbeddedSubtitleTracks(item, streams)...)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"tracks":            tracks,
+		"embeddedAvailable": embeddedAvailable,
+	})
+}
+
+// handleItemSubtitleTrack 返回转换为 WebVTT 的字幕轨道，结果缓存在 HLS 缓存目录中。
+func (s *Server) handleItemSubtitleTrack(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.loadVideoItem(w, r, "仅视频文件支持字幕")
+	if !ok {
+		return
+	}
+	source, sidecarID, streamIndex, ok := parseSubtitleTrackID(chi.URLParam(r, "track"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "字幕不存在")
+		return
+	}
+
+	var (
+		cachePath string
+		generate  func(ctx context.Context, outputPath string) error
+	)
+	switch source {
+	case subtitleSourceSidecar:
+		sidecar, ok := s.loadSubtitleSidecar(w, r, item, sidecarID)
+		if !ok {
+			return
+		}
+		cachePath = filepath.Join(s.subtitleCacheDir(item), subtitleTrackSidecarPrefix+s.thumbnailCacheKey(sidecar)+subtitleTrackSuffix)
+		generate = func(ctx context.Context, outputPath string) error {
+			return s.convertSidecarSubtitle(ctx, sidecar, outputPath)
+		}
+	default:
+		streams, err := s.resolveEmbeddedSubtitleStreams(r.Context(), item)
+		if err != nil {
+			s.writeSubtitleError(w, item, "probe embedded subtitles failed", err)
+			return
+		}
+		found := false
+		for _, stream := range streams {
+			if stream.Index == streamIndex {
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "not_found", "字幕不存在")
+			return
+		}
+		cachePath = filepath.Join(s.subtitleCacheDir(item), subtitleTrackEmbeddedPrefix+strconv.Itoa(streamIndex)+subtitleTrackSuffix)
+		generate = func(ctx context.Context, outputPath string) error {
+			return s.extractEmbeddedSubtitle(ctx, item, streamIndex, outputPath)
+		}
+	}
+
+	if s.tryServeSubtitle(w, r, cachePath) {
+		return
+	}
+
+	leader, wait := s.beginHLSGeneration(cachePath)
+	if !leader {
+		select {
+		case <-wait:
+			if s.tryServeSubtitle(w, r, cachePath) {
+				return
+			}
+			writeError(w, http.StatusBadGateway, "bad_gateway", "字幕提取失败")
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+	defer s.finishHLSGeneration(cachePath)
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		s.logger.Error("create subtitle cache dir failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "字幕缓存初始化失败")
+		return
+	}
+
+	settings, err := s.getRuntimeSettings(r.Context())
+	if err != nil {
+		s.logger.Error("get runtime settings failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取运行配置失败")
+		return
+	}
+	if err := s.acquireHLSTranscodeSlot(r.Context(), settings.HLSTranscodeConcurrency); err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return
+		}
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "转码队列繁忙，请稍后重试")
+		return
+	}
+	defer s.releaseHLSTranscode()
+
+	if err := generate(r.Context(), cachePath); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		s.writeSubtitleError(w, item, "extract subtitle failed", err)
+		return
+	}
+
+	if s.tryServeSubtitle(w, r, cachePath) {
+		return
+	}
+	writeError(w, http.StatusBadGateway, "bad_gateway", "字幕读取失败")
+}
+
+// loadSubtitleSidecar 校验外挂字幕确实与视频位于同一目录且文件名匹配，防止借字幕接口读取任意文件。
+func (s *Server) loadSubtitleSidecar(w http.ResponseWriter, r *http.Request, video store.Item, sidecarID uuid.UUID) (store.Item, bool) {
+	sidecar, err := store.New(s.db).GetItem(r.Context(), sidecarID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "字幕不存在")
+			return store.Item{}, false
+		}
+		s.logger.Error("get subtitle sidecar failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return store.Item{}, false
+	}
+	if len(buildSidecarSubtitleTracks(video, []store.Item{sidecar})) == 0 || !sameParent(video.ParentID, sidecar.ParentID) {
+		writeError(w, http.StatusNotFound, "not_found", "字幕不存在")
+		return store.Item{}, false
+	}
+	if sidecar.Size > subtitleSidecarMaxBytes {
+		writeError(w, http.StatusBadRequest, "bad_request", "字幕文件过大")
+		return store.Item{}, false
+	}
+	return sidecar, true
+}
+
+func sameParent(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (s *Server) convertSidecarSubtitle(ctx context.Context, sidecar store.Item, outputPath string) error {
+	readCtx, cancel := context.WithTimeout(ctx, subtitleSidecarReadTimeout)
+	defer cancel()
+	tmpPath, err := s.downloadItemPrefixToTempFile(readCtx, sidecar.ID, subtitleSidecarMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	switch strings.ToLower(filepath.Ext(sidecar.Name)) {
+	case ".srt", ".vtt":
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(filepath.Ext(sidecar.Name), ".vtt") {
+			data = normalizeWebVTT(data)
+		} else {
+			data = convertSRTToWebVTT(data)
+		}
+		return writeSubtitleFile(outputPath, data)
+	default:
+		// ASS/SSA 的样式较复杂，交给 ffmpeg 转换
+		return s.runFFmpegToFile(readCtx, outputPath, "tgcd-sub-*.vtt", "-i", tmpPath, "-map", "0:s:0", "-c:s", "webvtt", "-f", "webvtt")
+	}
+}
+
+func (s *Server) extractEmbeddedSubtitle(ctx context.Context, item store.Item, streamIndex int, outputPath string) error {
+	sourceURL, release, err := s.openHLSSourceURL(item.ID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	extractCtx, cancel := context.WithTimeout(ctx, subtitleExtractTimeout)
+	defer cancel()
+	return s.runFFmpegToFile(
+		extractCtx,
+		outputPath,
+		"tgcd-sub-*.vtt",
+		"-i", sourceURL,
+		"-map", fmt.Sprintf("0:%d", streamIndex),
+		"-vn", "-an",
+		"-c:s", "webvtt",
+		"-f", "webvtt",
+	)
+}
+
+// resolveEmbeddedSubtitleStreams 读取缓存的字幕流列表；缺失时通过回环地址执行 ffprobe。
+func (s *Server) resolveEmbeddedSubtitleStreams(ctx context.Context, item store.Item) ([]embeddedSubtitleStream, error) {
+	itemDir := s.hlsItemCacheDir(item)
+	streamsPath := filepath.Join(itemDir, subtitleStreamsFileName)
+	if streams, ok := readSubtitleStreamsFile(streamsPath); ok {
+		return streams, nil
+	}
+
+	leader, wait := s.beginHLSGeneration(streamsPath)
+	if !leader {
+		select {
+		case <-wait:
+			if streams, ok := readSubtitleStreamsFile(streamsPath); ok {
+				return streams, nil
+			}
+			return nil, errors.New("字幕探测失败")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	defer s.finishHLSGeneration(streamsPath)
+
+	ffprobeBinary, err := s.resolveFFprobeBinary()
+	if err != nil {
+		return nil, err
+	}
+	sourceURL, release, err := s.openHLSSourceURL(item.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	probeCtx, cancel := context.WithTimeout(ctx, hlsProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(
+		probeCtx,
+		ffprobeBinary,
+		"-v", "error",
+		"-select_streams", "s",
+		"-show_entries", "stream=index,codec_type,codec_name:stream_tags=language,title:stream_disposition=default,forced",
+		"-of", "json",
+		sourceURL,
+	)
+	raw, runErr := cmd.Output()
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("ffprobe 失败: %w: %s", runErr, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, runErr
+	}
+	streams, err := parseFFprobeSubtitleStreams(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(itemDir, 0o755); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(streams)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeSubtitleFile(streamsPath, encoded); err != nil {
+		return nil, err
+	}
+	return streams, nil
+}
+
+func readSubtitleStreamsFile(path string) ([]embeddedSubtitleStream, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var streams []embeddedSubtitleStream
+	if err := json.Unmarshal(raw, &streams); err != nil {
+		return nil, false
+	}
+	return streams, true
+}
+
+// writeSubtitleFile 先写临时文件再改名，避免并发读取到半截内容。
+func writeSubtitleFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "tgcd-sub-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func (s *Server) tryServeSubtitle(w http.ResponseWriter, r *http.Request, path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	w.Header().Set("Content-Type", subtitleContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeFile(w, r, path)
+	return true
+}
+
+func (s *Server) writeSubtitleError(w http.ResponseWriter, item store.Item, logMsg string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		writeError(w, http.StatusGatewayTimeout, "timeout", "字幕提取超时")
+		return
+	}
+	if isMediaToolMissingError(err) {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "ffmpeg 未安装，无法提取字幕")
+		return
+	}
+	s.logger.Warn(logMsg, "error", err.Error(), "item_id", item.ID.String())
+	writeError(w, http.StatusBadGateway, "bad_gateway", "字幕提取失败")
+}
+
+// subtitleCacheDir 位于视频的 HLS 缓存目录下，随视频版本失效并参与同一套容量清理。
+func (s *Server) subtitleCacheDir(item store.Item) string {
+	return filepath.Join(s.hlsItemCacheDir(item), subtitleCacheSubDir)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	textPreviewDefaultKB = 256
+	textPreviewMaxKB     = 2048
+)
+
+// handleItemTextPreview 只读取文件开头 maxKB，识别编码后以 UTF-8 JSON 返回；Markdown 可附带安全 HTML。
+func (s *Server) handleItemTextPreview(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	maxKB, err := parseTextPreviewMaxKB(r.URL.Query().Get("maxKB"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	item, err := st.GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if item.Type == store.ItemTypeFolder {
+		writeError(w, http.StatusBadRequest, "bad_request", "文件夹不支持预览")
+		return
+	}
+	if item.InVault && !s.requireVaultUnlocked(w, r) {
+		return
+	}
+
+	chunks, err := st.ListChunks(r.Context(), item.ID)
+	if err != nil {
+		s.logger.Error("list chunks failed", "error", err.Error(), "item_id", item.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	size := resolveItemContentSize(item, chunks)
+	limit := int64(maxKB) * 1024
+	truncated := size > limit
+	readSize := minInt64(size, limit)
+
+	var data []byte
+	if readSize > 0 {
+		reader := s.openItemChunkReader(r.Context(), chunks, 0, readSize-1)
+		var buf bytes.Buffer
+		buf.Grow(int(readSize))
+		_, err := io.Copy(&buf, reader)
+		_ = reader.Close()
+		if err != nil {
+			s.logger.Warn("read text preview failed", "error", err.Error(), "item_id", item.ID.String())
+			writeError(w, http.StatusBadGateway, "bad_gateway", "读取文件失败")
+			return
+		}
+		data = buf.Bytes()
+	}
+
+	content, charset, ok := decodeTextPreview(data, truncated)
+	if !ok {
+		writeError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "该文件不是文本文件，无法预览")
+		return
+	}
+
+	mimeType := resolveDownloadMimeType(item)
+	language := inferTextLanguage(item.Name, mimeType)
+	resp := map[string]any{
+		"itemId":      item.ID.String(),
+		"name":        item.Name,
+		"encoding":    charset,
+		"language":    language,
+		"content":     content,
+		"lineCount":   countTextLines(content),
+		"size":        size,
+		"bytesRead":   len(data),
+		"truncated":   truncated,
+		"maxKB":       maxKB,
+		"contentType": mimeType,
+	}
+	if language == "markdown" && isTruthyQuery(r.URL.Query().Get("html")) {
+		resp["html"] = renderMarkdownToSafeHTML(content)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func parseTextPreviewMaxKB(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return textPreviewDefaultKB, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value <= 0 {
+		return 0, errors.New("maxKB 必须为正整数")
+	}
+	if value > textPreviewMaxKB {
+		value = textPreviewMaxKB
+	}
+	return value, nil
+}
+
+// countTextLines 统计返回内容中的行数，末尾换行不额外计一行。
+func countTextLines(content string) int {
+	if content == "" {
+		return 0
+	}
+	lines := strings.Count(content, "\n")
+	if !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+	return lines
+}
+
+func isTruthyQuery(raw string) bool {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "1", "true", "yes":
+		return true
+	default:
+		return false
+	}
+}
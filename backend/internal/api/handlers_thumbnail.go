@@ -41,8 +41,9 @@ func (s *Server) handleItemThumbnail(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
 		return
 	}
-	if item.Type != store.ItemTypeVideo {
-		writeError(w, http.StatusBadRequest, "bad_request", "仅视频文件支持首帧缩略图")
+	kind := resolveThumbnailKind(item)
+	if kind == thumbnailKindNone {
+		writeError(w, http.StatusBadRequest, "bad_request", "该文件类型暂不支持缩略图")
 		return
 	}
 	if item.InVault {
@@ -67,8 +68,10 @@ func (s *Server) handleItemThumbnail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if s.tryServeThumbnailFromCache(w, r, cachePath, cacheTTL) {
+		s.metrics.recordThumbnailCacheLookup(true)
 		return
 	}
+	s.metrics.recordThumbnailCacheLookup(false)
 
 	cacheKey := s.thumbnailCacheKey(item)
 	leader, wait := s.beginThumbnailGeneration(cacheKey)
@@ -95,19 +98,31 @@ func (s *Server) handleItemThumbnail(w http.ResponseWriter, r *http.Request) {
 	}
 	defer s.releaseThumbnailGenerate()
 
-	if err := s.generateAndCacheVideoThumbnail(r.Context(), item, cachePath); err != nil {
+	if err := s.generateAndCacheThumbnail(r.Context(), item, kind, cachePath); err != nil {
 		var execErr *exec.Error
 		if errors.As(err, &execErr) && strings.Contains(strings.ToLower(execErr.Error()), "executable file not found") {
-			writeError(w, http.StatusServiceUnavailable, "service_unavailable", "ffmpeg 未安装，无法生成视频缩略图")
+			writeError(w, http.StatusServiceUnavailable, "service_unavailable", thumbnailToolMissingMessage(kind))
+			return
+		}
+		switch {
+		case errors.Is(err, errThumbnailSourceTooLarge):
+			writeError(w, http.StatusBadRequest, "bad_request", "文件过大，暂不生成缩略图")
+			return
+		case errors.Is(err, errThumbnailNoCover):
+			writeError(w, http.StatusNotFound, "not_found", "音频未包含封面")
+			return
+		case errors.Is(err, errThumbnailNotText):
+			writeError(w, http.StatusBadRequest, "bad_request", "文件内容不是文本，无法生成预览")
 			return
 		}
 		s.logger.Warn(
 			"generate thumbnail failed",
 			"error", err.Error(),
 			"item_id", item.ID.String(),
+			"kind", string(kind),
 			"path", item.Path,
 		)
-		writeError(w, http.StatusBadGateway, "bad_gateway", "视频缩略图生成失败")
+		writeError(w, http.StatusBadGateway, "bad_gateway", "缩略图生成失败")
 		return
 	}
 
@@ -116,7 +131,7 @@ func (s *Server) handleItemThumbnail(w http.ResponseWriter, r *http.Request) {
 	if s.tryServeThumbnailFromCache(w, r, cachePath, cacheTTL) {
 		return
 	}
-	writeError(w, http.StatusBadGateway, "bad_gateway", "视频缩略图读取失败")
+	writeError(w, http.StatusBadGateway, "bad_gateway", "缩略图读取失败")
 }
 
 func (s *Server) thumbnailCacheKey(item store.Item) string {
@@ -156,7 +171,7 @@ func (s *Server) tryServeThumbnailFromCache(
 	now := time.Now()
 	_ = os.Chtimes(cachePath, now, now)
 
-	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Content-Type", thumbnailCacheContentType(cachePath))
 	w.Header().Set("Cache-Control", "private, max-age=300")
 	http.ServeFile(w, r, cachePath)
 	return true
@@ -188,57 +203,17 @@ func (s *Server) finishThumbnailGeneration(key string) {
 }
 
 func (s *Server) generateAndCacheVideoThumbnail(ctx context.Context, item store.Item, cachePath string) error {
-	inputPath, err := s.downloadVideoPrefixToTempFile(ctx, item.ID, thumbnailInputMaxBytes)
+	inputPath, err := s.downloadItemPrefixToTempFile(ctx, item.ID, thumbnailInputMaxBytes)
 	if err != nil {
 		return err
 	}
 	defer os.Remove(inputPath)
 
-	outTmp, err := os.CreateTemp(filepath.Dir(cachePath), "tgcd-thumb-*.jpg")
-	if err != nil {
-		return err
-	}
-	outPath := outTmp.Name()
-	_ = outTmp.Close()
-	defer os.Remove(outPath)
-
 	filter := fmt.Sprintf("scale=min(%d\\,iw):-2", thumbnailWidthPx)
-	cmd := exec.CommandContext(
-		ctx,
-		s.cfg.FFmpegBinary,
-		"-hide_banner",
-		"-loglevel", "error",
-		"-y",
-		"-ss", thumbnailCaptureAtSec,
-		"-i", inputPath,
-		"-frames:v", "1",
-		"-vf", filter,
-		"-q:v", "4",
-		outPath,
-	)
-	if out, runErr := cmd.CombinedOutput(); runErr != nil {
-		msg := strings.TrimSpace(string(out))
-		if msg == "" {
-			return runErr
-		}
-		return fmt.Errorf("ffmpeg 执行失败: %w: %s", runErr, msg)
-	}
-
-	stat, err := os.Stat(outPath)
-	if err != nil {
-		return err
-	}
-	if stat.Size() <= 0 {
-		return errors.New("ffmpeg 未生成有效缩略图")
-	}
-
-	if err := os.Rename(outPath, cachePath); err != nil {
-		return err
-	}
-	return nil
+	return s.runFFmpegThumbnail(ctx, cachePath, "-ss", thumbnailCaptureAtSec, "-i", inputPath, "-frames:v", "1", "-vf", filter)
 }
 
-func (s *Server) downloadVideoPrefixToTempFile(ctx context.Context, itemID uuid.UUID, maxBytes int64) (string, error) {
+func (s *Server) downloadItemPrefixToTempFile(ctx context.Context, itemID uuid.UUID, maxBytes int64) (string, error) {
 	if maxBytes <= 0 {
 		maxBytes = thumbnailInputMaxBytes
 	}
@@ -249,7 +224,7 @@ func (s *Server) downloadVideoPrefixToTempFile(ctx context.Context, itemID uuid.
 		return "", err
 	}
 	if len(chunks) == 0 {
-		return "", errors.New("视频分块不存在")
+		return "", errors.New("文件分块不存在")
 	}
 
 	tmpFile, err := os.CreateTemp("", "tgcd-thumb-src-*.bin")
@@ -316,7 +291,7 @@ func (s *Server) downloadVideoPrefixToTempFile(ctx context.Context, itemID uuid.
 			req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", chunkNeed-1))
 		}
 
-		resp, err := httpClient.Do(req)
+		resp, err := doTelegramFileRequest(httpClient, req)
 		if err != nil {
 			return "", err
 		}
@@ -343,7 +318,7 @@ func (s *Server) downloadVideoPrefixToTempFile(ctx context.Context, itemID uuid.
 		return "", err
 	}
 	if info.Size() <= 0 {
-		return "", errors.New("未读取到可用于生成缩略图的视频数据")
+		return "", errors.New("未读取到可用于生成缩略图的文件数据")
 	}
 
 	if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,29 +23,38 @@ import (
 )
 
 type torrentTaskDTO struct {
-	ID                  string               `json:"id"`
-	SourceType          string               `json:"sourceType"`
-	SourceURL           *string              `json:"sourceUrl"`
-	TorrentName         string               `json:"torrentName"`
-	InfoHash            string               `json:"infoHash"`
-	TargetChatID        string               `json:"targetChatId"`
-	TargetParentID      *string              `json:"targetParentId"`
-	SubmittedBy         string               `json:"submittedBy"`
-	EstimatedSize       int64                `json:"estimatedSize"`
-	DownloadedBytes     int64                `json:"downloadedBytes"`
-	Progress            float64              `json:"progress"`
-	IsPrivate           bool                 `json:"isPrivate"`
-	TrackerHosts        []string             `json:"trackerHosts"`
-	Status              string               `json:"status"`
-	Error               *string              `json:"error"`
-	StartedAt           *time.Time           `json:"startedAt"`
-	FinishedAt          *time.Time           `json:"finishedAt"`
-	SourceCleanupPolicy string               `json:"sourceCleanupPolicy"`
-	DueAt               *time.Time           `json:"dueAt"`
-	SourceCleanupDone   bool                 `json:"sourceCleanupDone"`
-	CreatedAt           time.Time            `json:"createdAt"`
-	UpdatedAt           time.Time            `json:"updatedAt"`
-	Files               []torrentTaskFileDTO `json:"files,omitempty"`
+	ID                         string               `json:"id"`
+	SourceType                 string               `json:"sourceType"`
+	SourceURL                  *string              `json:"sourceUrl"`
+	TorrentName                string               `json:"torrentName"`
+	InfoHash                   string               `json:"infoHash"`
+	TargetChatID               string               `json:"targetChatId"`
+	TargetParentID             *string              `json:"targetParentId"`
+	SubmittedBy                string               `json:"submittedBy"`
+	EstimatedSize              int64                `json:"estimatedSize"`
+	DownloadedBytes            int64                `json:"downloadedBytes"`
+	Progress                   float64              `json:"progress"`
+	IsPrivate                  bool                 `json:"isPrivate"`
+	TrackerHosts               []string             `json:"trackerHosts"`
+	Status                     string               `json:"status"`
+	Error                      *string              `json:"error"`
+	StartedAt                  *time.Time           `json:"startedAt"`
+	FinishedAt                 *time.Time           `json:"finishedAt"`
+	SourceCleanupPolicy        string               `json:"sourceCleanupPolicy"`
+	DueAt                      *time.Time           `json:"dueAt"`
+	SourceCleanupDone          bool                 `json:"sourceCleanupDone"`
+	Priority                   int                  `json:"priority"`
+	PausedFromStatus           *string              `json:"pausedFromStatus"`
+	PreserveDirectoryStructure bool                 `json:"preserveDirectoryStructure"`
+	SeedRatioTarget            float64              `json:"seedRatioTarget"`
+	SeedMinMinutes             int                  `json:"seedMinMinutes"`
+	SeedRatio                  float64              `json:"seedRatio"`
+	SeedingSeconds             int64                `json:"seedingSeconds"`
+	SeedCheckedAt              *time.Time           `json:"seedCheckedAt"`
+	SeedTargetsMet             bool                 `json:"seedTargetsMet"`
+	CreatedAt                  time.Time            `json:"createdAt"`
+	UpdatedAt                  time.Time            `json:"updatedAt"`
+	Files                      []torrentTaskFileDTO `json:"files,omitempty"`
 }
 
 type torrentTaskFileDTO struct {
@@ -83,6 +93,9 @@ type createTorrentTaskPayload struct {
 	SourceType          store.TorrentSourceType
 	SourceURL           *string
 	SubmittedBy         string
+	Priority            int
+	// PreserveDirectoryStructure 为 nil 时使用运行时默认值
+	PreserveDirectoryStructure *bool
 }
 
 func normalizeStringSlice(items []string) []string {
@@ -106,29 +119,44 @@ func toTorrentTaskDTO(task store.TorrentTask, files []store.TorrentTaskFile) tor
 		parentID = &v
 	}
 
+	var pausedFromStatus *string
+	if task.PausedFromStatus != nil {
+		v := string(*task.PausedFromStatus)
+		pausedFromStatus = &v
+	}
+
 	dto := torrentTaskDTO{
-		ID:                  task.ID.String(),
-		SourceType:          string(task.SourceType),
-		SourceURL:           task.SourceURL,
-		TorrentName:         task.TorrentName,
-		InfoHash:            task.InfoHash,
-		TargetChatID:        task.TargetChatID,
-		TargetParentID:      parentID,
-		SubmittedBy:         task.SubmittedBy,
-		EstimatedSize:       task.EstimatedSize,
-		DownloadedBytes:     task.DownloadedBytes,
-		Progress:            task.Progress,
-		IsPrivate:           task.IsPrivate,
-		TrackerHosts:        normalizeStringSlice(task.TrackerHosts),
-		Status:              string(task.Status),
-		Error:               task.Error,
-		StartedAt:           task.StartedAt,
-		FinishedAt:          task.FinishedAt,
-		SourceCleanupPolicy: task.SourceCleanupPolicy,
-		DueAt:               task.SourceCleanupDueAt,
-		SourceCleanupDone:   task.SourceCleanupDone,
-		CreatedAt:           task.CreatedAt,
-		UpdatedAt:           task.UpdatedAt,
+		ID:                         task.ID.String(),
+		SourceType:                 string(task.SourceType),
+		SourceURL:                  task.SourceURL,
+		TorrentName:                task.TorrentName,
+		InfoHash:                   task.InfoHash,
+		TargetChatID:               task.TargetChatID,
+		TargetParentID:             parentID,
+		SubmittedBy:                task.SubmittedBy,
+		EstimatedSize:              task.EstimatedSize,
+		DownloadedBytes:            task.DownloadedBytes,
+		Progress:                   task.Progress,
+		IsPrivate:                  task.IsPrivate,
+		TrackerHosts:               normalizeStringSlice(task.TrackerHosts),
+		Status:                     string(task.Status),
+		Error:                      task.Error,
+		StartedAt:                  task.StartedAt,
+		FinishedAt:                 task.FinishedAt,
+		SourceCleanupPolicy:        task.SourceCleanupPolicy,
+		DueAt:                      task.SourceCleanupDueAt,
+		SourceCleanupDone:          task.SourceCleanupDone,
+		Priority:                   task.Priority,
+		PausedFromStatus:           pausedFromStatus,
+		PreserveDirectoryStructure: task.PreserveDirectoryStructure,
+		SeedRatioTarget:            task.SeedRatioTarget,
+		SeedMinMinutes:             task.SeedMinMinutes,
+		SeedRatio:                  task.SeedRatio,
+		SeedingSeconds:             task.SeedingSeconds,
+		SeedCheckedAt:              task.SeedCheckedAt,
+		SeedTargetsMet:             isTorrentSeedTargetMet(task.SeedRatioTarget, task.SeedMinMinutes, task.SeedRatio, task.SeedingSeconds),
+		CreatedAt:                  task.CreatedAt,
+		UpdatedAt:                  task.UpdatedAt,
 	}
 	if len(files) > 0 {
 		dto.Files = make([]torrentTaskFileDTO, 0, len(files))
@@ -187,6 +215,17 @@ func (s *Server) resolveTorrentTaskCleanupPolicy(ctx context.Context) string {
 	return mode
 }
 
+func (s *Server) resolveTorrentTaskPreserveDirectoryStructure(ctx context.Context, requested *bool) bool {
+	if requested != nil {
+		return *requested
+	}
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		settings = s.defaultRuntimeSettings()
+	}
+	return settings.TorrentPreserveDirectoryStructure
+}
+
 func (s *Server) handlePreviewTorrent(w http.ResponseWriter, r *http.Request) {
 	if !s.cfg.TorrentEnabled {
 		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "当前实例未启用 Torrent 功能")
@@ -269,6 +308,7 @@ func (s *Server) handleCreateTorrentTask(w http.ResponseWriter, r *http.Request)
 	now := time.Now()
 	taskID := uuid.New()
 	cleanupPolicy := s.resolveTorrentTaskCleanupPolicy(r.Context())
+	seedRatioTarget, seedMinMinutes := s.resolveTorrentTaskSeedTargets(r.Context())
 	if err := os.MkdirAll(s.cfg.TorrentWorkDir, 0o755); err != nil {
 		s.logger.Error("create torrent work dir failed", "error", err.Error())
 		writeError(w, http.StatusInternalServerError, "internal_error", "创建 Torrent 工作目录失败")
@@ -282,28 +322,32 @@ func (s *Server) handleCreateTorrentTask(w http.ResponseWriter, r *http.Request)
 	}
 
 	created, err := st.CreateTorrentTask(r.Context(), store.TorrentTask{
-		ID:                  taskID,
-		SourceType:          payload.SourceType,
-		SourceURL:           payload.SourceURL,
-		TorrentName:         meta.Name,
-		InfoHash:            meta.InfoHash,
-		TorrentFilePath:     torrentPath,
-		QBTorrentHash:       nil,
-		TargetChatID:        strings.TrimSpace(s.cfg.TGStorageChatID),
-		TargetParentID:      payload.ParentID,
-		SubmittedBy:         payload.SubmittedBy,
-		EstimatedSize:       meta.TotalSize,
-		DownloadedBytes:     0,
-		Progress:            0,
-		IsPrivate:           meta.IsPrivate,
-		TrackerHosts:        meta.AnnounceHosts,
-		Status:              store.TorrentTaskStatusQueued,
-		Error:               nil,
-		StartedAt:           nil,
-		FinishedAt:          nil,
-		SourceCleanupPolicy: cleanupPolicy,
-		CreatedAt:           now,
-		UpdatedAt:           now,
+		ID:                         taskID,
+		SourceType:                 payload.SourceType,
+		SourceURL:                  payload.SourceURL,
+		TorrentName:                meta.Name,
+		InfoHash:                   meta.InfoHash,
+		TorrentFilePath:            torrentPath,
+		QBTorrentHash:              nil,
+		TargetChatID:               strings.TrimSpace(s.cfg.TGStorageChatID),
+		TargetParentID:             payload.ParentID,
+		SubmittedBy:                payload.SubmittedBy,
+		EstimatedSize:              meta.TotalSize,
+		DownloadedBytes:            0,
+		Progress:                   0,
+		IsPrivate:                  meta.IsPrivate,
+		TrackerHosts:               meta.AnnounceHosts,
+		Status:                     store.TorrentTaskStatusQueued,
+		Error:                      nil,
+		StartedAt:                  nil,
+		FinishedAt:                 nil,
+		SourceCleanupPolicy:        cleanupPolicy,
+		Priority:                   payload.Priority,
+		PreserveDirectoryStructure: s.resolveTorrentTaskPreserveDirectoryStructure(r.Context(), payload.PreserveDirectoryStructure),
+		SeedRatioTarget:            seedRatioTarget,
+		SeedMinMinutes:             seedMinMinutes,
+		CreatedAt:                  now,
+		UpdatedAt:                  now,
 	})
 	if err != nil {
 		_ = os.Remove(torrentPath)
@@ -311,6 +355,7 @@ func (s *Server) handleCreateTorrentTask(w http.ResponseWriter, r *http.Request)
 		writeError(w, http.StatusInternalServerError, "internal_error", "创建 Torrent 任务失败")
 		return
 	}
+	s.recordTorrentTaskTraceParent(r.Context(), st, taskID)
 	taskFiles := buildTorrentTaskInitialFiles(taskID, meta.Files, selectedIndexSet)
 	if err := st.ReplaceTorrentTaskFiles(r.Context(), taskID, taskFiles, now); err != nil {
 		_ = st.DeleteTorrentTask(context.Background(), taskID)
@@ -333,6 +378,10 @@ func (s *Server) handleCreateTorrentTask(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	s.recordTorrentAudit(r.Context(), auditActionTorrentCreate, created, nil, map[string]any{
+		"sourceType":    string(created.SourceType),
+		"selectedFiles": len(selectedIndexSet),
+	})
 	writeJSON(w, http.StatusOK, map[string]any{
 		"task": toTorrentTaskDTO(created, taskFiles),
 	})
@@ -409,6 +458,13 @@ func (s *Server) handleGetTorrentTask(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "internal_error", "读取 Torrent 任务失败")
 		return
 	}
+	if shouldRefreshTorrentTaskSeedStats(task, time.Now()) {
+		if refreshed, _, refreshErr := s.refreshTorrentTaskSeedStats(r.Context(), st, task); refreshErr != nil {
+			s.logger.Warn("refresh torrent seed stats failed", "error", refreshErr.Error(), "task_id", taskID.String())
+		} else {
+			task = refreshed
+		}
+	}
 	files, err := st.ListTorrentTaskFiles(r.Context(), taskID)
 	if err != nil {
 		s.logger.Error("list torrent task files failed", "error", err.Error())
@@ -460,6 +516,7 @@ func (s *Server) handleDeleteTorrentTask(w http.ResponseWriter, r *http.Request)
 	if err := st.DeleteTransferJobByID(r.Context(), taskID); err == nil {
 		s.publishTransferDeletion(taskID)
 	}
+	s.recordTorrentAudit(r.Context(), auditActionTorrentDelete, task, nil, map[string]any{"status": string(task.Status)})
 
 	resp := map[string]any{
 		"deleted": true,
@@ -648,6 +705,7 @@ func (s *Server) handleDispatchTorrentTask(w http.ResponseWriter, r *http.Reques
 		s.logger.Warn("sync torrent transfer job after dispatch failed", "error", err.Error(), "task_id", taskID.String())
 	}
 
+	s.recordTorrentAudit(r.Context(), auditActionTorrentDispatch, updated, nil, map[string]any{"fileIndexes": req.FileIndexes})
 	writeJSON(w, http.StatusOK, map[string]any{
 		"task": toTorrentTaskDTO(updated, files),
 	})
@@ -703,6 +761,7 @@ func (s *Server) handleRetryTorrentTask(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		s.refreshTorrentTransferJobByTaskID(r.Context(), taskID, store.TransferJobStatusRunning, "")
+		s.recordTorrentAudit(r.Context(), auditActionTorrentRetry, updated, nil, map[string]any{"retryMode": "upload"})
 		resp := map[string]any{
 			"task":      toTorrentTaskDTO(updated, nil),
 			"retryMode": "upload",
@@ -742,6 +801,7 @@ func (s *Server) handleRetryTorrentTask(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	s.refreshTorrentTransferJobByTaskID(r.Context(), taskID, store.TransferJobStatusRunning, "")
+	s.recordTorrentAudit(r.Context(), auditActionTorrentRetry, updated, nil, map[string]any{"retryMode": "download"})
 
 	resp := map[string]any{
 		"task": toTorrentTaskDTO(updated, nil),
@@ -830,10 +890,12 @@ func (s *Server) cleanupTorrentTaskRetryResources(
 
 func (s *Server) parseCreateTorrentTaskPayloadJSON(r *http.Request) (createTorrentTaskPayload, error) {
 	var req struct {
-		ParentID            *string `json:"parentId"`
-		TorrentURL          string  `json:"torrentUrl"`
-		SelectedFileIndexes []int   `json:"selectedFileIndexes"`
-		SubmittedBy         string  `json:"submittedBy"`
+		ParentID                   *string `json:"parentId"`
+		TorrentURL                 string  `json:"torrentUrl"`
+		SelectedFileIndexes        []int   `json:"selectedFileIndexes"`
+		SubmittedBy                string  `json:"submittedBy"`
+		Priority                   *int    `json:"priority"`
+		PreserveDirectoryStructure *bool   `json:"preserveDirectoryStructure"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return createTorrentTaskPayload{}, errors.New("请求体不是合法 JSON")
@@ -846,6 +908,10 @@ func (s *Server) parseCreateTorrentTaskPayloadJSON(r *http.Request) (createTorre
 	if err != nil {
 		return createTorrentTaskPayload{}, err
 	}
+	priority, err := normalizeTorrentTaskPriority(req.Priority)
+	if err != nil {
+		return createTorrentTaskPayload{}, err
+	}
 	rawURL := strings.TrimSpace(req.TorrentURL)
 	if rawURL == "" {
 		return createTorrentTaskPayload{}, errors.New("torrentUrl 不能为空")
@@ -860,13 +926,15 @@ func (s *Server) parseCreateTorrentTaskPayloadJSON(r *http.Request) (createTorre
 		submittedBy = "admin"
 	}
 	return createTorrentTaskPayload{
-		ParentID:            parentID,
-		TorrentURL:          rawURL,
-		TorrentBytes:        torrentBytes,
-		SelectedFileIndexes: selectedFileIndexes,
-		SourceType:          store.TorrentSourceTypeURL,
-		SourceURL:           &sourceURL,
-		SubmittedBy:         submittedBy,
+		ParentID:                   parentID,
+		TorrentURL:                 rawURL,
+		TorrentBytes:               torrentBytes,
+		SelectedFileIndexes:        selectedFileIndexes,
+		SourceType:                 store.TorrentSourceTypeURL,
+		SourceURL:                  &sourceURL,
+		SubmittedBy:                submittedBy,
+		Priority:                   priority,
+		PreserveDirectoryStructure: req.PreserveDirectoryStructure,
 	}, nil
 }
 
@@ -883,6 +951,8 @@ func (s *Server) parseCreateTorrentTaskPayloadMultipart(r *http.Request) (create
 		fileBytes           []byte
 		fileName            string
 		selectedFileIndexes []int
+		priorityRaw         *int
+		preserveRaw         *bool
 	)
 
 	for {
@@ -930,6 +1000,32 @@ func (s *Server) parseCreateTorrentTaskPayloadMultipart(r *http.Request) (create
 				return createTorrentTaskPayload{}, parseErr
 			}
 			selectedFileIndexes = normalized
+		case "priority":
+			value, readErr := readSmallPartValue(part, 64)
+			_ = part.Close()
+			if readErr != nil {
+				return createTorrentTaskPayload{}, errors.New("读取 priority 失败")
+			}
+			if trimmed := strings.TrimSpace(value); trimmed != "" {
+				parsed, parseErr := strconv.Atoi(trimmed)
+				if parseErr != nil {
+					return createTorrentTaskPayload{}, errors.New("priority 必须为整数")
+				}
+				priorityRaw = &parsed
+			}
+		case "preserveDirectoryStructure":
+			value, readErr := readSmallPartValue(part, 64)
+			_ = part.Close()
+			if readErr != nil {
+				return createTorrentTaskPayload{}, errors.New("读取 preserveDirectoryStructure 失败")
+			}
+			if trimmed := strings.TrimSpace(value); trimmed != "" {
+				parsed, parseErr := strconv.ParseBool(trimmed)
+				if parseErr != nil {
+					return createTorrentTaskPayload{}, errors.New("preserveDirectoryStructure 必须为布尔值")
+				}
+				preserveRaw = &parsed
+			}
 		case "torrentFile":
 			name := strings.TrimSpace(part.FileName())
 			data, readErr := readLimitedPartBytes(part, s.cfg.TorrentMaxMetadataBytes)
@@ -954,16 +1050,22 @@ func (s *Server) parseCreateTorrentTaskPayloadMultipart(r *http.Request) (create
 	if submittedBy == "" {
 		submittedBy = "admin"
 	}
+	priority, err := normalizeTorrentTaskPriority(priorityRaw)
+	if err != nil {
+		return createTorrentTaskPayload{}, err
+	}
 
 	if len(fileBytes) > 0 {
 		return createTorrentTaskPayload{
-			ParentID:            parentID,
-			TorrentName:         fileName,
-			TorrentBytes:        fileBytes,
-			SelectedFileIndexes: selectedFileIndexes,
-			SourceType:          store.TorrentSourceTypeFile,
-			SourceURL:           nil,
-			SubmittedBy:         submittedBy,
+			ParentID:                   parentID,
+			TorrentName:                fileName,
+			TorrentBytes:               fileBytes,
+			SelectedFileIndexes:        selectedFileIndexes,
+			SourceType:                 store.TorrentSourceTypeFile,
+			SourceURL:                  nil,
+			SubmittedBy:                submittedBy,
+			Priority:                   priority,
+			PreserveDirectoryStructure: preserveRaw,
 		}, nil
 	}
 	if torrentURL == "" {
@@ -975,13 +1077,15 @@ func (s *Server) parseCreateTorrentTaskPayloadMultipart(r *http.Request) (create
 	}
 	sourceURL := torrentURL
 	return createTorrentTaskPayload{
-		ParentID:            parentID,
-		TorrentURL:          torrentURL,
-		TorrentBytes:        torrentBytes,
-		SelectedFileIndexes: selectedFileIndexes,
-		SourceType:          store.TorrentSourceTypeURL,
-		SourceURL:           &sourceURL,
-		SubmittedBy:         submittedBy,
+		ParentID:                   parentID,
+		TorrentURL:                 torrentURL,
+		TorrentBytes:               torrentBytes,
+		SelectedFileIndexes:        selectedFileIndexes,
+		SourceType:                 store.TorrentSourceTypeURL,
+		SourceURL:                  &sourceURL,
+		SubmittedBy:                submittedBy,
+		Priority:                   priority,
+		PreserveDirectoryStructure: preserveRaw,
 	}, nil
 }
 
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func normalizeTorrentTaskPriority(raw *int) (int, error) {
+	if raw == nil {
+		return 0, nil
+	}
+	if *raw < store.TorrentTaskPriorityMin || *raw > store.TorrentTaskPriorityMax {
+		return 0, fmt.Errorf("priority 范围应为 %d~%d", store.TorrentTaskPriorityMin, store.TorrentTaskPriorityMax)
+	}
+	return *raw, nil
+}
+
+func (s *Server) handlePauseTorrentTask(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+
+	st := store.New(s.db)
+	fromStatus, err := st.PauseTorrentTask(r.Context(), taskID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "Torrent 任务不存在")
+		case errors.Is(err, store.ErrConflict):
+			writeError(w, http.StatusConflict, "conflict", "当前任务状态不允许暂停")
+		default:
+			s.logger.Error("pause torrent task failed", "error", err.Error(), "task_id", taskID.String())
+			writeError(w, http.StatusInternalServerError, "internal_error", "暂停 Torrent 任务失败")
+		}
+		return
+	}
+
+	s.respondTorrentTaskStateChange(w, r, st, taskID, fromStatus == store.TorrentTaskStatusDownloading, true)
+}
+
+func (s *Server) handleResumeTorrentTask(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+
+	st := store.New(s.db)
+	toStatus, err := st.ResumeTorrentTask(r.Context(), taskID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "Torrent 任务不存在")
+		case errors.Is(err, store.ErrConflict):
+			writeError(w, http.StatusConflict, "conflict", "仅暂停状态的任务允许恢复")
+		default:
+			s.logger.Error("resume torrent task failed", "error", err.Error(), "task_id", taskID.String())
+			writeError(w, http.StatusInternalServerError, "internal_error", "恢复 Torrent 任务失败")
+		}
+		return
+	}
+
+	s.respondTorrentTaskStateChange(w, r, st, taskID, toStatus == store.TorrentTaskStatusDownloading, false)
+}
+
+func (s *Server) handleSetTorrentTaskPriority(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+
+	var req struct {
+		Priority *int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	if req.Priority == nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "priority 不能为空")
+		return
+	}
+	priority, err := normalizeTorrentTaskPriority(req.Priority)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	if err := st.SetTorrentTaskPriority(r.Context(), taskID, priority, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "Torrent 任务不存在")
+			return
+		}
+		s.logger.Error("set torrent task priority failed", "error", err.Error(), "task_id", taskID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "更新任务优先级失败")
+		return
+	}
+
+	updated, err := st.GetTorrentTask(r.Context(), taskID)
+	if err != nil {
+		s.logger.Error("get updated torrent task failed", "error", err.Error(), "task_id", taskID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取任务失败")
+		return
+	}
+	s.recordTorrentAudit(r.Context(), auditActionTorrentPriority, updated, nil, map[string]any{"priority": priority})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"task": toTorrentTaskDTO(updated, nil),
+	})
+}
+
+func (s *Server) respondTorrentTaskStateChange(
+	w http.ResponseWriter,
+	r *http.Request,
+	st *store.Store,
+	taskID uuid.UUID,
+	syncQBTorrent bool,
+	paused bool,
+) {
+	updated, err := st.GetTorrentTask(r.Context(), taskID)
+	if err != nil {
+		s.logger.Error("get updated torrent task failed", "error", err.Error(), "task_id", taskID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取任务失败")
+		return
+	}
+
+	warnings := make([]string, 0, 1)
+	if syncQBTorrent {
+		if warning := s.syncTorrentTaskQBTorrentPaused(r.Context(), updated, paused); warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	s.refreshTorrentTransferJobByTaskID(r.Context(), taskID, store.TransferJobStatusRunning, "")
+	action := auditActionTorrentResume
+	if paused {
+		action = auditActionTorrentPause
+	}
+	s.recordTorrentAudit(r.Context(), action, updated, nil, map[string]any{"status": string(updated.Status)})
+
+	resp := map[string]any{
+		"task": toTorrentTaskDTO(updated, nil),
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// syncTorrentTaskQBTorrentPaused 同步 qBittorrent 中的下载状态，失败仅返回告警。
+func (s *Server) syncTorrentTaskQBTorrentPaused(ctx context.Context, task store.TorrentTask, paused bool) string {
+	if task.QBTorrentHash == nil || strings.TrimSpace(*task.QBTorrentHash) == "" {
+		return ""
+	}
+	hash := strings.TrimSpace(*task.QBTorrentHash)
+	qbt, err := s.newQBittorrentClient(ctx)
+	if err != nil {
+		return "创建 qBittorrent 客户端失败：" + err.Error()
+	}
+	if err := qbt.Authenticate(ctx); err != nil {
+		return "qBittorrent 认证失败：" + err.Error()
+	}
+	if paused {
+		err = qbt.PauseTorrent(ctx, hash)
+	} else {
+		err = qbt.ResumeTorrent(ctx, hash)
+	}
+	if err != nil {
+		s.logger.Warn("sync qBittorrent torrent state failed", "error", err.Error(), "task_id", task.ID.String(), "paused", paused)
+		return "同步 qBittorrent 状态失败：" + err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestNormalizeTorrentTaskPriority(t *testing.T) {
+	t.Parallel()
+
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name    string
+		raw     *int
+		want    int
+		wantErr bool
+	}{
+		{name: "未指定时为默认优先级", raw: nil, want: 0},
+		{name: "范围内原样返回", raw: intPtr(5), want: 5},
+		{name: "下界可用", raw: intPtr(store.TorrentTaskPriorityMin), want: store.TorrentTaskPriorityMin},
+		{name: "超过上界报错", raw: intPtr(store.TorrentTaskPriorityMax + 1), wantErr: true},
+		{name: "低于下界报错", raw: intPtr(store.TorrentTaskPriorityMin - 1), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := normalizeTorrentTaskPriority(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("normalizeTorrentTaskPriority() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Fatalf("normalizeTorrentTaskPriority() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPausedTorrentTaskTransferView(t *testing.T) {
+	t.Parallel()
+
+	if got := resolveTorrentTransferPhase(store.TorrentTaskStatusPaused); got != transferPhasePaused {
+		t.Fatalf("resolveTorrentTransferPhase(paused) = %q, want %q", got, transferPhasePaused)
+	}
+	got := resolveTorrentTransferJobStatus(store.TorrentTaskStatusPaused, 3, 1, 0, store.TransferJobStatusError)
+	if got != store.TransferJobStatusRunning {
+		t.Fatalf("resolveTorrentTransferJobStatus(paused) = %q, want %q", got, store.TransferJobStatusRunning)
+	}
+	if got := resolveUploadSessionStatusPhase(store.UploadSessionStatusPaused, 2, 4); got != transferPhasePaused {
+		t.Fatalf("resolveUploadSessionStatusPhase(paused) = %q, want %q", got, transferPhasePaused)
+	}
+}
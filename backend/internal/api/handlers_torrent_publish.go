@@ -0,0 +1,355 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+const (
+	torrentPublishMaxFiles       = 10000
+	torrentPublishMaxExpiryHours = 24 * 365
+	torrentPublishDefaultExpiry  = 7 * 24 * time.Hour
+)
+
+type torrentPublishFile struct {
+	Item         store.Item
+	Chunks       []store.Chunk
+	RelativePath []string
+	Size         int64
+}
+
+// handlePublishItemTorrent 为文件或文件夹创建 torrent 发布记录，分片哈希在后台任务中计算，返回 202。
+func (s *Server) handlePublishItemTorrent(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	var req struct {
+		Announce       []string `json:"announce"`
+		ExpiresInHours *int     `json:"expiresInHours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	announce, err := parseTorrentPublishAnnounce(req.Announce)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	now := time.Now()
+	expiresAt, err := parseTorrentPublishExpiry(req.ExpiresInHours, now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	root, err := st.GetItem(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if root.InVault {
+		writeError(w, http.StatusBadRequest, "bad_request", "密码箱项目不支持发布为 torrent")
+		return
+	}
+
+	files, err := s.collectTorrentPublishFiles(r.Context(), st, root)
+	if err != nil {
+		if errors.Is(err, store.ErrBadInput) {
+			writeError(w, http.StatusBadRequest, "bad_request", "没有可发布的文件")
+			return
+		}
+		s.logger.Error("collect torrent publish files failed", "error", err.Error(), "item_id", root.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取文件列表失败")
+		return
+	}
+	if len(files) > torrentPublishMaxFiles {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("文件数量超过上限 %d", torrentPublishMaxFiles))
+		return
+	}
+
+	job := buildTorrentPublishTransferJob(root, files, now)
+	if err := st.CreateTransferJob(r.Context(), job); err != nil {
+		s.logger.Error("create torrent publish transfer job failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建 torrent 任务失败")
+		return
+	}
+	jobID := job.ID
+	publication, err := st.CreateTorrentPublication(r.Context(), store.TorrentPublication{
+		ItemID:        root.ID,
+		TransferJobID: &jobID,
+		Announce:      announce,
+		ExpiresAt:     expiresAt,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		_ = st.DeleteTransferJobByID(r.Context(), job.ID)
+		s.logger.Error("create torrent publication failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建 torrent 任务失败")
+		return
+	}
+
+	baseURL := publicBaseURL(r, s.cfg.BaseURL, s.cfg.PublicURLHeader)
+	ctx, cancel := context.WithCancel(tracing.Detach(r.Context()))
+	s.registerArchiveJob(job.ID, cancel)
+	s.publishRunningTransferJob(r.Context(), job)
+	s.goBackground(func() { s.runTorrentPublishJob(ctx, job, publication, root, files, baseURL) })
+
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"transferId":  job.ID.String(),
+		"publication": toTorrentPublicationDTO(publication),
+	})
+}
+
+func (s *Server) handleListItemTorrentPublications(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	publications, err := store.New(s.db).ListTorrentPublicationsByItem(r.Context(), id)
+	if err != nil {
+		s.logger.Error("list torrent publications failed", "error", err.Error(), "item_id", id.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	out := make([]torrentPublicationDTO, 0, len(publications))
+	for _, publication := range publications {
+		out = append(out, toTorrentPublicationDTO(publication))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": out})
+}
+
+// handleDownloadTorrentPublication 返回生成完成的 .torrent；撤销或过期后不再提供。
+func (s *Server) handleDownloadTorrentPublication(w http.ResponseWriter, r *http.Request) {
+	publication, ok := s.loadTorrentPublication(w, r)
+	if !ok {
+		return
+	}
+	if !publication.Active(time.Now()) {
+		writeError(w, http.StatusGone, "gone", "发布已撤销或已过期")
+		return
+	}
+	if !publication.Ready() {
+		writeError(w, http.StatusConflict, "conflict", "torrent 仍在生成中")
+		return
+	}
+	root, err := store.New(s.db).GetItem(r.Context(), publication.ItemID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		s.logger.Error("get item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-bittorrent")
+	w.Header().Set("Content-Disposition", contentDisposition(root.Name+".torrent", false))
+	w.Header().Set("Content-Length", strconv.Itoa(len(publication.Torrent)))
+	if publication.InfoHash != nil {
+		w.Header().Set("X-Torrent-Info-Hash", *publication.InfoHash)
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(publication.Torrent)
+}
+
+// handleRevokeTorrentPublication 撤销发布，webseed 令牌随即失效；本副本上仍在生成的任务一并取消。
+func (s *Server) handleRevokeTorrentPublication(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	publication, err := store.New(s.db).RevokeTorrentPublication(r.Context(), id, time.Now())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "发布记录不存在")
+			return
+		}
+		s.logger.Error("revoke torrent publication failed", "error", err.Error(), "id", id.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "撤销失败")
+		return
+	}
+	if publication.TransferJobID != nil {
+		s.requestArchiveJobCancel(r.Context(), *publication.TransferJobID)
+	}
+	publication.Torrent = nil
+	writeJSON(w, http.StatusOK, toTorrentPublicationDTO(publication))
+}
+
+func (s *Server) loadTorrentPublication(w http.ResponseWriter, r *http.Request) (store.TorrentPublication, bool) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return store.TorrentPublication{}, false
+	}
+	publication, err := store.New(s.db).GetTorrentPublication(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "发布记录不存在")
+			return store.TorrentPublication{}, false
+		}
+		s.logger.Error("get torrent publication failed", "error", err.Error(), "id", id.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return store.TorrentPublication{}, false
+	}
+	return publication, true
+}
+
+type torrentPublicationDTO struct {
+	ID         string     `json:"id"`
+	ItemID     string     `json:"itemId"`
+	TransferID *string    `json:"transferId"`
+	Announce   []string   `json:"announce"`
+	InfoHash   *string    `json:"infoHash"`
+	Ready      bool       `json:"ready"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func toTorrentPublicationDTO(publication store.TorrentPublication) torrentPublicationDTO {
+	var transferID *string
+	if publication.TransferJobID != nil {
+		v := publication.TransferJobID.String()
+		transferID = &v
+	}
+	return torrentPublicationDTO{
+		ID:         publication.ID.String(),
+		ItemID:     publication.ItemID.String(),
+		TransferID: transferID,
+		Announce:   publication.Announce,
+		InfoHash:   publication.InfoHash,
+		Ready:      publication.InfoHash != nil,
+		ExpiresAt:  publication.ExpiresAt,
+		RevokedAt:  publication.RevokedAt,
+		CreatedAt:  publication.CreatedAt,
+	}
+}
+
+func (s *Server) collectTorrentPublishFiles(ctx context.Context, st *store.Store, root store.Item) ([]torrentPublishFile, error) {
+	items := []store.Item{root}
+	if root.Type == store.ItemTypeFolder {
+		subtree, err := st.ListSubtreeItems(ctx, root.Path)
+		if err != nil {
+			return nil, err
+		}
+		items = subtree
+	}
+
+	out := make([]torrentPublishFile, 0, len(items))
+	for _, it := range items {
+		if it.Type == store.ItemTypeFolder || it.InVault {
+			continue
+		}
+		relative := []string{it.Name}
+		if root.Type == store.ItemTypeFolder {
+			relative = splitTorrentPublishRelativePath(root.Path, it.Path)
+			if len(relative) == 0 {
+				continue
+			}
+		}
+		chunks, err := st.ListChunks(ctx, it.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, torrentPublishFile{
+			Item:         it,
+			Chunks:       chunks,
+			RelativePath: relative,
+			Size:         resolveItemContentSize(it, chunks),
+		})
+	}
+	if len(out) == 0 {
+		return nil, store.ErrBadInput
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return strings.Join(out[i].RelativePath, "/") < strings.Join(out[j].RelativePath, "/")
+	})
+	return out, nil
+}
+
+func splitTorrentPublishRelativePath(rootPath string, itemPath string) []string {
+	prefix := strings.TrimRight(rootPath, "/") + "/"
+	if !strings.HasPrefix(itemPath, prefix) {
+		return nil
+	}
+	parts := strings.Split(strings.TrimPrefix(itemPath, prefix), "/")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+func parseTorrentPublishAnnounce(raw []string) ([]string, error) {
+	out := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, value := range raw {
+		trimmed := strings.TrimSpace(value)
+		if trimmed == "" {
+			continue
+		}
+		parsed, err := url.Parse(trimmed)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("announce 地址非法: %s", trimmed)
+		}
+		switch strings.ToLower(parsed.Scheme) {
+		case "http", "https", "udp":
+		default:
+			return nil, fmt.Errorf("announce 仅支持 http/https/udp: %s", trimmed)
+		}
+		if _, ok := seen[trimmed]; ok {
+			continue
+		}
+		seen[trimmed] = struct{}{}
+		out = append(out, trimmed)
+	}
+	return out, nil
+}
+
+// parseTorrentPublishExpiry 未指定时使用默认有效期；webseed 令牌必须有有限的过期时间。
+func parseTorrentPublishExpiry(hours *int, now time.Time) (time.Time, error) {
+	if hours == nil {
+		return now.Add(torrentPublishDefaultExpiry), nil
+	}
+	if *hours < 1 || *hours > torrentPublishMaxExpiryHours {
+		return time.Time{}, fmt.Errorf("expiresInHours 范围应为 1~%d", torrentPublishMaxExpiryHours)
+	}
+	return now.Add(time.Duration(*hours) * time.Hour), nil
+}
+
+// buildWebSeedURL 按 BEP 19 生成 url-list：单文件指向文件本身，多文件以 / 结尾由客户端追加 name/path。
+func buildWebSeedURL(baseURL string, token string, name string, multiFile bool) string {
+	prefix := strings.TrimRight(baseURL, "/") + "/ws/" + url.PathEscape(token) + "/"
+	if multiFile {
+		return prefix
+	}
+	return prefix + url.PathEscape(name)
+}
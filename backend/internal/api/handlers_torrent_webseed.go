@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// handleWebSeed 提供 BEP 19 webseed 下载，令牌签名绑定发布记录，发布撤销或过期后立即失效。
+func (s *Server) handleWebSeed(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	publicationID, err := parseWebSeedToken(chi.URLParam(r, "token"), s.cfg.CookieSecret, now)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+		return
+	}
+
+	st := store.New(s.db)
+	publication, err := st.GetTorrentPublication(r.Context(), publicationID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+			return
+		}
+		s.logger.Error("get torrent publication failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if !publication.Active(now) {
+		writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		settings, err := s.getRuntimeSettings(r.Context())
+		if err != nil {
+			s.logger.Error("get runtime settings failed", "error", err.Error())
+			writeError(w, http.StatusInternalServerError, "internal_error", "读取运行配置失败")
+			return
+		}
+		if err := s.acquireDownloadSlot(r.Context(), settings.DownloadConcurrency); err != nil {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return
+			}
+			writeError(w, http.StatusServiceUnavailable, "service_unavailable", "下载队列繁忙，请稍后重试")
+			return
+		}
+		defer s.releaseDownload()
+	}
+
+	root, err := st.GetItem(r.Context(), publication.ItemID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+			return
+		}
+		s.logger.Error("get webseed root item failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if root.InVault {
+		writeError(w, http.StatusNotFound, "not_found", "链接无效或已失效")
+		return
+	}
+
+	it := root
+	if root.Type == store.ItemTypeFolder {
+		relative, ok := resolveWebSeedRelativePath(webSeedWildcardPath(r), root.Name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		items, err := st.ListItemsByExactPaths(r.Context(), []string{store.BuildChildPath(root.Path, relative)})
+		if err != nil {
+			s.logger.Error("list webseed items failed", "error", err.Error())
+			writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+			return
+		}
+		found := false
+		for _, candidate := range items {
+			if candidate.Type != store.ItemTypeFolder && !candidate.InVault {
+				it = candidate
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+	}
+
+	chunks, err := st.ListChunks(r.Context(), it.ID)
+	if err != nil {
+		s.logger.Error("list chunks failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	_ = s.serveChunkedDownload(w, r, it, chunks)
+}
+
+func webSeedWildcardPath(r *http.Request) string {
+	raw := chi.URLParam(r, "*")
+	if r.URL.RawPath == "" {
+		return raw
+	}
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return raw
+	}
+	return decoded
+}
+
+// resolveWebSeedRelativePath 去掉客户端拼接的 torrent 名称前缀，返回相对根目录的路径。
+func resolveWebSeedRelativePath(raw string, rootName string) (string, bool) {
+	parts := strings.Split(strings.Trim(raw, "/"), "/")
+	if len(parts) < 2 || parts[0] != rootName {
+		return "", false
+	}
+	for _, part := range parts[1:] {
+		if part == "" || part == "." || part == ".." {
+			return "", false
+		}
+	}
+	return strings.Join(parts[1:], "/"), true
+}
@@ -112,7 +112,10 @@ func parseTransferSourceKind(raw string) store.TransferSourceKind {
 	case store.TransferSourceKindUploadSession,
 		store.TransferSourceKindUploadBatch,
 		store.TransferSourceKindTorrentTask,
-		store.TransferSourceKindDownloadTask:
+		store.TransferSourceKindDownloadTask,
+		store.TransferSourceKindArchiveExtract,
+		store.TransferSourceKindArchiveCreate,
+		store.TransferSourceKindTorrentPublish:
 		return store.TransferSourceKind(strings.ToLower(strings.TrimSpace(raw)))
 	default:
 		return ""
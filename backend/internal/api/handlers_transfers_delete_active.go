@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -37,33 +38,39 @@ func (s *Server) handleDeleteActiveTransfer(w http.ResponseWriter, r *http.Reque
 		writeError(w, http.StatusBadRequest, "bad_request", "暂不支持删除非上传任务")
 		return
 	}
-	if job.SourceKind != store.TransferSourceKindUploadSession && job.SourceKind != store.TransferSourceKindUploadBatch {
+	if job.SourceKind != store.TransferSourceKindUploadSession &&
+		job.SourceKind != store.TransferSourceKindUploadBatch &&
+		job.SourceKind != store.TransferSourceKindArchiveExtract &&
+		job.SourceKind != store.TransferSourceKindArchiveCreate {
 		writeError(w, http.StatusBadRequest, "bad_request", "暂不支持删除该类型的上传任务")
 		return
 	}
 
-	cleanup, err := s.deleteRunningUploadTransfer(r.Context(), st, job)
-	if err != nil {
-		s.logger.Error("delete running transfer failed", "error", err.Error(), "id", transferID.String())
-		writeError(w, http.StatusInternalServerError, "internal_error", "删除传输任务失败")
-		return
-	}
-	if len(cleanup.failures) > 0 {
-		if upsertErr := st.UpsertTelegramDeleteFailures(r.Context(), cleanup.failures); upsertErr != nil {
-			s.logger.Error("record telegram delete failures failed", "error", upsertErr.Error(), "count", len(cleanup.failures))
+	if isArchiveTransferSourceKind(job.SourceKind) && !s.hasArchiveJob(job.ID) && time.Since(job.UpdatedAt) < archiveJobStaleAfter {
+		// 任务在其他副本执行且心跳正常：记录删除请求，由执行副本取消任务后清理，避免清理期间仍有内容写入。
+		if err := st.RequestTransferJobCancel(r.Context(), job.ID, true, time.Now()); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, http.StatusConflict, "conflict", "仅运行中的任务支持删除")
+				return
+			}
+			s.logger.Error("request transfer job cancel failed", "error", err.Error(), "id", transferID.String())
+			writeError(w, http.StatusInternalServerError, "internal_error", "删除传输任务失败")
+			return
 		}
+		writeJSON(w, http.StatusAccepted, map[string]any{"ok": true, "pending": true})
+		return
 	}
 
-	if err := st.DeleteTransferJobByID(r.Context(), transferID); err != nil {
+	cleanup, err := s.deleteTransferJobWithCleanup(r.Context(), st, job)
+	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			writeError(w, http.StatusNotFound, "not_found", "传输任务不存在")
 			return
 		}
-		s.logger.Error("delete transfer job failed", "error", err.Error(), "id", transferID.String())
+		s.logger.Error("delete running transfer failed", "error", err.Error(), "id", transferID.String())
 		writeError(w, http.StatusInternalServerError, "internal_error", "删除传输任务失败")
 		return
 	}
-	s.publishTransferDeletion(transferID)
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"ok": true,
@@ -77,6 +84,32 @@ func (s *Server) handleDeleteActiveTransfer(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// deleteTransferJobWithCleanup 清理任务产物，记录 Telegram 删除失败项，然后删除任务记录并通知订阅端。
+func (s *Server) deleteTransferJobWithCleanup(
+	ctx context.Context,
+	st *store.Store,
+	job store.TransferJob,
+) (telegramCleanupResult, error) {
+	cleanup, err := s.deleteRunningUploadTransfer(ctx, st, job)
+	if err != nil {
+		return telegramCleanupResult{}, err
+	}
+	if len(cleanup.failures) > 0 {
+		if upsertErr := st.UpsertTelegramDeleteFailures(ctx, cleanup.failures); upsertErr != nil {
+			s.logger.Error("record telegram delete failures failed", "error", upsertErr.Error(), "count", len(cleanup.failures))
+		}
+	}
+	if err := st.DeleteTransferJobByID(ctx, job.ID); err != nil {
+		return cleanup, err
+	}
+	s.publishTransferDeletion(job.ID)
+	return cleanup, nil
+}
+
+func isArchiveTransferSourceKind(kind store.TransferSourceKind) bool {
+	return kind == store.TransferSourceKindArchiveExtract || kind == store.TransferSourceKindArchiveCreate
+}
+
 func (s *Server) deleteRunningUploadTransfer(
 	ctx context.Context,
 	st *store.Store,
@@ -87,6 +120,8 @@ func (s *Server) deleteRunningUploadTransfer(
 		return s.deleteUploadSessionTransfer(ctx, st, job)
 	case store.TransferSourceKindUploadBatch:
 		return s.deleteUploadBatchTransfer(ctx, st, job)
+	case store.TransferSourceKindArchiveExtract, store.TransferSourceKindArchiveCreate:
+		return s.deleteArchiveTransfer(ctx, st, job)
 	default:
 		return telegramCleanupResult{}, errors.New("unsupported source kind: " + strings.TrimSpace(string(job.SourceKind)))
 	}
@@ -154,6 +189,18 @@ func (s *Server) deleteUploadBatchTransfer(
 	return s.purgeUploadBatchItems(ctx, st, sessions)
 }
 
+func (s *Server) deleteArchiveTransfer(
+	ctx context.Context,
+	st *store.Store,
+	job store.TransferJob,
+) (telegramCleanupResult, error) {
+	s.cancelArchiveJob(ctx, job.ID)
+	if job.TargetItemID == nil {
+		return telegramCleanupResult{}, nil
+	}
+	return s.purgeItemTree(ctx, st, *job.TargetItemID)
+}
+
 func resolveUploadSessionTargetItemID(job store.TransferJob, session store.UploadSession) uuid.UUID {
 	if session.ID != uuid.Nil && session.ItemID != uuid.Nil {
 		return session.ItemID
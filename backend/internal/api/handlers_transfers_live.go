@@ -97,6 +97,9 @@ func (s *Server) handleTransferStream(w http.ResponseWriter, r *http.Request) {
 		select {
 		case <-r.Context().Done():
 			return
+		case <-s.shutdownCh:
+			// 服务关闭时主动结束推送，客户端会自动重连。
+			return
 		case <-keepAlive.C:
 			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
 				return
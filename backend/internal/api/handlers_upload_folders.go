@@ -115,6 +115,17 @@ func (s *Server) handleCreateUploadFolder(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if root, err := store.New(s.db).GetItem(r.Context(), result.RootItemID); err == nil {
+		s.recordItemAudit(r.Context(), auditActionItemCreate, root, map[string]any{
+			"type":        string(root.Type),
+			"source":      "upload_folder",
+			"directories": len(manifest.Directories),
+			"files":       len(manifest.Files),
+			"totalSize":   manifest.TotalSize,
+		})
+		s.emitItemWebhookEvent(webhookEventItemCreated, root, "upload_folder")
+	}
+
 	jobDTO, buildErr := s.buildTransferJobViewDTO(r.Context(), result.Job)
 	if buildErr != nil {
 		jobDTO = toTransferJobViewDTO(result.Job)
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func (s *Server) handlePauseUploadSession(w http.ResponseWriter, r *http.Request) {
+	s.transitionUploadSession(
+		w,
+		r,
+		store.UploadSessionStatusUploading,
+		store.UploadSessionStatusPaused,
+		"仅上传中的会话允许暂停",
+	)
+}
+
+func (s *Server) handleResumeUploadSession(w http.ResponseWriter, r *http.Request) {
+	s.transitionUploadSession(
+		w,
+		r,
+		store.UploadSessionStatusPaused,
+		store.UploadSessionStatusUploading,
+		"仅暂停的会话允许恢复",
+	)
+}
+
+func (s *Server) transitionUploadSession(
+	w http.ResponseWriter,
+	r *http.Request,
+	from store.UploadSessionStatus,
+	to store.UploadSessionStatus,
+	conflictMessage string,
+) {
+	sessionID, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+
+	st := store.New(s.db)
+	if err := st.TransitionUploadSessionStatus(r.Context(), sessionID, from, to, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "上传会话不存在")
+		case errors.Is(err, store.ErrConflict):
+			writeError(w, http.StatusConflict, "conflict", conflictMessage)
+		default:
+			s.logger.Error("transition upload session status failed", "error", err.Error(), "session_id", sessionID.String())
+			writeError(w, http.StatusInternalServerError, "internal_error", "更新上传会话状态失败")
+		}
+		return
+	}
+
+	session, err := st.GetUploadSession(r.Context(), sessionID)
+	if err != nil {
+		s.logger.Error("get upload session failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询上传会话失败")
+		return
+	}
+	uploaded, err := s.listUploadedChunkIndicesBySession(r.Context(), session)
+	if err != nil {
+		s.logger.Error("list uploaded chunks failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询上传进度失败")
+		return
+	}
+	s.syncUploadSessionProgressEvent(r.Context(), session)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session": toUploadSessionDTO(session, uploaded),
+	})
+}
@@ -321,6 +321,10 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		writeError(w, http.StatusConflict, "conflict", "上传会话已完成")
 		return
 	}
+	if session.Status == store.UploadSessionStatusPaused {
+		writeError(w, http.StatusConflict, "conflict", "上传会话已暂停")
+		return
+	}
 	recordChunkFailure := func(errMsg string, process *videoUploadProcessMeta) {
 		s.markUploadSessionFailed(context.Background(), session, errMsg)
 		s.recordUploadSessionTransferHistory(
@@ -337,17 +341,36 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		writeError(w, http.StatusBadRequest, "bad_request", "chunk index 超出范围")
 		return
 	}
-	if err := s.acquireUploadSessionChunkLock(r.Context(), session.ID, chunkIndex); err != nil {
+	chunkLock, err := s.acquireUploadSessionChunkLock(r.Context(), session.ID, chunkIndex)
+	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			return
 		}
 		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "分片处理中，请稍后重试")
 		return
 	}
-	defer s.releaseUploadSessionChunkLock(session.ID, chunkIndex)
+	defer s.releaseUploadSessionChunkLock(chunkLock)
+	// 后续读写都绑定锁的 ctx：租约丢失时正在进行的上传随之中止，避免与接管者重复写入同一分片。
+	r = r.WithContext(chunkLock.Context())
+	abortOnLostChunkLock := func(messageID int64) bool {
+		if !chunkLock.Lost() {
+			return false
+		}
+		s.logger.Warn("upload chunk lock lost, aborting chunk", "session_id", session.ID.String(), "chunk_index", chunkIndex)
+		if messageID > 0 {
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			_ = s.deleteMessageWithRetry(cleanupCtx, s.cfg.TGStorageChatID, messageID)
+			cancel()
+		}
+		writeError(w, http.StatusConflict, "conflict", "分片锁已失效，请重试")
+		return true
+	}
 
 	exists, err := s.hasUploadedChunkBySession(r.Context(), session, chunkIndex)
 	if err != nil {
+		if abortOnLostChunkLock(0) {
+			return
+		}
 		s.logger.Error("check chunk exists failed", "error", err.Error())
 		recordChunkFailure("检查分片状态失败", nil)
 		writeError(w, http.StatusInternalServerError, "internal_error", "检查分片状态失败")
@@ -398,6 +421,9 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := ensureDiskSpaceAvailableAt(spaceCheckPath, settings.ReservedDiskBytes, int64(expectedChunkSize)); err != nil {
+		if errors.Is(err, errInsufficientTempSpace) {
+			s.notifyLowDisk(spaceCheckPath, settings.ReservedDiskBytes)
+		}
 		writeError(w, http.StatusInsufficientStorage, "insufficient_storage", "服务器可用磁盘不足，请稍后重试或调低预留空间")
 		return
 	}
@@ -420,6 +446,9 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("分片大小不匹配，期望 %d 字节", expectedChunkSize))
 		return
 	}
+	if abortOnLostChunkLock(0) {
+		return
+	}
 
 	if useLocalChunkStaging {
 		if err := s.saveLocalSessionChunk(session, chunkIndex, tmpFile.path); err != nil {
@@ -443,7 +472,7 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 			return
 		}
 
-		_ = st.SetUploadSessionStatus(r.Context(), session.ID, store.UploadSessionStatusUploading, time.Now())
+		_ = st.TouchUploadSessionUploading(r.Context(), session.ID, time.Now())
 		uploadedCount, err := s.countUploadedChunksBySession(r.Context(), session)
 		if err != nil {
 			s.logger.Error("count uploaded chunks failed", "error", err.Error())
@@ -480,6 +509,9 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		msg, sendErr = s.sendDocumentFromPathWithRetry(r.Context(), s.cfg.TGStorageChatID, chunkFileName, tmpFile.path, caption)
 	}
 	if sendErr != nil {
+		if abortOnLostChunkLock(msg.MessageID) {
+			return
+		}
 		s.logger.Error("sendDocument failed", "error", sendErr.Error())
 		recordChunkFailure("上传到 Telegram 失败", uploadProcess)
 		writeError(w, http.StatusBadGateway, "bad_gateway", "上传到 Telegram 失败")
@@ -487,6 +519,9 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 	}
 	resolvedDoc, docErr := s.resolveMessageDocument(r.Context(), msg)
 	if docErr != nil {
+		if abortOnLostChunkLock(msg.MessageID) {
+			return
+		}
 		s.logger.Error(
 			"sendDocument missing file_id",
 			"session_id", session.ID.String(),
@@ -502,6 +537,9 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if abortOnLostChunkLock(msg.MessageID) {
+		return
+	}
 	chunk := store.Chunk{
 		ID:             uuid.New(),
 		ItemID:         session.ItemID,
@@ -525,7 +563,7 @@ func (s *Server) handleUploadSessionChunk(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	_ = st.SetUploadSessionStatus(r.Context(), session.ID, store.UploadSessionStatusUploading, time.Now())
+	_ = st.TouchUploadSessionUploading(r.Context(), session.ID, time.Now())
 	uploadedCount, err := s.countUploadedChunksBySession(r.Context(), session)
 	if err != nil {
 		s.logger.Error("count uploaded chunks failed", "error", err.Error())
@@ -573,6 +611,10 @@ func (s *Server) handleCompleteUploadSession(w http.ResponseWriter, r *http.Requ
 		})
 		return
 	}
+	if session.Status == store.UploadSessionStatusPaused {
+		writeError(w, http.StatusConflict, "conflict", "上传会话已暂停，请先恢复")
+		return
+	}
 	defer s.clearUploadTransferRuntimeBySession(context.Background(), session)
 
 	opCtx, opCancel := newUploadSessionOperationContext(r.Context())
@@ -854,6 +896,13 @@ func (s *Server) handleCompleteUploadSession(w http.ResponseWriter, r *http.Requ
 		uploadProcess,
 		time.Now(),
 	)
+	if uploadProcess != nil && uploadProcess.MediaMetadata != nil {
+		s.recordProbedItemMetadata(opCtx, item, *uploadProcess.MediaMetadata)
+	} else {
+		s.enqueueItemMetadata(item)
+	}
+	s.recordItemAudit(opCtx, auditActionItemCreate, item, map[string]any{"type": string(item.Type), "size": item.Size, "source": "upload"})
+	s.emitItemWebhookEvent(webhookEventItemCreated, item, "upload")
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"item":          toItemDTO(item),
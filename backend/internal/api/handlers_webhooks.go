@@ -0,0 +1,391 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+type webhookSubscriptionDTO struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type webhookDeliveryDTO struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	EventID        string          `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt"`
+	LastStatusCode *int            `json:"lastStatusCode"`
+	LastError      *string         `json:"lastError"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt"`
+}
+
+type webhookSubscriptionRequest struct {
+	Name         *string  `json:"name"`
+	URL          *string  `json:"url"`
+	Events       []string `json:"events"`
+	Enabled      *bool    `json:"enabled"`
+	Secret       *string  `json:"secret"`
+	RotateSecret bool     `json:"rotateSecret"`
+}
+
+func toWebhookSubscriptionDTO(sub store.WebhookSubscription) webhookSubscriptionDTO {
+	return webhookSubscriptionDTO{
+		ID:        sub.ID.String(),
+		Name:      sub.Name,
+		URL:       sub.URL,
+		Events:    sub.Events,
+		Enabled:   sub.Enabled,
+		CreatedAt: sub.CreatedAt,
+		UpdatedAt: sub.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryDTO(d store.WebhookDelivery) webhookDeliveryDTO {
+	return webhookDeliveryDTO{
+		ID:             d.ID.String(),
+		SubscriptionID: d.SubscriptionID.String(),
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
+
+func validateWebhookURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("url 需为 http(s) 地址")
+	}
+	return raw, nil
+}
+
+// normalizeWebhookEvents 去重并按 webhookEventTypes 的顺序排列事件，未知事件返回错误。
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	selected := map[string]bool{}
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if !isWebhookEventType(event) {
+			return nil, errors.New("不支持的事件类型：" + event)
+		}
+		selected[event] = true
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("至少需要订阅一个事件")
+	}
+	out := make([]string, 0, len(selected))
+	for _, event := range webhookEventTypes {
+		if selected[event] {
+			out = append(out, event)
+		}
+	}
+	return out, nil
+}
+
+func resolveWebhookSecret(provided *string) (string, error) {
+	if provided == nil || strings.TrimSpace(*provided) == "" {
+		return generateWebhookSecret()
+	}
+	secret := strings.TrimSpace(*provided)
+	if len(secret) < webhookSecretMinLength {
+		return "", errors.New("secret 长度至少为 16 个字符")
+	}
+	return secret, nil
+}
+
+func (s *Server) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := store.New(s.db).ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		s.logger.Error("list webhook subscriptions failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	out := make([]webhookSubscriptionDTO, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, toWebhookSubscriptionDTO(sub))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": out, "eventTypes": webhookEventTypes})
+}
+
+// handleCreateWebhook 创建订阅；未提供 secret 时自动生成，secret 只在创建与轮换时返回。
+func (s *Server) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	if req.URL == nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "url 不能为空")
+		return
+	}
+	target, err := validateWebhookURL(*req.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	secret, err := resolveWebhookSecret(req.Secret)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	sub := store.WebhookSubscription{
+		URL:     target,
+		Secret:  secret,
+		Events:  events,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if req.Name != nil {
+		sub.Name = *req.Name
+	}
+
+	created, err := store.New(s.db).CreateWebhookSubscription(r.Context(), sub)
+	if err != nil {
+		s.logger.Error("create webhook subscription failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "创建失败")
+		return
+	}
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionWebhookCreate, TargetID: created.ID.String(), Detail: auditDetailFromRequest(req)})
+	writeJSON(w, http.StatusOK, map[string]any{"item": toWebhookSubscriptionDTO(created), "secret": created.Secret})
+}
+
+// handlePatchWebhook 修改订阅；rotateSecret 为 true 时生成新密钥并在响应中返回。
+func (s *Server) handlePatchWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	var req webhookSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+
+	patch := store.WebhookSubscriptionPatch{Enabled: req.Enabled}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		patch.Name = &name
+	}
+	if req.URL != nil {
+		target, err := validateWebhookURL(*req.URL)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		patch.URL = &target
+	}
+	if req.Events != nil {
+		events, err := normalizeWebhookEvents(req.Events)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		patch.Events = events
+	}
+	if req.RotateSecret || req.Secret != nil {
+		secret, err := resolveWebhookSecret(req.Secret)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		patch.Secret = &secret
+	}
+
+	updated, err := store.New(s.db).UpdateWebhookSubscription(r.Context(), id, patch, time.Now())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "订阅不存在")
+			return
+		}
+		s.logger.Error("update webhook subscription failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "保存失败")
+		return
+	}
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionWebhookUpdate, TargetID: updated.ID.String(), Detail: auditDetailFromRequest(req)})
+	resp := map[string]any{"item": toWebhookSubscriptionDTO(updated)}
+	if patch.Secret != nil {
+		resp["secret"] = updated.Secret
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	if err := store.New(s.db).DeleteWebhookSubscription(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "订阅不存在")
+			return
+		}
+		s.logger.Error("delete webhook subscription failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "删除失败")
+		return
+	}
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionWebhookDelete, TargetID: id.String()})
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+// handleTestWebhook 向指定订阅投递一条 ping 事件（不受事件过滤与启用状态限制），结果可在投递记录中查看。
+func (s *Server) handleTestWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	st := store.New(s.db)
+	sub, err := st.GetWebhookSubscription(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "订阅不存在")
+			return
+		}
+		s.logger.Error("get webhook subscription failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+
+	deliveries, err := buildWebhookDeliveries(webhookPayload{
+		ID:        uuid.NewString(),
+		Type:      webhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]any{"subscriptionId": sub.ID.String()},
+	}, []store.WebhookSubscription{sub})
+	if err == nil {
+		// 先占住租约，避免投递循环在本次同步发送前领取同一条记录
+		leaseUntil := time.Now().Add(webhookDeliveryLease)
+		deliveries[0].NextAttemptAt = &leaseUntil
+		err = st.InsertWebhookDeliveries(r.Context(), deliveries)
+	}
+	if err != nil {
+		s.logger.Error("enqueue webhook ping failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "发送失败")
+		return
+	}
+
+	sub.Enabled = true
+	delivered, err := s.attemptWebhookDelivery(r.Context(), st, sub, deliveries[0])
+	if err != nil {
+		s.logger.Error("record webhook ping failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "发送失败")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"delivery": toWebhookDeliveryDTO(delivered)})
+}
+
+// handleListWebhookDeliveries 按时间倒序分页查询投递记录，支持 subscriptionId、status、event 过滤。
+func (s *Server) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params := store.WebhookDeliveryListParams{
+		EventType: q.Get("event"),
+		Page:      intFromQuery(q.Get("page"), 1),
+		PageSize:  intFromQuery(q.Get("pageSize"), 50),
+	}
+	if raw := strings.TrimSpace(q.Get("subscriptionId")); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "subscriptionId 非法")
+			return
+		}
+		params.SubscriptionID = &id
+	}
+	switch status := store.WebhookDeliveryStatus(strings.TrimSpace(q.Get("status"))); status {
+	case "":
+	case store.WebhookDeliveryStatusPending, store.WebhookDeliveryStatusSucceeded, store.WebhookDeliveryStatusFailed:
+		params.Status = &status
+	default:
+		writeError(w, http.StatusBadRequest, "bad_request", "status 仅支持 pending/succeeded/failed")
+		return
+	}
+
+	deliveries, total, err := store.New(s.db).ListWebhookDeliveries(r.Context(), params)
+	if err != nil {
+		s.logger.Error("list webhook deliveries failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	out := make([]webhookDeliveryDTO, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		out = append(out, toWebhookDeliveryDTO(delivery))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":      out,
+		"pagination": newPaginationResponse(params.Page, params.PageSize, total),
+	})
+}
+
+// handleRedeliverWebhook 以原事件内容新建一条投递记录并立即排队，原记录保持不变。
+func (s *Server) handleRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return
+	}
+	st := store.New(s.db)
+	original, err := st.GetWebhookDelivery(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "投递记录不存在")
+			return
+		}
+		s.logger.Error("get webhook delivery failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	redelivery := store.WebhookDelivery{
+		ID:             uuid.New(),
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         store.WebhookDeliveryStatusPending,
+		CreatedAt:      time.Now(),
+	}
+	if err := st.InsertWebhookDeliveries(r.Context(), []store.WebhookDelivery{redelivery}); err != nil {
+		s.logger.Error("enqueue webhook redelivery failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "重新投递失败")
+		return
+	}
+	s.wakeWebhookDelivery()
+	created, err := st.GetWebhookDelivery(r.Context(), redelivery.ID)
+	if err != nil {
+		s.logger.Error("get webhook delivery failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"delivery": toWebhookDeliveryDTO(created)})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	hlsCacheCleanupInterval = 30 * time.Minute
+	// 分段长时间未被访问即视为过期，避免冷门视频长期占用磁盘
+	hlsCacheIdleTTL        = 7 * 24 * time.Hour
+	hlsCacheDirGracePeriod = 10 * time.Minute
+)
+
+func (s *Server) startHLSCacheCleanupLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopHLSCacheCleanup)
+			settings, err := s.getRuntimeSettings(context.Background())
+			if err != nil {
+				s.logger.Warn("load runtime settings for hls cleanup failed", "error", err.Error())
+				settings = s.defaultRuntimeSettings()
+			}
+
+			s.cleanupHLSCache(settings.HLSCacheMaxBytes)
+			if !s.waitLoopInterval(hlsCacheCleanupInterval) {
+				return
+			}
+		}
+	})
+}
+
+// cleanupHLSCache 按最近访问时间淘汰分段与字幕，直到总大小不超过 maxBytes；同一时间只运行一次。
+func (s *Server) cleanupHLSCache(maxBytes int64) {
+	if !s.hlsCleanupRunning.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.hlsCleanupRunning.Store(false)
+
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	dir := s.hlsCacheDir()
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+
+	now := time.Now()
+	files := make([]thumbnailFileInfo, 0, 256)
+	var totalSize int64
+	walkErr := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		// 字幕轨道与分段一样按访问时间淘汰
+		if !strings.HasSuffix(entry.Name(), ".ts") && !strings.HasSuffix(entry.Name(), subtitleTrackSuffix) {
+			return nil
+		}
+		info, statErr := entry.Info()
+		if statErr != nil {
+			return nil
+		}
+		if now.Sub(info.ModTime()) > hlsCacheIdleTTL {
+			_ = os.Remove(path)
+			return nil
+		}
+		totalSize += info.Size()
+		files = append(files, thumbnailFileInfo{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if walkErr != nil {
+		s.logger.Warn("walk hls cache dir failed", "error", walkErr.Error(), "dir", dir)
+	}
+
+	if totalSize > maxBytes {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime.Before(files[j].modTime)
+		})
+		for _, file := range files {
+			if totalSize <= maxBytes {
+				break
+			}
+			if err := os.Remove(file.path); err != nil {
+				continue
+			}
+			totalSize -= file.size
+		}
+	}
+
+	s.removeEmptyHLSCacheDirs(dir, now)
+}
+
+// removeEmptyHLSCacheDirs 删除已无分段的清晰度目录，以及只剩探测结果的视频目录；
+// 最近修改过的目录可能正在转码，跳过。
+func (s *Server) removeEmptyHLSCacheDirs(dir string, now time.Time) {
+	itemDirs, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, itemDir := range itemDirs {
+		if !itemDir.IsDir() {
+			continue
+		}
+		itemPath := filepath.Join(dir, itemDir.Name())
+		entries, err := os.ReadDir(itemPath)
+		if err != nil {
+			continue
+		}
+		keep := false
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || now.Sub(info.ModTime()) < hlsCacheDirGracePeriod {
+				keep = true
+				continue
+			}
+			if !entry.IsDir() {
+				continue
+			}
+			// 目录非空时删除失败，说明仍有分段
+			if err := os.Remove(filepath.Join(itemPath, entry.Name())); err != nil {
+				keep = true
+			}
+		}
+		if !keep {
+			_ = os.RemoveAll(itemPath)
+		}
+	}
+}
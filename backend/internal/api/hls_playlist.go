@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	hlsSegmentSeconds = 6
+	hlsProbeTimeout   = 30 * time.Second
+	hlsProbeFileName  = "probe.json"
+)
+
+type hlsRendition struct {
+	Name          string
+	ShortSide     int
+	VideoBitrateK int
+	AudioBitrateK int
+}
+
+// hlsRenditions 按清晰度从高到低排列；只提供不高于原片短边的档位，避免放大。
+var hlsRenditions = []hlsRendition{
+	{Name: "1080p", ShortSide: 1080, VideoBitrateK: 5000, AudioBitrateK: 160},
+	{Name: "720p", ShortSide: 720, VideoBitrateK: 2800, AudioBitrateK: 128},
+	{Name: "480p", ShortSide: 480, VideoBitrateK: 1200, AudioBitrateK: 128},
+	{Name: "360p", ShortSide: 360, VideoBitrateK: 700, AudioBitrateK: 96},
+}
+
+type hlsProbe struct {
+	DurationSeconds float64 `json:"durationSeconds"`
+	Width           int     `json:"width"`
+	Height          int     `json:"height"`
+	HasAudio        bool    `json:"hasAudio"`
+}
+
+func (p hlsProbe) shortSide() int {
+	return minInt(p.Width, p.Height)
+}
+
+func (p hlsProbe) segmentCount() int {
+	if p.DurationSeconds <= 0 {
+		return 0
+	}
+	return int(math.Ceil(p.DurationSeconds / hlsSegmentSeconds))
+}
+
+// segmentDuration 返回第 index 段的时长，最后一段为剩余部分。
+func (p hlsProbe) segmentDuration(index int) float64 {
+	start := float64(index * hlsSegmentSeconds)
+	remaining := p.DurationSeconds - start
+	if remaining > hlsSegmentSeconds {
+		return hlsSegmentSeconds
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func availableHLSRenditions(probe hlsProbe) []hlsRendition {
+	shortSide := probe.shortSide()
+	out := make([]hlsRendition, 0, len(hlsRenditions))
+	for _, rendition := range hlsRenditions {
+		if rendition.ShortSide <= shortSide {
+			out = append(out, rendition)
+		}
+	}
+	if len(out) == 0 {
+		// 原片低于最低档时仍提供最低档，转码时保持原尺寸
+		out = append(out, hlsRenditions[len(hlsRenditions)-1])
+	}
+	return out
+}
+
+func findHLSRendition(probe hlsProbe, name string) (hlsRendition, bool) {
+	for _, rendition := range availableHLSRenditions(probe) {
+		if rendition.Name == name {
+			return rendition, true
+		}
+	}
+	return hlsRendition{}, false
+}
+
+// scaledSize 计算档位输出尺寸（宽高取偶数），用于主播放列表的 RESOLUTION。
+func (r hlsRendition) scaledSize(probe hlsProbe) (int, int) {
+	shortSide := probe.shortSide()
+	if shortSide <= 0 {
+		return 0, 0
+	}
+	target := minInt(r.ShortSide, shortSide)
+	scale := float64(target) / float64(shortSide)
+	return evenSide(float64(probe.Width) * scale), evenSide(float64(probe.Height) * scale)
+}
+
+func evenSide(value float64) int {
+	side := int(math.Round(value/2)) * 2
+	if side < 2 {
+		return 2
+	}
+	return side
+}
+
+// scaleFilter 按短边缩放，竖屏视频同样以短边对齐档位。
+func (r hlsRendition) scaleFilter(probe hlsProbe) string {
+	if probe.Width >= probe.Height {
+		return fmt.Sprintf("scale=-2:min(%d\\,ih)", r.ShortSide)
+	}
+	return fmt.Sprintf("scale=min(%d\\,iw):-2", r.ShortSide)
+}
+
+func buildHLSMasterPlaylist(probe hlsProbe) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, rendition := range availableHLSRenditions(probe) {
+		bandwidth := rendition.VideoBitrateK * 1000
+		if probe.HasAudio {
+			bandwidth += rendition.AudioBitrateK * 1000
+		}
+		width, height := rendition.scaledSize(probe)
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,NAME=\"%s\"\n", bandwidth, width, height, rendition.Name)
+		fmt.Fprintf(&b, "%s/index.m3u8\n", rendition.Name)
+	}
+	return b.String()
+}
+
+func buildHLSMediaPlaylist(probe hlsProbe) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", hlsSegmentSeconds)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n")
+	for i := 0; i < probe.segmentCount(); i++ {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\n%d.ts\n", probe.segmentDuration(i), i)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+// parseHLSSegmentName 解析形如 "12.ts" 的分段名。
+func parseHLSSegmentName(name string, probe hlsProbe) (int, bool) {
+	raw, ok := strings.CutSuffix(name, ".ts")
+	if !ok || raw == "" {
+		return 0, false
+	}
+	index, err := strconv.Atoi(raw)
+	if err != nil || index < 0 || index >= probe.segmentCount() || strconv.Itoa(index) != raw {
+		return 0, false
+	}
+	return index, true
+}
+
+// probeHLSSource 通过 ffprobe 读取时长与显示尺寸（已按旋转角度交换宽高）。
+func (s *Server) probeHLSSource(ctx context.Context, sourceURL string) (hlsProbe, error) {
+	ffprobeBinary, err := s.resolveFFprobeBinary()
+	if err != nil {
+		return hlsProbe{}, err
+	}
+
+	type ffprobeOutput struct {
+		Streams []struct {
+			CodecType    string            `json:"codec_type"`
+			Width        int               `json:"width"`
+			Height       int               `json:"height"`
+			Tags         map[string]string `json:"tags"`
+			SideDataList []map[string]any  `json:"side_data_list"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, hlsProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(
+		probeCtx,
+		ffprobeBinary,
+		"-v", "error",
+		"-show_entries", "stream=codec_type,width,height,tags,side_data_list:format=duration",
+		"-of", "json",
+		sourceURL,
+	)
+	raw, runErr := cmd.Output()
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && len(exitErr.Stderr) > 0 {
+			return hlsProbe{}, fmt.Errorf("ffprobe 失败: %w: %s", runErr, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return hlsProbe{}, runErr
+	}
+
+	var out ffprobeOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return hlsProbe{}, fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	}
+
+	probe := hlsProbe{}
+	for _, stream := range out.Streams {
+		switch stream.CodecType {
+		case "video":
+			if probe.Width > 0 || stream.Width <= 0 || stream.Height <= 0 {
+				continue
+			}
+			probe.Width, probe.Height = stream.Width, stream.Height
+			if shouldSwapVideoDimensionsByRotation(parseVideoRotation(stream.Tags, stream.SideDataList)) {
+				probe.Width, probe.Height = probe.Height, probe.Width
+			}
+		case "audio":
+			probe.HasAudio = true
+		}
+	}
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(out.Format.Duration), 64); err == nil && seconds > 0 {
+		probe.DurationSeconds = seconds
+	}
+	if probe.Width <= 0 || probe.DurationSeconds <= 0 {
+		return hlsProbe{}, errors.New("ffprobe 未返回有效的视频时长或尺寸")
+	}
+	return probe, nil
+}
+
+func readHLSProbeFile(path string) (hlsProbe, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return hlsProbe{}, false
+	}
+	var probe hlsProbe
+	if err := json.Unmarshal(raw, &probe); err != nil || probe.Width <= 0 || probe.DurationSeconds <= 0 {
+		return hlsProbe{}, false
+	}
+	return probe, true
+}
+
+func writeHLSProbeFile(path string, probe hlsProbe) error {
+	raw, err := json.Marshal(probe)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
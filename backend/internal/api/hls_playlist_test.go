@@ -0,0 +1,116 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAvailableHLSRenditions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		probe hlsProbe
+		want  []string
+	}{
+		{name: "4K 横屏", probe: hlsProbe{Width: 3840, Height: 2160}, want: []string{"1080p", "720p", "480p", "360p"}},
+		{name: "720p", probe: hlsProbe{Width: 1280, Height: 720}, want: []string{"720p", "480p", "360p"}},
+		{name: "竖屏按短边", probe: hlsProbe{Width: 1080, Height: 1920}, want: []string{"1080p", "720p", "480p", "360p"}},
+		{name: "低于最低档", probe: hlsProbe{Width: 320, Height: 240}, want: []string{"360p"}},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := availableHLSRenditions(tc.probe)
+			names := make([]string, 0, len(got))
+			for _, rendition := range got {
+				names = append(names, rendition.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("availableHLSRenditions() = %v, want %v", names, tc.want)
+			}
+		})
+	}
+}
+
+func TestHLSRenditionScaledSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		rendition    hlsRendition
+		probe        hlsProbe
+		wantW, wantH int
+	}{
+		{name: "横屏 720p", rendition: hlsRenditions[1], probe: hlsProbe{Width: 1920, Height: 1080}, wantW: 1280, wantH: 720},
+		{name: "竖屏 480p", rendition: hlsRenditions[2], probe: hlsProbe{Width: 1080, Height: 1920}, wantW: 480, wantH: 854},
+		{name: "不放大", rendition: hlsRenditions[3], probe: hlsProbe{Width: 320, Height: 240}, wantW: 320, wantH: 240},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			w, h := tc.rendition.scaledSize(tc.probe)
+			if w != tc.wantW || h != tc.wantH {
+				t.Fatalf("scaledSize() = %dx%d, want %dx%d", w, h, tc.wantW, tc.wantH)
+			}
+		})
+	}
+
+	if got := hlsRenditions[1].scaleFilter(hlsProbe{Width: 1080, Height: 1920}); got != "scale=min(720\\,iw):-2" {
+		t.Fatalf("scaleFilter(portrait) = %q", got)
+	}
+}
+
+func TestBuildHLSMediaPlaylist(t *testing.T) {
+	t.Parallel()
+
+	probe := hlsProbe{DurationSeconds: 14.5, Width: 1280, Height: 720}
+	got := buildHLSMediaPlaylist(probe)
+	want := "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:6\n#EXT-X-MEDIA-SEQUENCE:0\n#EXT-X-PLAYLIST-TYPE:VOD\n" +
+		"#EXTINF:6.000,\n0.ts\n#EXTINF:6.000,\n1.ts\n#EXTINF:2.500,\n2.ts\n#EXT-X-ENDLIST\n"
+	if got != want {
+		t.Fatalf("buildHLSMediaPlaylist() =\n%s\nwant\n%s", got, want)
+	}
+
+	master := buildHLSMasterPlaylist(hlsProbe{DurationSeconds: 10, Width: 854, Height: 480, HasAudio: true})
+	if !strings.Contains(master, "BANDWIDTH=1328000,RESOLUTION=854x480,NAME=\"480p\"\n480p/index.m3u8\n") {
+		t.Fatalf("buildHLSMasterPlaylist() missing 480p entry:\n%s", master)
+	}
+	if strings.Contains(master, "720p") {
+		t.Fatalf("buildHLSMasterPlaylist() should not upscale:\n%s", master)
+	}
+}
+
+func TestParseHLSSegmentName(t *testing.T) {
+	t.Parallel()
+
+	probe := hlsProbe{DurationSeconds: 20, Width: 1280, Height: 720}
+	cases := []struct {
+		name   string
+		want   int
+		wantOK bool
+	}{
+		{name: "0.ts", want: 0, wantOK: true},
+		{name: "3.ts", want: 3, wantOK: true},
+		{name: "4.ts", wantOK: false},
+		{name: "-1.ts", wantOK: false},
+		{name: "01.ts", wantOK: false},
+		{name: "1.mp4", wantOK: false},
+		{name: ".ts", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseHLSSegmentName(tc.name, probe)
+			if ok != tc.wantOK || (ok && got != tc.want) {
+				t.Fatalf("parseHLSSegmentName(%q) = %d, %v; want %d, %v", tc.name, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// hlsSourceServer 仅监听回环地址，把分块内容以支持 Range 的 HTTP 形式提供给 ffmpeg/ffprobe，
+// 这样转码时可以按需跳转，而不必把整个视频落盘。
+type hlsSourceServer struct {
+	baseURL string
+	server  *http.Server
+	tokens  map[string]uuid.UUID
+}
+
+func (s *Server) ensureHLSSourceServer() (*hlsSourceServer, error) {
+	s.hlsSourceMu.Lock()
+	defer s.hlsSourceMu.Unlock()
+	if s.hlsSource != nil {
+		return s.hlsSource, nil
+	}
+	if s.isShuttingDown() {
+		return nil, errServerShuttingDown
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	source := &hlsSourceServer{
+		baseURL: "http://" + ln.Addr().String(),
+		tokens:  map[string]uuid.UUID{},
+	}
+	source.server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleHLSSourceRequest(w, r, source)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := source.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Warn("hls source server stopped", "error", err.Error())
+		}
+	}()
+
+	s.hlsSource = source
+	return source, nil
+}
+
+// closeHLSSourceServer 关闭回环服务并释放监听端口，在后台任务退出后由 Shutdown 调用。
+func (s *Server) closeHLSSourceServer() {
+	s.hlsSourceMu.Lock()
+	source := s.hlsSource
+	s.hlsSource = nil
+	s.hlsSourceMu.Unlock()
+	if source == nil {
+		return
+	}
+	if err := source.server.Close(); err != nil {
+		s.logger.Warn("close hls source server failed", "error", err.Error())
+	}
+}
+
+// openHLSSourceURL 为文件签发一次性回环地址，调用方用完后必须调用 release。
+func (s *Server) openHLSSourceURL(itemID uuid.UUID) (string, func(), error) {
+	source, err := s.ensureHLSSourceServer()
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	token := hex.EncodeToString(raw)
+
+	s.hlsSourceMu.Lock()
+	source.tokens[token] = itemID
+	s.hlsSourceMu.Unlock()
+
+	release := func() {
+		s.hlsSourceMu.Lock()
+		delete(source.tokens, token)
+		s.hlsSourceMu.Unlock()
+	}
+	return source.baseURL + "/" + token, release, nil
+}
+
+func (s *Server) handleHLSSourceRequest(w http.ResponseWriter, r *http.Request, source *hlsSourceServer) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "不支持的请求方法")
+		return
+	}
+
+	token := strings.Trim(r.URL.Path, "/")
+	s.hlsSourceMu.Lock()
+	itemID, ok := source.tokens[token]
+	s.hlsSourceMu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "资源不存在")
+		return
+	}
+
+	st := store.New(s.db)
+	item, err := st.GetItem(r.Context(), itemID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "文件不存在")
+			return
+		}
+		if !errors.Is(err, context.Canceled) {
+			s.logger.Error("get hls source item failed", "error", err.Error())
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	chunks, err := st.ListChunks(r.Context(), item.ID)
+	if err != nil {
+		s.logger.Error("list chunks failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	_ = s.serveChunkedDownload(w, r, item, chunks)
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	imageHashBits             = 64
+	imagePHashSampleSize      = 32
+	imagePHashLowFreqSize     = 8
+	imageSimilarityDefault    = 90
+	imageSimilarityMin        = 50
+	imageSimilarityMax        = 100
+	imageSimilarityMaxEntries = 20000
+)
+
+// computeImageHashes 计算 dHash（9x8 灰度相邻像素梯度）与 pHash（32x32 DCT 低频 8x8 与中位数比较）。
+// 输入应为已按 Exif 校正方向的图像，缩略图即可，结果对缩放与 JPEG 重编码不敏感。
+func computeImageHashes(img image.Image) (dhash uint64, phash uint64) {
+	return computeImageDHash(img), computeImagePHash(img)
+}
+
+func computeImageDHash(img image.Image) uint64 {
+	gray := sampleImageGray(img, 9, 8)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y*9+x] < gray[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func computeImagePHash(img image.Image) uint64 {
+	const n = imagePHashSampleSize
+	const k = imagePHashLowFreqSize
+	gray := sampleImageGray(img, n, n)
+
+	// 可分离的二维 DCT-II：先按行、再按列，只保留左上角 k x k 的低频系数
+	cosTable := make([]float64, k*n)
+	for u := 0; u < k; u++ {
+		for x := 0; x < n; x++ {
+			cosTable[u*n+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / float64(2*n))
+		}
+	}
+	rows := make([]float64, n*k)
+	for y := 0; y < n; y++ {
+		for u := 0; u < k; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += gray[y*n+x] * cosTable[u*n+x]
+			}
+			rows[y*k+u] = sum
+		}
+	}
+	coeffs := make([]float64, k*k)
+	for v := 0; v < k; v++ {
+		for u := 0; u < k; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y*k+u] * cosTable[v*n+y]
+			}
+			coeffs[v*k+u] = sum
+		}
+	}
+
+	// 直流分量只反映整体亮度，不参与中位数计算
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for _, c := range coeffs {
+		hash <<= 1
+		if c > median {
+			hash |= 1
+		}
+	}
+	return hash
+}
+
+// sampleImageGray 把图像缩放到 w x h 并转为亮度值（0~255）。
+func sampleImageGray(img image.Image, w int, h int) []float64 {
+	small := resizeImageSampled(img, w, h, true)
+	out := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := small.RGBAAt(x, y)
+			out[y*w+x] = 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+		}
+	}
+	return out
+}
+
+func hammingDistance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// imageSimilarityMaxDistance 把相似度百分比换算为允许的最大汉明距离，100 表示指纹完全一致。
+func imageSimilarityMaxDistance(similarity int) int {
+	return int(math.Round(float64(imageSimilarityMax-similarity) * imageHashBits / 100))
+}
+
+type similarImageMember struct {
+	entry    store.ImageHashEntry
+	distance int
+}
+
+type similarImageCluster struct {
+	members         []similarImageMember
+	totalSize       int64
+	reclaimableSize int64
+}
+
+// clusterSimilarImages 两两比较指纹，dHash 与 pHash 距离都不超过 maxDistance 视为相似，并按连通关系合并成组。
+// 组内按大小降序、创建时间升序排列，第一张为建议保留的图片；组按可释放空间降序返回，单张图片不成组。
+func clusterSimilarImages(entries []store.ImageHashEntry, maxDistance int) []similarImageCluster {
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			if hammingDistance(entries[i].PHash, entries[j].PHash) > maxDistance ||
+				hammingDistance(entries[i].DHash, entries[j].DHash) > maxDistance {
+				continue
+			}
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[rj] = ri
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	order := make([]int, 0)
+	for i := range entries {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	clusters := make([]similarImageCluster, 0)
+	for _, root := range order {
+		indexes := groups[root]
+		if len(indexes) < 2 {
+			continue
+		}
+		sort.SliceStable(indexes, func(a, b int) bool {
+			left, right := entries[indexes[a]].Item, entries[indexes[b]].Item
+			if left.Size != right.Size {
+				return left.Size > right.Size
+			}
+			return left.CreatedAt.Before(right.CreatedAt)
+		})
+		keep := entries[indexes[0]]
+		cluster := similarImageCluster{members: make([]similarImageMember, 0, len(indexes))}
+		for pos, idx := range indexes {
+			entry := entries[idx]
+			cluster.members = append(cluster.members, similarImageMember{
+				entry:    entry,
+				distance: hammingDistance(keep.PHash, entry.PHash),
+			})
+			cluster.totalSize += entry.Item.Size
+			if pos > 0 {
+				cluster.reclaimableSize += entry.Item.Size
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+	sort.SliceStable(clusters, func(a, b int) bool {
+		if clusters[a].reclaimableSize != clusters[b].reclaimableSize {
+			return clusters[a].reclaimableSize > clusters[b].reclaimableSize
+		}
+		return len(clusters[a].members) > len(clusters[b].members)
+	})
+	return clusters
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func buildGradientTestImage(w int, h int, invert bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*255/w + y*128/h) / 2)
+			if (x*8/w+y*8/h)%2 == 0 {
+				v += 100
+			}
+			if invert {
+				v = 255 - v
+			}
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestComputeImageHashesStableAcrossScale(t *testing.T) {
+	t.Parallel()
+
+	large := buildGradientTestImage(640, 480, false)
+	small := resizeImageSampled(large, 160, 120, true)
+	other := buildGradientTestImage(640, 480, true)
+
+	dLarge, pLarge := computeImageHashes(large)
+	dSmall, pSmall := computeImageHashes(small)
+	dOther, pOther := computeImageHashes(other)
+
+	if d := hammingDistance(dLarge, dSmall); d > 4 {
+		t.Fatalf("dHash distance after resize = %d", d)
+	}
+	if d := hammingDistance(pLarge, pSmall); d > 4 {
+		t.Fatalf("pHash distance after resize = %d", d)
+	}
+	if d := hammingDistance(pLarge, pOther); d < 20 {
+		t.Fatalf("pHash distance of different images = %d, want >= 20", d)
+	}
+	if d := hammingDistance(dLarge, dOther); d < 20 {
+		t.Fatalf("dHash distance of different images = %d, want >= 20", d)
+	}
+}
+
+func TestImageSimilarityMaxDistance(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]int{100: 0, 95: 3, 90: 6, 80: 13, 50: 32}
+	for similarity, want := range cases {
+		if got := imageSimilarityMaxDistance(similarity); got != want {
+			t.Fatalf("imageSimilarityMaxDistance(%d) = %d, want %d", similarity, got, want)
+		}
+	}
+	if _, err := parseImageSimilarity("49"); err == nil {
+		t.Fatal("similarity below minimum should be rejected")
+	}
+	if got, err := parseImageSimilarity(""); err != nil || got != imageSimilarityDefault {
+		t.Fatalf("parseImageSimilarity(\"\") = %d, %v", got, err)
+	}
+}
+
+func TestClusterSimilarImages(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(name string, size int64, offset int, dhash uint64, phash uint64) store.ImageHashEntry {
+		return store.ImageHashEntry{
+			Item: store.Item{
+				ID:        uuid.New(),
+				Type:      store.ItemTypeImage,
+				Name:      name,
+				Size:      size,
+				CreatedAt: base.Add(time.Duration(offset) * time.Minute),
+			},
+			DHash: dhash,
+			PHash: phash,
+		}
+	}
+	entries := []store.ImageHashEntry{
+		entry("a.jpg", 100, 0, 0xFF00, 0xF0F0),
+		entry("a-copy.jpg", 300, 1, 0xFF01, 0xF0F1),
+		// 与 a-copy 相似但与 a 距离较远，应经传递关系并入同一组
+		entry("a-edit.jpg", 200, 2, 0xFF03, 0xF0F3),
+		entry("b.jpg", 50, 3, 0x1234567890ABCDEF, 0x0FEDCBA987654321),
+		entry("b-copy.jpg", 50, 4, 0x1234567890ABCDEF, 0x0FEDCBA987654321),
+		entry("lonely.jpg", 999, 5, ^uint64(0), ^uint64(0)),
+	}
+
+	clusters := clusterSimilarImages(entries, 1)
+	if len(clusters) != 2 {
+		t.Fatalf("clusters = %d, want 2", len(clusters))
+	}
+	first := clusters[0]
+	if len(first.members) != 3 || first.members[0].entry.Item.Name != "a-copy.jpg" ||
+		first.members[1].entry.Item.Name != "a-edit.jpg" || first.members[2].entry.Item.Name != "a.jpg" {
+		t.Fatalf("first cluster order unexpected: %+v", first.members)
+	}
+	if first.totalSize != 600 || first.reclaimableSize != 300 {
+		t.Fatalf("first cluster sizes = %d/%d", first.totalSize, first.reclaimableSize)
+	}
+	second := clusters[1]
+	if len(second.members) != 2 || second.members[0].entry.Item.Name != "b.jpg" || second.members[1].distance != 0 {
+		t.Fatalf("second cluster unexpected: %+v", second.members)
+	}
+
+	if got := clusterSimilarImages(entries, 0); len(got) != 1 || len(got[0].members) != 2 {
+		t.Fatalf("exact clusters = %+v", got)
+	}
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	imageHashBackfillPageSize    = 50
+	imageHashBackfillConcurrency = 2
+	imageHashItemTimeout         = 2 * time.Minute
+	imageHashStoreTimeout        = 10 * time.Second
+)
+
+type similarImageItemDTO struct {
+	Item          ItemDTO `json:"item"`
+	Distance      int     `json:"distance"`
+	SuggestedKeep bool    `json:"suggestedKeep"`
+}
+
+type similarImageClusterDTO struct {
+	Items           []similarImageItemDTO `json:"items"`
+	TotalSize       int64                 `json:"totalSize"`
+	ReclaimableSize int64                 `json:"reclaimableSize"`
+}
+
+// storeItemImageHashFromThumbnail 解码已生成的缩略图计算指纹；统一以缩略图为输入，保证纯 Go 与 ffmpeg 两条路径结果可比。
+func (s *Server) storeItemImageHashFromThumbnail(ctx context.Context, item store.Item, thumbPath string) error {
+	f, err := os.Open(thumbPath)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(bufio.NewReader(f))
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	dhash, phash := computeImageHashes(img)
+
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), imageHashStoreTimeout)
+	defer cancel()
+	err = store.New(s.db).UpsertItemImageHash(storeCtx, store.ItemImageHash{
+		ItemID:     item.ID,
+		Status:     store.ItemImageHashStatusOK,
+		DHash:      dhash,
+		PHash:      phash,
+		ComputedAt: time.Now(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// refreshItemImageHash 优先复用缩略图缓存；没有缓存时按缩略图流程生成（同时写入缓存与指纹）。
+// 无法生成时写入 failed 状态，避免回填任务反复处理。
+func (s *Server) refreshItemImageHash(ctx context.Context, item store.Item) error {
+	err := s.computeItemImageHash(ctx, item)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	message := truncateMediaMetadataError(err.Error())
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), imageHashStoreTimeout)
+	defer cancel()
+	if storeErr := store.New(s.db).UpsertItemImageHash(storeCtx, store.ItemImageHash{
+		ItemID:     item.ID,
+		Status:     store.ItemImageHashStatusFailed,
+		Error:      &message,
+		ComputedAt: time.Now(),
+	}); storeErr != nil && !errors.Is(storeErr, store.ErrNotFound) {
+		s.logger.Warn("store image hash failure failed", "error", storeErr.Error(), "item_id", item.ID.String())
+	}
+	return err
+}
+
+func (s *Server) computeItemImageHash(ctx context.Context, item store.Item) error {
+	if resolveThumbnailKind(item) != thumbnailKindImage {
+		return errors.New("不支持的图片格式")
+	}
+	cachePath, err := s.thumbnailCachePath(item)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(cachePath); err == nil && !info.IsDir() {
+		return s.storeItemImageHashFromThumbnail(ctx, item, cachePath)
+	}
+
+	cacheKey := s.thumbnailCacheKey(item)
+	leader, wait := s.beginThumbnailGeneration(cacheKey)
+	if !leader {
+		select {
+		case <-wait:
+			return s.storeItemImageHashFromThumbnail(ctx, item, cachePath)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer s.finishThumbnailGeneration(cacheKey)
+
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.acquireThumbnailGenerateSlot(ctx, settings.ThumbnailGenerateConcurrency); err != nil {
+		return err
+	}
+	defer s.releaseThumbnailGenerate()
+
+	// generateAndCacheImageThumbnail 成功后会顺带写入指纹
+	return s.generateAndCacheImageThumbnail(ctx, item, cachePath)
+}
+
+// handleListSimilarImages 按指纹把相似图片分组；parentId 限定目录子树，缺省为全部文件。
+// 未计算指纹的图片不参与比对，返回 unhashed 数量提示先运行回填任务。
+func (s *Server) handleListSimilarImages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	similarity, err := parseImageSimilarity(q.Get("similarity"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	st := store.New(s.db)
+	prefix, includeVault, ok := s.resolveItemScanScope(w, r, st, q.Get("parentId"))
+	if !ok {
+		return
+	}
+
+	entries, err := st.ListImageHashes(r.Context(), prefix, includeVault, imageSimilarityMaxEntries+1)
+	if err != nil {
+		s.logger.Error("list image hashes failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	truncated := len(entries) > imageSimilarityMaxEntries
+	if truncated {
+		entries = entries[:imageSimilarityMaxEntries]
+	}
+	totalImages, unhashed, err := st.CountImagesInPrefix(r.Context(), prefix, includeVault)
+	if err != nil {
+		s.logger.Error("count images failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+
+	maxDistance := imageSimilarityMaxDistance(similarity)
+	clusters := clusterSimilarImages(entries, maxDistance)
+	out := make([]similarImageClusterDTO, 0, len(clusters))
+	for _, cluster := range clusters {
+		dto := similarImageClusterDTO{
+			Items:           make([]similarImageItemDTO, 0, len(cluster.members)),
+			TotalSize:       cluster.totalSize,
+			ReclaimableSize: cluster.reclaimableSize,
+		}
+		for i, member := range cluster.members {
+			dto.Items = append(dto.Items, similarImageItemDTO{
+				Item:          toItemDTO(member.entry.Item),
+				Distance:      member.distance,
+				SuggestedKeep: i == 0,
+			})
+		}
+		out = append(out, dto)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"similarity":  similarity,
+		"maxDistance": maxDistance,
+		"totalImages": totalImages,
+		"scanned":     len(entries),
+		"unhashed":    unhashed,
+		"truncated":   truncated,
+		"clusters":    out,
+	})
+}
+
+// resolveItemScanScope 解析查重类接口的范围：parentId 为空时是整个网盘，否则是该目录子树。
+// 密码箱内的文件只在解锁后纳入；失败时已写入响应。
+func (s *Server) resolveItemScanScope(w http.ResponseWriter, r *http.Request, st *store.Store, rawParentID string) (string, bool, bool) {
+	prefix := "/"
+	if raw := strings.TrimSpace(rawParentID); raw != "" {
+		parentID, err := uuid.Parse(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "parentId 非法")
+			return "", false, false
+		}
+		parent, err := st.GetItem(r.Context(), parentID)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, http.StatusNotFound, "not_found", "目录不存在")
+				return "", false, false
+			}
+			s.logger.Error("get item failed", "error", err.Error())
+			writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+			return "", false, false
+		}
+		if parent.Type != store.ItemTypeFolder {
+			writeError(w, http.StatusBadRequest, "bad_request", "parentId 不是目录")
+			return "", false, false
+		}
+		if parent.InVault && !s.requireVaultUnlocked(w, r) {
+			return "", false, false
+		}
+		prefix = parent.Path
+	}
+
+	vaultStatus, err := s.getVaultStatusResponse(r)
+	if err != nil {
+		s.logger.Error("get vault status failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "读取密码箱状态失败")
+		return "", false, false
+	}
+	return prefix, vaultStatus.Enabled && vaultStatus.Unlocked, true
+}
+
+// handleDeleteSimilarImages 批量删除选中的图片，复用批量删除任务（含 Telegram 消息清理与进度推送）。
+func (s *Server) handleDeleteSimilarImages(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ItemIDs []string `json:"itemIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	if len(req.ItemIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "缺少 itemIds")
+		return
+	}
+	ids, err := parseUniqueBatchVaultIDs(req.ItemIDs)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	if len(ids) > itemBatchMaxTargets {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("单次最多处理 %d 个项目", itemBatchMaxTargets))
+		return
+	}
+
+	st := store.New(s.db)
+	items, err := loadItemBatchItems(r.Context(), st, ids)
+	if err != nil {
+		s.logger.Error("load item batch targets failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询文件失败")
+		return
+	}
+	hasVault := false
+	for _, item := range items {
+		// 只允许删除图片，防止误传目录 id 删掉整个子树
+		if item.Type != store.ItemTypeImage {
+			writeError(w, http.StatusBadRequest, "bad_request", "只能删除图片文件："+item.Name)
+			return
+		}
+		hasVault = hasVault || item.InVault
+	}
+	if hasVault && !s.requireVaultUnlocked(w, r) {
+		return
+	}
+
+	dto := s.startItemBatchJob(r.Context(), itemBatchActionDelete, nil, false, false, ids, items)
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": dto})
+}
+
+func parseImageSimilarity(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return imageSimilarityDefault, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < imageSimilarityMin || value > imageSimilarityMax {
+		return 0, fmt.Errorf("similarity 必须为 %d~%d 的整数", imageSimilarityMin, imageSimilarityMax)
+	}
+	return value, nil
+}
+
+// handleStartImageHashBackfill 为尚无指纹的图片生成缩略图并计算指纹。
+func (s *Server) handleStartImageHashBackfill(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RetryFailed bool `json:"retryFailed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+
+	s.imageHashBackfillMu.Lock()
+	if s.imageHashBackfill != nil && s.imageHashBackfill.running() {
+		s.imageHashBackfillMu.Unlock()
+		writeError(w, http.StatusConflict, "conflict", "已有图片指纹回填任务在运行")
+		return
+	}
+	total, err := store.New(s.db).CountImagesMissingHash(r.Context(), req.RetryFailed)
+	if err != nil {
+		s.imageHashBackfillMu.Unlock()
+		s.logger.Error("count images missing hash failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	job := &itemBackfillJob{
+		id:          uuid.New(),
+		status:      itemBackfillStatusRunning,
+		retryFailed: req.RetryFailed,
+		total:       total,
+		cancel:      cancel,
+		startedAt:   time.Now(),
+	}
+	s.imageHashBackfill = job
+	s.imageHashBackfillMu.Unlock()
+
+	s.goBackground(func() { s.runImageHashBackfill(ctx, job) })
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleGetImageHashBackfill(w http.ResponseWriter, r *http.Request) {
+	s.imageHashBackfillMu.Lock()
+	job := s.imageHashBackfill
+	s.imageHashBackfillMu.Unlock()
+	if job == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"job": nil})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleCancelImageHashBackfill(w http.ResponseWriter, r *http.Request) {
+	s.imageHashBackfillMu.Lock()
+	job := s.imageHashBackfill
+	s.imageHashBackfillMu.Unlock()
+	if job == nil {
+		writeError(w, http.StatusConflict, "conflict", "当前副本没有图片指纹回填任务")
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, "conflict", "任务已结束")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) runImageHashBackfill(ctx context.Context, job *itemBackfillJob) {
+	st := store.New(s.db)
+	s.runItemBackfill(ctx, job, itemBackfillRunner{
+		name:        "image hash backfill",
+		pageSize:    imageHashBackfillPageSize,
+		concurrency: imageHashBackfillConcurrency,
+		itemTimeout: imageHashItemTimeout,
+		list: func(ctx context.Context, after time.Time, afterID uuid.UUID, limit int) ([]store.Item, error) {
+			return st.ListImagesMissingHash(ctx, job.retryFailed, after, afterID, limit)
+		},
+		process: s.refreshItemImageHash,
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+)
+
+// itemActionError 携带单项操作失败时返回给前端的状态码与提示；批量任务只取提示文案记入逐项结果。
+type itemActionError struct {
+	status  int
+	code    string
+	message string
+}
+
+func newItemActionError(status int, code string, message string) *itemActionError {
+	return &itemActionError{status: status, code: code, message: message}
+}
+
+func (e *itemActionError) Error() string {
+	return e.message
+}
+
+func isItemActionError(err error) bool {
+	var actionErr *itemActionError
+	return errors.As(err, &actionErr)
+}
+
+func writeItemActionError(w http.ResponseWriter, err error) {
+	var actionErr *itemActionError
+	if errors.As(err, &actionErr) {
+		writeError(w, actionErr.status, actionErr.code, actionErr.message)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal_error", "操作失败")
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	itemBackfillStatusRunning   = "running"
+	itemBackfillStatusCompleted = "completed"
+	itemBackfillStatusCanceled  = "canceled"
+	itemBackfillStatusFailed    = "failed"
+)
+
+// itemBackfillJob 记录后台回填任务（元数据、图片指纹）的进度；同类任务同一时间只运行一个，状态只保存在内存中。
+type itemBackfillJob struct {
+	mu              sync.Mutex
+	id              uuid.UUID
+	status          string
+	retryFailed     bool
+	total           int64
+	processed       int64
+	succeeded       int64
+	failed          int64
+	lastError       string
+	cancelRequested bool
+	cancel          context.CancelFunc
+	startedAt       time.Time
+	finishedAt      *time.Time
+}
+
+type itemBackfillJobDTO struct {
+	ID              string     `json:"id"`
+	Status          string     `json:"status"`
+	RetryFailed     bool       `json:"retryFailed"`
+	Total           int64      `json:"total"`
+	Processed       int64      `json:"processed"`
+	Succeeded       int64      `json:"succeeded"`
+	Failed          int64      `json:"failed"`
+	LastError       string     `json:"lastError,omitempty"`
+	CancelRequested bool       `json:"cancelRequested"`
+	StartedAt       time.Time  `json:"startedAt"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (j *itemBackfillJob) snapshot() itemBackfillJobDTO {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	dto := itemBackfillJobDTO{
+		ID:              j.id.String(),
+		Status:          j.status,
+		RetryFailed:     j.retryFailed,
+		Total:           j.total,
+		Processed:       j.processed,
+		Succeeded:       j.succeeded,
+		Failed:          j.failed,
+		LastError:       j.lastError,
+		CancelRequested: j.cancelRequested,
+		StartedAt:       j.startedAt,
+	}
+	if j.finishedAt != nil {
+		finishedAt := *j.finishedAt
+		dto.FinishedAt = &finishedAt
+	}
+	return dto
+}
+
+func (j *itemBackfillJob) running() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status == itemBackfillStatusRunning
+}
+
+func (j *itemBackfillJob) recordResult(item store.Item, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.processed++
+	if err != nil {
+		j.failed++
+		j.lastError = item.Name + ": " + truncateMediaMetadataError(err.Error())
+		return
+	}
+	j.succeeded++
+}
+
+func (j *itemBackfillJob) finish(runErr error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case j.cancelRequested:
+		j.status = itemBackfillStatusCanceled
+	case runErr != nil:
+		j.status = itemBackfillStatusFailed
+		j.lastError = runErr.Error()
+	default:
+		j.status = itemBackfillStatusCompleted
+	}
+	now := time.Now()
+	j.finishedAt = &now
+}
+
+// requestCancel 返回 false 表示任务已结束，无需取消。
+func (j *itemBackfillJob) requestCancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != itemBackfillStatusRunning {
+		return false
+	}
+	j.cancelRequested = true
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return true
+}
+
+// itemBackfillRunner 描述一类回填任务：list 按 (created_at, id) 游标分页返回待处理文件，process 处理单个文件；
+// itemTimeout 为 0 表示单个文件不限时。
+type itemBackfillRunner struct {
+	name        string
+	pageSize    int
+	concurrency int
+	itemTimeout time.Duration
+	list        func(ctx context.Context, after time.Time, afterID uuid.UUID, limit int) ([]store.Item, error)
+	process     func(ctx context.Context, item store.Item) error
+}
+
+func (s *Server) runItemBackfill(ctx context.Context, job *itemBackfillJob, runner itemBackfillRunner) {
+	defer job.cancel()
+	var afterTime time.Time
+	afterID := uuid.Nil
+	var runErr error
+	for ctx.Err() == nil {
+		items, err := runner.list(ctx, afterTime, afterID, runner.pageSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				runErr = err
+				s.logger.Error("list backfill items failed", "job", runner.name, "error", err.Error())
+			}
+			break
+		}
+		if len(items) == 0 {
+			break
+		}
+		last := items[len(items)-1]
+		afterTime, afterID = last.CreatedAt, last.ID
+
+		sem := make(chan struct{}, runner.concurrency)
+		var wg sync.WaitGroup
+		for _, item := range items {
+			if ctx.Err() != nil {
+				break
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(item store.Item) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				itemCtx, cancel := ctx, context.CancelFunc(func() {})
+				if runner.itemTimeout > 0 {
+					itemCtx, cancel = context.WithTimeout(ctx, runner.itemTimeout)
+				}
+				err := runner.process(itemCtx, item)
+				cancel()
+				if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+					return
+				}
+				job.recordResult(item, err)
+			}(item)
+		}
+		wg.Wait()
+	}
+	job.finish(runErr)
+	dto := job.snapshot()
+	s.logger.Info(
+		runner.name+" finished",
+		"status", dto.Status,
+		"processed", dto.Processed,
+		"succeeded", dto.Succeeded,
+		"failed", dto.Failed,
+	)
+}
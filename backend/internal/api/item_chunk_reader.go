@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+type itemChunkSpan struct {
+	Chunk    store.Chunk
+	StartAbs int64
+	EndAbs   int64
+}
+
+// buildItemChunkSpans 计算每个分块在完整文件中的绝对偏移，返回分块总长度。
+func buildItemChunkSpans(chunks []store.Chunk) ([]itemChunkSpan, int64) {
+	spans := make([]itemChunkSpan, 0, len(chunks))
+	var offset int64
+	for _, c := range chunks {
+		if c.ChunkSize <= 0 {
+			continue
+		}
+		start := offset
+		end := offset + int64(c.ChunkSize) - 1
+		spans = append(spans, itemChunkSpan{Chunk: c, StartAbs: start, EndAbs: end})
+		offset = end + 1
+	}
+	return spans, offset
+}
+
+func resolveItemContentSize(it store.Item, chunks []store.Chunk) int64 {
+	if it.Size > 0 {
+		return it.Size
+	}
+	_, total := buildItemChunkSpans(chunks)
+	return total
+}
+
+// copyItemChunkRange 将文件 [start, end] 区间的内容按分块顺序写入 dst。
+func (s *Server) copyItemChunkRange(
+	ctx context.Context,
+	dst io.Writer,
+	chunks []store.Chunk,
+	start int64,
+	end int64,
+) error {
+	if start < 0 || end < start {
+		return fmt.Errorf("读取区间非法: %d-%d", start, end)
+	}
+	spans, total := buildItemChunkSpans(chunks)
+	if end >= total {
+		return errors.New("文件分块缺失")
+	}
+
+	for _, sp := range spans {
+		if start > sp.EndAbs {
+			continue
+		}
+		if end < sp.StartAbs {
+			break
+		}
+		subStart := maxInt64(start, sp.StartAbs) - sp.StartAbs
+		subEnd := minInt64(end, sp.EndAbs) - sp.StartAbs
+		if err := s.copyChunkRange(ctx, dst, sp.Chunk, subStart, subEnd); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyChunkRange 读取单个分块的 [subStart, subEnd] 区间，优先本地文件，其次 Telegram 文件接口。
+func (s *Server) copyChunkRange(
+	ctx context.Context,
+	dst io.Writer,
+	chunk store.Chunk,
+	subStart int64,
+	subEnd int64,
+) error {
+	subLen := subEnd - subStart + 1
+	if subLen <= 0 {
+		return nil
+	}
+
+	fileID, err := s.ensureChunkFileID(ctx, chunk)
+	if err != nil {
+		return err
+	}
+	filePath, err := s.getCachedFilePath(ctx, fileID)
+	if err != nil {
+		return err
+	}
+
+	localFile, _, openErr := openTelegramLocalFileByFilePath(filePath)
+	if openErr == nil {
+		defer localFile.Close()
+		if subStart > 0 {
+			if _, err := localFile.Seek(subStart, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		_, err := io.CopyN(dst, localFile, subLen)
+		return err
+	}
+
+	tgClient := s.telegramClient()
+	if tgClient == nil {
+		return errors.New("telegram 客户端未初始化")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tgClient.DownloadURLFromFilePath(filePath), nil)
+	if err != nil {
+		return err
+	}
+	if subStart != 0 || subEnd != int64(chunk.ChunkSize-1) {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", subStart, subEnd))
+	}
+	resp, err := doTelegramFileRequest(&http.Client{}, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("上游文件服务状态异常: %d", resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusOK && subStart > 0 {
+		// Range 不生效，丢弃前置字节
+		if _, err := io.CopyN(io.Discard, resp.Body, subStart); err != nil {
+			return err
+		}
+	}
+	_, err = io.CopyN(dst, resp.Body, subLen)
+	return err
+}
+
+// openItemChunkReader 以流的形式返回文件 [start, end] 区间内容，调用方负责 Close。
+func (s *Server) openItemChunkReader(
+	ctx context.Context,
+	chunks []store.Chunk,
+	start int64,
+	end int64,
+) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.copyItemChunkRange(ctx, pw, chunks, start, end))
+	}()
+	return pr
+}
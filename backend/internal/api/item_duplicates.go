@@ -0,0 +1,448 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	contentHashBackfillPageSize    = 50
+	contentHashBackfillConcurrency = 2
+	contentHashStoreTimeout        = 10 * time.Second
+
+	duplicateMatchSHA256       = "sha256"
+	duplicateMatchTelegramFile = "telegram_file"
+
+	duplicateKeepNewest = "keep_newest"
+	duplicateKeepOldest = "keep_oldest"
+)
+
+type duplicateGroup struct {
+	key         string
+	matchedBy   string
+	size        int64
+	items       []store.Item
+	wastedBytes int64
+}
+
+type duplicateGroupDTO struct {
+	Key         string    `json:"key"`
+	MatchedBy   string    `json:"matchedBy"`
+	Size        int64     `json:"size"`
+	Count       int       `json:"count"`
+	WastedBytes int64     `json:"wastedBytes"`
+	Items       []ItemDTO `json:"items"`
+}
+
+// groupDuplicateCandidates 内容哈希相同或 Telegram 文件签名相同的文件归为一组（两种关系可传递合并）。
+// 组内按创建时间升序；组按浪费空间降序。
+func groupDuplicateCandidates(candidates []store.DuplicateCandidate) []duplicateGroup {
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(first map[string]int, key *string, i int) {
+		if key == nil || *key == "" {
+			return
+		}
+		if j, ok := first[*key]; ok {
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[ri] = rj
+			}
+			return
+		}
+		first[*key] = i
+	}
+	byHash := make(map[string]int)
+	bySignature := make(map[string]int)
+	for i, c := range candidates {
+		union(byHash, c.ContentHash, i)
+		union(bySignature, c.FileSignature, i)
+	}
+
+	members := make(map[int][]int)
+	order := make([]int, 0)
+	for i := range candidates {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	groups := make([]duplicateGroup, 0)
+	for _, root := range order {
+		indexes := members[root]
+		if len(indexes) < 2 {
+			continue
+		}
+		group := duplicateGroup{matchedBy: duplicateMatchTelegramFile}
+		signature := ""
+		for _, idx := range indexes {
+			c := candidates[idx]
+			group.items = append(group.items, c.Item)
+			if c.ContentHash != nil && group.key == "" {
+				group.key = duplicateMatchSHA256 + ":" + *c.ContentHash
+				group.matchedBy = duplicateMatchSHA256
+			}
+			if c.FileSignature != nil && signature == "" {
+				signature = *c.FileSignature
+			}
+		}
+		if group.key == "" {
+			// 签名随分块数增长，对外只暴露其摘要
+			sum := sha256.Sum256([]byte(signature))
+			group.key = duplicateMatchTelegramFile + ":" + hex.EncodeToString(sum[:16])
+		}
+		sort.SliceStable(group.items, func(a, b int) bool {
+			if !group.items[a].CreatedAt.Equal(group.items[b].CreatedAt) {
+				return group.items[a].CreatedAt.Before(group.items[b].CreatedAt)
+			}
+			return group.items[a].ID.String() < group.items[b].ID.String()
+		})
+		group.size = group.items[0].Size
+		group.wastedBytes = group.size * int64(len(group.items)-1)
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(a, b int) bool {
+		if groups[a].wastedBytes != groups[b].wastedBytes {
+			return groups[a].wastedBytes > groups[b].wastedBytes
+		}
+		return groups[a].key < groups[b].key
+	})
+	return groups
+}
+
+// selectDuplicateDeletions 按策略每组保留一个（最新或最早创建的），返回其余待删除的文件。
+func selectDuplicateDeletions(groups []duplicateGroup, strategy string) []store.Item {
+	var out []store.Item
+	for _, group := range groups {
+		keep := 0
+		if strategy == duplicateKeepNewest {
+			keep = len(group.items) - 1
+		}
+		for i, item := range group.items {
+			if i != keep {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+func toDuplicateGroupDTO(group duplicateGroup) duplicateGroupDTO {
+	return duplicateGroupDTO{
+		Key:         group.key,
+		MatchedBy:   group.matchedBy,
+		Size:        group.size,
+		Count:       len(group.items),
+		WastedBytes: group.wastedBytes,
+		Items:       toItemDTOs(group.items),
+	}
+}
+
+func parseDuplicateMinSize(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 1, nil
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 0 {
+		return 0, errors.New("minSize 必须为非负整数")
+	}
+	return value, nil
+}
+
+// computeItemContentHash 通过分块读取完整内容计算 SHA-256；读取字节数与文件大小不符时视为失败。
+func (s *Server) computeItemContentHash(ctx context.Context, item store.Item) error {
+	st := store.New(s.db)
+	chunks, err := st.ListChunks(ctx, item.ID)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return errors.New("文件没有分块")
+	}
+	size := resolveItemContentSize(item, chunks)
+	hasher := sha256.New()
+	counter := &countingWriter{w: hasher}
+	if err := s.copyItemChunkRange(ctx, counter, chunks, 0, size-1); err != nil {
+		return err
+	}
+	if counter.n != size {
+		return fmt.Errorf("读取字节数 %d 与文件大小 %d 不一致", counter.n, size)
+	}
+
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), contentHashStoreTimeout)
+	defer cancel()
+	err = st.UpsertItemContentHash(storeCtx, store.ItemContentHash{
+		ItemID:     item.ID,
+		Status:     store.ItemContentHashStatusOK,
+		SHA256:     hasher.Sum(nil),
+		Size:       item.Size,
+		ComputedAt: time.Now(),
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) refreshItemContentHash(ctx context.Context, item store.Item) error {
+	err := s.computeItemContentHash(ctx, item)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	message := truncateMediaMetadataError(err.Error())
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), contentHashStoreTimeout)
+	defer cancel()
+	if storeErr := store.New(s.db).UpsertItemContentHash(storeCtx, store.ItemContentHash{
+		ItemID:     item.ID,
+		Status:     store.ItemContentHashStatusFailed,
+		Error:      &message,
+		Size:       item.Size,
+		ComputedAt: time.Now(),
+	}); storeErr != nil && !errors.Is(storeErr, store.ErrNotFound) {
+		s.logger.Warn("store content hash failure failed", "error", storeErr.Error(), "item_id", item.ID.String())
+	}
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (s *Server) loadDuplicateGroups(ctx context.Context, st *store.Store, prefix string, includeVault bool, minSize int64) ([]duplicateGroup, error) {
+	candidates, err := st.ListDuplicateCandidates(ctx, prefix, includeVault, minSize)
+	if err != nil {
+		return nil, err
+	}
+	return groupDuplicateCandidates(candidates), nil
+}
+
+// handleListDuplicates 返回完全相同的文件分组：优先按内容 SHA-256，未计算哈希的文件按大小与 tg_file_unique_id 匹配。
+func (s *Server) handleListDuplicates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	minSize, err := parseDuplicateMinSize(q.Get("minSize"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	st := store.New(s.db)
+	prefix, includeVault, ok := s.resolveItemScanScope(w, r, st, q.Get("parentId"))
+	if !ok {
+		return
+	}
+
+	groups, err := s.loadDuplicateGroups(r.Context(), st, prefix, includeVault, minSize)
+	if err != nil {
+		s.logger.Error("list duplicate candidates failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	unhashed, err := st.CountItemsMissingContentHash(r.Context(), false, true)
+	if err != nil {
+		s.logger.Error("count items missing content hash failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+
+	out := make([]duplicateGroupDTO, 0, len(groups))
+	var wasted int64
+	duplicateItems := 0
+	for _, group := range groups {
+		out = append(out, toDuplicateGroupDTO(group))
+		wasted += group.wastedBytes
+		duplicateItems += len(group.items) - 1
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"groups":           out,
+		"groupCount":       len(out),
+		"duplicateItems":   duplicateItems,
+		"totalWastedBytes": wasted,
+		"unhashed":         unhashed,
+	})
+}
+
+// handleResolveDuplicates 按 keep_newest / keep_oldest 每组保留一个文件，其余以批量删除任务删除。
+// 服务端重新计算分组，groupKeys 为空时处理范围内的全部分组。
+func (s *Server) handleResolveDuplicates(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Strategy  string   `json:"strategy"`
+		GroupKeys []string `json:"groupKeys"`
+		ParentID  string   `json:"parentId"`
+		MinSize   *int64   `json:"minSize"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	strategy := strings.ToLower(strings.TrimSpace(req.Strategy))
+	if strategy != duplicateKeepNewest && strategy != duplicateKeepOldest {
+		writeError(w, http.StatusBadRequest, "bad_request", "strategy 仅支持 keep_newest/keep_oldest")
+		return
+	}
+	minSize := int64(1)
+	if req.MinSize != nil {
+		if *req.MinSize < 0 {
+			writeError(w, http.StatusBadRequest, "bad_request", "minSize 必须为非负整数")
+			return
+		}
+		minSize = *req.MinSize
+	}
+
+	st := store.New(s.db)
+	prefix, includeVault, ok := s.resolveItemScanScope(w, r, st, req.ParentID)
+	if !ok {
+		return
+	}
+	groups, err := s.loadDuplicateGroups(r.Context(), st, prefix, includeVault, minSize)
+	if err != nil {
+		s.logger.Error("list duplicate candidates failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	if len(req.GroupKeys) > 0 {
+		wanted := make(map[string]struct{}, len(req.GroupKeys))
+		for _, key := range req.GroupKeys {
+			wanted[strings.TrimSpace(key)] = struct{}{}
+		}
+		filtered := groups[:0]
+		for _, group := range groups {
+			if _, ok := wanted[group.key]; ok {
+				filtered = append(filtered, group)
+			}
+		}
+		groups = filtered
+	}
+
+	deletions := selectDuplicateDeletions(groups, strategy)
+	if len(deletions) == 0 {
+		writeError(w, http.StatusConflict, "conflict", "没有可删除的重复文件")
+		return
+	}
+	if len(deletions) > itemBatchMaxTargets {
+		writeError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("单次最多处理 %d 个项目，请缩小范围或指定 groupKeys", itemBatchMaxTargets))
+		return
+	}
+	ids := make([]uuid.UUID, 0, len(deletions))
+	items := make(map[uuid.UUID]store.Item, len(deletions))
+	for _, item := range deletions {
+		ids = append(ids, item.ID)
+		items[item.ID] = item
+	}
+
+	dto := s.startItemBatchJob(r.Context(), itemBatchActionDelete, nil, false, false, ids, items)
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"job":        dto,
+		"groupCount": len(groups),
+	})
+}
+
+// handleStartContentHashBackfill 为缺少内容哈希的文件逐个读取全部分块计算 SHA-256。
+// 默认只处理与其他文件大小相同的文件（大小唯一不可能重复），all=true 时处理全部。
+func (s *Server) handleStartContentHashBackfill(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RetryFailed bool `json:"retryFailed"`
+		All         bool `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+
+	s.contentHashBackfillMu.Lock()
+	if s.contentHashBackfill != nil && s.contentHashBackfill.running() {
+		s.contentHashBackfillMu.Unlock()
+		writeError(w, http.StatusConflict, "conflict", "已有内容哈希任务在运行")
+		return
+	}
+	total, err := store.New(s.db).CountItemsMissingContentHash(r.Context(), req.RetryFailed, !req.All)
+	if err != nil {
+		s.contentHashBackfillMu.Unlock()
+		s.logger.Error("count items missing content hash failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	job := &itemBackfillJob{
+		id:          uuid.New(),
+		status:      itemBackfillStatusRunning,
+		retryFailed: req.RetryFailed,
+		total:       total,
+		cancel:      cancel,
+		startedAt:   time.Now(),
+	}
+	s.contentHashBackfill = job
+	s.contentHashBackfillMu.Unlock()
+
+	s.goBackground(func() { s.runContentHashBackfill(ctx, job, !req.All) })
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleGetContentHashBackfill(w http.ResponseWriter, r *http.Request) {
+	s.contentHashBackfillMu.Lock()
+	job := s.contentHashBackfill
+	s.contentHashBackfillMu.Unlock()
+	if job == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"job": nil})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleCancelContentHashBackfill(w http.ResponseWriter, r *http.Request) {
+	s.contentHashBackfillMu.Lock()
+	job := s.contentHashBackfill
+	s.contentHashBackfillMu.Unlock()
+	if job == nil {
+		writeError(w, http.StatusConflict, "conflict", "当前副本没有内容哈希任务")
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, "conflict", "任务已结束")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+// runContentHashBackfill 单个文件不限时：大文件需要完整下载一遍。
+func (s *Server) runContentHashBackfill(ctx context.Context, job *itemBackfillJob, sizeCandidatesOnly bool) {
+	st := store.New(s.db)
+	s.runItemBackfill(ctx, job, itemBackfillRunner{
+		name:        "content hash backfill",
+		pageSize:    contentHashBackfillPageSize,
+		concurrency: contentHashBackfillConcurrency,
+		list: func(ctx context.Context, after time.Time, afterID uuid.UUID, limit int) ([]store.Item, error) {
+			return st.ListItemsMissingContentHash(ctx, job.retryFailed, sizeCandidatesOnly, after, afterID, limit)
+		},
+		process: s.refreshItemContentHash,
+	})
+}
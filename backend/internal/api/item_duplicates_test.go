@@ -0,0 +1,66 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestGroupDuplicateCandidates(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	str := func(v string) *string { return &v }
+	candidate := func(name string, size int64, minute int, hash *string, sig *string) store.DuplicateCandidate {
+		return store.DuplicateCandidate{
+			Item: store.Item{
+				ID:        uuid.New(),
+				Type:      store.ItemTypeDocument,
+				Name:      name,
+				Path:      "/" + name,
+				Size:      size,
+				CreatedAt: base.Add(time.Duration(minute) * time.Minute),
+			},
+			ContentHash:   hash,
+			FileSignature: sig,
+		}
+	}
+	candidates := []store.DuplicateCandidate{
+		candidate("a.bin", 100, 0, str("aa"), str("100:u1")),
+		// 复制得到的副本与 a 共享 tg_file_unique_id 但尚未计算哈希
+		candidate("a-copy.bin", 100, 1, nil, str("100:u1")),
+		// 重新上传的同内容文件只能通过哈希匹配
+		candidate("a-upload.bin", 100, 2, str("aa"), str("100:u9")),
+		candidate("b.bin", 10, 3, nil, str("10:u2")),
+		candidate("b-copy.bin", 10, 4, nil, str("10:u2")),
+		candidate("c.bin", 10, 5, str("cc"), nil),
+	}
+
+	groups := groupDuplicateCandidates(candidates)
+	if len(groups) != 2 {
+		t.Fatalf("groups = %d, want 2", len(groups))
+	}
+	first := groups[0]
+	if first.key != "sha256:aa" || first.matchedBy != duplicateMatchSHA256 || len(first.items) != 3 || first.wastedBytes != 200 {
+		t.Fatalf("first group = %+v", first)
+	}
+	if first.items[0].Name != "a.bin" || first.items[2].Name != "a-upload.bin" {
+		t.Fatalf("first group order = %v, %v", first.items[0].Name, first.items[2].Name)
+	}
+	second := groups[1]
+	if second.matchedBy != duplicateMatchTelegramFile || !strings.HasPrefix(second.key, "telegram_file:") || second.wastedBytes != 10 {
+		t.Fatalf("second group = %+v", second)
+	}
+
+	newest := selectDuplicateDeletions(groups, duplicateKeepNewest)
+	if len(newest) != 3 || newest[0].Name != "a.bin" || newest[1].Name != "a-copy.bin" || newest[2].Name != "b.bin" {
+		t.Fatalf("keep newest deletions = %+v", newest)
+	}
+	oldest := selectDuplicateDeletions(groups, duplicateKeepOldest)
+	if len(oldest) != 3 || oldest[0].Name != "a-copy.bin" || oldest[2].Name != "b-copy.bin" {
+		t.Fatalf("keep oldest deletions = %+v", oldest)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	metadataBackfillPageSize    = 50
+	metadataBackfillConcurrency = 2
+)
+
+// handleStartMetadataBackfill 启动后台任务，通过分块读取为缺少元数据的媒体文件补齐探测结果。
+func (s *Server) handleStartMetadataBackfill(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RetryFailed bool `json:"retryFailed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+
+	s.metadataBackfillMu.Lock()
+	if s.metadataBackfill != nil && s.metadataBackfill.running() {
+		s.metadataBackfillMu.Unlock()
+		writeError(w, http.StatusConflict, "conflict", "已有元数据回填任务在运行")
+		return
+	}
+	total, err := store.New(s.db).CountItemsMissingMetadata(r.Context(), mediaMetadataItemTypes, req.RetryFailed)
+	if err != nil {
+		s.metadataBackfillMu.Unlock()
+		s.logger.Error("count items missing metadata failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	job := &itemBackfillJob{
+		id:          uuid.New(),
+		status:      itemBackfillStatusRunning,
+		retryFailed: req.RetryFailed,
+		total:       total,
+		cancel:      cancel,
+		startedAt:   time.Now(),
+	}
+	s.metadataBackfill = job
+	s.metadataBackfillMu.Unlock()
+
+	s.goBackground(func() { s.runMetadataBackfill(ctx, job) })
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleGetMetadataBackfill(w http.ResponseWriter, r *http.Request) {
+	s.metadataBackfillMu.Lock()
+	job := s.metadataBackfill
+	s.metadataBackfillMu.Unlock()
+	if job == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"job": nil})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleCancelMetadataBackfill(w http.ResponseWriter, r *http.Request) {
+	s.metadataBackfillMu.Lock()
+	job := s.metadataBackfill
+	s.metadataBackfillMu.Unlock()
+	if job == nil {
+		writeError(w, http.StatusConflict, "conflict", "当前副本没有元数据回填任务")
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, "conflict", "任务已结束")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+// runMetadataBackfill 失败的文件会写入 failed 状态，因此不会在同一轮中重复出现。
+func (s *Server) runMetadataBackfill(ctx context.Context, job *itemBackfillJob) {
+	st := store.New(s.db)
+	s.runItemBackfill(ctx, job, itemBackfillRunner{
+		name:        "item metadata backfill",
+		pageSize:    metadataBackfillPageSize,
+		concurrency: metadataBackfillConcurrency,
+		itemTimeout: 2 * mediaMetadataProbeTimeout,
+		list: func(ctx context.Context, after time.Time, afterID uuid.UUID, limit int) ([]store.Item, error) {
+			return st.ListItemsMissingMetadata(ctx, mediaMetadataItemTypes, job.retryFailed, after, afterID, limit)
+		},
+		process: func(ctx context.Context, item store.Item) error {
+			return s.refreshItemMetadata(ctx, item, "")
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// leaderAdvisoryLockKey 为单例后台任务的 PostgreSQL 会话级 advisory lock 键（"tgcd" + 1）。
+	leaderAdvisoryLockKey     int64 = 0x7467636400000001
+	leaderElectionInterval          = 10 * time.Second
+	leaderElectionCallTimeout       = 5 * time.Second
+)
+
+var errLeadershipLost = errors.New("已不再是主副本")
+
+// isLeader 表示当前副本持有单例后台任务的主锁；Torrent worker、清理与保留期任务只在主副本执行。
+func (s *Server) isLeader() bool {
+	return s.leader.Load()
+}
+
+// startLeaderElectionLoop 用一条专用连接竞争 advisory lock，持锁期间定期探活；连接断开时 PostgreSQL 会自动释放锁。
+// 启动时先同步竞争一次，使单副本部署的后台任务第一轮即可执行。
+// 关闭时循环只退出不放锁，由 Shutdown 在其他后台任务结束后调用 resignLeadership，避免任务交接期间重复执行。
+func (s *Server) startLeaderElectionLoop() {
+	if s.db == nil {
+		return
+	}
+	s.leaderConn = s.runLeaderElectionRound(nil)
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopLeaderElection)
+			if !s.waitLoopInterval(leaderElectionInterval) {
+				return
+			}
+			s.leaderConn = s.runLeaderElectionRound(s.leaderConn)
+		}
+	})
+}
+
+func (s *Server) runLeaderElectionRound(conn *pgxpool.Conn) *pgxpool.Conn {
+	ctx, cancel := context.WithTimeout(s.backgroundCtx, leaderElectionCallTimeout)
+	defer cancel()
+
+	if conn != nil {
+		err := conn.Ping(ctx)
+		if err == nil {
+			return conn
+		}
+		s.logger.Warn("leader lock connection lost, stepping down", "error", err.Error())
+		s.leader.Store(false)
+		// 关闭底层连接以结束会话，确保锁随之释放。
+		_ = conn.Conn().Close(ctx)
+		conn.Release()
+	}
+
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		s.logger.Warn("acquire leader election connection failed", "error", err.Error())
+		return nil
+	}
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, leaderAdvisoryLockKey).Scan(&acquired); err != nil {
+		s.logger.Warn("try leader advisory lock failed", "error", err.Error())
+		conn.Release()
+		return nil
+	}
+	if !acquired {
+		conn.Release()
+		return nil
+	}
+	s.leader.Store(true)
+	s.logger.Info("acquired leadership for singleton background loops", "instance_id", s.instanceID.String())
+	return conn
+}
+
+// resignLeadership 只能在选举循环退出后调用。
+func (s *Server) resignLeadership() {
+	conn := s.leaderConn
+	s.leaderConn = nil
+	if conn == nil {
+		return
+	}
+	s.leader.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), leaderElectionCallTimeout)
+	defer cancel()
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, leaderAdvisoryLockKey); err != nil {
+		_ = conn.Conn().Close(ctx)
+	}
+	conn.Release()
+	s.logger.Info("released leadership", "instance_id", s.instanceID.String())
+}
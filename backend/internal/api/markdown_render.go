@@ -0,0 +1,331 @@
+package api
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// 这里只实现预览所需的 Markdown 子集：标题、段落、强调、行内代码、代码块、引用、列表、表格、链接与图片。
+// 所有文本都先转义，原始 HTML 不会透传；链接只允许 http(s)、mailto 与相对地址，因此输出无需再做清洗。
+
+var (
+	markdownHeadingPattern     = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	markdownRulePattern        = regexp.MustCompile(`^ {0,3}(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	markdownUnorderedPattern   = regexp.MustCompile(`^\s{0,3}[-*+]\s+(.*)$`)
+	markdownOrderedPattern     = regexp.MustCompile(`^\s{0,3}\d{1,9}[.)]\s+(.*)$`)
+	markdownFencePattern       = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+#.-]*)")
+	markdownTableDelimPattern  = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	markdownSafeLanguageFilter = regexp.MustCompile(`[^\w+#.-]`)
+)
+
+func renderMarkdownToSafeHTML(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	var out strings.Builder
+	renderMarkdownBlocks(&out, strings.Split(src, "\n"))
+	return out.String()
+}
+
+func renderMarkdownBlocks(out *strings.Builder, lines []string) {
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		out.WriteString("<p>")
+		for i, line := range paragraph {
+			if i > 0 {
+				if strings.HasSuffix(paragraph[i-1], "  ") {
+					out.WriteString("<br>")
+				}
+				out.WriteByte('\n')
+			}
+			out.WriteString(renderMarkdownInline(strings.TrimSpace(line)))
+		}
+		out.WriteString("</p>\n")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if m := markdownFencePattern.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			fence := m[1]
+			var code []string
+			i++
+			for ; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			out.WriteString("<pre><code")
+			if language := markdownSafeLanguageFilter.ReplaceAllString(m[2], ""); language != "" {
+				out.WriteString(` class="language-` + html.EscapeString(language) + `"`)
+			}
+			out.WriteString(">")
+			out.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			out.WriteString("</code></pre>\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+		case markdownHeadingPattern.MatchString(trimmed):
+			flushParagraph()
+			m := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + renderMarkdownInline(m[2]) + "</h" + level + ">\n")
+		case markdownRulePattern.MatchString(line):
+			flushParagraph()
+			out.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			var quoted []string
+			for ; i < len(lines); i++ {
+				current := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(current, ">") {
+					i--
+					break
+				}
+				current = strings.TrimPrefix(current, ">")
+				quoted = append(quoted, strings.TrimPrefix(current, " "))
+			}
+			out.WriteString("<blockquote>\n")
+			renderMarkdownBlocks(out, quoted)
+			out.WriteString("</blockquote>\n")
+		case markdownUnorderedPattern.MatchString(line) || markdownOrderedPattern.MatchString(line):
+			flushParagraph()
+			i = renderMarkdownList(out, lines, i) - 1
+		case strings.Contains(line, "|") && i+1 < len(lines) && markdownTableDelimPattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-"):
+			flushParagraph()
+			i = renderMarkdownTable(out, lines, i) - 1
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flushParagraph()
+}
+
+// renderMarkdownList 渲染从 start 开始的连续列表项（不支持嵌套），返回下一行的下标。
+func renderMarkdownList(out *strings.Builder, lines []string, start int) int {
+	ordered := markdownOrderedPattern.MatchString(lines[start])
+	pattern := markdownUnorderedPattern
+	tag := "ul"
+	if ordered {
+		pattern = markdownOrderedPattern
+		tag = "ol"
+	}
+	out.WriteString("<" + tag + ">\n")
+	i := start
+	for i < len(lines) {
+		m := pattern.FindStringSubmatch(lines[i])
+		if m == nil {
+			break
+		}
+		item := m[1]
+		i++
+		// 缩进的续行归入当前列表项
+		for i < len(lines) && strings.TrimSpace(lines[i]) != "" && (strings.HasPrefix(lines[i], "  ") || strings.HasPrefix(lines[i], "\t")) &&
+			!markdownUnorderedPattern.MatchString(lines[i]) && !markdownOrderedPattern.MatchString(lines[i]) {
+			item += " " + strings.TrimSpace(lines[i])
+			i++
+		}
+		out.WriteString("<li>" + renderMarkdownListItem(item) + "</li>\n")
+	}
+	out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func renderMarkdownListItem(item string) string {
+	lower := strings.ToLower(item)
+	switch {
+	case strings.HasPrefix(lower, "[ ] "):
+		return `<input type="checkbox" disabled> ` + renderMarkdownInline(item[4:])
+	case strings.HasPrefix(lower, "[x] "):
+		return `<input type="checkbox" checked disabled> ` + renderMarkdownInline(item[4:])
+	default:
+		return renderMarkdownInline(item)
+	}
+}
+
+func renderMarkdownTable(out *strings.Builder, lines []string, start int) int {
+	header := splitMarkdownTableRow(lines[start])
+	out.WriteString("<table>\n<thead><tr>")
+	for _, cell := range header {
+		out.WriteString("<th>" + renderMarkdownInline(cell) + "</th>")
+	}
+	out.WriteString("</tr></thead>\n<tbody>\n")
+	i := start + 2
+	for ; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" || !strings.Contains(lines[i], "|") {
+			break
+		}
+		out.WriteString("<tr>")
+		cells := splitMarkdownTableRow(lines[i])
+		for col := range header {
+			cell := ""
+			if col < len(cells) {
+				cell = cells[col]
+			}
+			out.WriteString("<td>" + renderMarkdownInline(cell) + "</td>")
+		}
+		out.WriteString("</tr>\n")
+	}
+	out.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func splitMarkdownTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// renderMarkdownInline 顺序扫描行内标记，未识别的字符一律转义输出。
+func renderMarkdownInline(text string) string {
+	var out strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|~<>", rune(rest[1])):
+			out.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:ticks]
+			if end := strings.Index(rest[ticks:], fence); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				out.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += ticks + end + ticks
+				continue
+			}
+		case strings.HasPrefix(rest, "!["):
+			if label, target, n, ok := parseMarkdownLink(rest[1:]); ok {
+				if href, safe := sanitizeMarkdownURL(target); safe {
+					out.WriteString(`<img src="` + html.EscapeString(href) + `" alt="` + html.EscapeString(label) + `">`)
+				} else {
+					out.WriteString(html.EscapeString(label))
+				}
+				i += 1 + n
+				continue
+			}
+		case rest[0] == '[':
+			if label, target, n, ok := parseMarkdownLink(rest); ok {
+				inner := renderMarkdownInline(label)
+				if href, safe := sanitizeMarkdownURL(target); safe {
+					out.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener noreferrer">` + inner + `</a>`)
+				} else {
+					out.WriteString(inner)
+				}
+				i += n
+				continue
+			}
+		case rest[0] == '<':
+			if end := strings.IndexByte(rest, '>'); end > 0 {
+				target := rest[1:end]
+				if !strings.ContainsAny(target, " \t") && (strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")) {
+					out.WriteString(`<a href="` + html.EscapeString(target) + `" rel="nofollow noopener noreferrer">` + html.EscapeString(target) + `</a>`)
+					i += end + 1
+					continue
+				}
+			}
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				out.WriteString("<strong>" + renderMarkdownInline(rest[2:2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+		case strings.HasPrefix(rest, "~~"):
+			if end := strings.Index(rest[2:], "~~"); end > 0 {
+				out.WriteString("<del>" + renderMarkdownInline(rest[2:2+end]) + "</del>")
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || rest[0] == '_':
+			// 下划线只在单词边界生效，避免把 snake_case 当成强调
+			boundary := rest[0] == '*' || i == 0 || !isMarkdownWordByte(text[i-1])
+			if end := strings.IndexByte(rest[1:], rest[0]); boundary && end > 0 && rest[1] != ' ' {
+				closeAt := i + 1 + end
+				if rest[0] == '*' || closeAt+1 >= len(text) || !isMarkdownWordByte(text[closeAt+1]) {
+					out.WriteString("<em>" + renderMarkdownInline(rest[1:1+end]) + "</em>")
+					i += end + 2
+					continue
+				}
+			}
+		}
+		out.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+	return out.String()
+}
+
+// parseMarkdownLink 解析 "[label](target)"，返回消耗的字节数。
+func parseMarkdownLink(text string) (label string, target string, n int, ok bool) {
+	depth := 0
+	closeLabel := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closeLabel = i
+			}
+		}
+		if closeLabel >= 0 {
+			break
+		}
+	}
+	if closeLabel < 0 || closeLabel+1 >= len(text) || text[closeLabel+1] != '(' {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(text[closeLabel+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	target = strings.TrimSpace(text[closeLabel+2 : closeLabel+2+end])
+	// 去掉可选的标题部分：[a](url "title")
+	if idx := strings.IndexAny(target, " \t"); idx >= 0 {
+		target = target[:idx]
+	}
+	target = strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	return text[1:closeLabel], target, closeLabel + 3 + end, true
+}
+
+func sanitizeMarkdownURL(raw string) (string, bool) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return "", false
+	}
+	lower := strings.ToLower(value)
+	for _, prefix := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, prefix) {
+			return value, true
+		}
+	}
+	// 没有协议的相对地址；冒号出现在第一个 / ? # 之前意味着带了协议（如 javascript:）
+	colon := strings.IndexByte(value, ':')
+	if colon < 0 {
+		return value, true
+	}
+	if cut := strings.IndexAny(value, "/?#"); cut >= 0 && cut < colon {
+		return value, true
+	}
+	return "", false
+}
+
+func isMarkdownWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	mediaMetadataProbeTimeout  = 30 * time.Second
+	mediaMetadataQueueSize     = 256
+	mediaMetadataErrorMaxRunes = 500
+)
+
+var errMediaMetadataUnsupported = errors.New("item type has no media metadata")
+
+// isMediaMetadataItem 只有视频、音频与图片会提取元数据。
+func isMediaMetadataItem(item store.Item) bool {
+	switch item.Type {
+	case store.ItemTypeVideo, store.ItemTypeAudio, store.ItemTypeImage:
+		return item.Size > 0
+	default:
+		return false
+	}
+}
+
+var mediaMetadataItemTypes = []store.ItemType{store.ItemTypeVideo, store.ItemTypeAudio, store.ItemTypeImage}
+
+// refreshItemMetadata 提取并保存元数据；localPath 为空时通过分块读取。失败结果同样落库，便于回填任务跳过或重试。
+func (s *Server) refreshItemMetadata(ctx context.Context, item store.Item, localPath string) error {
+	if !isMediaMetadataItem(item) {
+		return errMediaMetadataUnsupported
+	}
+
+	meta, err := s.extractItemMetadata(ctx, item, localPath)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		msg := truncateMediaMetadataError(err.Error())
+		meta = store.ItemMetadata{Status: store.ItemMetadataStatusFailed, Error: &msg}
+	}
+	meta.ItemID = item.ID
+	meta.ExtractedAt = time.Now()
+	if upsertErr := store.New(s.db).UpsertItemMetadata(context.WithoutCancel(ctx), meta); upsertErr != nil {
+		if errors.Is(upsertErr, store.ErrNotFound) {
+			return nil
+		}
+		return upsertErr
+	}
+	return err
+}
+
+// recordItemMetadataFromFile 在上传流程仍持有本地文件时顺带提取，失败只记录日志。
+func (s *Server) recordItemMetadataFromFile(ctx context.Context, item store.Item, localPath string) {
+	if s.db == nil || !isMediaMetadataItem(item) {
+		return
+	}
+	probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mediaMetadataProbeTimeout)
+	defer cancel()
+	if err := s.refreshItemMetadata(probeCtx, item, localPath); err != nil {
+		s.logger.Warn("extract item metadata failed", "error", err.Error(), "item_id", item.ID.String())
+	}
+}
+
+// recordUploadedItemMetadata 优先保存发送视频时已探测到的结果，只有没有探测结果时才读取本地文件。
+func (s *Server) recordUploadedItemMetadata(ctx context.Context, item store.Item, process *videoUploadProcessMeta, localPath string) {
+	if process != nil && process.MediaMetadata != nil {
+		s.recordProbedItemMetadata(ctx, item, *process.MediaMetadata)
+		return
+	}
+	s.recordItemMetadataFromFile(ctx, item, localPath)
+}
+
+// recordProbedItemMetadata 直接保存上传流程中已有的 ffprobe 结果，不再重复探测。
+func (s *Server) recordProbedItemMetadata(ctx context.Context, item store.Item, meta store.ItemMetadata) {
+	if s.db == nil || !isMediaMetadataItem(item) {
+		return
+	}
+	meta.ItemID = item.ID
+	meta.Status = store.ItemMetadataStatusOK
+	meta.Error = nil
+	meta.ExtractedAt = time.Now()
+	if err := store.New(s.db).UpsertItemMetadata(context.WithoutCancel(ctx), meta); err != nil && !errors.Is(err, store.ErrNotFound) {
+		s.logger.Warn("save probed item metadata failed", "error", err.Error(), "item_id", item.ID.String())
+	}
+}
+
+// enqueueItemMetadata 把文件交给后台队列通过分块读取提取；队列满或服务关闭时丢弃，由回填任务补齐。
+func (s *Server) enqueueItemMetadata(item store.Item) {
+	if s.db == nil || !isMediaMetadataItem(item) || s.isShuttingDown() {
+		return
+	}
+	s.ensureMediaMetadataWorker()
+	select {
+	case s.metadataQueue <- item:
+	default:
+		s.logger.Warn("item metadata queue full, skip", "item_id", item.ID.String())
+	}
+}
+
+// ensureMediaMetadataWorker 启动唯一的后台提取协程；进入关闭流程后不再处理队列中剩余的文件。
+func (s *Server) ensureMediaMetadataWorker() {
+	s.metadataWorkerOnce.Do(func() {
+		s.metadataQueue = make(chan store.Item, mediaMetadataQueueSize)
+		s.goBackground(func() {
+			for {
+				select {
+				case <-s.shutdownCh:
+					return
+				case item := <-s.metadataQueue:
+					ctx, cancel := context.WithTimeout(s.backgroundCtx, 2*mediaMetadataProbeTimeout)
+					if err := s.refreshItemMetadata(ctx, item, ""); err != nil && !errors.Is(err, context.Canceled) {
+						s.logger.Warn("extract item metadata failed", "error", err.Error(), "item_id", item.ID.String())
+					}
+					cancel()
+				}
+			}
+		})
+	})
+}
+
+func (s *Server) extractItemMetadata(ctx context.Context, item store.Item, localPath string) (store.ItemMetadata, error) {
+	if item.Type == store.ItemTypeImage {
+		return s.extractImageMetadata(ctx, item, localPath)
+	}
+
+	input := localPath
+	if input == "" {
+		sourceURL, release, err := s.openHLSSourceURL(item.ID)
+		if err != nil {
+			return store.ItemMetadata{}, err
+		}
+		defer release()
+		input = sourceURL
+	}
+	return s.probeMediaMetadata(ctx, input)
+}
+
+// extractImageMetadata 只读取文件头部：JPEG 的 Exif 与尺寸通常都在前 256KB 内，其余格式回退 ffprobe。
+func (s *Server) extractImageMetadata(ctx context.Context, item store.Item, localPath string) (store.ItemMetadata, error) {
+	path := localPath
+	if path == "" {
+		tmpPath, err := s.downloadItemPrefixToTempFile(ctx, item.ID, exifSearchMaxBytes)
+		if err != nil {
+			return store.ItemMetadata{}, err
+		}
+		defer os.Remove(tmpPath)
+		path = tmpPath
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return store.ItemMetadata{}, err
+	}
+	head, err := io.ReadAll(io.LimitReader(f, exifSearchMaxBytes))
+	_ = f.Close()
+	if err != nil {
+		return store.ItemMetadata{}, err
+	}
+
+	exif := parseJPEGExifMetadata(head)
+	meta := store.ItemMetadata{
+		CameraMake:   optionalString(exif.CameraMake),
+		CameraModel:  optionalString(exif.CameraModel),
+		TakenAt:      exif.TakenAt,
+		GPSLatitude:  exif.GPSLatitude,
+		GPSLongitude: exif.GPSLongitude,
+	}
+	if rotation := exifOrientationRotation(exif.Orientation); rotation != 0 {
+		meta.Rotation = &rotation
+	}
+
+	cfg, _, cfgErr := image.DecodeConfig(bytes.NewReader(head))
+	if cfgErr == nil && cfg.Width > 0 && cfg.Height > 0 {
+		width, height := cfg.Width, cfg.Height
+		if exif.Orientation >= 5 && exif.Orientation <= exifOrientationMaxSupported {
+			width, height = height, width
+		}
+		meta.Width, meta.Height = &width, &height
+		return meta, nil
+	}
+
+	// WebP/HEIC 等格式交给 ffprobe 读取尺寸；部分下载时仅头部可用，失败则保留 Exif 结果
+	probed, probeErr := s.probeMediaMetadata(ctx, path)
+	if probeErr != nil {
+		if meta.CameraMake != nil || meta.TakenAt != nil {
+			return meta, nil
+		}
+		return store.ItemMetadata{}, probeErr
+	}
+	meta.Width, meta.Height = probed.Width, probed.Height
+	if meta.Rotation == nil {
+		meta.Rotation = probed.Rotation
+	}
+	return meta, nil
+}
+
+func (s *Server) probeMediaMetadata(ctx context.Context, input string) (store.ItemMetadata, error) {
+	ffprobeBinary, err := s.resolveFFprobeBinary()
+	if err != nil {
+		return store.ItemMetadata{}, err
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, mediaMetadataProbeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(
+		probeCtx,
+		ffprobeBinary,
+		"-v", "error",
+		"-show_format",
+		"-show_streams",
+		"-of", "json",
+		input,
+	)
+	raw, runErr := cmd.Output()
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) && len(exitErr.Stderr) > 0 {
+			return store.ItemMetadata{}, fmt.Errorf("ffprobe 失败: %w: %s", runErr, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return store.ItemMetadata{}, runErr
+	}
+	return parseFFprobeMediaMetadata(raw)
+}
+
+type ffprobeMediaOutput struct {
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		CodecName    string            `json:"codec_name"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []map[string]any  `json:"side_data_list"`
+		Disposition  map[string]int    `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		Duration string            `json:"duration"`
+		BitRate  string            `json:"bit_rate"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+func parseFFprobeMediaMetadata(raw []byte) (store.ItemMetadata, error) {
+	var out ffprobeMediaOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return store.ItemMetadata{}, fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	}
+
+	meta := store.ItemMetadata{}
+	for _, stream := range out.Streams {
+		switch stream.CodecType {
+		case "video":
+			// 音频内嵌封面也是 video 流，跳过 attached_pic
+			if meta.VideoCodec != nil || stream.Disposition["attached_pic"] == 1 {
+				continue
+			}
+			meta.VideoCodec = optionalString(strings.ToLower(stream.CodecName))
+			if stream.Width > 0 && stream.Height > 0 {
+				width, height := stream.Width, stream.Height
+				rotation := normalizeRotationDegrees(parseVideoRotation(stream.Tags, stream.SideDataList))
+				if shouldSwapVideoDimensionsByRotation(float64(rotation)) {
+					width, height = height, width
+				}
+				meta.Width, meta.Height = &width, &height
+				if rotation != 0 {
+					meta.Rotation = &rotation
+				}
+			}
+		case "audio":
+			if meta.AudioCodec == nil {
+				meta.AudioCodec = optionalString(strings.ToLower(stream.CodecName))
+			}
+		}
+	}
+
+	if seconds, err := strconv.ParseFloat(strings.TrimSpace(out.Format.Duration), 64); err == nil && seconds > 0 {
+		meta.DurationSeconds = &seconds
+	}
+	if bitRate, err := strconv.ParseInt(strings.TrimSpace(out.Format.BitRate), 10, 64); err == nil && bitRate > 0 {
+		meta.BitRate = &bitRate
+	}
+	tags := lowerCaseKeys(out.Format.Tags)
+	meta.AudioTitle = optionalString(tags["title"])
+	meta.AudioArtist = optionalString(firstNonEmpty(tags["artist"], tags["album_artist"]))
+	meta.AudioAlbum = optionalString(tags["album"])
+	if meta.VideoCodec != nil {
+		// 视频的 title 标签通常是封装器写入的，不当作音频标签
+		meta.AudioTitle, meta.AudioArtist, meta.AudioAlbum = nil, nil, nil
+		if created, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(tags["creation_time"])); err == nil && created.Year() > 1970 {
+			takenAt := created.UTC()
+			meta.TakenAt = &takenAt
+		}
+	}
+
+	if meta.VideoCodec == nil && meta.AudioCodec == nil && meta.DurationSeconds == nil {
+		return store.ItemMetadata{}, errors.New("ffprobe 未返回可用的媒体信息")
+	}
+	return meta, nil
+}
+
+// normalizeRotationDegrees 把 ffprobe 的旋转角（可能为负）换算为 0/90/180/270。
+func normalizeRotationDegrees(rotation float64) int {
+	degrees := int(math.Round(rotation/90)) * 90 % 360
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+func exifOrientationRotation(orientation int) int {
+	switch orientation {
+	case 3, 4:
+		return 180
+	case 5, 6:
+		return 90
+	case 7, 8:
+		return 270
+	default:
+		return 0
+	}
+}
+
+func lowerCaseKeys(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	return out
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func optionalString(value string) *string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+func truncateMediaMetadataError(msg string) string {
+	runes := []rune(strings.TrimSpace(msg))
+	if len(runes) <= mediaMetadataErrorMaxRunes {
+		return string(runes)
+	}
+	return string(runes[:mediaMetadataErrorMaxRunes])
+}
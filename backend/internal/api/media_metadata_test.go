@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/binary"
+	"math"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type exifTestEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+func exifTestASCII(tag uint16, value string) exifTestEntry {
+	data := append([]byte(value), 0)
+	return exifTestEntry{tag: tag, typ: exifTypeASCII, count: uint32(len(data)), data: data}
+}
+
+func exifTestLong(tag uint16, value uint32) exifTestEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, value)
+	return exifTestEntry{tag: tag, typ: 4, count: 1, data: data}
+}
+
+func exifTestRationals(tag uint16, values ...[2]uint32) exifTestEntry {
+	data := make([]byte, 0, len(values)*8)
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, v[0])
+		data = binary.LittleEndian.AppendUint32(data, v[1])
+	}
+	return exifTestEntry{tag: tag, typ: exifTypeRational, count: uint32(len(values)), data: data}
+}
+
+// encodeExifTestIFD 按小端序编码一个 IFD，超过 4 字节的值紧跟在 IFD 之后存放。
+func encodeExifTestIFD(base int, entries []exifTestEntry) []byte {
+	headerLen := 2 + len(entries)*12 + 4
+	out := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	var extra []byte
+	for _, entry := range entries {
+		out = binary.LittleEndian.AppendUint16(out, entry.tag)
+		out = binary.LittleEndian.AppendUint16(out, entry.typ)
+		out = binary.LittleEndian.AppendUint32(out, entry.count)
+		if len(entry.data) <= 4 {
+			value := make([]byte, 4)
+			copy(value, entry.data)
+			out = append(out, value...)
+			continue
+		}
+		out = binary.LittleEndian.AppendUint32(out, uint32(base+headerLen+len(extra)))
+		extra = append(extra, entry.data...)
+	}
+	out = binary.LittleEndian.AppendUint32(out, 0)
+	return append(out, extra...)
+}
+
+func buildExifTestJPEG(t *testing.T) []byte {
+	t.Helper()
+
+	ifd0Entries := func(exifPtr, gpsPtr uint32) []exifTestEntry {
+		return []exifTestEntry{
+			exifTestASCII(exifTagMake, "Canon"),
+			exifTestASCII(exifTagModel, "EOS R5"),
+			{tag: exifOrientationTag, typ: 3, count: 1, data: []byte{6, 0}},
+			exifTestLong(exifTagExifIFDPointer, exifPtr),
+			exifTestLong(exifTagGPSIFDPointer, gpsPtr),
+		}
+	}
+	ifd0 := encodeExifTestIFD(8, ifd0Entries(0, 0))
+	exifBase := 8 + len(ifd0)
+	exifIFD := encodeExifTestIFD(exifBase, []exifTestEntry{
+		exifTestASCII(exifTagDateTimeOriginal, "2024:05:01 08:30:00"),
+		exifTestASCII(exifTagOffsetTimeOriginal, "+08:00"),
+	})
+	gpsBase := exifBase + len(exifIFD)
+	gpsIFD := encodeExifTestIFD(gpsBase, []exifTestEntry{
+		exifTestASCII(exifTagGPSLatitudeRef, "N"),
+		exifTestRationals(exifTagGPSLatitude, [2]uint32{31, 1}, [2]uint32{14, 1}, [2]uint32{24, 1}),
+		exifTestASCII(exifTagGPSLongitudeRef, "W"),
+		exifTestRationals(exifTagGPSLongitude, [2]uint32{121, 1}, [2]uint32{30, 1}, [2]uint32{0, 1}),
+	})
+	ifd0 = encodeExifTestIFD(8, ifd0Entries(uint32(exifBase), uint32(gpsBase)))
+
+	tiff := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	tiff = append(tiff, ifd0...)
+	tiff = append(tiff, exifIFD...)
+	tiff = append(tiff, gpsIFD...)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, 0xFF, 0xD9)
+}
+
+func TestParseJPEGExifMetadata(t *testing.T) {
+	t.Parallel()
+
+	got := parseJPEGExifMetadata(buildExifTestJPEG(t))
+	if got.CameraMake != "Canon" || got.CameraModel != "EOS R5" {
+		t.Fatalf("camera = %q %q", got.CameraMake, got.CameraModel)
+	}
+	if got.Orientation != 6 {
+		t.Fatalf("orientation = %d, want 6", got.Orientation)
+	}
+	wantTaken := time.Date(2024, 5, 1, 0, 30, 0, 0, time.UTC)
+	if got.TakenAt == nil || !got.TakenAt.Equal(wantTaken) {
+		t.Fatalf("takenAt = %v, want %v", got.TakenAt, wantTaken)
+	}
+	if got.GPSLatitude == nil || math.Abs(*got.GPSLatitude-31.24) > 1e-9 {
+		t.Fatalf("latitude = %v, want 31.24", got.GPSLatitude)
+	}
+	if got.GPSLongitude == nil || math.Abs(*got.GPSLongitude+121.5) > 1e-9 {
+		t.Fatalf("longitude = %v, want -121.5", got.GPSLongitude)
+	}
+
+	if empty := parseJPEGExifMetadata([]byte{0xFF, 0xD8, 0xFF, 0xD9}); empty.TakenAt != nil || empty.CameraMake != "" {
+		t.Fatalf("parseJPEGExifMetadata(no exif) = %+v", empty)
+	}
+}
+
+func TestParseFFprobeMediaMetadata(t *testing.T) {
+	t.Parallel()
+
+	video := []byte(`{
+  "streams": [
+    {"codec_type": "video", "codec_name": "H264", "width": 1920, "height": 1080, "side_data_list": [{"rotation": -90}]},
+    {"codec_type": "audio", "codec_name": "aac"}
+  ],
+  "format": {"duration": "12.5", "bit_rate": "800000", "tags": {"title": "clip", "creation_time": "2023-07-01T10:00:00.000000Z"}}
+}`)
+	meta, err := parseFFprobeMediaMetadata(video)
+	if err != nil {
+		t.Fatalf("parseFFprobeMediaMetadata(video) error = %v", err)
+	}
+	if meta.VideoCodec == nil || *meta.VideoCodec != "h264" || meta.AudioCodec == nil || *meta.AudioCodec != "aac" {
+		t.Fatalf("codecs = %v %v", meta.VideoCodec, meta.AudioCodec)
+	}
+	if meta.Width == nil || meta.Height == nil || *meta.Width != 1080 || *meta.Height != 1920 {
+		t.Fatalf("size = %v x %v, want 1080x1920", meta.Width, meta.Height)
+	}
+	if meta.Rotation == nil || *meta.Rotation != 270 {
+		t.Fatalf("rotation = %v, want 270", meta.Rotation)
+	}
+	if meta.DurationSeconds == nil || *meta.DurationSeconds != 12.5 || meta.BitRate == nil || *meta.BitRate != 800000 {
+		t.Fatalf("duration/bitrate = %v %v", meta.DurationSeconds, meta.BitRate)
+	}
+	if meta.AudioTitle != nil {
+		t.Fatalf("video should not keep audio title, got %q", *meta.AudioTitle)
+	}
+	if meta.TakenAt == nil || !meta.TakenAt.Equal(time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("takenAt = %v", meta.TakenAt)
+	}
+
+	audio := []byte(`{
+  "streams": [
+    {"codec_type": "audio", "codec_name": "mp3"},
+    {"codec_type": "video", "codec_name": "mjpeg", "width": 500, "height": 500, "disposition": {"attached_pic": 1}}
+  ],
+  "format": {"duration": "200.0", "tags": {"TITLE": "Song", "album_artist": "Band", "ALBUM": "Record"}}
+}`)
+	meta, err = parseFFprobeMediaMetadata(audio)
+	if err != nil {
+		t.Fatalf("parseFFprobeMediaMetadata(audio) error = %v", err)
+	}
+	if meta.VideoCodec != nil || meta.Width != nil {
+		t.Fatalf("cover art should be ignored, got codec %v width %v", meta.VideoCodec, meta.Width)
+	}
+	if meta.AudioTitle == nil || *meta.AudioTitle != "Song" || meta.AudioArtist == nil || *meta.AudioArtist != "Band" || meta.AudioAlbum == nil || *meta.AudioAlbum != "Record" {
+		t.Fatalf("audio tags = %v %v %v", meta.AudioTitle, meta.AudioArtist, meta.AudioAlbum)
+	}
+
+	if _, err := parseFFprobeMediaMetadata([]byte(`{"streams": [], "format": {}}`)); err == nil {
+		t.Fatalf("parseFFprobeMediaMetadata(empty) should fail")
+	}
+}
+
+func TestNormalizeRotationDegrees(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		in   float64
+		want int
+	}{
+		{in: 0, want: 0},
+		{in: 90, want: 90},
+		{in: -90, want: 270},
+		{in: -180, want: 180},
+		{in: 450, want: 90},
+		{in: 89.6, want: 90},
+	}
+	for _, tc := range cases {
+		if got := normalizeRotationDegrees(tc.in); got != tc.want {
+			t.Fatalf("normalizeRotationDegrees(%v) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseListMetadataFilter(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "空参数", query: ""},
+		{name: "时长为负", query: "minDuration=-1", wantErr: true},
+		{name: "宽度非数字", query: "minWidth=abc", wantErr: true},
+		{name: "日期非法", query: "takenFrom=2024/01/01", wantErr: true},
+		{name: "hasGps 非法", query: "hasGps=maybe", wantErr: true},
+		{name: "完整参数", query: "minDuration=60&maxDuration=600.5&minWidth=1920&minHeight=1080&videoCodec=hevc&camera=Sony&takenFrom=2024-01-01&takenTo=2024-01-31&hasGps=true"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseQuery() error = %v", err)
+			}
+			_, err = parseListMetadataFilter(q)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseListMetadataFilter(%q) error = %v, wantErr %v", tc.query, err, tc.wantErr)
+			}
+		})
+	}
+
+	q, _ := url.ParseQuery("minDuration=60&minWidth=1920&videoCodec=hevc&takenFrom=2024-01-01&takenTo=2024-01-31&hasGps=false")
+	filter, err := parseListMetadataFilter(q)
+	if err != nil {
+		t.Fatalf("parseListMetadataFilter() error = %v", err)
+	}
+	if filter.MinDurationSeconds == nil || *filter.MinDurationSeconds != 60 || filter.MinWidth == nil || *filter.MinWidth != 1920 {
+		t.Fatalf("numeric filters = %+v", filter)
+	}
+	if filter.VideoCodec != "hevc" || filter.HasGPS == nil || *filter.HasGPS {
+		t.Fatalf("codec/gps filters = %+v", filter)
+	}
+	if !filter.TakenFrom.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !filter.TakenTo.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("taken range = %v ~ %v", filter.TakenFrom, filter.TakenTo)
+	}
+}
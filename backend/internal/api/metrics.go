@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/metrics"
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/telegram"
+)
+
+const (
+	metricsRecordedJobsLimit = 4096
+	metricsScrapeTimeout     = 10 * time.Second
+)
+
+var (
+	transferDurationBuckets = []float64{1, 5, 15, 60, 300, 900, 3600, 4 * 3600, 12 * 3600}
+	telegramCallBuckets     = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+	torrentTaskStatuses     = []store.TorrentTaskStatus{
+		store.TorrentTaskStatusQueued,
+		store.TorrentTaskStatusDownloading,
+		store.TorrentTaskStatusAwaitingSelection,
+		store.TorrentTaskStatusUploading,
+		store.TorrentTaskStatusPaused,
+		store.TorrentTaskStatusCompleted,
+		store.TorrentTaskStatusError,
+	}
+)
+
+// serverMetrics 汇总 /metrics 暴露的指标；事件类指标在发生处累加，活跃数、任务数等在抓取时现算。
+// 方法允许 nil 接收者，测试中直接构造的 Server 无需初始化指标。
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	transferJobs      *metrics.CounterVec
+	transferBytes     *metrics.CounterVec
+	transferDurations *metrics.HistogramVec
+
+	telegramCalls          *metrics.CounterVec
+	telegramCallDurations  *metrics.HistogramVec
+	telegramRetryAfter     *metrics.CounterVec
+	telegramRetryAfterSecs *metrics.CounterVec
+
+	thumbnailCacheRequests *metrics.CounterVec
+	thumbnailCacheBytes    *metrics.GaugeVec
+	thumbnailCacheFiles    *metrics.GaugeVec
+
+	uploadSessionCleanupRuns    *metrics.CounterVec
+	uploadSessionCleanupResults *metrics.CounterVec
+
+	// 同一个结束的传输任务可能被多次同步（例如批量上传逐个会话刷新），只统计一次。
+	recordedJobs recentKeySet[uuid.UUID]
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	reg := metrics.NewRegistry()
+	m := &serverMetrics{
+		registry:     reg,
+		recordedJobs: recentKeySet[uuid.UUID]{limit: metricsRecordedJobsLimit},
+		transferJobs: reg.NewCounterVec(
+			"tgcd_transfer_jobs_total",
+			"Finished transfer jobs by direction, source kind and final status.",
+			"direction", "source_kind", "status",
+		),
+		transferBytes: reg.NewCounterVec(
+			"tgcd_transfer_bytes_total",
+			"Bytes of completed transfer jobs by direction and source kind.",
+			"direction", "source_kind",
+		),
+		transferDurations: reg.NewHistogramVec(
+			"tgcd_transfer_duration_seconds",
+			"Wall-clock duration of finished transfer jobs.",
+			transferDurationBuckets,
+			"direction", "source_kind",
+		),
+		telegramCalls: reg.NewCounterVec(
+			"tgcd_telegram_api_calls_total",
+			"Telegram Bot API calls by method and result (ok, error, rate_limited, transport_error).",
+			"method", "result",
+		),
+		telegramCallDurations: reg.NewHistogramVec(
+			"tgcd_telegram_api_call_duration_seconds",
+			"Telegram Bot API call latency by method.",
+			telegramCallBuckets,
+			"method",
+		),
+		telegramRetryAfter: reg.NewCounterVec(
+			"tgcd_telegram_retry_after_total",
+			"Telegram responses asking the client to retry later (HTTP 429).",
+			"method",
+		),
+		telegramRetryAfterSecs: reg.NewCounterVec(
+			"tgcd_telegram_retry_after_seconds_total",
+			"Sum of retry_after seconds requested by Telegram.",
+			"method",
+		),
+		thumbnailCacheRequests: reg.NewCounterVec(
+			"tgcd_thumbnail_cache_requests_total",
+			"Thumbnail requests by cache result (hit, miss).",
+			"result",
+		),
+		thumbnailCacheBytes: reg.NewGaugeVec(
+			"tgcd_thumbnail_cache_bytes",
+			"Thumbnail cache size in bytes after the last cleanup pass.",
+		),
+		thumbnailCacheFiles: reg.NewGaugeVec(
+			"tgcd_thumbnail_cache_files",
+			"Thumbnail cache file count after the last cleanup pass.",
+		),
+		uploadSessionCleanupRuns: reg.NewCounterVec(
+			"tgcd_upload_session_cleanup_runs_total",
+			"Expired upload session cleanup passes.",
+		),
+		uploadSessionCleanupResults: reg.NewCounterVec(
+			"tgcd_upload_session_cleanup_total",
+			"Upload session cleanup results (cleaned, failed, orphan_dir).",
+			"result",
+		),
+	}
+
+	reg.NewGaugeFunc("tgcd_active_uploads", "Uploads currently holding an upload slot.", nil, func(context.Context) ([]metrics.Sample, error) {
+		s.transferMu.Lock()
+		defer s.transferMu.Unlock()
+		return []metrics.Sample{{Value: float64(s.activeUploads)}}, nil
+	})
+	reg.NewGaugeFunc("tgcd_active_downloads", "Downloads currently holding a download slot.", nil, func(context.Context) ([]metrics.Sample, error) {
+		s.transferMu.Lock()
+		defer s.transferMu.Unlock()
+		return []metrics.Sample{{Value: float64(s.activeDownloads)}}, nil
+	})
+	reg.NewGaugeFunc("tgcd_torrent_tasks", "Torrent tasks by status.", []string{"status"}, func(ctx context.Context) ([]metrics.Sample, error) {
+		if s.db == nil {
+			return nil, nil
+		}
+		counts, err := store.New(s.db).CountTorrentTasksByStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		samples := make([]metrics.Sample, 0, len(torrentTaskStatuses))
+		for _, status := range torrentTaskStatuses {
+			samples = append(samples, metrics.Sample{LabelValues: []string{string(status)}, Value: float64(counts[status])})
+		}
+		return samples, nil
+	})
+	return m
+}
+
+// recordFinishedTransferJob 统计结束的传输任务；字节数只计入成功完成的任务。
+func (m *serverMetrics) recordFinishedTransferJob(job store.TransferJob) {
+	if m == nil || job.Status == store.TransferJobStatusRunning || !m.markJobRecorded(job.ID) {
+		return
+	}
+	direction, sourceKind := string(job.Direction), string(job.SourceKind)
+	m.transferJobs.Inc(direction, sourceKind, string(job.Status))
+	if job.Status == store.TransferJobStatusCompleted && job.TotalSize > 0 {
+		m.transferBytes.Add(float64(job.TotalSize), direction, sourceKind)
+	}
+	if !job.StartedAt.IsZero() && job.FinishedAt.After(job.StartedAt) {
+		m.transferDurations.Observe(job.FinishedAt.Sub(job.StartedAt).Seconds(), direction, sourceKind)
+	}
+}
+
+func (m *serverMetrics) markJobRecorded(id uuid.UUID) bool {
+	return m.recordedJobs.add(id)
+}
+
+func (m *serverMetrics) observeTelegramCall(stats telegram.CallStats) {
+	if m == nil {
+		return
+	}
+	method := stats.Method
+	result := "ok"
+	switch {
+	case stats.Err != nil:
+		result = "transport_error"
+	case stats.ErrorCode == http.StatusTooManyRequests:
+		result = "rate_limited"
+		m.telegramRetryAfter.Inc(method)
+		m.telegramRetryAfterSecs.Add(stats.RetryAfter.Seconds(), method)
+	case !stats.OK:
+		result = "error"
+	}
+	m.telegramCalls.Inc(method, result)
+	m.telegramCallDurations.Observe(stats.Duration.Seconds(), method)
+}
+
+func (m *serverMetrics) recordThumbnailCacheLookup(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.thumbnailCacheRequests.Inc("hit")
+		return
+	}
+	m.thumbnailCacheRequests.Inc("miss")
+}
+
+func (m *serverMetrics) setThumbnailCacheUsage(bytes int64, files int) {
+	if m == nil {
+		return
+	}
+	m.thumbnailCacheBytes.Set(float64(bytes))
+	m.thumbnailCacheFiles.Set(float64(files))
+}
+
+func (m *serverMetrics) recordUploadSessionCleanup(cleaned int, failed int, orphanDirs int) {
+	if m == nil {
+		return
+	}
+	m.uploadSessionCleanupRuns.Inc()
+	m.uploadSessionCleanupResults.Add(float64(cleaned), "cleaned")
+	m.uploadSessionCleanupResults.Add(float64(failed), "failed")
+	m.uploadSessionCleanupResults.Add(float64(orphanDirs), "orphan_dir")
+}
+
+// telegramClientOptions 返回主 Telegram 客户端的公共选项（目前只有调用统计）。
+func (s *Server) telegramClientOptions() []telegram.ClientOption {
+	if s.metrics == nil {
+		return nil
+	}
+	return []telegram.ClientOption{telegram.WithCallObserver(s.metrics.observeTelegramCall)}
+}
+
+// handleMetrics 以 Prometheus 文本格式输出指标；未配置 METRICS_TOKEN 时视为未启用。
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(s.cfg.MetricsToken)
+	if token == "" {
+		writeError(w, http.StatusNotFound, "not_found", "指标接口未启用")
+		return
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+		writeError(w, http.StatusUnauthorized, "unauthorized", "指标令牌无效")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), metricsScrapeTimeout)
+	defer cancel()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if s.metrics == nil {
+		return
+	}
+	if err := s.metrics.registry.WriteText(ctx, w); err != nil {
+		s.logger.Warn("write metrics failed", "error", err.Error())
+	}
+}
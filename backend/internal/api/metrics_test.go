@@ -0,0 +1,89 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/config"
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/telegram"
+)
+
+func TestHandleMetrics_RequiresToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		configToken   string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "disabled", configToken: "", authorization: "Bearer secret", wantStatus: http.StatusNotFound},
+		{name: "missing", configToken: "secret", authorization: "", wantStatus: http.StatusUnauthorized},
+		{name: "wrong", configToken: "secret", authorization: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "ok", configToken: "secret", authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := &Server{logger: slog.Default(), cfg: config.Config{MetricsToken: tc.configToken}}
+			s.metrics = newServerMetrics(s)
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			s.handleMetrics(rec, req)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusOK && !strings.Contains(rec.Body.String(), "tgcd_active_uploads 0\n") {
+				t.Fatalf("body = %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerMetrics_RecordsEachFinishedTransferJobOnce(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{logger: slog.Default(), cfg: config.Config{MetricsToken: "secret"}}
+	s.metrics = newServerMetrics(s)
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	job := store.TransferJob{
+		ID:         uuid.New(),
+		Direction:  store.TransferDirectionUpload,
+		SourceKind: store.TransferSourceKindUploadSession,
+		TotalSize:  2048,
+		Status:     store.TransferJobStatusCompleted,
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(30 * time.Second),
+	}
+	s.metrics.recordFinishedTransferJob(job)
+	s.metrics.recordFinishedTransferJob(job)
+	s.metrics.observeTelegramCall(telegram.CallStats{Method: "sendDocument", ErrorCode: 429, RetryAfter: 3 * time.Second})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.handleMetrics(rec, req)
+	body := rec.Body.String()
+	for _, want := range []string{
+		`tgcd_transfer_jobs_total{direction="upload",source_kind="upload_session",status="completed"} 1`,
+		`tgcd_transfer_bytes_total{direction="upload",source_kind="upload_session"} 2048`,
+		`tgcd_transfer_duration_seconds_sum{direction="upload",source_kind="upload_session"} 30`,
+		`tgcd_telegram_api_calls_total{method="sendDocument",result="rate_limited"} 1`,
+		`tgcd_telegram_retry_after_seconds_total{method="sendDocument"} 3`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Fatalf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
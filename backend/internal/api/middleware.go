@@ -9,6 +9,9 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/tracing"
 )
 
 func recoverMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
@@ -39,17 +42,57 @@ func requestLogMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 			rr := &responseRecorder{ResponseWriter: w, status: 200}
 			next.ServeHTTP(rr, r)
 
-			logger.Info("request",
+			attrs := []any{
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.Int("status", rr.status),
 				slog.Int("bytes", rr.bytes),
 				slog.Duration("duration", time.Since(start)),
-			)
+			}
+			if traceID := tracing.TraceIDFromContext(r.Context()); traceID != "" {
+				attrs = append(attrs, slog.String("trace_id", traceID))
+			}
+			logger.Info("request", attrs...)
 		})
 	}
 }
 
+// tracingMiddleware 为每个请求创建服务端 span，并接续请求头中的 traceparent；
+// span 名在路由完成后改为 chi 路由模板，避免把 ID 等路径参数带进 span 名。
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !tracing.Enabled() || r.URL.Path == "/healthz" || r.URL.Path == "/metrics" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, span := tracing.Start(
+			tracing.Extract(r.Context(), r.Header),
+			tracing.KindServer,
+			r.Method,
+			tracing.String("http.request.method", r.Method),
+			tracing.String("url.path", r.URL.Path),
+		)
+		defer span.End()
+
+		rr := &responseRecorder{ResponseWriter: w, status: 200}
+		next.ServeHTTP(rr, r.WithContext(ctx))
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(tracing.String("http.route", pattern))
+			}
+		}
+		span.SetAttributes(
+			tracing.Int("http.response.status_code", rr.status),
+			tracing.Int("http.response.body.size", rr.bytes),
+		)
+		if rr.status >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(rr.status))
+		}
+	})
+}
+
 type responseRecorder struct {
 	http.ResponseWriter
 	status int
@@ -75,3 +75,48 @@ func shouldInlinePreviewDownload(itemType store.ItemType, mimeType string, force
 	}
 	return isPreviewableItemType(itemType)
 }
+
+// textLanguageByExtension 用于文本预览的语法高亮提示，取值与常见高亮库的语言名保持一致。
+var textLanguageByExtension = map[string]string{
+	".txt": "plaintext", ".log": "plaintext", ".text": "plaintext",
+	".md": "markdown", ".markdown": "markdown",
+	".go": "go", ".py": "python", ".rb": "ruby", ".php": "php", ".java": "java", ".kt": "kotlin",
+	".rs": "rust", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp",
+	".swift": "swift", ".scala": "scala", ".lua": "lua", ".r": "r", ".dart": "dart",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".tsx": "typescript", ".vue": "vue",
+	".html": "html", ".htm": "html", ".css": "css", ".scss": "scss", ".less": "less",
+	".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".xml": "xml",
+	".ini": "ini", ".conf": "ini", ".cfg": "ini", ".env": "ini", ".properties": "properties",
+	".sh": "bash", ".bash": "bash", ".zsh": "bash", ".ps1": "powershell", ".bat": "bat",
+	".sql": "sql", ".csv": "csv", ".tsv": "csv", ".srt": "plaintext", ".vtt": "plaintext", ".ass": "plaintext",
+}
+
+var textLanguageByFileName = map[string]string{
+	"dockerfile": "dockerfile",
+	"makefile":   "makefile",
+	"go.mod":     "go",
+	"readme":     "markdown",
+}
+
+// inferTextLanguage 优先按扩展名判断，再按特殊文件名，最后参考 MIME。
+func inferTextLanguage(fileName string, mimeType string) string {
+	ext := strings.ToLower(strings.TrimSpace(filepath.Ext(fileName)))
+	if language, ok := textLanguageByExtension[ext]; ok {
+		return language
+	}
+	if language, ok := textLanguageByFileName[strings.ToLower(strings.TrimSpace(filepath.Base(fileName)))]; ok {
+		return language
+	}
+	switch normalizeMimeType(mimeType) {
+	case "text/markdown":
+		return "markdown"
+	case "application/json":
+		return "json"
+	case "text/html":
+		return "html"
+	case "application/xml", "text/xml":
+		return "xml"
+	}
+	return "plaintext"
+}
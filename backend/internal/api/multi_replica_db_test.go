@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"tg-cloud-drive-api/internal/db"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// openTestDB 连接 TGCD_TEST_DATABASE_URL 指向的空库并执行迁移；未设置时跳过依赖数据库的测试。
+func openTestDB(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	dsn := strings.TrimSpace(os.Getenv("TGCD_TEST_DATABASE_URL"))
+	if dsn == "" {
+		t.Skip("TGCD_TEST_DATABASE_URL 未设置")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	if err := db.Migrate(ctx, pool); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return pool
+}
+
+// 两个副本共享同一数据库，advisory lock 保证同一时刻只有一个主副本，主副本退出后另一个接管。
+func TestLeaderElectionSingleLeaderAndHandover(t *testing.T) {
+	pool := openTestDB(t)
+	a, err := NewServer(ServerDeps{DB: pool})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	b, err := NewServer(ServerDeps{DB: pool})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(a.resignLeadership)
+	t.Cleanup(b.resignLeadership)
+
+	a.leaderConn = a.runLeaderElectionRound(nil)
+	b.leaderConn = b.runLeaderElectionRound(nil)
+	if !a.isLeader() || a.leaderConn == nil {
+		t.Fatalf("first replica should acquire leadership")
+	}
+	if b.isLeader() || b.leaderConn != nil {
+		t.Fatalf("second replica must not become leader while the lock is held")
+	}
+
+	// 持锁期间的探活轮次保持原连接
+	conn := a.leaderConn
+	if a.leaderConn = a.runLeaderElectionRound(a.leaderConn); a.leaderConn != conn || !a.isLeader() {
+		t.Fatalf("leader should keep its connection while it is healthy")
+	}
+
+	a.resignLeadership()
+	if a.isLeader() {
+		t.Fatalf("isLeader() = true after resign")
+	}
+	b.leaderConn = b.runLeaderElectionRound(b.leaderConn)
+	if !b.isLeader() {
+		t.Fatalf("second replica should take over after the leader resigns")
+	}
+}
+
+// 接管过期租约后，原持有者续期返回 ErrNotFound，由续期循环据此取消其上传。
+func TestUploadChunkLockContentionAndTakeover(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	st := store.New(pool)
+
+	now := time.Now()
+	itemID := uuid.New()
+	if err := st.InsertItemRaw(ctx, store.InsertItemRawInput{
+		ID:        itemID,
+		Type:      store.ItemTypeOther,
+		Name:      "lock-test-" + itemID.String(),
+		Path:      "/lock-test-" + itemID.String(),
+		Size:      2,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		t.Fatalf("InsertItemRaw: %v", err)
+	}
+	sessionID := uuid.New()
+	if err := st.CreateUploadSession(ctx, store.UploadSession{
+		ID:           sessionID,
+		ItemID:       itemID,
+		FileName:     "lock-test.bin",
+		FileSize:     2,
+		ChunkSize:    1,
+		TotalChunks:  2,
+		AccessMethod: setupAccessMethodOfficial,
+		UploadMode:   store.UploadSessionModeDirectChunk,
+		Status:       store.UploadSessionStatusUploading,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}); err != nil {
+		t.Fatalf("CreateUploadSession: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM items WHERE id = $1`, itemID)
+	})
+
+	first, second := uuid.New(), uuid.New()
+	if ok, err := st.TryAcquireUploadChunkLock(ctx, sessionID, 0, first, time.Minute); err != nil || !ok {
+		t.Fatalf("first acquire = %v, %v", ok, err)
+	}
+	if ok, err := st.TryAcquireUploadChunkLock(ctx, sessionID, 0, second, time.Minute); err != nil || ok {
+		t.Fatalf("acquire of a held lock = %v, %v; want false", ok, err)
+	}
+	if ok, err := st.TryAcquireUploadChunkLock(ctx, sessionID, 1, second, time.Minute); err != nil || !ok {
+		t.Fatalf("other chunk acquire = %v, %v", ok, err)
+	}
+	if err := st.ExtendUploadChunkLock(ctx, sessionID, 0, first, time.Minute); err != nil {
+		t.Fatalf("holder extend: %v", err)
+	}
+
+	// 让租约立即过期，模拟持有者续期中断
+	if err := st.ExtendUploadChunkLock(ctx, sessionID, 0, first, -time.Second); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	if ok, err := st.TryAcquireUploadChunkLock(ctx, sessionID, 0, second, time.Minute); err != nil || !ok {
+		t.Fatalf("takeover of an expired lock = %v, %v", ok, err)
+	}
+	if err := st.ExtendUploadChunkLock(ctx, sessionID, 0, first, time.Minute); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("extend by previous holder err = %v, want ErrNotFound", err)
+	}
+
+	// 原持有者释放不能删除接管者的锁
+	if err := st.ReleaseUploadChunkLock(ctx, sessionID, 0, first); err != nil {
+		t.Fatalf("release by previous holder: %v", err)
+	}
+	if ok, err := st.TryAcquireUploadChunkLock(ctx, sessionID, 0, first, time.Minute); err != nil || ok {
+		t.Fatalf("lock should still belong to the new holder: %v, %v", ok, err)
+	}
+}
+
+// 其他副本的删除请求记录在任务行上：执行副本的心跳读到请求，任务结束后由执行副本删除任务记录。
+func TestArchiveJobDeleteRequestedByAnotherReplica(t *testing.T) {
+	pool := openTestDB(t)
+	ctx := context.Background()
+	owner, err := NewServer(ServerDeps{DB: pool})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	st := store.New(pool)
+
+	now := time.Now()
+	job := store.TransferJob{
+		ID:         uuid.New(),
+		Direction:  store.TransferDirectionUpload,
+		SourceKind: store.TransferSourceKindArchiveCreate,
+		SourceRef:  uuid.NewString(),
+		UnitKind:   store.TransferUnitKindFile,
+		Name:       "remote-cancel.zip",
+		ItemCount:  1,
+		Status:     store.TransferJobStatusRunning,
+		StartedAt:  now,
+		FinishedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := st.CreateTransferJob(ctx, job); err != nil {
+		t.Fatalf("CreateTransferJob: %v", err)
+	}
+	t.Cleanup(func() { _ = st.DeleteTransferJobByID(context.Background(), job.ID) })
+
+	jobCtx, cancel := context.WithCancel(context.Background())
+	owner.registerArchiveJob(job.ID, cancel)
+
+	if err := st.RequestTransferJobCancel(ctx, job.ID, true, time.Now()); err != nil {
+		t.Fatalf("RequestTransferJobCancel: %v", err)
+	}
+	req, err := st.TouchRunningTransferJob(ctx, job.ID, time.Now())
+	if err != nil {
+		t.Fatalf("TouchRunningTransferJob: %v", err)
+	}
+	if !req.Requested || !req.Delete {
+		t.Fatalf("cancel request = %+v, want requested delete", req)
+	}
+	if jobCtx.Err() != nil {
+		t.Fatalf("job should only be canceled by its own heartbeat")
+	}
+
+	// 模拟执行副本在取消后结束任务
+	owner.updateArchiveJobProgress(st, job.ID, 0, 0, 1, store.TransferJobStatusCanceled, nil)
+	owner.finishArchiveJob(job.ID)
+	if jobCtx.Err() == nil {
+		t.Fatalf("finishArchiveJob should cancel the job context")
+	}
+	if _, err := st.GetTransferJobByID(ctx, job.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("job should be deleted after the requested cancel, err = %v", err)
+	}
+	if err := st.RequestTransferJobCancel(ctx, job.ID, true, time.Now()); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("cancel request on a missing job err = %v, want ErrNotFound", err)
+	}
+}
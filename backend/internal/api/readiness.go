@@ -0,0 +1,377 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"tg-cloud-drive-api/internal/db"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	readinessStatusOK          = "ok"
+	readinessStatusDegraded    = "degraded"
+	readinessStatusSkipped     = "skipped"
+	readinessStatusUnavailable = "unavailable"
+)
+
+const (
+	readinessCheckTimeout      = 5 * time.Second
+	readinessTelegramOKTTL     = time.Minute
+	readinessTelegramFailedTTL = 15 * time.Second
+	loopHeartbeatGrace         = time.Minute
+)
+
+const (
+	loopUploadSessionCleanup = "upload_session_cleanup"
+	loopThumbnailCacheClean  = "thumbnail_cache_cleanup"
+	loopHLSCacheCleanup      = "hls_cache_cleanup"
+	loopTorrentWorker        = "torrent_worker"
+	loopTelegramDeleteRetry  = "telegram_delete_retry"
+	loopAuditLogRetention    = "audit_log_retention"
+	loopWebhookDelivery      = "webhook_delivery"
+	loopAdminNotify          = "admin_notify"
+	loopLeaderElection       = "leader_election"
+	loopArchiveJobReaper     = "archive_job_reaper"
+	loopTransferFanout       = "transfer_fanout"
+
+	loopTorrentPublicationCleanup = "torrent_publication_cleanup"
+)
+
+type readinessCheckDTO struct {
+	Status    string         `json:"status"`
+	Critical  bool           `json:"critical"`
+	Message   string         `json:"message,omitempty"`
+	LatencyMs int64          `json:"latencyMs"`
+	CheckedAt time.Time      `json:"checkedAt"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type readinessResponse struct {
+	Status    string                       `json:"status"`
+	CheckedAt time.Time                    `json:"checkedAt"`
+	Checks    map[string]readinessCheckDTO `json:"checks"`
+}
+
+// backgroundLoopSpec 描述一个后台循环的预期心跳间隔；interval 返回 0 表示单轮耗时不可预估，不做超时判断。
+type backgroundLoopSpec struct {
+	Name     string
+	Interval func(settings store.RuntimeSettings) time.Duration
+}
+
+func fixedLoopInterval(d time.Duration) func(store.RuntimeSettings) time.Duration {
+	return func(store.RuntimeSettings) time.Duration { return d }
+}
+
+func (s *Server) backgroundLoopSpecs() []backgroundLoopSpec {
+	specs := []backgroundLoopSpec{
+		{Name: loopUploadSessionCleanup, Interval: func(settings store.RuntimeSettings) time.Duration {
+			return uploadSessionCleanupIntervalFromMins(settings.UploadSessionCleanupIntervalMins)
+		}},
+		{Name: loopThumbnailCacheClean, Interval: fixedLoopInterval(thumbnailCacheCleanupInterval)},
+		{Name: loopHLSCacheCleanup, Interval: fixedLoopInterval(hlsCacheCleanupInterval)},
+		{Name: loopTelegramDeleteRetry, Interval: fixedLoopInterval(telegramDeleteRetryInterval)},
+		{Name: loopAuditLogRetention, Interval: fixedLoopInterval(auditLogRetentionInterval)},
+		{Name: loopWebhookDelivery, Interval: fixedLoopInterval(webhookDeliveryInterval)},
+		{Name: loopAdminNotify, Interval: fixedLoopInterval(adminNotifyFlushInterval)},
+		{Name: loopArchiveJobReaper, Interval: fixedLoopInterval(archiveJobReapInterval)},
+		{Name: loopTorrentPublicationCleanup, Interval: fixedLoopInterval(torrentPublicationCleanupInterval)},
+	}
+	if s.db != nil {
+		specs = append(specs,
+			backgroundLoopSpec{Name: loopLeaderElection, Interval: fixedLoopInterval(leaderElectionInterval)},
+			backgroundLoopSpec{Name: loopTransferFanout, Interval: fixedLoopInterval(transferFanoutListenTimeout)},
+		)
+	}
+	if s.cfg.TorrentEnabled {
+		// 种子任务在一轮循环内同步下载/上传，耗时取决于任务大小，只记录心跳不判断超时。
+		specs = append(specs, backgroundLoopSpec{Name: loopTorrentWorker, Interval: fixedLoopInterval(0)})
+	}
+	return specs
+}
+
+// markLoopHeartbeat 由后台循环在每轮开始时调用，供 /readyz 判断循环是否卡住。
+func (s *Server) markLoopHeartbeat(name string) {
+	s.loopHeartbeatsMu.Lock()
+	s.loopHeartbeats[name] = time.Now()
+	s.loopHeartbeatsMu.Unlock()
+}
+
+func (s *Server) loopHeartbeatSnapshot() map[string]time.Time {
+	s.loopHeartbeatsMu.Lock()
+	defer s.loopHeartbeatsMu.Unlock()
+	out := make(map[string]time.Time, len(s.loopHeartbeats))
+	for name, at := range s.loopHeartbeats {
+		out[name] = at
+	}
+	return out
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	resp := s.runReadinessChecks(r.Context())
+	status := http.StatusOK
+	if resp.Status == readinessStatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, resp)
+}
+
+// runReadinessChecks 并发执行各组件检查；任一关键组件异常时整体为 unavailable，仅非关键组件异常时为 degraded。
+func (s *Server) runReadinessChecks(ctx context.Context) readinessResponse {
+	settings := s.defaultRuntimeSettings()
+	if s.db != nil && s.isSystemInitialized() {
+		settingsCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+		if loaded, err := s.getRuntimeSettings(settingsCtx); err == nil {
+			settings = loaded
+		}
+		cancel()
+	}
+
+	checks := map[string]func(context.Context) readinessCheckDTO{
+		"database":    s.checkDatabaseReadiness,
+		"telegram":    s.checkTelegramReadiness,
+		"qbittorrent": s.checkQBittorrentReadiness,
+		"loops": func(context.Context) readinessCheckDTO {
+			return s.checkBackgroundLoopsReadiness(settings, time.Now())
+		},
+	}
+	for name, dir := range s.readinessDirs() {
+		dir := dir
+		checks["disk."+name] = func(context.Context) readinessCheckDTO {
+			return checkReadinessDir(dir.Path, settings.ReservedDiskBytes, dir.Critical)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]readinessCheckDTO, len(checks))
+	)
+	for name, check := range checks {
+		name, check := name, check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+			result := check(checkCtx)
+			mu.Lock()
+			results[name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return readinessResponse{
+		Status:    summarizeReadiness(results),
+		CheckedAt: time.Now(),
+		Checks:    results,
+	}
+}
+
+func summarizeReadiness(checks map[string]readinessCheckDTO) string {
+	status := readinessStatusOK
+	for _, check := range checks {
+		if check.Status != readinessStatusDegraded {
+			continue
+		}
+		if check.Critical {
+			return readinessStatusUnavailable
+		}
+		status = readinessStatusDegraded
+	}
+	return status
+}
+
+func newReadinessCheck(critical bool, startedAt time.Time, err error) readinessCheckDTO {
+	check := readinessCheckDTO{
+		Status:    readinessStatusOK,
+		Critical:  critical,
+		LatencyMs: time.Since(startedAt).Milliseconds(),
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		check.Status = readinessStatusDegraded
+		check.Message = err.Error()
+	}
+	return check
+}
+
+func skippedReadinessCheck(critical bool, message string) readinessCheckDTO {
+	return readinessCheckDTO{
+		Status:    readinessStatusSkipped,
+		Critical:  critical,
+		Message:   message,
+		CheckedAt: time.Now(),
+	}
+}
+
+func (s *Server) checkDatabaseReadiness(ctx context.Context) readinessCheckDTO {
+	startedAt := time.Now()
+	if s.db == nil {
+		return newReadinessCheck(true, startedAt, errors.New("未配置数据库连接"))
+	}
+	if err := s.db.Ping(ctx); err != nil {
+		return newReadinessCheck(true, startedAt, fmt.Errorf("数据库连接失败：%w", err))
+	}
+	pending, err := db.PendingMigrations(ctx, s.db)
+	if err != nil {
+		return newReadinessCheck(true, startedAt, err)
+	}
+	if len(pending) > 0 {
+		check := newReadinessCheck(true, startedAt, fmt.Errorf("存在 %d 个未执行的数据库迁移", len(pending)))
+		check.Details = map[string]any{"pendingMigrations": pending}
+		return check
+	}
+	return newReadinessCheck(true, startedAt, nil)
+}
+
+// checkTelegramReadiness 调用 SelfCheck（含 GetMe 与存储频道权限校验）；结果会缓存，避免探针频繁请求 Bot API。
+func (s *Server) checkTelegramReadiness(ctx context.Context) readinessCheckDTO {
+	if !s.isSystemInitialized() {
+		return skippedReadinessCheck(true, "系统尚未初始化")
+	}
+
+	s.readinessTelegramMu.Lock()
+	defer s.readinessTelegramMu.Unlock()
+
+	if cached := s.readinessTelegram; cached != nil {
+		ttl := readinessTelegramOKTTL
+		if cached.Status != readinessStatusOK {
+			ttl = readinessTelegramFailedTTL
+		}
+		if time.Since(cached.CheckedAt) < ttl {
+			return *cached
+		}
+	}
+
+	startedAt := time.Now()
+	tgClient, err := s.requireTelegramClient()
+	if err == nil {
+		err = tgClient.SelfCheck(ctx, s.cfg.TGStorageChatID)
+	}
+	check := newReadinessCheck(true, startedAt, err)
+	s.readinessTelegram = &check
+	return check
+}
+
+func (s *Server) checkQBittorrentReadiness(ctx context.Context) readinessCheckDTO {
+	if !s.cfg.TorrentEnabled {
+		return skippedReadinessCheck(false, "种子下载未启用")
+	}
+	if !s.isSystemInitialized() {
+		return skippedReadinessCheck(false, "系统尚未初始化")
+	}
+	startedAt := time.Now()
+	qbt, err := s.newQBittorrentClient(ctx)
+	if err == nil {
+		err = qbt.Authenticate(ctx)
+	}
+	if err != nil {
+		err = fmt.Errorf("qBittorrent 不可用：%w", err)
+	}
+	return newReadinessCheck(false, startedAt, err)
+}
+
+type readinessDir struct {
+	Path     string
+	Critical bool
+}
+
+func (s *Server) readinessDirs() map[string]readinessDir {
+	dirs := map[string]readinessDir{
+		"temp":      {Path: os.TempDir(), Critical: true},
+		"thumbnail": {Path: s.thumbnailCacheDir()},
+	}
+	if s.cfg.TorrentEnabled {
+		dirs["torrentWork"] = readinessDir{Path: s.cfg.TorrentWorkDir}
+		dirs["torrentDownload"] = readinessDir{Path: s.cfg.TorrentDownloadDir}
+	}
+	return dirs
+}
+
+// checkReadinessDir 确认目录可写，且可用空间不低于预留空间。
+func checkReadinessDir(path string, reservedBytes int64, critical bool) readinessCheckDTO {
+	startedAt := time.Now()
+	details := map[string]any{"path": path, "reservedBytes": reservedBytes}
+	finish := func(err error) readinessCheckDTO {
+		check := newReadinessCheck(critical, startedAt, err)
+		check.Details = details
+		return check
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return finish(errors.New("目录未配置"))
+	}
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return finish(fmt.Errorf("创建目录失败：%w", err))
+	}
+	f, err := os.CreateTemp(path, ".tgcd-readyz-*")
+	if err != nil {
+		return finish(fmt.Errorf("目录不可写：%w", err))
+	}
+	_, writeErr := f.Write([]byte("ok"))
+	closeErr := f.Close()
+	_ = os.Remove(f.Name())
+	if writeErr != nil || closeErr != nil {
+		return finish(fmt.Errorf("目录不可写：%w", errors.Join(writeErr, closeErr)))
+	}
+
+	freeBytes, err := getAvailableDiskBytes(path)
+	if err != nil {
+		return finish(fmt.Errorf("读取可用空间失败：%w", err))
+	}
+	details["freeBytes"] = freeBytes
+	if reservedBytes > 0 && freeBytes < reservedBytes {
+		return finish(fmt.Errorf("可用空间 %s 低于预留值 %s", formatNotifyBytes(freeBytes), formatNotifyBytes(reservedBytes)))
+	}
+	return finish(nil)
+}
+
+func (s *Server) checkBackgroundLoopsReadiness(settings store.RuntimeSettings, now time.Time) readinessCheckDTO {
+	if !s.loopsStarted.Load() {
+		return skippedReadinessCheck(false, "后台任务尚未启动（系统尚未初始化）")
+	}
+	return evaluateLoopHeartbeats(s.backgroundLoopSpecs(), s.loopHeartbeatSnapshot(), settings, now)
+}
+
+// evaluateLoopHeartbeats 超过两个周期（另加宽限）没有心跳的循环视为卡住。
+func evaluateLoopHeartbeats(specs []backgroundLoopSpec, beats map[string]time.Time, settings store.RuntimeSettings, now time.Time) readinessCheckDTO {
+	details := make(map[string]any, len(specs))
+	var stalled []string
+	for _, spec := range specs {
+		entry := map[string]any{}
+		last, ok := beats[spec.Name]
+		if ok {
+			entry["lastHeartbeatAt"] = last
+		}
+		if interval := spec.Interval(settings); interval > 0 {
+			maxSilence := 2*interval + loopHeartbeatGrace
+			entry["maxSilenceSeconds"] = int64(maxSilence / time.Second)
+			// 循环启动后会立即打一次心跳，从未出现心跳说明启动失败。
+			if !ok || now.Sub(last) > maxSilence {
+				entry["stalled"] = true
+				stalled = append(stalled, spec.Name)
+			}
+		}
+		details[spec.Name] = entry
+	}
+
+	check := readinessCheckDTO{
+		Status:    readinessStatusOK,
+		CheckedAt: now,
+		Details:   details,
+	}
+	if len(stalled) > 0 {
+		check.Status = readinessStatusDegraded
+		check.Message = "后台任务无心跳：" + strings.Join(stalled, ", ")
+	}
+	return check
+}
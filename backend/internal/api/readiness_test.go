@@ -0,0 +1,151 @@
+package api
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestSummarizeReadiness(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		checks map[string]readinessCheckDTO
+		want   string
+	}{
+		{
+			name: "all ok or skipped",
+			checks: map[string]readinessCheckDTO{
+				"database": {Status: readinessStatusOK, Critical: true},
+				"telegram": {Status: readinessStatusSkipped, Critical: true},
+			},
+			want: readinessStatusOK,
+		},
+		{
+			name: "non critical degraded",
+			checks: map[string]readinessCheckDTO{
+				"database":    {Status: readinessStatusOK, Critical: true},
+				"qbittorrent": {Status: readinessStatusDegraded},
+			},
+			want: readinessStatusDegraded,
+		},
+		{
+			name: "critical degraded",
+			checks: map[string]readinessCheckDTO{
+				"database":    {Status: readinessStatusDegraded, Critical: true},
+				"qbittorrent": {Status: readinessStatusDegraded},
+			},
+			want: readinessStatusUnavailable,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := summarizeReadiness(tc.checks); got != tc.want {
+				t.Fatalf("summarizeReadiness() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckReadinessDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	blocker := filepath.Join(root, "file")
+	if err := os.WriteFile(blocker, []byte("x"), 0o644); err != nil {
+		t.Fatalf("write blocker: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		reserved int64
+		want     string
+	}{
+		{name: "writable", path: filepath.Join(root, "cache"), want: readinessStatusOK},
+		{name: "reserve not met", path: root, reserved: math.MaxInt64, want: readinessStatusDegraded},
+		{name: "not a directory", path: filepath.Join(blocker, "sub"), want: readinessStatusDegraded},
+		{name: "empty path", path: " ", want: readinessStatusDegraded},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := checkReadinessDir(tc.path, tc.reserved, true)
+			if got.Status != tc.want || !got.Critical {
+				t.Fatalf("checkReadinessDir(%q) = %+v, want status %q", tc.path, got, tc.want)
+			}
+			if tc.want == readinessStatusOK {
+				entries, err := os.ReadDir(tc.path)
+				if err != nil || len(entries) != 0 {
+					t.Fatalf("probe file should be removed, entries=%v err=%v", entries, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateLoopHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	specs := []backgroundLoopSpec{
+		{Name: "fast", Interval: fixedLoopInterval(time.Minute)},
+		{Name: "settings", Interval: func(settings store.RuntimeSettings) time.Duration {
+			return uploadSessionCleanupIntervalFromMins(settings.UploadSessionCleanupIntervalMins)
+		}},
+		{Name: "unbounded", Interval: fixedLoopInterval(0)},
+	}
+	settings := store.RuntimeSettings{UploadSessionCleanupIntervalMins: 60}
+
+	tests := []struct {
+		name        string
+		beats       map[string]time.Time
+		wantStatus  string
+		wantStalled []string
+	}{
+		{
+			name: "all fresh",
+			beats: map[string]time.Time{
+				"fast":     now.Add(-2 * time.Minute),
+				"settings": now.Add(-90 * time.Minute),
+			},
+			wantStatus: readinessStatusOK,
+		},
+		{
+			name: "stalled and missing",
+			beats: map[string]time.Time{
+				"fast":      now.Add(-4 * time.Minute),
+				"unbounded": now.Add(-48 * time.Hour),
+			},
+			wantStatus:  readinessStatusDegraded,
+			wantStalled: []string{"fast", "settings"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := evaluateLoopHeartbeats(specs, tc.beats, settings, now)
+			if got.Status != tc.wantStatus || got.Critical {
+				t.Fatalf("evaluateLoopHeartbeats() = %+v, want status %q", got, tc.wantStatus)
+			}
+			for _, name := range tc.wantStalled {
+				entry, _ := got.Details[name].(map[string]any)
+				if entry["stalled"] != true {
+					t.Fatalf("loop %q should be stalled: %+v", name, got.Details)
+				}
+			}
+			if entry, _ := got.Details["unbounded"].(map[string]any); entry["stalled"] != nil {
+				t.Fatalf("unbounded loop should never be stalled: %+v", entry)
+			}
+		})
+	}
+}
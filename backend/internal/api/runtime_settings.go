@@ -23,22 +23,32 @@ func (s *Server) defaultRuntimeSettings() store.RuntimeSettings {
 	}
 
 	return store.RuntimeSettings{
-		UploadConcurrency:                s.cfg.UploadConcurrencyDefault,
-		DownloadConcurrency:              s.cfg.DownloadConcurrencyDefault,
-		TelegramDeleteConcurrency:        s.cfg.DeleteTelegramConcurrencyDefault,
-		ReservedDiskBytes:                s.cfg.ReservedDiskBytesDefault,
-		UploadSessionTTLHours:            ttlHours,
-		UploadSessionCleanupIntervalMins: cleanupIntervalMins,
-		ThumbnailCacheMaxBytes:           s.cfg.ThumbnailCacheMaxBytes,
-		ThumbnailCacheTTLHours:           thumbnailTTLHours,
-		ThumbnailGenerateConcurrency:     s.cfg.ThumbnailGenerateConcurrency,
-		VaultPasswordHash:                "",
-		VaultSessionTTLMins:              60,
-		TorrentQBTPassword:               s.cfg.TorrentQBTPassword,
-		TorrentSourceDeleteMode:          torrentSourceDeleteModeImmediate,
-		TorrentSourceDeleteFixedMinutes:  30,
-		TorrentSourceDeleteRandomMinMins: 30,
-		TorrentSourceDeleteRandomMaxMins: 120,
+		UploadConcurrency:                 s.cfg.UploadConcurrencyDefault,
+		DownloadConcurrency:               s.cfg.DownloadConcurrencyDefault,
+		TelegramDeleteConcurrency:         s.cfg.DeleteTelegramConcurrencyDefault,
+		ReservedDiskBytes:                 s.cfg.ReservedDiskBytesDefault,
+		UploadSessionTTLHours:             ttlHours,
+		UploadSessionCleanupIntervalMins:  cleanupIntervalMins,
+		ThumbnailCacheMaxBytes:            s.cfg.ThumbnailCacheMaxBytes,
+		ThumbnailCacheTTLHours:            thumbnailTTLHours,
+		ThumbnailGenerateConcurrency:      s.cfg.ThumbnailGenerateConcurrency,
+		VaultPasswordHash:                 "",
+		VaultSessionTTLMins:               60,
+		TorrentQBTPassword:                s.cfg.TorrentQBTPassword,
+		TorrentSourceDeleteMode:           torrentSourceDeleteModeImmediate,
+		TorrentSourceDeleteFixedMinutes:   30,
+		TorrentSourceDeleteRandomMinMins:  30,
+		TorrentSourceDeleteRandomMaxMins:  120,
+		TorrentPreserveDirectoryStructure: false,
+		TorrentSeedRatioTarget:            0,
+		TorrentSeedMinMinutes:             0,
+		HLSTranscodeConcurrency:           s.cfg.HLSTranscodeConcurrency,
+		HLSCacheMaxBytes:                  s.cfg.HLSCacheMaxBytes,
+		AuditLogRetentionDays:             auditLogRetentionDaysDefault,
+		NotifyChatIDs:                     []string{},
+		NotifyTorrentAwaitingSelection:    true,
+		NotifyTransferFailed:              true,
+		NotifyLowDisk:                     true,
 	}
 }
 
@@ -164,6 +174,36 @@ func (s *Server) releaseThumbnailGenerate() {
 	}
 }
 
+func (s *Server) acquireHLSTranscodeSlot(ctx context.Context, limit int) error {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	for {
+		s.transferMu.Lock()
+		if s.activeHLSJobs < limit {
+			s.activeHLSJobs++
+			s.transferMu.Unlock()
+			return nil
+		}
+		s.transferMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(120 * time.Millisecond):
+		}
+	}
+}
+
+func (s *Server) releaseHLSTranscode() {
+	s.transferMu.Lock()
+	defer s.transferMu.Unlock()
+	if s.activeHLSJobs > 0 {
+		s.activeHLSJobs--
+	}
+}
+
 func getAvailableDiskBytes(path string) (int64, error) {
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(path, &stat); err != nil {
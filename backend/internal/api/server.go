@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"tg-cloud-drive-api/internal/config"
+	"tg-cloud-drive-api/internal/store"
 	"tg-cloud-drive-api/internal/telegram"
 )
 
@@ -33,6 +34,19 @@ type Server struct {
 	setupInitialized  atomic.Bool
 	loopsStarted      atomic.Bool
 
+	shuttingDown     atomic.Bool
+	shutdownCh       chan struct{}
+	backgroundCtx    context.Context
+	cancelBackground context.CancelFunc
+	background       sync.WaitGroup
+
+	// instanceID 标识当前进程，用于多副本之间区分事件来源。
+	instanceID uuid.UUID
+	leader     atomic.Bool
+	leaderConn *pgxpool.Conn
+
+	transferFanout chan []byte
+
 	filePathMu    sync.Mutex
 	filePathCache map[string]cachedFilePath
 
@@ -40,6 +54,7 @@ type Server struct {
 	activeUploads         int
 	activeDownloads       int
 	activeThumbnailJobs   int
+	activeHLSJobs         int
 	transferEventsMu      sync.RWMutex
 	transferSubscribers   map[uint64]chan transferStreamEvent
 	transferSubscriberSeq atomic.Uint64
@@ -48,14 +63,52 @@ type Server struct {
 	uploadRuntimeMu       sync.RWMutex
 	uploadRuntime         map[uuid.UUID]uploadTransferRuntimeState
 
-	chunkUploadMu       sync.Mutex
-	chunkUploadInFlight map[string]struct{}
-
 	cleanupMu      sync.Mutex
 	cleanupRunning bool
 
 	thumbGenMu      sync.Mutex
 	thumbGenerating map[string]chan struct{}
+
+	hlsGenMu          sync.Mutex
+	hlsGenerating     map[string]chan struct{}
+	hlsSourceMu       sync.Mutex
+	hlsSource         *hlsSourceServer
+	hlsCleanupRunning atomic.Bool
+
+	itemBatchMu   sync.Mutex
+	itemBatchJobs map[uuid.UUID]*itemBatchJob
+
+	metadataWorkerOnce sync.Once
+	metadataQueue      chan store.Item
+	metadataBackfillMu sync.Mutex
+	metadataBackfill   *itemBackfillJob
+
+	imageHashBackfillMu sync.Mutex
+	imageHashBackfill   *itemBackfillJob
+
+	contentHashBackfillMu sync.Mutex
+	contentHashBackfill   *itemBackfillJob
+
+	telegramOrphanScanMu sync.Mutex
+	telegramOrphanScan   *telegramOrphanScanJob
+
+	archiveJobsMu sync.Mutex
+	archiveJobs   map[uuid.UUID]*archiveJobHandle
+
+	// finishedJobEvents 记录已发送过 Webhook 与通知的（任务, 结束状态）。
+	finishedJobEvents recentKeySet[finishedTransferJobKey]
+
+	webhookWake               chan struct{}
+	awaitingSelectionMu       sync.Mutex
+	awaitingSelectionNotified map[string]struct{}
+	adminNotifier             *adminNotifier
+
+	loopHeartbeatsMu    sync.Mutex
+	loopHeartbeats      map[string]time.Time
+	readinessTelegramMu sync.Mutex
+	readinessTelegram   *readinessCheckDTO
+
+	metrics *serverMetrics
 }
 
 type cachedFilePath struct {
@@ -82,6 +135,7 @@ func NewServer(deps ServerDeps) (*Server, error) {
 		l = slog.Default()
 	}
 
+	backgroundCtx, cancelBackground := context.WithCancel(context.Background())
 	srv := &Server{
 		logger:              l,
 		cfg:                 deps.Cfg,
@@ -91,8 +145,22 @@ func NewServer(deps ServerDeps) (*Server, error) {
 		downloadProgress:    map[uuid.UUID]downloadTransferProgress{},
 		uploadRuntime:       map[uuid.UUID]uploadTransferRuntimeState{},
 		thumbGenerating:     map[string]chan struct{}{},
-		chunkUploadInFlight: map[string]struct{}{},
+		hlsGenerating:       map[string]chan struct{}{},
+		itemBatchJobs:       map[uuid.UUID]*itemBatchJob{},
+		archiveJobs:         map[uuid.UUID]*archiveJobHandle{},
+
+		webhookWake:               make(chan struct{}, 1),
+		awaitingSelectionNotified: map[string]struct{}{},
+		adminNotifier:             newAdminNotifier(),
+		loopHeartbeats:            map[string]time.Time{},
+
+		shutdownCh:       make(chan struct{}),
+		backgroundCtx:    backgroundCtx,
+		cancelBackground: cancelBackground,
+		instanceID:       uuid.New(),
+		transferFanout:   make(chan []byte, transferFanoutQueueSize),
 	}
+	srv.metrics = newServerMetrics(srv)
 
 	if deps.DB != nil {
 		if err := srv.bootstrapSystemConfig(context.Background()); err != nil {
@@ -108,10 +176,14 @@ func (s *Server) Router() http.Handler {
 
 	r.Use(s.corsMiddleware)
 	r.Use(recoverMiddleware(s.logger))
+	r.Use(tracingMiddleware)
 	r.Use(requestLogMiddleware(s.logger))
 	r.Use(s.rejectIPHostMiddleware)
+	r.Use(s.auditContextMiddleware)
 
 	r.Get("/healthz", s.handleHealthz)
+	r.Get("/readyz", s.handleReadyz)
+	r.Get("/metrics", s.handleMetrics)
 
 	r.Route("/api", func(api chi.Router) {
 		api.Get("/setup/status", s.handleSetupStatus)
@@ -134,6 +206,21 @@ func (s *Server) Router() http.Handler {
 			pr.Get("/storage/stats", s.handleGetStorageStats)
 			pr.Get("/storage/local-residual", s.handleListLocalResidual)
 			pr.Post("/storage/local-residual/{id}/cleanup", s.handleCleanupLocalResidual)
+			pr.Get("/storage/telegram-delete-failures", s.handleListTelegramDeleteFailures)
+			pr.Post("/storage/telegram-delete-failures/{id}/retry", s.handleRetryTelegramDeleteFailure)
+			pr.Post("/storage/telegram-delete-failures/{id}/dismiss", s.handleDismissTelegramDeleteFailure)
+			pr.Get("/storage/telegram-orphans/scan", s.handleGetTelegramOrphanScan)
+			pr.Post("/storage/telegram-orphans/scan", s.handleStartTelegramOrphanScan)
+			pr.Post("/storage/telegram-orphans/scan/cancel", s.handleCancelTelegramOrphanScan)
+			pr.Post("/storage/telegram-orphans/delete", s.handleDeleteTelegramOrphans)
+			pr.Get("/audit-log", s.handleListAuditLog)
+			pr.Get("/webhooks", s.handleListWebhooks)
+			pr.Post("/webhooks", s.handleCreateWebhook)
+			pr.Get("/webhooks/deliveries", s.handleListWebhookDeliveries)
+			pr.Post("/webhooks/deliveries/{id}/redeliver", s.handleRedeliverWebhook)
+			pr.Patch("/webhooks/{id}", s.handlePatchWebhook)
+			pr.Delete("/webhooks/{id}", s.handleDeleteWebhook)
+			pr.Post("/webhooks/{id}/test", s.handleTestWebhook)
 			pr.Get("/vault/status", s.handleVaultStatus)
 			pr.Get("/transfers/active", s.handleGetActiveTransfers)
 			pr.Get("/transfers/history", s.handleGetTransferHistory)
@@ -154,11 +241,30 @@ func (s *Server) Router() http.Handler {
 			pr.Delete("/torrents/tasks/{id}", s.handleDeleteTorrentTask)
 			pr.Post("/torrents/tasks/{id}/dispatch", s.handleDispatchTorrentTask)
 			pr.Post("/torrents/tasks/{id}/retry", s.handleRetryTorrentTask)
+			pr.Post("/torrents/tasks/{id}/pause", s.handlePauseTorrentTask)
+			pr.Post("/torrents/tasks/{id}/resume", s.handleResumeTorrentTask)
+			pr.Post("/torrents/tasks/{id}/priority", s.handleSetTorrentTaskPriority)
 
 			pr.Patch("/items/{id}", s.handlePatchItem)
 			pr.Post("/items/{id}/star", s.handleSetItemStar)
 			pr.Post("/items/{id}/vault", s.handleSetItemVault)
 			pr.Post("/items/vault/batch", s.handleBatchSetItemsVault)
+			pr.Post("/items/batch", s.handleCreateItemBatch)
+			pr.Get("/items/batch/{id}", s.handleGetItemBatch)
+			pr.Post("/items/batch/{id}/cancel", s.handleCancelItemBatch)
+			pr.Post("/items/metadata/backfill", s.handleStartMetadataBackfill)
+			pr.Get("/items/metadata/backfill", s.handleGetMetadataBackfill)
+			pr.Post("/items/metadata/backfill/cancel", s.handleCancelMetadataBackfill)
+			pr.Get("/items/images/similar", s.handleListSimilarImages)
+			pr.Post("/items/images/similar/delete", s.handleDeleteSimilarImages)
+			pr.Post("/items/images/hash/backfill", s.handleStartImageHashBackfill)
+			pr.Get("/items/images/hash/backfill", s.handleGetImageHashBackfill)
+			pr.Post("/items/images/hash/backfill/cancel", s.handleCancelImageHashBackfill)
+			pr.Get("/items/duplicates", s.handleListDuplicates)
+			pr.Post("/items/duplicates/resolve", s.handleResolveDuplicates)
+			pr.Post("/items/duplicates/hash/backfill", s.handleStartContentHashBackfill)
+			pr.Get("/items/duplicates/hash/backfill", s.handleGetContentHashBackfill)
+			pr.Post("/items/duplicates/hash/backfill/cancel", s.handleCancelContentHashBackfill)
 			pr.Delete("/items/{id}", s.handleDeleteItemPermanently)
 			pr.Post("/items/{id}/copy", s.handleCopyItem)
 
@@ -172,10 +278,28 @@ func (s *Server) Router() http.Handler {
 			pr.Get("/uploads/{id}", s.handleGetUploadSession)
 			pr.Post("/uploads/{id}/chunks/{index}", s.handleUploadSessionChunk)
 			pr.Post("/uploads/{id}/complete", s.handleCompleteUploadSession)
+			pr.Post("/uploads/{id}/pause", s.handlePauseUploadSession)
+			pr.Post("/uploads/{id}/resume", s.handleResumeUploadSession)
 
 			pr.MethodFunc(http.MethodGet, "/items/{id}/content", s.handleItemContent)
 			pr.MethodFunc(http.MethodHead, "/items/{id}/content", s.handleItemContent)
 			pr.MethodFunc(http.MethodGet, "/items/{id}/thumbnail", s.handleItemThumbnail)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/image", s.handleItemImage)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/hls/master.m3u8", s.handleItemHLSMaster)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/hls/{rendition}/index.m3u8", s.handleItemHLSPlaylist)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/hls/{rendition}/{segment}", s.handleItemHLSSegment)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/subtitles", s.handleListItemSubtitles)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/subtitles/{track}", s.handleItemSubtitleTrack)
+			pr.Get("/items/{id}/preview/text", s.handleItemTextPreview)
+			pr.Get("/items/{id}/archive/entries", s.handleListArchiveEntries)
+			pr.MethodFunc(http.MethodGet, "/items/{id}/archive/entry", s.handleArchiveEntryContent)
+			pr.MethodFunc(http.MethodHead, "/items/{id}/archive/entry", s.handleArchiveEntryContent)
+			pr.Post("/items/{id}/archive/extract", s.handleExtractArchive)
+			pr.Post("/items/{id}/archive/create", s.handleCreateFolderArchive)
+			pr.Post("/items/{id}/torrent", s.handlePublishItemTorrent)
+			pr.Get("/items/{id}/torrent/publications", s.handleListItemTorrentPublications)
+			pr.Get("/torrent-publications/{id}/torrent", s.handleDownloadTorrentPublication)
+			pr.Delete("/torrent-publications/{id}", s.handleRevokeTorrentPublication)
 		})
 	})
 
@@ -183,6 +307,9 @@ func (s *Server) Router() http.Handler {
 		pub.Use(s.setupRequiredMiddleware)
 		pub.MethodFunc(http.MethodGet, "/d/{code}", s.handleSharedDownload)
 		pub.MethodFunc(http.MethodHead, "/d/{code}", s.handleSharedDownload)
+		pub.MethodFunc(http.MethodGet, "/d/{code}/image", s.handleSharedImage)
+		pub.MethodFunc(http.MethodGet, "/ws/{token}/*", s.handleWebSeed)
+		pub.MethodFunc(http.MethodHead, "/ws/{token}/*", s.handleWebSeed)
 	})
 
 	return r
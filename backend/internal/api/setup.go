@@ -74,9 +74,18 @@ func (s *Server) startBackgroundLoopsIfNeeded() {
 	if !s.loopsStarted.CompareAndSwap(false, true) {
 		return
 	}
+	s.startLeaderElectionLoop()
+	s.startTransferFanoutLoops()
+	s.startArchiveJobReaperLoop()
+	s.startTorrentPublicationCleanupLoop()
 	s.startUploadSessionCleanupLoop()
 	s.startThumbnailCacheCleanupLoop()
+	s.startHLSCacheCleanupLoop()
 	s.startTorrentTaskWorkerLoop()
+	s.startTelegramDeleteRetryLoop()
+	s.startAuditLogRetentionLoop()
+	s.startWebhookDeliveryLoop()
+	s.startAdminNotifyLoop()
 }
 
 func (s *Server) bootstrapSystemConfig(ctx context.Context) error {
@@ -97,7 +106,7 @@ func (s *Server) bootstrapSystemConfig(ctx context.Context) error {
 		}
 	}
 
-	tg := buildTelegramClient(cfg.TGBotToken, cfg.AccessMethod, cfg.TGAPIBaseURL, 5*time.Minute)
+	tg := buildTelegramClient(cfg.TGBotToken, cfg.AccessMethod, cfg.TGAPIBaseURL, 5*time.Minute, s.telegramClientOptions()...)
 	if err := tg.SelfCheck(ctx, cfg.TGStorageChatID); err != nil {
 		return err
 	}
@@ -243,7 +252,7 @@ func (s *Server) handleSetupInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tg := buildTelegramClient(created.TGBotToken, created.AccessMethod, created.TGAPIBaseURL, 5*time.Minute)
+	tg := buildTelegramClient(created.TGBotToken, created.AccessMethod, created.TGAPIBaseURL, 5*time.Minute, s.telegramClientOptions()...)
 	s.applySystemConfig(created, tg)
 	s.setAuthCookie(w, time.Now())
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
@@ -361,8 +370,9 @@ func resolveSelfHostedBotAPIBaseURL(raw *string) string {
 	return setupDefaultBotAPIBaseURL
 }
 
-func buildTelegramClient(token string, accessMethod string, tgAPIBaseURL *string, timeout time.Duration) *telegram.Client {
-	options := make([]telegram.ClientOption, 0, 1)
+func buildTelegramClient(token string, accessMethod string, tgAPIBaseURL *string, timeout time.Duration, extra ...telegram.ClientOption) *telegram.Client {
+	options := make([]telegram.ClientOption, 0, 1+len(extra))
+	options = append(options, extra...)
 	if accessMethod == setupAccessMethodSelfHosted {
 		options = append(options, telegram.WithBaseURL(resolveSelfHostedBotAPIBaseURL(tgAPIBaseURL)))
 	}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	// shutdownForceWait 为超过期限、取消剩余任务后，等待它们记录取消状态并退出的时间。
+	shutdownForceWait    = 5 * time.Second
+	shutdownFlushTimeout = 5 * time.Second
+)
+
+var errServerShuttingDown = errors.New("服务正在关闭")
+
+func (s *Server) isShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// BeginShutdown 标记服务进入关闭流程：后台循环不再领取新任务，实时传输推送流立即结束。
+// 应在 http.Server.Shutdown 之前调用，否则常驻的 SSE 连接会一直占用关闭期限。
+func (s *Server) BeginShutdown() {
+	if !s.shuttingDown.CompareAndSwap(false, true) {
+		return
+	}
+	close(s.shutdownCh)
+}
+
+// Shutdown 在 ctx 期限内等待后台循环与后台任务自然结束；超过期限则取消剩余任务。
+// 返回前会为被中断的 Torrent 任务保存断点，并把内存中的传输进度落库。
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.BeginShutdown()
+
+	drained := waitGroupWithContext(ctx, &s.background)
+	if !drained {
+		s.logger.Warn("background work still running at shutdown deadline, canceling")
+		s.cancelBackground()
+		s.cancelRunningJobsForShutdown()
+		forceCtx, cancel := context.WithTimeout(context.Background(), shutdownForceWait)
+		waitGroupWithContext(forceCtx, &s.background)
+		cancel()
+	}
+	s.cancelBackground()
+	s.closeHLSSourceServer()
+	s.resignLeadership()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+	s.flushTransferProgressForShutdown(flushCtx)
+	s.flushAdminNotifications(flushCtx)
+
+	if !drained {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// goBackground 在后台运行 fn，并登记到关闭流程的等待列表。
+func (s *Server) goBackground(fn func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		fn()
+	}()
+}
+
+// waitLoopInterval 等待后台循环的下一轮；进入关闭流程时立即返回 false。
+func (s *Server) waitLoopInterval(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-s.shutdownCh:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func waitGroupWithContext(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (s *Server) cancelRunningJobsForShutdown() {
+	s.itemBatchMu.Lock()
+	for _, job := range s.itemBatchJobs {
+		job.requestCancel()
+	}
+	s.itemBatchMu.Unlock()
+
+	s.archiveJobsMu.Lock()
+	for _, handle := range s.archiveJobs {
+		handle.cancel()
+	}
+	s.archiveJobsMu.Unlock()
+
+	for _, backfill := range []struct {
+		mu  *sync.Mutex
+		job **itemBackfillJob
+	}{
+		{&s.metadataBackfillMu, &s.metadataBackfill},
+		{&s.imageHashBackfillMu, &s.imageHashBackfill},
+		{&s.contentHashBackfillMu, &s.contentHashBackfill},
+	} {
+		backfill.mu.Lock()
+		if job := *backfill.job; job != nil {
+			job.requestCancel()
+		}
+		backfill.mu.Unlock()
+	}
+
+	s.telegramOrphanScanMu.Lock()
+	if job := s.telegramOrphanScan; job != nil {
+		job.requestCancel()
+		job.cancelDelete()
+	}
+	s.telegramOrphanScanMu.Unlock()
+}
+
+// checkpointTorrentTask 因关闭或失去主副本身份而中断的 Torrent 任务保持原状态，由之后的主副本重新领取；
+// 已上传的文件均已逐个记录，这里只同步一次传输任务进度。
+func (s *Server) checkpointTorrentTask(task store.TorrentTask, phase string) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+	s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusRunning, "")
+	s.logger.Info("torrent task checkpointed", "task_id", task.ID.String(), "phase", phase)
+}
+
+// flushTransferProgressForShutdown 处理仍在内存中的传输进度：未结束的下载记为已取消，
+// 上传会话按已落库的分片刷新传输任务，并清理不再有意义的阶段信息。
+func (s *Server) flushTransferProgressForShutdown(ctx context.Context) {
+	if s.db == nil {
+		return
+	}
+	st := store.New(s.db)
+
+	s.downloadProgressMu.RLock()
+	downloadJobIDs := make([]uuid.UUID, 0, len(s.downloadProgress))
+	for jobID := range s.downloadProgress {
+		downloadJobIDs = append(downloadJobIDs, jobID)
+	}
+	s.downloadProgressMu.RUnlock()
+	for _, jobID := range downloadJobIDs {
+		if err := st.UpdateTransferJobProgress(ctx, jobID, 0, 0, 1, store.TransferJobStatusCanceled, nil, time.Now()); err != nil && !errors.Is(err, store.ErrNotFound) {
+			s.logger.Warn("flush download transfer progress failed", "error", err.Error(), "job_id", jobID.String())
+		}
+		s.clearDownloadTransferProgress(jobID)
+	}
+
+	s.uploadRuntimeMu.RLock()
+	sessionIDs := make([]uuid.UUID, 0, len(s.uploadRuntime))
+	for sessionID := range s.uploadRuntime {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	s.uploadRuntimeMu.RUnlock()
+	for _, sessionID := range sessionIDs {
+		s.clearUploadSessionRuntime(sessionID)
+		session, err := st.GetUploadSession(ctx, sessionID)
+		if err != nil {
+			continue
+		}
+		s.syncUploadSessionProgressEvent(ctx, session)
+	}
+
+	if len(downloadJobIDs) > 0 || len(sessionIDs) > 0 {
+		s.logger.Info("transfer progress flushed for shutdown", "downloads", len(downloadJobIDs), "upload_sessions", len(sessionIDs))
+	}
+}
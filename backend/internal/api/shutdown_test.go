@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestServerShutdownStopsLoopsAndWaitsForJobs(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+
+	loopExited := make(chan struct{})
+	srv.goBackground(func() {
+		defer close(loopExited)
+		for srv.waitLoopInterval(time.Hour) {
+		}
+	})
+	jobFinished := false
+	release := make(chan struct{})
+	srv.goBackground(func() {
+		<-release
+		jobFinished = true
+	})
+
+	srv.BeginShutdown()
+	select {
+	case <-loopExited:
+	case <-time.After(time.Second):
+		t.Fatalf("loop should exit once shutdown begins")
+	}
+	if !srv.isShuttingDown() {
+		t.Fatalf("isShuttingDown() = false after BeginShutdown")
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() err = %v", err)
+	}
+	if !jobFinished {
+		t.Fatalf("Shutdown should wait for running background jobs")
+	}
+	if srv.backgroundCtx.Err() == nil {
+		t.Fatalf("background context should be canceled after shutdown")
+	}
+	// 重复调用不应 panic。
+	srv.BeginShutdown()
+}
+
+func TestServerShutdownCancelsJobsAfterDeadline(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	canceled := make(chan struct{})
+	srv.goBackground(func() {
+		<-srv.backgroundCtx.Done()
+		close(canceled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() err = %v, want deadline exceeded", err)
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Fatalf("job should observe background cancellation before Shutdown returns")
+	}
+}
+
+func TestServerShutdownClosesHLSSourceServer(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	sourceURL, release, err := srv.openHLSSourceURL(uuid.New())
+	if err != nil {
+		t.Fatalf("openHLSSourceURL() err = %v", err)
+	}
+	defer release()
+	addr := strings.TrimPrefix(sourceURL, "http://")
+	addr = addr[:strings.Index(addr, "/")]
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() err = %v", err)
+	}
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		_ = conn.Close()
+		t.Fatalf("hls source listener %s should be closed after shutdown", addr)
+	}
+	if _, _, err := srv.openHLSSourceURL(uuid.New()); !errors.Is(err, errServerShuttingDown) {
+		t.Fatalf("openHLSSourceURL() after shutdown err = %v, want errServerShuttingDown", err)
+	}
+}
+
+func TestServerShutdownStopsMediaMetadataWorker(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	srv.ensureMediaMetadataWorker()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() err = %v, metadata worker should exit with the drain", err)
+	}
+}
+
+// 回填、频道扫描与孤立消息删除任务在关闭期限到达后被取消。
+func TestServerShutdownCancelsBackfillAndOrphanScanJobs(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	backfillCtx, cancelBackfill := context.WithCancel(context.Background())
+	backfill := &itemBackfillJob{id: uuid.New(), status: itemBackfillStatusRunning, cancel: cancelBackfill}
+	srv.contentHashBackfill = backfill
+	scanCtx, cancelScan := context.WithCancel(context.Background())
+	scan := &telegramOrphanScanJob{id: uuid.New(), status: itemBackfillStatusRunning, cancel: cancelScan}
+	srv.telegramOrphanScan = scan
+	deleteCtx, cancelDelete := context.WithCancel(context.Background())
+	scan.deleting = true
+	scan.deleteCancel = cancelDelete
+	for _, jobCtx := range []context.Context{backfillCtx, scanCtx, deleteCtx} {
+		srv.goBackground(func() { <-jobCtx.Done() })
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown() err = %v, want deadline exceeded", err)
+	}
+	if backfillCtx.Err() == nil || !backfill.snapshot().CancelRequested {
+		t.Fatalf("content hash backfill should be canceled at the shutdown deadline")
+	}
+	if scanCtx.Err() == nil || !scan.snapshot().CancelRequested {
+		t.Fatalf("telegram orphan scan should be canceled at the shutdown deadline")
+	}
+	if deleteCtx.Err() == nil {
+		t.Fatalf("telegram orphan delete should be canceled at the shutdown deadline")
+	}
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	subtitleSourceSidecar  = "sidecar"
+	subtitleSourceEmbedded = "embedded"
+
+	subtitleTrackSidecarPrefix  = "sidecar-"
+	subtitleTrackEmbeddedPrefix = "stream-"
+	subtitleTrackSuffix         = ".vtt"
+)
+
+var subtitleSidecarExtensions = []string{".srt", ".ass", ".ssa", ".vtt"}
+
+// 图形字幕无法转换为 WebVTT 文本，不对外列出
+var bitmapSubtitleCodecs = map[string]struct{}{
+	"hdmv_pgs_subtitle": {},
+	"dvd_subtitle":      {},
+	"dvb_subtitle":      {},
+	"xsub":              {},
+}
+
+var srtTimestampPattern = regexp.MustCompile(`(\d+):(\d{2}):(\d{2})[,.](\d{1,3})`)
+
+type subtitleTrackDTO struct {
+	ID       string `json:"id"`
+	Source   string `json:"source"`
+	Label    string `json:"label"`
+	Language string `json:"language,omitempty"`
+	Format   string `json:"format"`
+	Default  bool   `json:"default"`
+	Forced   bool   `json:"forced"`
+	URL      string `json:"url"`
+}
+
+type embeddedSubtitleStream struct {
+	Index    int    `json:"index"`
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Default  bool   `json:"default"`
+	Forced   bool   `json:"forced"`
+}
+
+func subtitleTrackURL(itemID uuid.UUID, trackID string) string {
+	return "/api/items/" + itemID.String() + "/subtitles/" + trackID + subtitleTrackSuffix
+}
+
+// matchSubtitleSidecar 判断 name 是否为视频 videoName 的外挂字幕：主文件名相同，或以「主文件名.」开头（如 movie.zh.srt）。
+// 返回主文件名之后的附加部分（如 "zh"），用作语言与标题。
+func matchSubtitleSidecar(videoName string, name string) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	supported := false
+	for _, candidate := range subtitleSidecarExtensions {
+		if ext == candidate {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return "", false
+	}
+
+	videoBase := strings.ToLower(strings.TrimSuffix(videoName, filepath.Ext(videoName)))
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	lowerBase := strings.ToLower(base)
+	if videoBase == "" {
+		return "", false
+	}
+	if lowerBase == videoBase {
+		return "", true
+	}
+	if !strings.HasPrefix(lowerBase, videoBase+".") {
+		return "", false
+	}
+	return strings.Trim(base[len(videoBase)+1:], ". "), true
+}
+
+func buildSidecarSubtitleTracks(video store.Item, siblings []store.Item) []subtitleTrackDTO {
+	tracks := make([]subtitleTrackDTO, 0, len(siblings))
+	for _, sibling := range siblings {
+		// 普通目录下的视频不暴露密码箱中的字幕
+		if sibling.InVault && !video.InVault {
+			continue
+		}
+		suffix, ok := matchSubtitleSidecar(video.Name, sibling.Name)
+		if !ok {
+			continue
+		}
+		language := ""
+		if suffix != "" {
+			language = strings.SplitN(suffix, ".", 2)[0]
+		}
+		trackID := subtitleTrackSidecarPrefix + sibling.ID.String()
+		tracks = append(tracks, subtitleTrackDTO{
+			ID:       trackID,
+			Source:   subtitleSourceSidecar,
+			Label:    sibling.Name,
+			Language: language,
+			Format:   strings.TrimPrefix(strings.ToLower(filepath.Ext(sibling.Name)), "."),
+			URL:      subtitleTrackURL(video.ID, trackID),
+		})
+	}
+	return tracks
+}
+
+func buildEmbeddedSubtitleTracks(video store.Item, streams []embeddedSubtitleStream) []subtitleTrackDTO {
+	tracks := make([]subtitleTrackDTO, 0, len(streams))
+	for i, stream := range streams {
+		label := stream.Title
+		if label == "" {
+			label = stream.Language
+		}
+		if label == "" {
+			label = fmt.Sprintf("内嵌字幕 %d", i+1)
+		}
+		trackID := subtitleTrackEmbeddedPrefix + strconv.Itoa(stream.Index)
+		tracks = append(tracks, subtitleTrackDTO{
+			ID:       trackID,
+			Source:   subtitleSourceEmbedded,
+			Label:    label,
+			Language: stream.Language,
+			Format:   stream.Codec,
+			Default:  stream.Default,
+			Forced:   stream.Forced,
+			URL:      subtitleTrackURL(video.ID, trackID),
+		})
+	}
+	return tracks
+}
+
+// parseSubtitleTrackID 解析 "sidecar-<uuid>.vtt" 或 "stream-<index>.vtt"。
+func parseSubtitleTrackID(raw string) (source string, sidecarID uuid.UUID, streamIndex int, ok bool) {
+	id, found := strings.CutSuffix(strings.TrimSpace(raw), subtitleTrackSuffix)
+	if !found {
+		return "", uuid.Nil, 0, false
+	}
+	if rest, found := strings.CutPrefix(id, subtitleTrackSidecarPrefix); found {
+		parsed, err := uuid.Parse(rest)
+		if err != nil {
+			return "", uuid.Nil, 0, false
+		}
+		return subtitleSourceSidecar, parsed, 0, true
+	}
+	if rest, found := strings.CutPrefix(id, subtitleTrackEmbeddedPrefix); found {
+		index, err := strconv.Atoi(rest)
+		if err != nil || index < 0 || strconv.Itoa(index) != rest {
+			return "", uuid.Nil, 0, false
+		}
+		return subtitleSourceEmbedded, uuid.Nil, index, true
+	}
+	return "", uuid.Nil, 0, false
+}
+
+func parseFFprobeSubtitleStreams(raw []byte) ([]embeddedSubtitleStream, error) {
+	var out struct {
+		Streams []struct {
+			Index       int               `json:"index"`
+			CodecType   string            `json:"codec_type"`
+			CodecName   string            `json:"codec_name"`
+			Tags        map[string]string `json:"tags"`
+			Disposition map[string]int    `json:"disposition"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	}
+
+	streams := make([]embeddedSubtitleStream, 0, len(out.Streams))
+	for _, stream := range out.Streams {
+		if stream.CodecType != "" && stream.CodecType != "subtitle" {
+			continue
+		}
+		codec := strings.ToLower(strings.TrimSpace(stream.CodecName))
+		if _, bitmap := bitmapSubtitleCodecs[codec]; bitmap {
+			continue
+		}
+		tags := lowerCaseKeys(stream.Tags)
+		language := tags["language"]
+		if language == "und" {
+			language = ""
+		}
+		streams = append(streams, embeddedSubtitleStream{
+			Index:    stream.Index,
+			Codec:    codec,
+			Language: language,
+			Title:    tags["title"],
+			Default:  stream.Disposition["default"] == 1,
+			Forced:   stream.Disposition["forced"] == 1,
+		})
+	}
+	return streams, nil
+}
+
+// convertSRTToWebVTT 去除 BOM、统一换行，并把时间戳中的逗号换成点；序号行在 WebVTT 中作为 cue 标识保留。
+func convertSRTToWebVTT(data []byte) []byte {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var buf bytes.Buffer
+	buf.WriteString("WEBVTT\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if strings.Contains(line, "-->") {
+			line = srtTimestampPattern.ReplaceAllStringFunc(line, normalizeSRTTimestamp)
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func normalizeSRTTimestamp(raw string) string {
+	m := srtTimestampPattern.FindStringSubmatch(raw)
+	if len(m) != 5 {
+		return raw
+	}
+	hours, _ := strconv.Atoi(m[1])
+	millis := m[4]
+	for len(millis) < 3 {
+		millis += "0"
+	}
+	return fmt.Sprintf("%02d:%s:%s.%s", hours, m[2], m[3], millis)
+}
+
+// normalizeWebVTT 保证外挂 .vtt 以 WEBVTT 头开始。
+func normalizeWebVTT(data []byte) []byte {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	if strings.HasPrefix(text, "WEBVTT") {
+		return []byte(text)
+	}
+	return []byte("WEBVTT\n\n" + strings.TrimLeft(text, "\r\n"))
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestMatchSubtitleSidecar(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		video      string
+		sidecar    string
+		wantSuffix string
+		wantOK     bool
+	}{
+		{name: "同名 srt", video: "Movie.2020.mkv", sidecar: "Movie.2020.srt", wantOK: true},
+		{name: "带语言后缀", video: "Movie.2020.mkv", sidecar: "movie.2020.zh-CN.ass", wantSuffix: "zh-CN", wantOK: true},
+		{name: "多段后缀", video: "ep01.mp4", sidecar: "ep01.chs.forced.srt", wantSuffix: "chs.forced", wantOK: true},
+		{name: "前缀相同但不是同一视频", video: "ep1.mp4", sidecar: "ep10.srt", wantOK: false},
+		{name: "不支持的扩展名", video: "ep01.mp4", sidecar: "ep01.txt", wantOK: false},
+		{name: "其他文件", video: "ep01.mp4", sidecar: "ep02.srt", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			suffix, ok := matchSubtitleSidecar(tc.video, tc.sidecar)
+			if ok != tc.wantOK || suffix != tc.wantSuffix {
+				t.Fatalf("matchSubtitleSidecar(%q, %q) = %q, %v; want %q, %v", tc.video, tc.sidecar, suffix, ok, tc.wantSuffix, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildSidecarSubtitleTracksSkipsVault(t *testing.T) {
+	t.Parallel()
+
+	video := store.Item{ID: uuid.New(), Name: "a.mkv"}
+	visible := store.Item{ID: uuid.New(), Name: "a.en.srt"}
+	hidden := store.Item{ID: uuid.New(), Name: "a.zh.srt", InVault: true}
+
+	tracks := buildSidecarSubtitleTracks(video, []store.Item{visible, hidden})
+	if len(tracks) != 1 || tracks[0].Language != "en" || tracks[0].Format != "srt" {
+		t.Fatalf("buildSidecarSubtitleTracks() = %+v", tracks)
+	}
+	wantURL := "/api/items/" + video.ID.String() + "/subtitles/sidecar-" + visible.ID.String() + ".vtt"
+	if tracks[0].URL != wantURL {
+		t.Fatalf("track url = %q, want %q", tracks[0].URL, wantURL)
+	}
+
+	video.InVault = true
+	if tracks := buildSidecarSubtitleTracks(video, []store.Item{visible, hidden}); len(tracks) != 2 {
+		t.Fatalf("vault video should see vault sidecar, got %d tracks", len(tracks))
+	}
+}
+
+func TestParseSubtitleTrackID(t *testing.T) {
+	t.Parallel()
+
+	id := uuid.New()
+	cases := []struct {
+		raw        string
+		wantSource string
+		wantIndex  int
+		wantOK     bool
+	}{
+		{raw: "sidecar-" + id.String() + ".vtt", wantSource: subtitleSourceSidecar, wantOK: true},
+		{raw: "stream-3.vtt", wantSource: subtitleSourceEmbedded, wantIndex: 3, wantOK: true},
+		{raw: "stream-03.vtt", wantOK: false},
+		{raw: "stream--1.vtt", wantOK: false},
+		{raw: "stream-3.srt", wantOK: false},
+		{raw: "sidecar-nope.vtt", wantOK: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			source, sidecarID, index, ok := parseSubtitleTrackID(tc.raw)
+			if ok != tc.wantOK || source != tc.wantSource || index != tc.wantIndex {
+				t.Fatalf("parseSubtitleTrackID(%q) = %q, %d, %v", tc.raw, source, index, ok)
+			}
+			if ok && source == subtitleSourceSidecar && sidecarID != id {
+				t.Fatalf("sidecar id = %s, want %s", sidecarID, id)
+			}
+		})
+	}
+}
+
+func TestParseFFprobeSubtitleStreams(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte(`{"streams": [
+  {"index": 2, "codec_type": "subtitle", "codec_name": "subrip", "tags": {"language": "chi", "title": "简体"}, "disposition": {"default": 1, "forced": 0}},
+  {"index": 3, "codec_type": "subtitle", "codec_name": "hdmv_pgs_subtitle", "tags": {"language": "eng"}},
+  {"index": 4, "codec_type": "subtitle", "codec_name": "ass", "tags": {"LANGUAGE": "und"}, "disposition": {"forced": 1}}
+]}`)
+	streams, err := parseFFprobeSubtitleStreams(raw)
+	if err != nil {
+		t.Fatalf("parseFFprobeSubtitleStreams() error = %v", err)
+	}
+	if len(streams) != 2 {
+		t.Fatalf("parseFFprobeSubtitleStreams() returned %d streams, want 2 (bitmap skipped)", len(streams))
+	}
+	if streams[0].Index != 2 || streams[0].Language != "chi" || streams[0].Title != "简体" || !streams[0].Default {
+		t.Fatalf("streams[0] = %+v", streams[0])
+	}
+	if streams[1].Index != 4 || streams[1].Language != "" || !streams[1].Forced {
+		t.Fatalf("streams[1] = %+v", streams[1])
+	}
+
+	tracks := buildEmbeddedSubtitleTracks(store.Item{ID: uuid.New()}, streams)
+	if tracks[0].Label != "简体" || tracks[1].Label != "内嵌字幕 2" || tracks[1].ID != "stream-4" {
+		t.Fatalf("buildEmbeddedSubtitleTracks() = %+v", tracks)
+	}
+}
+
+func TestConvertSRTToWebVTT(t *testing.T) {
+	t.Parallel()
+
+	srt := "\ufeff1\r\n0:00:01,5 --> 00:00:03,250\r\n你好\r\n\r\n2\r\n00:01:00,000 --> 00:01:02,000\r\n第二行, 含逗号 12:34:56,789\r\n"
+	want := "WEBVTT\n\n1\n00:00:01.500 --> 00:00:03.250\n你好\n\n2\n00:01:00.000 --> 00:01:02.000\n第二行, 含逗号 12:34:56,789\n"
+	if got := string(convertSRTToWebVTT([]byte(srt))); got != want {
+		t.Fatalf("convertSRTToWebVTT() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got := string(normalizeWebVTT([]byte("WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n"))); got != "WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n" {
+		t.Fatalf("normalizeWebVTT(valid) = %q", got)
+	}
+	if got := string(normalizeWebVTT([]byte("\n00:00.000 --> 00:01.000\nhi\n"))); got != "WEBVTT\n\n00:00.000 --> 00:01.000\nhi\n" {
+		t.Fatalf("normalizeWebVTT(missing header) = %q", got)
+	}
+}
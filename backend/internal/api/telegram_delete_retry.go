@@ -0,0 +1,277 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	telegramDeleteRetryInterval   = 2 * time.Minute
+	telegramDeleteRetryBatchSize  = 50
+	telegramDeleteRetryBaseDelay  = 5 * time.Minute
+	telegramDeleteRetryMaxDelay   = 24 * time.Hour
+	telegramDeleteRetryMaxRetries = 10
+	telegramDeleteRetryTimeout    = 2 * time.Minute
+)
+
+type telegramDeleteFailureDTO struct {
+	ID                 string     `json:"id"`
+	ItemID             *string    `json:"itemId"`
+	ItemPath           string     `json:"itemPath"`
+	ChatID             string     `json:"chatId"`
+	MessageID          int64      `json:"messageId"`
+	Error              string     `json:"error"`
+	RetryCount         int        `json:"retryCount"`
+	Resolved           bool       `json:"resolved"`
+	Resolution         *string    `json:"resolution"`
+	FailedAt           time.Time  `json:"failedAt"`
+	LastRetryAt        time.Time  `json:"lastRetryAt"`
+	NextRetryAt        *time.Time `json:"nextRetryAt"`
+	ResolvedAt         *time.Time `json:"resolvedAt"`
+	AutoRetryExhausted bool       `json:"autoRetryExhausted"`
+}
+
+func toTelegramDeleteFailureDTO(f store.TelegramDeleteFailure) telegramDeleteFailureDTO {
+	dto := telegramDeleteFailureDTO{
+		ID:                 f.ID.String(),
+		ItemPath:           f.ItemPath,
+		ChatID:             f.TGChatID,
+		MessageID:          f.TGMessageID,
+		Error:              f.Error,
+		RetryCount:         f.RetryCount,
+		Resolved:           f.Resolved,
+		FailedAt:           f.FailedAt,
+		LastRetryAt:        f.LastRetryAt,
+		NextRetryAt:        f.NextRetryAt,
+		ResolvedAt:         f.ResolvedAt,
+		AutoRetryExhausted: !f.Resolved && f.RetryCount >= telegramDeleteRetryMaxRetries,
+	}
+	if f.ItemID != nil {
+		id := f.ItemID.String()
+		dto.ItemID = &id
+	}
+	if f.Resolution != nil {
+		resolution := string(*f.Resolution)
+		dto.Resolution = &resolution
+	}
+	return dto
+}
+
+// telegramDeleteRetryBackoff 返回第 retryCount 次重试失败后的等待时间：5 分钟起按 2 倍递增，最长 24 小时。
+func telegramDeleteRetryBackoff(retryCount int) time.Duration {
+	delay := telegramDeleteRetryBaseDelay
+	for i := 1; i < retryCount && delay < telegramDeleteRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > telegramDeleteRetryMaxDelay {
+		delay = telegramDeleteRetryMaxDelay
+	}
+	return delay
+}
+
+func (s *Server) startTelegramDeleteRetryLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopTelegramDeleteRetry)
+			if s.isLeader() {
+				s.runTelegramDeleteRetryPass(s.backgroundCtx)
+			}
+			if !s.waitLoopInterval(telegramDeleteRetryInterval) {
+				return
+			}
+		}
+	})
+}
+
+// runTelegramDeleteRetryPass 处理所有到期的失败项；未完成初始化（无 Telegram 客户端）时跳过。
+func (s *Server) runTelegramDeleteRetryPass(ctx context.Context) {
+	if _, err := s.requireTelegramClient(); err != nil {
+		return
+	}
+	st := store.New(s.db)
+	failures, err := st.ListDueTelegramDeleteFailures(ctx, time.Now(), telegramDeleteRetryMaxRetries, telegramDeleteRetryBatchSize)
+	if err != nil {
+		s.logger.Warn("list due telegram delete failures failed", "error", err.Error())
+		return
+	}
+	resolved := 0
+	for _, failure := range failures {
+		updated, err := s.retryTelegramDeleteFailure(ctx, st, failure)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				s.logger.Warn("retry telegram delete failure failed", "error", err.Error(), "failure_id", failure.ID.String())
+			}
+			continue
+		}
+		if updated.Resolved {
+			resolved++
+		}
+	}
+	if len(failures) > 0 {
+		s.logger.Info("telegram delete retry pass finished", "attempted", len(failures), "resolved", resolved)
+	}
+}
+
+// retryTelegramDeleteFailure 复用删除流程中的 cleanupTelegramTarget：无法删除的旧消息同样会被替换为占位内容。
+// 原消息的发送时间与文件类型已无从查询，类型按路径中的文件名推断。
+func (s *Server) retryTelegramDeleteFailure(
+	ctx context.Context,
+	st *store.Store,
+	failure store.TelegramDeleteFailure,
+) (store.TelegramDeleteFailure, error) {
+	item := store.Item{
+		Path: failure.ItemPath,
+		Name: path.Base(failure.ItemPath),
+	}
+	if failure.ItemID != nil {
+		item.ID = *failure.ItemID
+	}
+	item.Type = store.GuessItemType(item.Name, "")
+	target := telegramDeleteTarget{
+		chatID:    failure.TGChatID,
+		messageID: failure.TGMessageID,
+		itemType:  item.Type,
+	}
+
+	retryCtx, cancel := context.WithTimeout(ctx, telegramDeleteRetryTimeout)
+	result := s.cleanupTelegramTarget(retryCtx, item, target, time.Now())
+	cancel()
+
+	now := time.Now()
+	storeCtx := context.WithoutCancel(ctx)
+	switch result.action {
+	case telegramDeleteActionDeleted:
+		return st.ResolveTelegramDeleteFailure(storeCtx, failure.ID, store.TelegramDeleteResolutionDeleted, now)
+	case telegramDeleteActionReplaced:
+		return st.ResolveTelegramDeleteFailure(storeCtx, failure.ID, store.TelegramDeleteResolutionReplaced, now)
+	}
+
+	var nextRetryAt *time.Time
+	if retryCount := failure.RetryCount + 1; retryCount < telegramDeleteRetryMaxRetries {
+		next := now.Add(telegramDeleteRetryBackoff(retryCount))
+		nextRetryAt = &next
+	}
+	return st.MarkTelegramDeleteFailureRetryFailed(storeCtx, failure.ID, errorTextOrEmpty(result.err), now, nextRetryAt)
+}
+
+func parseTelegramDeleteFailureState(raw string) (*store.TelegramDeleteFailureState, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", string(store.TelegramDeleteFailureStateUnresolved):
+		state := store.TelegramDeleteFailureStateUnresolved
+		return &state, nil
+	case string(store.TelegramDeleteFailureStateResolved):
+		state := store.TelegramDeleteFailureStateResolved
+		return &state, nil
+	case string(store.TelegramDeleteFailureStateDismissed):
+		state := store.TelegramDeleteFailureStateDismissed
+		return &state, nil
+	case "all":
+		return nil, nil
+	default:
+		return nil, errors.New("status 仅支持 unresolved/resolved/dismissed/all")
+	}
+}
+
+// handleListTelegramDeleteFailures 列出删除失败的 Telegram 消息；status 默认 unresolved，q 匹配路径或错误信息。
+func (s *Server) handleListTelegramDeleteFailures(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	state, err := parseTelegramDeleteFailureState(q.Get("status"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	page := intFromQuery(q.Get("page"), 1)
+	pageSize := intFromQuery(q.Get("pageSize"), 50)
+	failures, total, err := store.New(s.db).ListTelegramDeleteFailures(r.Context(), store.TelegramDeleteFailureListParams{
+		State:    state,
+		ChatID:   q.Get("chatId"),
+		Query:    q.Get("q"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		s.logger.Error("list telegram delete failures failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	out := make([]telegramDeleteFailureDTO, 0, len(failures))
+	for _, failure := range failures {
+		out = append(out, toTelegramDeleteFailureDTO(failure))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":      out,
+		"pagination": newPaginationResponse(page, pageSize, total),
+	})
+}
+
+// handleRetryTelegramDeleteFailure 立即重试一次，不受退避时间与自动重试次数限制。
+func (s *Server) handleRetryTelegramDeleteFailure(w http.ResponseWriter, r *http.Request) {
+	failure, ok := s.loadUnresolvedTelegramDeleteFailure(w, r)
+	if !ok {
+		return
+	}
+	if _, err := s.requireTelegramClient(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Telegram 未配置")
+		return
+	}
+	updated, err := s.retryTelegramDeleteFailure(r.Context(), store.New(s.db), failure)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusConflict, "conflict", "该记录已处理")
+			return
+		}
+		s.logger.Error("retry telegram delete failure failed", "error", err.Error(), "failure_id", failure.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "重试失败")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"item": toTelegramDeleteFailureDTO(updated)})
+}
+
+// handleDismissTelegramDeleteFailure 人工忽略：不再重试，消息保留在频道中。
+func (s *Server) handleDismissTelegramDeleteFailure(w http.ResponseWriter, r *http.Request) {
+	failure, ok := s.loadUnresolvedTelegramDeleteFailure(w, r)
+	if !ok {
+		return
+	}
+	updated, err := store.New(s.db).ResolveTelegramDeleteFailure(r.Context(), failure.ID, store.TelegramDeleteResolutionDismissed, time.Now())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusConflict, "conflict", "该记录已处理")
+			return
+		}
+		s.logger.Error("dismiss telegram delete failure failed", "error", err.Error(), "failure_id", failure.ID.String())
+		writeError(w, http.StatusInternalServerError, "internal_error", "操作失败")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"item": toTelegramDeleteFailureDTO(updated)})
+}
+
+func (s *Server) loadUnresolvedTelegramDeleteFailure(w http.ResponseWriter, r *http.Request) (store.TelegramDeleteFailure, bool) {
+	id, err := parseUUIDParam(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "id 非法")
+		return store.TelegramDeleteFailure{}, false
+	}
+	failure, err := store.New(s.db).GetTelegramDeleteFailure(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "记录不存在")
+			return store.TelegramDeleteFailure{}, false
+		}
+		s.logger.Error("get telegram delete failure failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return store.TelegramDeleteFailure{}, false
+	}
+	if failure.Resolved {
+		writeError(w, http.StatusConflict, "conflict", "该记录已处理")
+		return store.TelegramDeleteFailure{}, false
+	}
+	return failure, true
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestTelegramDeleteRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		retryCount int
+		want       time.Duration
+	}{
+		{retryCount: 0, want: 5 * time.Minute},
+		{retryCount: 1, want: 5 * time.Minute},
+		{retryCount: 2, want: 10 * time.Minute},
+		{retryCount: 4, want: 40 * time.Minute},
+		{retryCount: 9, want: 1280 * time.Minute},
+		{retryCount: 10, want: 24 * time.Hour},
+		{retryCount: 100, want: 24 * time.Hour},
+	}
+	for _, tc := range cases {
+		if got := telegramDeleteRetryBackoff(tc.retryCount); got != tc.want {
+			t.Fatalf("telegramDeleteRetryBackoff(%d) = %s, want %s", tc.retryCount, got, tc.want)
+		}
+	}
+}
+
+func TestParseTelegramDeleteFailureState(t *testing.T) {
+	t.Parallel()
+
+	state, err := parseTelegramDeleteFailureState("")
+	if err != nil || state == nil || *state != store.TelegramDeleteFailureStateUnresolved {
+		t.Fatalf("default state = %v, %v", state, err)
+	}
+	if state, err := parseTelegramDeleteFailureState("all"); err != nil || state != nil {
+		t.Fatalf("all state = %v, %v", state, err)
+	}
+	if _, err := parseTelegramDeleteFailureState("pending"); err == nil {
+		t.Fatal("unknown state should be rejected")
+	}
+}
+
+func TestToTelegramDeleteFailureDTOExhausted(t *testing.T) {
+	t.Parallel()
+
+	failure := store.TelegramDeleteFailure{RetryCount: telegramDeleteRetryMaxRetries}
+	if !toTelegramDeleteFailureDTO(failure).AutoRetryExhausted {
+		t.Fatal("unresolved failure at max retries should be exhausted")
+	}
+	failure.Resolved = true
+	if toTelegramDeleteFailureDTO(failure).AutoRetryExhausted {
+		t.Fatal("resolved failure should not be exhausted")
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+// doTelegramFileRequest 发起 Telegram 文件下载请求；链路追踪开启时 span 持续到响应体关闭，
+// 因而覆盖完整的分片传输耗时，而不仅是拿到响应头。
+func doTelegramFileRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	_, span := tracing.StartChild(
+		req.Context(),
+		tracing.KindClient,
+		"telegram file fetch",
+		tracing.String("http.request.range", req.Header.Get("Range")),
+	)
+	resp, err := client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	if span == nil {
+		return resp, nil
+	}
+	span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetError(resp.Status)
+	}
+	resp.Body = &tracedResponseBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+type tracedResponseBody struct {
+	io.ReadCloser
+	span      *tracing.Span
+	bytes     int64
+	closeOnce sync.Once
+}
+
+func (b *tracedResponseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	return n, err
+}
+
+func (b *tracedResponseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.closeOnce.Do(func() {
+		b.span.SetAttributes(tracing.Int64("http.response.body.read", b.bytes))
+		b.span.End()
+	})
+	return err
+}
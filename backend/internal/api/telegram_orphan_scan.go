@@ -0,0 +1,631 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	telegramOrphanProbeBatchSize = 100
+	telegramOrphanProbePause     = time.Second
+	telegramOrphanReportLimit    = 10000
+	// 孤立消息不属于任何文件，删除失败时以该路径记录到 telegram_delete_failures。
+	telegramOrphanFailurePath = "/[孤立消息]"
+)
+
+type telegramOrphanDanglingRef struct {
+	MessageID  int64  `json:"messageId"`
+	ChunkID    string `json:"chunkId"`
+	ChunkIndex int    `json:"chunkIndex"`
+	ItemID     string `json:"itemId"`
+	ItemPath   string `json:"itemPath"`
+}
+
+// telegramOrphanScanJob 逐段探测存储频道中的消息，并与 telegram_chunks 对比；同一时间只运行一个，报告只保存在内存中。
+type telegramOrphanScanJob struct {
+	mu               sync.Mutex
+	id               uuid.UUID
+	status           string
+	chatID           string
+	fromMessageID    int64
+	toMessageID      int64
+	scannedUpTo      int64
+	existing         int64
+	referenced       int64
+	orphanCount      int64
+	orphans          []int64
+	danglingCount    int64
+	dangling         []telegramOrphanDanglingRef
+	lastError        string
+	cancelRequested  bool
+	cancel           context.CancelFunc
+	startedAt        time.Time
+	finishedAt       *time.Time
+	deleting         bool
+	deleteCancel     context.CancelFunc
+	deleteStats      telegramCleanupStats
+	deleteFinishedAt *time.Time
+}
+
+type telegramOrphanDeleteDTO struct {
+	Running    bool       `json:"running"`
+	Attempted  int        `json:"attempted"`
+	Deleted    int        `json:"deleted"`
+	Replaced   int        `json:"replaced"`
+	Failed     int        `json:"failed"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+type telegramOrphanScanDTO struct {
+	ID                string                      `json:"id"`
+	Status            string                      `json:"status"`
+	ChatID            string                      `json:"chatId"`
+	FromMessageID     int64                       `json:"fromMessageId"`
+	ToMessageID       int64                       `json:"toMessageId"`
+	ScannedUpTo       int64                       `json:"scannedUpTo"`
+	ExistingMessages  int64                       `json:"existingMessages"`
+	ReferencedExist   int64                       `json:"referencedMessages"`
+	OrphanCount       int64                       `json:"orphanCount"`
+	Orphans           []int64                     `json:"orphans"`
+	OrphansTruncated  bool                        `json:"orphansTruncated"`
+	DanglingCount     int64                       `json:"danglingCount"`
+	Dangling          []telegramOrphanDanglingRef `json:"dangling"`
+	DanglingTruncated bool                        `json:"danglingTruncated"`
+	LastError         string                      `json:"lastError,omitempty"`
+	CancelRequested   bool                        `json:"cancelRequested"`
+	StartedAt         time.Time                   `json:"startedAt"`
+	FinishedAt        *time.Time                  `json:"finishedAt,omitempty"`
+	Delete            *telegramOrphanDeleteDTO    `json:"delete,omitempty"`
+}
+
+func (j *telegramOrphanScanJob) snapshot() telegramOrphanScanDTO {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	dto := telegramOrphanScanDTO{
+		ID:                j.id.String(),
+		Status:            j.status,
+		ChatID:            j.chatID,
+		FromMessageID:     j.fromMessageID,
+		ToMessageID:       j.toMessageID,
+		ScannedUpTo:       j.scannedUpTo,
+		ExistingMessages:  j.existing,
+		ReferencedExist:   j.referenced,
+		OrphanCount:       j.orphanCount,
+		Orphans:           append([]int64{}, j.orphans...),
+		OrphansTruncated:  j.orphanCount > int64(len(j.orphans)),
+		DanglingCount:     j.danglingCount,
+		Dangling:          append([]telegramOrphanDanglingRef{}, j.dangling...),
+		DanglingTruncated: j.danglingCount > int64(len(j.dangling)),
+		LastError:         j.lastError,
+		CancelRequested:   j.cancelRequested,
+		StartedAt:         j.startedAt,
+		FinishedAt:        j.finishedAt,
+	}
+	if j.deleting || j.deleteFinishedAt != nil {
+		dto.Delete = &telegramOrphanDeleteDTO{
+			Running:    j.deleting,
+			Attempted:  j.deleteStats.Attempted,
+			Deleted:    j.deleteStats.Deleted,
+			Replaced:   j.deleteStats.Replaced,
+			Failed:     j.deleteStats.Failed,
+			FinishedAt: j.deleteFinishedAt,
+		}
+	}
+	return dto
+}
+
+func (j *telegramOrphanScanJob) running() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status == itemBackfillStatusRunning || j.deleting
+}
+
+func (j *telegramOrphanScanJob) recordWindow(upTo int64, existing int, referenced int, orphans []int64, dangling []store.TelegramChunkMessageRef) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.scannedUpTo = upTo
+	j.existing += int64(existing)
+	j.referenced += int64(referenced)
+	j.orphanCount += int64(len(orphans))
+	for _, id := range orphans {
+		if len(j.orphans) >= telegramOrphanReportLimit {
+			break
+		}
+		j.orphans = append(j.orphans, id)
+	}
+	j.danglingCount += int64(len(dangling))
+	for _, ref := range dangling {
+		if len(j.dangling) >= telegramOrphanReportLimit {
+			break
+		}
+		j.dangling = append(j.dangling, telegramOrphanDanglingRef{
+			MessageID:  ref.TGMessageID,
+			ChunkID:    ref.ChunkID.String(),
+			ChunkIndex: ref.ChunkIndex,
+			ItemID:     ref.ItemID.String(),
+			ItemPath:   ref.ItemPath,
+		})
+	}
+}
+
+func (j *telegramOrphanScanJob) finish(runErr error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch {
+	case j.cancelRequested:
+		j.status = itemBackfillStatusCanceled
+	case runErr != nil:
+		j.status = itemBackfillStatusFailed
+		j.lastError = runErr.Error()
+	default:
+		j.status = itemBackfillStatusCompleted
+	}
+	now := time.Now()
+	j.finishedAt = &now
+}
+
+func (j *telegramOrphanScanJob) requestCancel() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status != itemBackfillStatusRunning {
+		return false
+	}
+	j.cancelRequested = true
+	if j.cancel != nil {
+		j.cancel()
+	}
+	return true
+}
+
+// cancelDelete 中止正在进行的孤立消息删除；未处理的消息保留在报告中，可以再次删除。
+func (j *telegramOrphanScanJob) cancelDelete() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.deleting && j.deleteCancel != nil {
+		j.deleteCancel()
+	}
+}
+
+// probeExistingTelegramMessages 用批量转发判断消息是否存在：Telegram 会跳过不存在的消息且不返回对应关系，
+// 因此转发数量不足时二分后分别重试，直到定位出缺失的消息。probeCopies 为转发产生的副本，需要调用方删除。
+func probeExistingTelegramMessages(
+	ctx context.Context,
+	forward func(ctx context.Context, ids []int64) ([]int64, error),
+	ids []int64,
+) (existing map[int64]bool, probeCopies []int64, err error) {
+	existing = make(map[int64]bool, len(ids))
+	pending := [][]int64{ids}
+	for len(pending) > 0 {
+		if err := ctx.Err(); err != nil {
+			return existing, probeCopies, err
+		}
+		batch := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if len(batch) == 0 {
+			continue
+		}
+		copies, err := forward(ctx, batch)
+		probeCopies = append(probeCopies, copies...)
+		if err != nil {
+			return existing, probeCopies, err
+		}
+		switch {
+		case len(copies) >= len(batch):
+			for _, id := range batch {
+				existing[id] = true
+			}
+		case len(copies) == 0 || len(batch) == 1:
+		default:
+			mid := len(batch) / 2
+			pending = append(pending, batch[mid:], batch[:mid])
+		}
+	}
+	return existing, probeCopies, nil
+}
+
+// diffTelegramChannelMessages 对比一段消息 ID 的探测结果与分块引用：
+// 存在但无引用的为孤立消息，被引用但已不存在的为悬空引用。
+func diffTelegramChannelMessages(
+	ids []int64,
+	existing map[int64]bool,
+	refs []store.TelegramChunkMessageRef,
+) (orphans []int64, dangling []store.TelegramChunkMessageRef, referenced int) {
+	referencedIDs := make(map[int64]struct{}, len(refs))
+	for _, ref := range refs {
+		referencedIDs[ref.TGMessageID] = struct{}{}
+		if !existing[ref.TGMessageID] {
+			dangling = append(dangling, ref)
+		}
+	}
+	for _, id := range ids {
+		if !existing[id] {
+			continue
+		}
+		if _, ok := referencedIDs[id]; ok {
+			referenced++
+			continue
+		}
+		orphans = append(orphans, id)
+	}
+	return orphans, dangling, referenced
+}
+
+// telegramOrphanProbePauseFor 返回两次探测请求之间的间隔；自建 Bot API 没有官方服务器的频率限制，无需等待。
+func (s *Server) telegramOrphanProbePauseFor(ctx context.Context) time.Duration {
+	cfg, err := store.New(s.db).GetSystemConfig(ctx)
+	if err == nil && cfg.AccessMethod == setupAccessMethodSelfHosted {
+		return 0
+	}
+	return telegramOrphanProbePause
+}
+
+func (s *Server) forwardTelegramProbe(ctx context.Context, chatID string, ids []int64) ([]int64, error) {
+	tgClient, err := s.requireTelegramClient()
+	if err != nil {
+		return nil, err
+	}
+	return retryTelegramCall(ctx, defaultTelegramRetryPolicy(), func() ([]int64, error) {
+		return tgClient.ForwardMessages(ctx, chatID, chatID, ids)
+	})
+}
+
+// deleteTelegramProbeCopies 删除探测产生的转发副本；删除失败的副本记录为删除失败，由重试任务继续处理。
+func (s *Server) deleteTelegramProbeCopies(ctx context.Context, chatID string, copies []int64) {
+	if len(copies) == 0 {
+		return
+	}
+	tgClient, err := s.requireTelegramClient()
+	if err == nil {
+		for start := 0; start < len(copies); start += telegramOrphanProbeBatchSize {
+			end := min(start+telegramOrphanProbeBatchSize, len(copies))
+			batch := copies[start:end]
+			if err = retryTelegramAction(ctx, func() error {
+				return tgClient.DeleteMessages(ctx, chatID, batch)
+			}); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		return
+	}
+	s.logger.Warn("delete telegram probe copies failed", "error", err.Error(), "count", len(copies))
+	now := time.Now()
+	failures := make([]store.TelegramDeleteFailure, 0, len(copies))
+	for _, id := range copies {
+		failures = append(failures, store.TelegramDeleteFailure{
+			ID:          uuid.New(),
+			ItemPath:    telegramOrphanFailurePath,
+			TGChatID:    chatID,
+			TGMessageID: id,
+			Error:       err.Error(),
+			FailedAt:    now,
+		})
+	}
+	if upsertErr := store.New(s.db).UpsertTelegramDeleteFailures(context.WithoutCancel(ctx), failures); upsertErr != nil {
+		s.logger.Error("record telegram delete failures failed", "error", upsertErr.Error(), "count", len(failures))
+	}
+}
+
+// resolveTelegramChannelHead 转发最新一条被引用的消息，新消息的 ID 即频道当前最大 ID；
+// 该消息已不存在时退回到被引用的最大 ID。
+func (s *Server) resolveTelegramChannelHead(ctx context.Context, chatID string) (int64, error) {
+	maxRef, err := store.New(s.db).MaxTelegramChunkMessageID(ctx, chatID)
+	if err != nil {
+		return 0, err
+	}
+	if maxRef <= 0 {
+		return 0, nil
+	}
+	copies, err := s.forwardTelegramProbe(ctx, chatID, []int64{maxRef})
+	s.deleteTelegramProbeCopies(ctx, chatID, copies)
+	if err != nil {
+		return 0, err
+	}
+	if len(copies) == 0 {
+		return maxRef, nil
+	}
+	return copies[0] - 1, nil
+}
+
+func (s *Server) runTelegramOrphanScan(ctx context.Context, job *telegramOrphanScanJob) {
+	defer job.cancel()
+	st := store.New(s.db)
+	pause := s.telegramOrphanProbePauseFor(ctx)
+	forward := func(ctx context.Context, ids []int64) ([]int64, error) {
+		if err := sleepWithContext(ctx, pause); err != nil {
+			return nil, err
+		}
+		return s.forwardTelegramProbe(ctx, job.chatID, ids)
+	}
+
+	var runErr error
+	for start := job.fromMessageID; start <= job.toMessageID && ctx.Err() == nil; start += telegramOrphanProbeBatchSize {
+		end := minInt64(start+telegramOrphanProbeBatchSize-1, job.toMessageID)
+		ids := make([]int64, 0, end-start+1)
+		for id := start; id <= end; id++ {
+			ids = append(ids, id)
+		}
+		refs, err := st.ListTelegramChunkMessageRefs(ctx, job.chatID, start, end)
+		if err != nil {
+			runErr = err
+			break
+		}
+		existing, copies, err := probeExistingTelegramMessages(ctx, forward, ids)
+		s.deleteTelegramProbeCopies(context.WithoutCancel(ctx), job.chatID, copies)
+		if err != nil {
+			runErr = err
+			break
+		}
+		orphans, dangling, referenced := diffTelegramChannelMessages(ids, existing, refs)
+		job.recordWindow(end, len(existing), referenced, orphans, dangling)
+	}
+	if runErr != nil && ctx.Err() == nil {
+		s.logger.Error("telegram orphan scan failed", "error", runErr.Error(), "chat_id", job.chatID)
+	}
+	job.finish(runErr)
+	snapshot := job.snapshot()
+	s.logger.Info(
+		"telegram orphan scan finished",
+		"status", snapshot.Status,
+		"scanned_up_to", snapshot.ScannedUpTo,
+		"orphans", snapshot.OrphanCount,
+		"dangling", snapshot.DanglingCount,
+	)
+}
+
+func (s *Server) currentTelegramOrphanScan() *telegramOrphanScanJob {
+	s.telegramOrphanScanMu.Lock()
+	defer s.telegramOrphanScanMu.Unlock()
+	return s.telegramOrphanScan
+}
+
+// handleStartTelegramOrphanScan 扫描存储频道中 [fromMessageId, toMessageId] 的消息；
+// 未指定 toMessageId 时扫描到频道当前最新的消息。
+func (s *Server) handleStartTelegramOrphanScan(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FromMessageID int64 `json:"fromMessageId"`
+		ToMessageID   int64 `json:"toMessageId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+	if req.FromMessageID < 0 || req.ToMessageID < 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "消息 ID 不能为负数")
+		return
+	}
+	if _, err := s.requireTelegramClient(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Telegram 未配置")
+		return
+	}
+	chatID := strings.TrimSpace(s.cfg.TGStorageChatID)
+
+	s.telegramOrphanScanMu.Lock()
+	defer s.telegramOrphanScanMu.Unlock()
+	if s.telegramOrphanScan != nil && s.telegramOrphanScan.running() {
+		writeError(w, http.StatusConflict, "conflict", "已有频道扫描或孤立消息删除在运行")
+		return
+	}
+
+	from := maxInt64(req.FromMessageID, 1)
+	to := req.ToMessageID
+	if to == 0 {
+		head, err := s.resolveTelegramChannelHead(r.Context(), chatID)
+		if err != nil {
+			s.logger.Error("resolve telegram channel head failed", "error", err.Error())
+			writeError(w, http.StatusBadGateway, "bad_gateway", "无法确定频道最新消息 ID，请指定 toMessageId")
+			return
+		}
+		if head == 0 {
+			writeError(w, http.StatusBadRequest, "bad_request", "数据库中没有任何分块引用，请指定 toMessageId")
+			return
+		}
+		to = head
+	}
+	if to < from {
+		writeError(w, http.StatusBadRequest, "bad_request", "toMessageId 不能小于 fromMessageId")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	job := &telegramOrphanScanJob{
+		id:            uuid.New(),
+		status:        itemBackfillStatusRunning,
+		chatID:        chatID,
+		fromMessageID: from,
+		toMessageID:   to,
+		scannedUpTo:   from - 1,
+		orphans:       make([]int64, 0),
+		dangling:      make([]telegramOrphanDanglingRef, 0),
+		cancel:        cancel,
+		startedAt:     time.Now(),
+	}
+	s.telegramOrphanScan = job
+
+	s.goBackground(func() { s.runTelegramOrphanScan(ctx, job) })
+	writeJSON(w, http.StatusAccepted, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleGetTelegramOrphanScan(w http.ResponseWriter, r *http.Request) {
+	job := s.currentTelegramOrphanScan()
+	if job == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"job": nil})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+func (s *Server) handleCancelTelegramOrphanScan(w http.ResponseWriter, r *http.Request) {
+	job := s.currentTelegramOrphanScan()
+	if job == nil {
+		writeError(w, http.StatusConflict, "conflict", "当前副本没有频道扫描任务")
+		return
+	}
+	if !job.requestCancel() {
+		writeError(w, http.StatusConflict, "conflict", "任务已结束")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"job": job.snapshot()})
+}
+
+// handleDeleteTelegramOrphans 通过删除 worker 清理报告中的孤立消息；messageIds 为空时清理报告中的全部孤立消息。
+// 删除前会重新核对引用，扫描之后才写入分块记录的消息不会被误删。
+func (s *Server) handleDeleteTelegramOrphans(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MessageIDs []int64 `json:"messageIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "bad_request", "请求体不是合法 JSON")
+		return
+	}
+
+	s.telegramOrphanScanMu.Lock()
+	defer s.telegramOrphanScanMu.Unlock()
+	job := s.telegramOrphanScan
+	if job == nil {
+		writeError(w, http.StatusConflict, "conflict", "当前副本没有频道扫描报告")
+		return
+	}
+	if job.running() {
+		writeError(w, http.StatusConflict, "conflict", "频道扫描或孤立消息删除仍在运行")
+		return
+	}
+
+	job.mu.Lock()
+	candidates := selectTelegramOrphansToDelete(job.orphans, req.MessageIDs)
+	chatID := job.chatID
+	job.mu.Unlock()
+	if len(candidates) == 0 {
+		writeError(w, http.StatusConflict, "conflict", "没有可删除的孤立消息")
+		return
+	}
+
+	referenced, err := store.New(s.db).ListReferencedTelegramMessageIDs(r.Context(), chatID, candidates)
+	if err != nil {
+		s.logger.Error("list referenced telegram messages failed", "error", err.Error())
+		writeError(w, http.StatusInternalServerError, "internal_error", "查询失败")
+		return
+	}
+	targets := buildTelegramOrphanDeleteTargets(chatID, candidates, referenced)
+	if len(targets) == 0 {
+		writeError(w, http.StatusConflict, "conflict", "所选消息均已被分块引用")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	job.mu.Lock()
+	job.deleting = true
+	job.deleteCancel = cancel
+	job.deleteStats = telegramCleanupStats{Attempted: len(targets)}
+	job.deleteFinishedAt = nil
+	job.mu.Unlock()
+
+	s.goBackground(func() { s.runTelegramOrphanDelete(ctx, job, targets) })
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"job":     job.snapshot(),
+		"skipped": len(candidates) - len(targets),
+	})
+}
+
+// selectTelegramOrphansToDelete 返回 requested 与报告中孤立消息的交集；requested 为空时返回全部孤立消息。
+func selectTelegramOrphansToDelete(orphans []int64, requested []int64) []int64 {
+	if len(requested) == 0 {
+		return append([]int64{}, orphans...)
+	}
+	known := make(map[int64]struct{}, len(orphans))
+	for _, id := range orphans {
+		known[id] = struct{}{}
+	}
+	out := make([]int64, 0, len(requested))
+	for _, id := range requested {
+		if _, ok := known[id]; !ok {
+			continue
+		}
+		delete(known, id)
+		out = append(out, id)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func buildTelegramOrphanDeleteTargets(chatID string, candidates []int64, referenced []int64) []telegramDeleteTarget {
+	skip := make(map[int64]struct{}, len(referenced))
+	for _, id := range referenced {
+		skip[id] = struct{}{}
+	}
+	targets := make([]telegramDeleteTarget, 0, len(candidates))
+	for _, id := range candidates {
+		if _, ok := skip[id]; ok {
+			continue
+		}
+		// 孤立消息的发送时间未知，先尝试删除，不可删除时再替换为占位内容。
+		targets = append(targets, telegramDeleteTarget{
+			chatID:    chatID,
+			messageID: id,
+			itemType:  store.ItemTypeDocument,
+		})
+	}
+	return targets
+}
+
+func (s *Server) runTelegramOrphanDelete(ctx context.Context, job *telegramOrphanScanJob, targets []telegramDeleteTarget) {
+	item := store.Item{Path: telegramOrphanFailurePath, Name: telegramOrphanFailurePath[1:], Type: store.ItemTypeDocument}
+	results := s.runTelegramDeleteJobs(ctx, item, targets)
+	cleanup := buildTelegramCleanupResult(item, len(targets), results)
+	for idx := range cleanup.failures {
+		cleanup.failures[idx].ItemID = nil
+	}
+	if len(cleanup.failures) > 0 {
+		// 删除被取消时仍记录已失败的消息，交给重试 worker 处理
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownFlushTimeout)
+		if err := store.New(s.db).UpsertTelegramDeleteFailures(recordCtx, cleanup.failures); err != nil {
+			s.logger.Error("record telegram delete failures failed", "error", err.Error(), "count", len(cleanup.failures))
+		}
+		cancel()
+	}
+
+	removed := make(map[int64]struct{}, len(results))
+	for _, result := range results {
+		if result.action == telegramDeleteActionDeleted || result.action == telegramDeleteActionReplaced {
+			removed[result.target.messageID] = struct{}{}
+		}
+	}
+
+	job.mu.Lock()
+	remaining := job.orphans[:0]
+	for _, id := range job.orphans {
+		if _, ok := removed[id]; !ok {
+			remaining = append(remaining, id)
+		}
+	}
+	job.orphans = remaining
+	job.orphanCount -= int64(len(removed))
+	job.deleting = false
+	if job.deleteCancel != nil {
+		job.deleteCancel()
+		job.deleteCancel = nil
+	}
+	job.deleteStats = cleanup.stats
+	now := time.Now()
+	job.deleteFinishedAt = &now
+	job.mu.Unlock()
+
+	s.logger.Info(
+		"telegram orphan delete finished",
+		"attempted", cleanup.stats.Attempted,
+		"deleted", cleanup.stats.Deleted,
+		"replaced", cleanup.stats.Replaced,
+		"failed", cleanup.stats.Failed,
+	)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestProbeExistingTelegramMessages(t *testing.T) {
+	t.Parallel()
+
+	ids := make([]int64, 0, 100)
+	present := make(map[int64]bool, 100)
+	for id := int64(1); id <= 100; id++ {
+		ids = append(ids, id)
+		present[id] = id != 37 && id != 80
+	}
+	calls := 0
+	nextCopyID := int64(1000)
+	forward := func(_ context.Context, ids []int64) ([]int64, error) {
+		calls++
+		copies := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if present[id] {
+				nextCopyID++
+				copies = append(copies, nextCopyID)
+			}
+		}
+		return copies, nil
+	}
+
+	existing, copies, err := probeExistingTelegramMessages(context.Background(), forward, ids)
+	if err != nil {
+		t.Fatalf("probe error = %v", err)
+	}
+	for _, id := range ids {
+		if existing[id] != present[id] {
+			t.Fatalf("existing[%d] = %v, want %v", id, existing[id], present[id])
+		}
+	}
+	// 每条缺失消息约需 2·log2(100) 次调用，仍远少于逐条探测
+	if calls > 30 {
+		t.Fatalf("calls = %d, want bisection to stay well below one call per message", calls)
+	}
+	if int64(len(copies)) != nextCopyID-1000 {
+		t.Fatalf("probe copies = %d, want %d", len(copies), nextCopyID-1000)
+	}
+}
+
+func TestDiffTelegramChannelMessages(t *testing.T) {
+	t.Parallel()
+
+	ref := func(messageID int64) store.TelegramChunkMessageRef {
+		return store.TelegramChunkMessageRef{TGMessageID: messageID, ChunkID: uuid.New(), ItemID: uuid.New(), ItemPath: "/a.bin"}
+	}
+	ids := []int64{10, 11, 12, 13}
+	existing := map[int64]bool{10: true, 11: true, 13: true}
+	refs := []store.TelegramChunkMessageRef{ref(10), ref(12), ref(12)}
+
+	orphans, dangling, referenced := diffTelegramChannelMessages(ids, existing, refs)
+	if !reflect.DeepEqual(orphans, []int64{11, 13}) {
+		t.Fatalf("orphans = %v", orphans)
+	}
+	if len(dangling) != 2 || dangling[0].TGMessageID != 12 {
+		t.Fatalf("dangling = %+v", dangling)
+	}
+	if referenced != 1 {
+		t.Fatalf("referenced = %d, want 1", referenced)
+	}
+}
+
+func TestSelectTelegramOrphansToDelete(t *testing.T) {
+	t.Parallel()
+
+	orphans := []int64{3, 5, 9}
+	tests := []struct {
+		name      string
+		requested []int64
+		want      []int64
+	}{
+		{name: "all", requested: nil, want: []int64{3, 5, 9}},
+		{name: "subset", requested: []int64{9, 3}, want: []int64{3, 9}},
+		{name: "unknown and duplicate", requested: []int64{4, 5, 5}, want: []int64{5}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := selectTelegramOrphansToDelete(orphans, tc.requested)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("selectTelegramOrphansToDelete() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	targets := buildTelegramOrphanDeleteTargets("-100", []int64{3, 5, 9}, []int64{5})
+	if len(targets) != 2 || targets[0].messageID != 3 || targets[1].messageID != 9 || targets[0].chatID != "-100" {
+		t.Fatalf("targets = %+v", targets)
+	}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+const (
+	textCharsetUTF8     = "utf-8"
+	textCharsetUTF16LE  = "utf-16le"
+	textCharsetUTF16BE  = "utf-16be"
+	textCharsetGB18030  = "gb18030"
+	textCharsetShiftJIS = "shift_jis"
+)
+
+// 只有这两种本地编码在实际文件中常见，逐一试解码后按打分挑选
+var legacyTextCharsets = []struct {
+	name     string
+	encoding encoding.Encoding
+}{
+	{name: textCharsetGB18030, encoding: simplifiedchinese.GB18030},
+	{name: textCharsetShiftJIS, encoding: japanese.ShiftJIS},
+}
+
+// decodeTextPreview 识别编码并转为 UTF-8。truncated 表示 data 是截断的前缀，
+// 末尾不完整的多字节字符会被丢弃。无法识别为文本时 ok 为 false。
+func decodeTextPreview(data []byte, truncated bool) (text string, charset string, ok bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return decodeUTF8Preview(data[3:], truncated), textCharsetUTF8, true
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return decodeUTF16Preview(data, xunicode.LittleEndian, truncated), textCharsetUTF16LE, true
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeUTF16Preview(data, xunicode.BigEndian, truncated), textCharsetUTF16BE, true
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return "", "", false
+	}
+	if isValidUTF8Prefix(data, truncated) {
+		return decodeUTF8Preview(data, truncated), textCharsetUTF8, true
+	}
+
+	bestScore := 0
+	for _, candidate := range legacyTextCharsets {
+		decoded, err := candidate.encoding.NewDecoder().Bytes(data)
+		if err != nil {
+			continue
+		}
+		value := string(decoded)
+		if truncated {
+			value = trimTrailingReplacement(value)
+		}
+		score, plausible := scoreDecodedText(candidate.name, value)
+		if !plausible {
+			continue
+		}
+		if charset == "" || score > bestScore {
+			text, charset, bestScore = value, candidate.name, score
+		}
+	}
+	return text, charset, charset != ""
+}
+
+func isValidUTF8Prefix(data []byte, truncated bool) bool {
+	if utf8.Valid(data) {
+		return true
+	}
+	if !truncated {
+		return false
+	}
+	// 截断处可能落在多字节字符中间
+	for cut := 1; cut < utf8.UTFMax && cut <= len(data); cut++ {
+		if utf8.Valid(data[:len(data)-cut]) {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeUTF8Preview(data []byte, truncated bool) string {
+	if truncated {
+		for cut := 0; cut < utf8.UTFMax-1 && len(data) > 0; cut++ {
+			if r, size := utf8.DecodeLastRune(data); r != utf8.RuneError || size != 1 {
+				break
+			}
+			data = data[:len(data)-1]
+		}
+	}
+	return string(bytes.ToValidUTF8(data, []byte("\uFFFD")))
+}
+
+func decodeUTF16Preview(data []byte, endianness xunicode.Endianness, truncated bool) string {
+	if truncated && len(data)%2 == 1 {
+		data = data[:len(data)-1]
+	}
+	decoded, err := xunicode.UTF16(endianness, xunicode.ExpectBOM).NewDecoder().Bytes(data)
+	if err != nil {
+		return ""
+	}
+	value := string(decoded)
+	if truncated {
+		value = trimTrailingReplacement(value)
+	}
+	return value
+}
+
+// trimTrailingReplacement 去掉截断处不完整字符解码出的替换字符。
+func trimTrailingReplacement(value string) string {
+	if r, size := utf8.DecodeLastRuneInString(value); r == utf8.RuneError && size > 0 {
+		return value[:len(value)-size]
+	}
+	return value
+}
+
+// scoreDecodedText 给解码结果打分：替换字符与控制字符扣分；日文假名对 Shift-JIS 加分，
+// 而半角片假名在正常中文/日文文本中极少出现，通常意味着把 GBK 误当成了 Shift-JIS。
+func scoreDecodedText(charset string, value string) (int, bool) {
+	var total, bad, kana, halfWidthKana, han int
+	for _, r := range value {
+		if r < utf8.RuneSelf {
+			if r < 0x20 && r != '\n' && r != '\r' && r != '\t' && r != '\f' {
+				bad++
+			}
+			continue
+		}
+		total++
+		switch {
+		case r == utf8.RuneError:
+			bad++
+		case r >= 0xFF61 && r <= 0xFF9F:
+			halfWidthKana++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		}
+	}
+	if total == 0 {
+		return 0, bad == 0
+	}
+	// 超过 5% 的非 ASCII 字符无法解码，视为不是该编码
+	if bad*20 > total {
+		return 0, false
+	}
+	score := han + kana - bad*10 - halfWidthKana*3
+	if charset == textCharsetShiftJIS {
+		score += kana * 2
+	}
+	return score, true
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestDecodeTextPreview(t *testing.T) {
+	t.Parallel()
+
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().String("中文编码测试，这是一段简体中文内容。\n第二行")
+	sjis, _ := japanese.ShiftJIS.NewEncoder().String("これは日本語のテキストです。\nひらがなとカタカナ")
+
+	cases := []struct {
+		name        string
+		data        []byte
+		truncated   bool
+		wantCharset string
+		wantText    string
+		wantOK      bool
+	}{
+		{name: "utf8", data: []byte("hello 世界"), wantCharset: textCharsetUTF8, wantText: "hello 世界", wantOK: true},
+		{name: "utf8 bom", data: []byte("\xEF\xBB\xBFabc"), wantCharset: textCharsetUTF8, wantText: "abc", wantOK: true},
+		{name: "utf8 截断在多字节中间", data: []byte("ab世界")[:6], truncated: true, wantCharset: textCharsetUTF8, wantText: "ab世", wantOK: true},
+		{name: "utf16le bom", data: []byte{0xFF, 0xFE, 'h', 0, 'i', 0}, wantCharset: textCharsetUTF16LE, wantText: "hi", wantOK: true},
+		{name: "gbk", data: []byte(gbk), wantCharset: textCharsetGB18030, wantText: "中文编码测试，这是一段简体中文内容。\n第二行", wantOK: true},
+		{name: "shift_jis", data: []byte(sjis), wantCharset: textCharsetShiftJIS, wantText: "これは日本語のテキストです。\nひらがなとカタカナ", wantOK: true},
+		{name: "gbk 截断", data: []byte(gbk)[:len(gbk)-1], truncated: true, wantCharset: textCharsetGB18030, wantText: "中文编码测试，这是一段简体中文内容。\n第二", wantOK: true},
+		{name: "二进制", data: []byte{0x89, 'P', 'N', 'G', 0, 0, 0, 0x0d}, wantOK: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			text, charset, ok := decodeTextPreview(tc.data, tc.truncated)
+			if ok != tc.wantOK || charset != tc.wantCharset || text != tc.wantText {
+				t.Fatalf("decodeTextPreview() = %q, %q, %v; want %q, %q, %v", text, charset, ok, tc.wantText, tc.wantCharset, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestInferTextLanguage(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"main.go":       "go",
+		"README.md":     "markdown",
+		"Dockerfile":    "dockerfile",
+		"config.yml":    "yaml",
+		"notes":         "plaintext",
+		"script.PS1":    "powershell",
+		"component.tsx": "typescript",
+	}
+	for name, want := range cases {
+		if got := inferTextLanguage(name, ""); got != want {
+			t.Fatalf("inferTextLanguage(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := inferTextLanguage("data", "application/json"); got != "json" {
+		t.Fatalf("inferTextLanguage by mime = %q", got)
+	}
+}
+
+func TestCountTextLines(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]int{"": 0, "a": 1, "a\n": 1, "a\nb": 2, "a\r\nb\r\n": 2}
+	for content, want := range cases {
+		if got := countTextLines(content); got != want {
+			t.Fatalf("countTextLines(%q) = %d, want %d", content, got, want)
+		}
+	}
+}
+
+func TestRenderMarkdownToSafeHTML(t *testing.T) {
+	t.Parallel()
+
+	src := strings.Join([]string{
+		"# 标题 <b>",
+		"",
+		"正文 **加粗** 与 *斜体*，`a<b>`，snake_case_name。",
+		"[链接](https://example.com) [坏链接](javascript:alert(1)) ![图](../a.png)",
+		"<script>alert(1)</script>",
+		"",
+		"- [x] 完成",
+		"- 未完成",
+		"",
+		"> 引用",
+		"",
+		"| a | b |",
+		"|---|:-:|",
+		"| 1 | 2 |",
+		"",
+		"```go\"><script>",
+		"fmt.Println(\"<hi>\")",
+		"```",
+	}, "\n")
+	got := renderMarkdownToSafeHTML(src)
+
+	for _, want := range []string{
+		"<h1>标题 &lt;b&gt;</h1>",
+		"<strong>加粗</strong>",
+		"<em>斜体</em>",
+		"<code>a&lt;b&gt;</code>",
+		"snake_case_name",
+		`<a href="https://example.com" rel="nofollow noopener noreferrer">链接</a>`,
+		`<img src="../a.png" alt="图">`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		`<li><input type="checkbox" checked disabled> 完成</li>`,
+		"<blockquote>\n<p>引用</p>\n</blockquote>",
+		"<td>1</td><td>2</td>",
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("rendered html missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<script>") || strings.Contains(got, "javascript:") {
+		t.Fatalf("rendered html is not sanitized:\n%s", got)
+	}
+}
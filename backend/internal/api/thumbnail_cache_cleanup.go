@@ -18,8 +18,9 @@ type thumbnailFileInfo struct {
 }
 
 func (s *Server) startThumbnailCacheCleanupLoop() {
-	go func() {
+	s.goBackground(func() {
 		for {
+			s.markLoopHeartbeat(loopThumbnailCacheClean)
 			settings, err := s.getRuntimeSettings(context.Background())
 			if err != nil {
 				s.logger.Warn("load runtime settings for thumbnail cleanup failed", "error", err.Error())
@@ -28,9 +29,11 @@ func (s *Server) startThumbnailCacheCleanupLoop() {
 
 			ttl := thumbnailCacheTTLFromHours(settings.ThumbnailCacheTTLHours)
 			s.cleanupThumbnailCache(ttl, settings.ThumbnailCacheMaxBytes)
-			time.Sleep(thumbnailCacheCleanupInterval)
+			if !s.waitLoopInterval(thumbnailCacheCleanupInterval) {
+				return
+			}
 		}
-	}()
+	})
 }
 
 func thumbnailCacheTTLFromHours(hours int) time.Duration {
@@ -70,7 +73,7 @@ func (s *Server) cleanupThumbnailCache(ttl time.Duration, maxBytes int64) {
 		}
 
 		name := strings.ToLower(strings.TrimSpace(entry.Name()))
-		if !strings.HasSuffix(name, ".jpg") {
+		if !isThumbnailCacheFileName(name) {
 			continue
 		}
 
@@ -98,13 +101,19 @@ func (s *Server) cleanupThumbnailCache(ttl time.Duration, maxBytes int64) {
 	}
 
 	if maxBytes <= 0 {
+		remaining := len(files)
 		for _, file := range files {
-			_ = os.Remove(file.path)
+			if os.Remove(file.path) == nil {
+				totalSize -= file.size
+				remaining--
+			}
 		}
+		s.metrics.setThumbnailCacheUsage(totalSize, remaining)
 		return
 	}
 
 	if totalSize <= maxBytes {
+		s.metrics.setThumbnailCacheUsage(totalSize, len(files))
 		return
 	}
 
@@ -112,6 +121,7 @@ func (s *Server) cleanupThumbnailCache(ttl time.Duration, maxBytes int64) {
 		return files[i].modTime.Before(files[j].modTime)
 	})
 
+	remaining := len(files)
 	for _, file := range files {
 		if totalSize <= maxBytes {
 			break
@@ -120,5 +130,7 @@ func (s *Server) cleanupThumbnailCache(ttl time.Duration, maxBytes int64) {
 			continue
 		}
 		totalSize -= file.size
+		remaining--
 	}
+	s.metrics.setThumbnailCacheUsage(totalSize, remaining)
 }
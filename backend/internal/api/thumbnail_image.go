@@ -0,0 +1,428 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	// 超过该像素数的图片交给 ffmpeg 处理，避免纯 Go 解码占用过多内存
+	thumbnailImageMaxPixels     = 40 * 1000 * 1000
+	thumbnailImageJPEGQuality   = 82
+	thumbnailResizeMaxSamples   = 4
+	exifOrientationTag          = 0x0112
+	exifSearchMaxBytes          = 256 * 1024
+	exifOrientationNormal       = 1
+	exifOrientationMaxSupported = 8
+)
+
+func (s *Server) generateAndCacheImageThumbnail(ctx context.Context, item store.Item, cachePath string) error {
+	inputPath, err := s.downloadItemFullToTempFile(ctx, item, thumbnailFullInputMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputPath)
+
+	thumb, err := buildImageThumbnailFromFile(inputPath, thumbnailWidthPx)
+	if err != nil {
+		// 大图与 webp/heic/bmp 等标准库无法解码的格式交给 ffmpeg，方向校正与纯 Go 路径保持一致
+		inputArgs, orientFilter := ffmpegImageOrientationArgs(inputPath)
+		args := append(inputArgs, "-i", inputPath, "-frames:v", "1", "-vf", joinFFmpegFilters(orientFilter, thumbnailFitFilter()))
+		err = s.runFFmpegThumbnail(ctx, cachePath, args...)
+	} else {
+		err = writeThumbnailJPEG(thumb, cachePath)
+	}
+	if err != nil {
+		return err
+	}
+	// 顺带从缩略图计算感知指纹，供相似图片查找使用；失败不影响缩略图
+	if hashErr := s.storeItemImageHashFromThumbnail(ctx, item, cachePath); hashErr != nil {
+		s.logger.Warn("store image hash failed", "error", hashErr.Error(), "item_id", item.ID.String())
+	}
+	return nil
+}
+
+func buildImageThumbnailFromFile(path string, maxSide int) (image.Image, error) {
+	src, orientation, _, err := decodeImageFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	dw, dh := fitThumbnailSize(bounds.Dx(), bounds.Dy(), maxSide)
+	return applyExifOrientation(resizeImageSampled(src, dw, dh, true), orientation), nil
+}
+
+// decodeImageFile 以纯 Go 解码 JPEG/PNG/GIF，同时返回 Exif 方向与 JPEG 头部数据（用于保留 Exif）。
+func decodeImageFile(path string) (image.Image, int, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(bufio.NewReader(f))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > thumbnailImageMaxPixels {
+		return nil, 0, nil, errors.New("图片尺寸超出纯 Go 处理范围")
+	}
+
+	orientation := exifOrientationNormal
+	var head []byte
+	if format == "jpeg" {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, nil, err
+		}
+		head = make([]byte, exifSearchMaxBytes)
+		n, _ := io.ReadFull(f, head)
+		head = head[:n]
+		orientation = parseJPEGExifOrientation(head)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, err
+	}
+	src, _, err := image.Decode(bufio.NewReader(f))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return src, orientation, head, nil
+}
+
+func writeThumbnailJPEG(img image.Image, cachePath string) error {
+	outTmp, err := os.CreateTemp(filepath.Dir(cachePath), "tgcd-thumb-*.jpg")
+	if err != nil {
+		return err
+	}
+	outPath := outTmp.Name()
+	defer os.Remove(outPath)
+
+	if err := jpeg.Encode(outTmp, img, &jpeg.Options{Quality: thumbnailImageJPEGQuality}); err != nil {
+		_ = outTmp.Close()
+		return err
+	}
+	if err := outTmp.Close(); err != nil {
+		return err
+	}
+	return commitThumbnailFile(outPath, cachePath)
+}
+
+// fitThumbnailSize 等比缩放到最长边不超过 maxSide，不放大。
+func fitThumbnailSize(width int, height int, maxSide int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return 1, 1
+	}
+	if maxSide <= 0 || (width <= maxSide && height <= maxSide) {
+		return width, height
+	}
+	if width >= height {
+		h := int(int64(height) * int64(maxSide) / int64(width))
+		if h < 1 {
+			h = 1
+		}
+		return maxSide, h
+	}
+	w := int(int64(width) * int64(maxSide) / int64(height))
+	if w < 1 {
+		w = 1
+	}
+	return w, maxSide
+}
+
+// resizeImageSampled 在每个目标像素对应的源区域内均匀取至多 4x4 个采样点求平均；
+// flatten 为 true 时透明像素合成到白底（JPEG 不支持透明）。
+func resizeImageSampled(src image.Image, dw int, dh int, flatten bool) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0 := y * sh / dh
+		y1 := (y + 1) * sh / dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < dw; x++ {
+			x0 := x * sw / dw
+			x1 := (x + 1) * sw / dw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			dst.SetRGBA(x, y, averageImageRegion(src, bounds.Min.X+x0, bounds.Min.Y+y0, x1-x0, y1-y0, flatten))
+		}
+	}
+	return dst
+}
+
+func averageImageRegion(src image.Image, x0 int, y0 int, w int, h int, flatten bool) color.RGBA {
+	stepsX := minInt(w, thumbnailResizeMaxSamples)
+	stepsY := minInt(h, thumbnailResizeMaxSamples)
+	var r, g, b, a uint64
+	for iy := 0; iy < stepsY; iy++ {
+		sy := y0 + (2*iy+1)*h/(2*stepsY)
+		for ix := 0; ix < stepsX; ix++ {
+			sx := x0 + (2*ix+1)*w/(2*stepsX)
+			cr, cg, cb, ca := src.At(sx, sy).RGBA()
+			white := uint64(0)
+			if flatten {
+				white = 0xffff - uint64(ca)
+				ca = 0xffff
+			}
+			r += uint64(cr) + white
+			g += uint64(cg) + white
+			b += uint64(cb) + white
+			a += uint64(ca)
+		}
+	}
+	n := uint64(stepsX * stepsY)
+	return color.RGBA{
+		R: uint8((r / n) >> 8),
+		G: uint8((g / n) >> 8),
+		B: uint8((b / n) >> 8),
+		A: uint8((a / n) >> 8),
+	}
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// parseJPEGExifOrientation 从 JPEG 头部的 APP1 Exif 段读取方向标记；缺失或异常时返回 1。
+func parseJPEGExifOrientation(data []byte) int {
+	start, end, ok := findJPEGExifSegment(data)
+	if !ok {
+		return exifOrientationNormal
+	}
+	value, _ := locateTIFFOrientation(data[start+10 : end])
+	return value
+}
+
+// extractJPEGExifSegment 复制 APP1 Exif 段（含标记与长度），并把方向重置为 1，
+// 因为输出图像已按原方向校正。
+func extractJPEGExifSegment(data []byte) []byte {
+	start, end, ok := findJPEGExifSegment(data)
+	if !ok {
+		return nil
+	}
+	segment := append([]byte(nil), data[start:end]...)
+	tiff := segment[10:]
+	if _, offset := locateTIFFOrientation(tiff); offset > 0 {
+		order := tiffByteOrder(tiff)
+		order.PutUint16(tiff[offset:offset+2], exifOrientationNormal)
+	}
+	return segment
+}
+
+// findJPEGExifSegment 返回 APP1 Exif 段在 data 中的范围 [start, end)，start 指向 0xFF 标记。
+func findJPEGExifSegment(data []byte) (int, int, bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0, 0, false
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, 0, false
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return 0, 0, false
+		}
+		segmentLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if segmentLen < 2 || pos+2+segmentLen > len(data) {
+			return 0, 0, false
+		}
+		segment := data[pos+4 : pos+2+segmentLen]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return pos, pos + 2 + segmentLen, true
+		}
+		pos += 2 + segmentLen
+	}
+	return 0, 0, false
+}
+
+func tiffByteOrder(tiff []byte) binary.ByteOrder {
+	if len(tiff) < 2 {
+		return nil
+	}
+	switch string(tiff[:2]) {
+	case "II":
+		return binary.LittleEndian
+	case "MM":
+		return binary.BigEndian
+	default:
+		return nil
+	}
+}
+
+// locateTIFFOrientation 返回 IFD0 中的方向值及其在 tiff 中的偏移；未找到时偏移为 0。
+func locateTIFFOrientation(tiff []byte) (int, int) {
+	order := tiffByteOrder(tiff)
+	if order == nil || len(tiff) < 8 || order.Uint16(tiff[2:4]) != 42 {
+		return exifOrientationNormal, 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return exifOrientationNormal, 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+		value := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if value < exifOrientationNormal || value > exifOrientationMaxSupported {
+			return exifOrientationNormal, entry + 8
+		}
+		return value, entry + 8
+	}
+	return exifOrientationNormal, 0
+}
+
+// applyExifOrientation 按 Exif 方向（1~8）旋转/翻转，使输出为正向显示。
+func applyExifOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= exifOrientationNormal || orientation > exifOrientationMaxSupported {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
+
+// ffmpegImageOrientationArgs 读取 JPEG/WebP 的 Exif 方向，返回关闭 ffmpeg 自动旋转的输入参数与对应的校正滤镜；
+// 其他格式（如 heic）交给 ffmpeg 按容器内的旋转信息自动处理。
+func ffmpegImageOrientationArgs(path string) ([]string, string) {
+	orientation, ok := readImageFileExifOrientation(path)
+	if !ok {
+		return nil, ""
+	}
+	return []string{"-noautorotate"}, exifOrientationFFmpegFilter(orientation)
+}
+
+// exifOrientationFFmpegFilter 返回与 applyExifOrientation 等价的 ffmpeg 滤镜，方向为 1 或非法时返回空串。
+func exifOrientationFFmpegFilter(orientation int) string {
+	switch orientation {
+	case 2:
+		return "hflip"
+	case 3:
+		return "hflip,vflip"
+	case 4:
+		return "vflip"
+	case 5:
+		return "transpose=cclock_flip"
+	case 6:
+		return "transpose=clock"
+	case 7:
+		return "transpose=clock_flip"
+	case 8:
+		return "transpose=cclock"
+	default:
+		return ""
+	}
+}
+
+func joinFFmpegFilters(filters ...string) string {
+	parts := make([]string, 0, len(filters))
+	for _, filter := range filters {
+		if filter != "" {
+			parts = append(parts, filter)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// readImageFileExifOrientation 读取 JPEG 或 WebP 文件的 Exif 方向；ok 为 false 表示不是这两种格式。
+func readImageFileExifOrientation(path string) (int, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return exifOrientationNormal, false
+	}
+	defer f.Close()
+
+	head := make([]byte, exifSearchMaxBytes)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	switch {
+	case len(head) >= 2 && head[0] == 0xFF && head[1] == 0xD8:
+		return parseJPEGExifOrientation(head), true
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return readWebPExifOrientation(f), true
+	default:
+		return exifOrientationNormal, false
+	}
+}
+
+// readWebPExifOrientation 按 RIFF 块遍历查找 EXIF 块；EXIF 通常位于图像数据之后，因此逐块跳过而不是只读头部。
+func readWebPExifOrientation(f *os.File) int {
+	pos := int64(12)
+	header := make([]byte, 8)
+	for {
+		if _, err := f.ReadAt(header, pos); err != nil {
+			return exifOrientationNormal
+		}
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		if string(header[0:4]) == "EXIF" {
+			if size <= 0 || size > exifSearchMaxBytes {
+				return exifOrientationNormal
+			}
+			data := make([]byte, size)
+			if _, err := f.ReadAt(data, pos+8); err != nil {
+				return exifOrientationNormal
+			}
+			value, _ := locateTIFFOrientation(bytes.TrimPrefix(data, []byte("Exif\x00\x00")))
+			return value
+		}
+		// RIFF 块按偶数字节对齐
+		pos += 8 + size + size%2
+	}
+}
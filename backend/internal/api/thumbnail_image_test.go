@@ -0,0 +1,275 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestResolveThumbnailKind(t *testing.T) {
+	t.Parallel()
+
+	pdfMime := "application/pdf"
+	jsonMime := "application/json"
+	cases := []struct {
+		name string
+		item store.Item
+		want thumbnailKind
+	}{
+		{name: "视频", item: store.Item{Type: store.ItemTypeVideo, Name: "a.mp4"}, want: thumbnailKindVideo},
+		{name: "图片", item: store.Item{Type: store.ItemTypeImage, Name: "a.jpg"}, want: thumbnailKindImage},
+		{name: "音频", item: store.Item{Type: store.ItemTypeAudio, Name: "a.mp3"}, want: thumbnailKindAudio},
+		{name: "代码", item: store.Item{Type: store.ItemTypeCode, Name: "main.go"}, want: thumbnailKindText},
+		{name: "PDF 按 mime", item: store.Item{Type: store.ItemTypeDocument, Name: "report", MimeType: &pdfMime}, want: thumbnailKindPDF},
+		{name: "PDF 按扩展名", item: store.Item{Type: store.ItemTypeDocument, Name: "report.PDF"}, want: thumbnailKindPDF},
+		{name: "JSON 文档", item: store.Item{Type: store.ItemTypeDocument, Name: "data", MimeType: &jsonMime}, want: thumbnailKindText},
+		{name: "Markdown", item: store.Item{Type: store.ItemTypeDocument, Name: "README.md"}, want: thumbnailKindText},
+		{name: "压缩包", item: store.Item{Type: store.ItemTypeArchive, Name: "a.zip"}, want: thumbnailKindNone},
+		{name: "文件夹", item: store.Item{Type: store.ItemTypeFolder, Name: "docs.txt"}, want: thumbnailKindNone},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := resolveThumbnailKind(tc.item); got != tc.want {
+				t.Fatalf("resolveThumbnailKind() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseJPEGExifOrientation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		order binary.ByteOrder
+		value uint16
+		want  int
+	}{
+		{name: "小端旋转 90°", order: binary.LittleEndian, value: 6, want: 6},
+		{name: "大端旋转 270°", order: binary.BigEndian, value: 8, want: 8},
+		{name: "非法值回退", order: binary.LittleEndian, value: 9, want: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			data := buildTestExifJPEGHeader(tc.order, tc.value)
+			if got := parseJPEGExifOrientation(data); got != tc.want {
+				t.Fatalf("parseJPEGExifOrientation() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	if got := parseJPEGExifOrientation([]byte("not a jpeg")); got != 1 {
+		t.Fatalf("parseJPEGExifOrientation(non-jpeg) = %d, want 1", got)
+	}
+}
+
+func TestApplyExifOrientation(t *testing.T) {
+	t.Parallel()
+
+	// 2x1：左红右蓝
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(1, 0, blue)
+
+	cases := []struct {
+		name        string
+		orientation int
+		wantW       int
+		wantH       int
+		wantFirst   color.RGBA
+	}{
+		{name: "正常", orientation: 1, wantW: 2, wantH: 1, wantFirst: red},
+		{name: "水平翻转", orientation: 2, wantW: 2, wantH: 1, wantFirst: blue},
+		{name: "顺时针 90°", orientation: 6, wantW: 1, wantH: 2, wantFirst: red},
+		{name: "逆时针 90°", orientation: 8, wantW: 1, wantH: 2, wantFirst: blue},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := applyExifOrientation(src, tc.orientation)
+			if got.Bounds().Dx() != tc.wantW || got.Bounds().Dy() != tc.wantH {
+				t.Fatalf("applyExifOrientation() size = %v, want %dx%d", got.Bounds(), tc.wantW, tc.wantH)
+			}
+			if first := got.RGBAAt(0, 0); first != tc.wantFirst {
+				t.Fatalf("applyExifOrientation() first pixel = %v, want %v", first, tc.wantFirst)
+			}
+		})
+	}
+}
+
+func TestFitThumbnailSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		width, height int
+		wantW, wantH  int
+	}{
+		{name: "小图不放大", width: 100, height: 50, wantW: 100, wantH: 50},
+		{name: "横图", width: 4000, height: 3000, wantW: 480, wantH: 360},
+		{name: "竖图", width: 1000, height: 4000, wantW: 120, wantH: 480},
+		{name: "极窄图", width: 10000, height: 2, wantW: 480, wantH: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			w, h := fitThumbnailSize(tc.width, tc.height, thumbnailWidthPx)
+			if w != tc.wantW || h != tc.wantH {
+				t.Fatalf("fitThumbnailSize() = %dx%d, want %dx%d", w, h, tc.wantW, tc.wantH)
+			}
+		})
+	}
+}
+
+func TestResizeImageSampledCompositesTransparentOnWhite(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	got := resizeImageSampled(src, 2, 2, true)
+	if px := got.RGBAAt(1, 1); px != (color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		t.Fatalf("resizeImageSampled() transparent pixel = %v, want white", px)
+	}
+}
+
+func TestBuildImageThumbnailFromFile(t *testing.T) {
+	t.Parallel()
+
+	src := image.NewRGBA(image.Rect(0, 0, 960, 480))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		t.Fatalf("encode jpeg failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "src.jpg")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write jpeg failed: %v", err)
+	}
+
+	thumb, err := buildImageThumbnailFromFile(path, thumbnailWidthPx)
+	if err != nil {
+		t.Fatalf("buildImageThumbnailFromFile() error = %v", err)
+	}
+	if thumb.Bounds().Dx() != 480 || thumb.Bounds().Dy() != 240 {
+		t.Fatalf("buildImageThumbnailFromFile() size = %v, want 480x240", thumb.Bounds())
+	}
+}
+
+func TestRenderTextThumbnail(t *testing.T) {
+	t.Parallel()
+
+	if looksLikeText([]byte{'a', 0, 'b'}) {
+		t.Fatalf("looksLikeText(binary) = true, want false")
+	}
+	img := renderTextThumbnail([]byte("package main\n\n\tfmt.Println(42) // 你好\n"))
+	if img.Bounds().Dx() != textThumbnailWidth || img.Bounds().Dy() != textThumbnailHeight {
+		t.Fatalf("renderTextThumbnail() size = %v", img.Bounds())
+	}
+	x := textThumbnailPadding
+	y := textThumbnailPadding
+	if img.RGBAAt(x, y) != textThumbnailInk {
+		t.Fatalf("renderTextThumbnail() first glyph = %v, want ink", img.RGBAAt(x, y))
+	}
+	if img.RGBAAt(x, y+textThumbnailLineHeight) != textThumbnailBackground {
+		t.Fatalf("renderTextThumbnail() blank line should stay background")
+	}
+}
+
+func buildTestExifJPEGHeader(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3)
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	segLen := make([]byte, 2)
+	binary.BigEndian.PutUint16(segLen, uint16(len(payload)+2))
+	out = append(out, segLen...)
+	out = append(out, payload...)
+	return append(out, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestReadImageFileExifOrientation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	jpegPath := filepath.Join(dir, "rotated.jpg")
+	if err := os.WriteFile(jpegPath, buildTestExifJPEGHeader(binary.BigEndian, 6), 0o600); err != nil {
+		t.Fatalf("write jpeg failed: %v", err)
+	}
+	if got, ok := readImageFileExifOrientation(jpegPath); !ok || got != 6 {
+		t.Fatalf("readImageFileExifOrientation(jpeg) = %d, %v, want 6, true", got, ok)
+	}
+
+	// EXIF 块位于奇数长度的图像块之后，验证填充字节的跳过
+	tiff := buildTestExifJPEGHeader(binary.LittleEndian, 8)[12:]
+	tiff = tiff[:len(tiff)-4]
+	webp := []byte("RIFF\x00\x00\x00\x00WEBP")
+	webp = append(webp, "VP8L"...)
+	webp = binary.LittleEndian.AppendUint32(webp, 3)
+	webp = append(webp, 1, 2, 3, 0)
+	webp = append(webp, "EXIF"...)
+	webp = binary.LittleEndian.AppendUint32(webp, uint32(len(tiff)))
+	webp = append(webp, tiff...)
+	webpPath := filepath.Join(dir, "rotated.webp")
+	if err := os.WriteFile(webpPath, webp, 0o600); err != nil {
+		t.Fatalf("write webp failed: %v", err)
+	}
+	if got, ok := readImageFileExifOrientation(webpPath); !ok || got != 8 {
+		t.Fatalf("readImageFileExifOrientation(webp) = %d, %v, want 8, true", got, ok)
+	}
+
+	pngPath := filepath.Join(dir, "plain.png")
+	if err := os.WriteFile(pngPath, []byte("\x89PNG\r\n\x1a\n"), 0o600); err != nil {
+		t.Fatalf("write png failed: %v", err)
+	}
+	if _, ok := readImageFileExifOrientation(pngPath); ok {
+		t.Fatalf("readImageFileExifOrientation(png) ok = true, want false")
+	}
+}
+
+func TestFFmpegImageOrientationArgs(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rotated.jpg")
+	if err := os.WriteFile(path, buildTestExifJPEGHeader(binary.BigEndian, 6), 0o600); err != nil {
+		t.Fatalf("write jpeg failed: %v", err)
+	}
+	args, filter := ffmpegImageOrientationArgs(path)
+	if len(args) != 1 || args[0] != "-noautorotate" || filter != "transpose=clock" {
+		t.Fatalf("ffmpegImageOrientationArgs() = %v, %q", args, filter)
+	}
+	if got := joinFFmpegFilters(filter, "scale=480:-1"); got != "transpose=clock,scale=480:-1" {
+		t.Fatalf("joinFFmpegFilters() = %q", got)
+	}
+	if got := joinFFmpegFilters(exifOrientationFFmpegFilter(1), "scale=480:-1"); got != "scale=480:-1" {
+		t.Fatalf("joinFFmpegFilters(normal) = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+type thumbnailKind string
+
+const (
+	thumbnailKindNone  thumbnailKind = ""
+	thumbnailKindVideo thumbnailKind = "video"
+	thumbnailKindImage thumbnailKind = "image"
+	thumbnailKindAudio thumbnailKind = "audio"
+	thumbnailKindPDF   thumbnailKind = "pdf"
+	thumbnailKindText  thumbnailKind = "text"
+
+	// 图片与 PDF 需要完整内容才能解码，超过上限直接拒绝，避免把大文件整份拉到本地
+	thumbnailFullInputMaxBytes int64 = 64 * 1024 * 1024
+	thumbnailTextInputMaxBytes int64 = 16 * 1024
+)
+
+var (
+	errThumbnailSourceTooLarge = errors.New("thumbnail source too large")
+	errThumbnailNoCover        = errors.New("audio has no cover art")
+	errThumbnailNotText        = errors.New("content is not text")
+)
+
+var thumbnailTextExtensions = map[string]struct{}{
+	".txt": {}, ".md": {}, ".markdown": {}, ".csv": {}, ".tsv": {}, ".log": {},
+	".json": {}, ".xml": {}, ".yaml": {}, ".yml": {}, ".toml": {}, ".ini": {},
+	".conf": {}, ".cfg": {}, ".env": {}, ".srt": {}, ".vtt": {}, ".ass": {},
+}
+
+var thumbnailTextMimeTypes = map[string]struct{}{
+	"application/json":       {},
+	"application/xml":        {},
+	"application/x-yaml":     {},
+	"application/yaml":       {},
+	"application/toml":       {},
+	"application/javascript": {},
+	"application/x-sh":       {},
+}
+
+func resolveThumbnailKind(item store.Item) thumbnailKind {
+	switch item.Type {
+	case store.ItemTypeVideo:
+		return thumbnailKindVideo
+	case store.ItemTypeImage:
+		return thumbnailKindImage
+	case store.ItemTypeAudio:
+		return thumbnailKindAudio
+	case store.ItemTypeCode:
+		return thumbnailKindText
+	case store.ItemTypeFolder:
+		return thumbnailKindNone
+	}
+
+	mimeType := resolveDownloadMimeType(item)
+	ext := strings.ToLower(filepath.Ext(item.Name))
+	if mimeType == "application/pdf" || ext == ".pdf" {
+		return thumbnailKindPDF
+	}
+	if strings.HasPrefix(mimeType, "text/") {
+		return thumbnailKindText
+	}
+	if _, ok := thumbnailTextMimeTypes[mimeType]; ok {
+		return thumbnailKindText
+	}
+	if _, ok := thumbnailTextExtensions[ext]; ok {
+		return thumbnailKindText
+	}
+	return thumbnailKindNone
+}
+
+func thumbnailToolMissingMessage(kind thumbnailKind) string {
+	if kind == thumbnailKindPDF {
+		return "pdftoppm 未安装，无法生成 PDF 缩略图"
+	}
+	return "ffmpeg 未安装，无法生成缩略图"
+}
+
+func (s *Server) generateAndCacheThumbnail(ctx context.Context, item store.Item, kind thumbnailKind, cachePath string) error {
+	switch kind {
+	case thumbnailKindVideo:
+		return s.generateAndCacheVideoThumbnail(ctx, item, cachePath)
+	case thumbnailKindImage:
+		return s.generateAndCacheImageThumbnail(ctx, item, cachePath)
+	case thumbnailKindAudio:
+		return s.generateAndCacheAudioCoverThumbnail(ctx, item, cachePath)
+	case thumbnailKindPDF:
+		return s.generateAndCachePDFThumbnail(ctx, item, cachePath)
+	case thumbnailKindText:
+		return s.generateAndCacheTextThumbnail(ctx, item, cachePath)
+	default:
+		return fmt.Errorf("unsupported thumbnail kind: %s", kind)
+	}
+}
+
+// downloadItemFullToTempFile 拉取完整内容；超过上限返回 errThumbnailSourceTooLarge。
+func (s *Server) downloadItemFullToTempFile(ctx context.Context, item store.Item, maxBytes int64) (string, error) {
+	if item.Size > maxBytes {
+		return "", errThumbnailSourceTooLarge
+	}
+	return s.downloadItemPrefixToTempFile(ctx, item.ID, maxBytes)
+}
+
+func (s *Server) generateAndCacheAudioCoverThumbnail(ctx context.Context, item store.Item, cachePath string) error {
+	// 封面通常位于 ID3/元数据头部，读取前缀即可
+	inputPath, err := s.downloadItemPrefixToTempFile(ctx, item.ID, thumbnailInputMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputPath)
+
+	err = s.runFFmpegThumbnail(ctx, cachePath, "-i", inputPath, "-map", "0:v:0", "-frames:v", "1", "-vf", thumbnailFitFilter())
+	if err != nil && strings.Contains(err.Error(), "matches no streams") {
+		return errThumbnailNoCover
+	}
+	return err
+}
+
+func (s *Server) generateAndCachePDFThumbnail(ctx context.Context, item store.Item, cachePath string) error {
+	inputPath, err := s.downloadItemFullToTempFile(ctx, item, thumbnailFullInputMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputPath)
+
+	outDir, err := os.MkdirTemp(filepath.Dir(cachePath), "tgcd-thumb-pdf-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(outDir)
+
+	outPrefix := filepath.Join(outDir, "page")
+	cmd := exec.CommandContext(
+		ctx,
+		s.cfg.PDFToPPMBinary,
+		"-f", "1",
+		"-l", "1",
+		"-singlefile",
+		"-jpeg",
+		"-scale-to", fmt.Sprintf("%d", thumbnailWidthPx),
+		inputPath,
+		outPrefix,
+	)
+	if out, runErr := cmd.CombinedOutput(); runErr != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return runErr
+		}
+		return fmt.Errorf("pdftoppm 执行失败: %w: %s", runErr, msg)
+	}
+	return commitThumbnailFile(outPrefix+".jpg", cachePath)
+}
+
+// runFFmpegThumbnail 以给定输入参数输出单帧 JPEG，并原子替换到缓存路径。
+func (s *Server) runFFmpegThumbnail(ctx context.Context, cachePath string, inputArgs ...string) error {
+	args := append(append([]string(nil), inputArgs...), "-q:v", "4")
+	return s.runFFmpegToFile(ctx, cachePath, "tgcd-thumb-*.jpg", args...)
+}
+
+// runFFmpegToFile 先输出到同目录临时文件（pattern 决定扩展名，ffmpeg 据此选择封装格式），成功后原子替换 outputPath。
+func (s *Server) runFFmpegToFile(ctx context.Context, outputPath string, pattern string, ffmpegArgs ...string) error {
+	outTmp, err := os.CreateTemp(filepath.Dir(outputPath), pattern)
+	if err != nil {
+		return err
+	}
+	outPath := outTmp.Name()
+	_ = outTmp.Close()
+	defer os.Remove(outPath)
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	args = append(args, ffmpegArgs...)
+	args = append(args, outPath)
+	cmd := exec.CommandContext(ctx, s.cfg.FFmpegBinary, args...)
+	if out, runErr := cmd.CombinedOutput(); runErr != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return runErr
+		}
+		return fmt.Errorf("ffmpeg 执行失败: %w: %s", runErr, msg)
+	}
+	return commitThumbnailFile(outPath, outputPath)
+}
+
+func commitThumbnailFile(outPath string, cachePath string) error {
+	stat, err := os.Stat(outPath)
+	if err != nil {
+		return err
+	}
+	if stat.Size() <= 0 {
+		return errors.New("未生成有效缩略图")
+	}
+	return os.Rename(outPath, cachePath)
+}
+
+func thumbnailFitFilter() string {
+	return fmt.Sprintf(
+		"scale=w=min(%d\\,iw):h=min(%d\\,ih):force_original_aspect_ratio=decrease",
+		thumbnailWidthPx,
+		thumbnailWidthPx,
+	)
+}
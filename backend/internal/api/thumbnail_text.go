@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	textThumbnailWidth      = thumbnailWidthPx
+	textThumbnailHeight     = 360
+	textThumbnailPadding    = 16
+	textThumbnailCellWidth  = 4
+	textThumbnailLineHeight = 7
+	textThumbnailGlyphWidth = 3
+	textThumbnailGlyphTall  = 4
+	textThumbnailTabWidth   = 4
+)
+
+var (
+	textThumbnailBackground = color.RGBA{R: 0xfb, G: 0xfb, B: 0xfc, A: 0xff}
+	textThumbnailInk        = color.RGBA{R: 0x4b, G: 0x55, B: 0x63, A: 0xff}
+	textThumbnailPunct      = color.RGBA{R: 0x9c, G: 0xa3, B: 0xaf, A: 0xff}
+	textThumbnailDigit      = color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff}
+)
+
+// generateAndCacheTextThumbnail 读取文本开头，按“代码缩略图”风格把每个字符画成小色块，
+// 不依赖字体，CJK 等宽字符占两格。
+func (s *Server) generateAndCacheTextThumbnail(ctx context.Context, item store.Item, cachePath string) error {
+	inputPath, err := s.downloadItemPrefixToTempFile(ctx, item.ID, thumbnailTextInputMaxBytes)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(inputPath)
+
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, thumbnailTextInputMaxBytes))
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+	if !looksLikeText(data) {
+		return errThumbnailNotText
+	}
+	return writeThumbnailJPEG(renderTextThumbnail(data), cachePath)
+}
+
+func looksLikeText(data []byte) bool {
+	return len(data) > 0 && !bytes.Contains(data, []byte{0})
+}
+
+func renderTextThumbnail(data []byte) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, textThumbnailWidth, textThumbnailHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: textThumbnailBackground}, image.Point{}, draw.Src)
+
+	maxCols := (textThumbnailWidth - 2*textThumbnailPadding) / textThumbnailCellWidth
+	maxLines := (textThumbnailHeight - 2*textThumbnailPadding) / textThumbnailLineHeight
+	col, line := 0, 0
+	for len(data) > 0 && line < maxLines {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		switch {
+		case r == '\n':
+			col = 0
+			line++
+			continue
+		case r == '\r':
+			continue
+		case r == '\t':
+			col += textThumbnailTabWidth - col%textThumbnailTabWidth
+			continue
+		}
+
+		width := textThumbnailRuneCells(r)
+		if col+width > maxCols {
+			// 超出宽度的部分直接截断，保持行结构
+			continue
+		}
+		if !unicode.IsSpace(r) && unicode.IsPrint(r) {
+			x := textThumbnailPadding + col*textThumbnailCellWidth
+			y := textThumbnailPadding + line*textThumbnailLineHeight
+			glyph := image.Rect(x, y, x+width*textThumbnailCellWidth-(textThumbnailCellWidth-textThumbnailGlyphWidth), y+textThumbnailGlyphTall)
+			draw.Draw(img, glyph, &image.Uniform{C: textThumbnailRuneColor(r)}, image.Point{}, draw.Src)
+		}
+		col += width
+	}
+	return img
+}
+
+func textThumbnailRuneCells(r rune) int {
+	if r >= 0x1100 && (unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hangul, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		(r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0x3000 && r <= 0x303F)) {
+		return 2
+	}
+	return 1
+}
+
+func textThumbnailRuneColor(r rune) color.RGBA {
+	switch {
+	case unicode.IsDigit(r):
+		return textThumbnailDigit
+	case unicode.IsPunct(r) || unicode.IsSymbol(r):
+		return textThumbnailPunct
+	default:
+		return textThumbnailInk
+	}
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// torrentTaskFolderResolver 负责在目标目录下按 torrent 内相对路径逐级准备文件夹，
+// 同一任务内复用已解析的目录，避免每个文件重复查询。
+type torrentTaskFolderResolver struct {
+	st           *store.Store
+	downloadRoot string
+	rootParentID *uuid.UUID
+	rootPath     string
+	resolved     map[string]*uuid.UUID
+}
+
+func (s *Server) newTorrentTaskFolderResolver(
+	ctx context.Context,
+	st *store.Store,
+	task store.TorrentTask,
+) (*torrentTaskFolderResolver, error) {
+	rootPath, err := s.resolveUploadFolderParentPath(ctx, task.TargetParentID)
+	if err != nil {
+		return nil, fmt.Errorf("读取目标目录失败: %w", err)
+	}
+	return &torrentTaskFolderResolver{
+		st:           st,
+		downloadRoot: strings.TrimSpace(s.cfg.TorrentDownloadDir),
+		rootParentID: task.TargetParentID,
+		rootPath:     rootPath,
+		resolved:     map[string]*uuid.UUID{"": task.TargetParentID},
+	}, nil
+}
+
+// resolveParent 返回文件应写入的目录 ID；无法确定文件在 torrent 内的目录时返回错误。
+func (r *torrentTaskFolderResolver) resolveParent(ctx context.Context, file store.TorrentTaskFile) (*uuid.UUID, error) {
+	segments, err := resolveTorrentTaskFileDirSegments(r.downloadRoot, file)
+	if err != nil {
+		return nil, err
+	}
+	parentID := r.rootParentID
+	parentPath := r.rootPath
+	key := ""
+	for _, segment := range segments {
+		key = strings.TrimPrefix(key+"/"+segment, "/")
+		childPath := store.BuildChildPath(parentPath, segment)
+		if cached, ok := r.resolved[key]; ok {
+			parentID = cached
+			parentPath = childPath
+			continue
+		}
+
+		folderID, err := r.ensureFolder(ctx, parentID, childPath, segment)
+		if err != nil {
+			return nil, err
+		}
+		r.resolved[key] = folderID
+		parentID = folderID
+		parentPath = childPath
+	}
+	return parentID, nil
+}
+
+func (r *torrentTaskFolderResolver) ensureFolder(
+	ctx context.Context,
+	parentID *uuid.UUID,
+	childPath string,
+	name string,
+) (*uuid.UUID, error) {
+	existing, err := r.st.ListItemsByExactPaths(ctx, []string{childPath})
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range existing {
+		if item.Type == store.ItemTypeFolder && !item.InVault {
+			id := item.ID
+			return &id, nil
+		}
+	}
+	if len(existing) > 0 {
+		return nil, errors.New(buildTorrentTaskFolderConflictMessage(existing))
+	}
+
+	folder, err := r.st.CreateFolder(ctx, parentID, name, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	id := folder.ID
+	return &id, nil
+}
+
+func buildTorrentTaskFolderConflictMessage(items []store.Item) string {
+	preview, _, hasVault := summarizeUploadFolderConflicts(items)
+	if hasVault {
+		return fmt.Sprintf("目标位置存在同名文件或文件夹（含密码箱项目）：%s，无法保留目录结构", preview)
+	}
+	return fmt.Sprintf("目标位置存在同名文件：%s，无法保留目录结构", preview)
+}
+
+// resolveTorrentTaskFileDirSegments 计算文件在 torrent 内的父级目录片段，优先使用下载完成时记录的相对路径。
+// 升级前的记录没有相对路径，按文件相对下载根目录的位置计算；文件不在下载根目录内时返回错误，而不是平铺到目标目录。
+func resolveTorrentTaskFileDirSegments(downloadRoot string, file store.TorrentTaskFile) ([]string, error) {
+	var dir string
+	if rel := strings.TrimSpace(file.RelativePath); rel != "" {
+		rel = path.Clean(filepath.ToSlash(rel))
+		if path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("torrent 内文件路径非法：%s", file.RelativePath)
+		}
+		dir = path.Dir(rel)
+	} else {
+		root := strings.TrimSpace(downloadRoot)
+		filePath := strings.TrimSpace(file.FilePath)
+		if root == "" || filePath == "" {
+			return nil, errors.New("无法确定文件在 torrent 内的目录，无法保留目录结构")
+		}
+		rel, err := filepath.Rel(filepath.Clean(root), filepath.Dir(filepath.Clean(filePath)))
+		if err != nil {
+			return nil, fmt.Errorf("文件 %s 不在下载目录 %s 内，无法保留目录结构", filePath, root)
+		}
+		dir = filepath.ToSlash(rel)
+		if dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("文件 %s 不在下载目录 %s 内，无法保留目录结构", filePath, root)
+		}
+	}
+
+	segments := make([]string, 0, strings.Count(dir, "/")+1)
+	for _, segment := range strings.Split(dir, "/") {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || segment == "." {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
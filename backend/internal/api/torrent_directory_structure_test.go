@@ -0,0 +1,48 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestResolveTorrentTaskFileDirSegments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		root    string
+		file    store.TorrentTaskFile
+		want    []string
+		wantErr bool
+	}{
+		{name: "相对路径多级目录", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/mnt/qbt/Show/Season 1/ep01.mkv", RelativePath: "Show/Season 1/ep01.mkv"}, want: []string{"Show", "Season 1"}},
+		{name: "相对路径单文件", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/mnt/qbt/movie.mkv", RelativePath: "movie.mkv"}, want: nil},
+		{name: "相对路径越界", root: "/data/torrent", file: store.TorrentTaskFile{RelativePath: "../x/01.flac"}, wantErr: true},
+		{name: "相对路径为绝对路径", root: "/data/torrent", file: store.TorrentTaskFile{RelativePath: "/x/01.flac"}, wantErr: true},
+		{name: "旧记录多级目录", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/data/torrent/Show/Season 1/ep01.mkv"}, want: []string{"Show", "Season 1"}},
+		{name: "旧记录根目录下单文件", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/data/torrent/movie.mkv"}, want: nil},
+		{name: "旧记录下载根目录末尾斜杠", root: "/data/torrent/", file: store.TorrentTaskFile{FilePath: "/data/torrent/Album/01.flac"}, want: []string{"Album"}},
+		{name: "旧记录不在下载根目录内", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/other/Album/01.flac"}, wantErr: true},
+		{name: "旧记录相对路径越界", root: "/data/torrent", file: store.TorrentTaskFile{FilePath: "/data/torrent/../x/01.flac"}, wantErr: true},
+		{name: "旧记录缺少下载根目录", root: "", file: store.TorrentTaskFile{FilePath: "/data/torrent/Album/01.flac"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := resolveTorrentTaskFileDirSegments(tc.root, tc.file)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("resolveTorrentTaskFileDirSegments() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(got) == 0 && len(tc.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("resolveTorrentTaskFileDirSegments() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+	itorrent "tg-cloud-drive-api/internal/torrent"
+)
+
+const (
+	torrentPublicationCleanupInterval = time.Hour
+	// 过期或撤销的发布记录保留一段时间供查询，之后连同 .torrent 内容一起删除。
+	torrentPublicationRetention = 7 * 24 * time.Hour
+)
+
+// runTorrentPublishJob 逐个文件经分块读取器计算分片哈希，完成后把 .torrent 写入发布记录。
+func (s *Server) runTorrentPublishJob(
+	ctx context.Context,
+	job store.TransferJob,
+	publication store.TorrentPublication,
+	root store.Item,
+	files []torrentPublishFile,
+	baseURL string,
+) {
+	defer s.finishArchiveJob(job.ID)
+
+	st := store.New(s.db)
+	err := s.buildTorrentPublication(ctx, st, job, publication, root, files, baseURL)
+	if err == nil {
+		s.updateArchiveJobProgress(st, job.ID, len(files), 0, 0, store.TransferJobStatusCompleted, nil)
+		return
+	}
+
+	status := store.TransferJobStatusError
+	canceled, failed := 0, len(files)
+	if ctx.Err() != nil || errors.Is(err, store.ErrNotFound) {
+		// 发布在生成期间被撤销
+		status = store.TransferJobStatusCanceled
+		canceled, failed = len(files), 0
+	} else {
+		s.logger.Warn("build torrent publication failed", "error", err.Error(), "job_id", job.ID.String(), "item_id", root.ID.String())
+	}
+	msg := err.Error()
+	s.updateArchiveJobProgress(st, job.ID, 0, failed, canceled, status, &msg)
+}
+
+func (s *Server) buildTorrentPublication(
+	ctx context.Context,
+	st *store.Store,
+	job store.TransferJob,
+	publication store.TorrentPublication,
+	root store.Item,
+	files []torrentPublishFile,
+	baseURL string,
+) error {
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	pieceLength := itorrent.ChoosePieceLength(total)
+	hasher, err := itorrent.NewPieceHasher(pieceLength)
+	if err != nil {
+		return err
+	}
+	for i, file := range files {
+		if err := s.hashTorrentPublishFile(ctx, hasher, file); err != nil {
+			return err
+		}
+		s.updateArchiveJobProgress(st, job.ID, i+1, 0, 0, store.TransferJobStatusRunning, nil)
+	}
+
+	multiFile := root.Type == store.ItemTypeFolder
+	entries := make([]itorrent.CreateFileEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, itorrent.CreateFileEntry{Path: file.RelativePath, Length: file.Size})
+	}
+	token := buildWebSeedToken(publication.ID, publication.ExpiresAt, s.cfg.CookieSecret)
+	data, infoHash, err := itorrent.BuildTorrent(itorrent.CreateOptions{
+		Name:         root.Name,
+		PieceLength:  pieceLength,
+		Pieces:       hasher.Pieces(),
+		Files:        entries,
+		MultiFile:    multiFile,
+		Announce:     publication.Announce,
+		URLList:      []string{buildWebSeedURL(baseURL, token, root.Name, multiFile)},
+		CreationDate: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("生成 torrent 失败: %w", err)
+	}
+	// 任务可能已被撤销取消，保存结果时不复用任务 ctx
+	saveCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return st.SetTorrentPublicationContent(saveCtx, publication.ID, infoHash, data, time.Now())
+}
+
+// hashTorrentPublishFile 每个文件单独占用一个下载名额，避免长时间生成任务挤占普通下载。
+func (s *Server) hashTorrentPublishFile(ctx context.Context, hasher *itorrent.PieceHasher, file torrentPublishFile) error {
+	if file.Size <= 0 {
+		return nil
+	}
+	limit := 1
+	if settings, err := s.getRuntimeSettings(ctx); err == nil {
+		limit = settings.DownloadConcurrency
+	}
+	if err := s.acquireDownloadSlot(ctx, limit); err != nil {
+		return err
+	}
+	defer s.releaseDownload()
+
+	before := hasher.Written()
+	if err := s.copyItemChunkRange(ctx, hasher, file.Chunks, 0, file.Size-1); err != nil {
+		return fmt.Errorf("%s: %w", file.Item.Name, err)
+	}
+	if hasher.Written()-before != file.Size {
+		return fmt.Errorf("%s: 读取长度与文件大小不一致", file.Item.Name)
+	}
+	return nil
+}
+
+func buildTorrentPublishTransferJob(root store.Item, files []torrentPublishFile, now time.Time) store.TransferJob {
+	jobID := uuid.New()
+	rootID := root.ID
+	unitKind := store.TransferUnitKindFile
+	if root.Type == store.ItemTypeFolder {
+		unitKind = store.TransferUnitKindFolder
+	}
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	return store.TransferJob{
+		ID:           jobID,
+		Direction:    store.TransferDirectionDownload,
+		SourceKind:   store.TransferSourceKindTorrentPublish,
+		SourceRef:    root.ID.String() + ":" + jobID.String(),
+		UnitKind:     unitKind,
+		Name:         strings.TrimSpace(root.Name) + ".torrent",
+		TargetItemID: &rootID,
+		TotalSize:    total,
+		ItemCount:    len(files),
+		Status:       store.TransferJobStatusRunning,
+		StartedAt:    now,
+		FinishedAt:   now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func (s *Server) startTorrentPublicationCleanupLoop() {
+	s.goBackground(func() {
+		for {
+			s.markLoopHeartbeat(loopTorrentPublicationCleanup)
+			if s.isLeader() {
+				s.purgeExpiredTorrentPublications(s.backgroundCtx)
+			}
+			if !s.waitLoopInterval(torrentPublicationCleanupInterval) {
+				return
+			}
+		}
+	})
+}
+
+func (s *Server) purgeExpiredTorrentPublications(ctx context.Context) {
+	if s.db == nil {
+		return
+	}
+	purgeCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	deleted, err := store.New(s.db).PurgeTorrentPublicationsBefore(purgeCtx, time.Now().Add(-torrentPublicationRetention))
+	if err != nil {
+		s.logger.Warn("purge torrent publications failed", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("torrent publications purged", "deleted", deleted)
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	torrentSeedRatioTargetMax = 100
+	torrentSeedMinMinutesMax  = 30 * 24 * 60
+
+	// 任务详情读取时做种数据的最短刷新间隔
+	torrentSeedStatsRefreshInterval = 30 * time.Second
+)
+
+func (s *Server) resolveTorrentTaskSeedTargets(ctx context.Context) (float64, int) {
+	settings, err := s.getRuntimeSettings(ctx)
+	if err != nil {
+		settings = s.defaultRuntimeSettings()
+	}
+	return settings.TorrentSeedRatioTarget, settings.TorrentSeedMinMinutes
+}
+
+func hasTorrentSeedTargets(task store.TorrentTask) bool {
+	return task.SeedRatioTarget > 0 || task.SeedMinMinutes > 0
+}
+
+// isTorrentSeedTargetMet 任一已配置的目标（分享率或做种时长）达成即视为满足，未配置目标时直接满足。
+func isTorrentSeedTargetMet(ratioTarget float64, minMinutes int, ratio float64, seedingSeconds int64) bool {
+	if ratioTarget <= 0 && minMinutes <= 0 {
+		return true
+	}
+	if ratioTarget > 0 && ratio >= ratioTarget {
+		return true
+	}
+	if minMinutes > 0 && seedingSeconds >= int64(minMinutes)*60 {
+		return true
+	}
+	return false
+}
+
+// checkTorrentTaskSeedTargets 从 qBittorrent 读取最新做种数据并判断是否允许清理源文件。
+// qBittorrent 中已不存在该任务时无法继续做种，视为满足。
+func (s *Server) checkTorrentTaskSeedTargets(ctx context.Context, st *store.Store, task store.TorrentTask) (bool, error) {
+	if !hasTorrentSeedTargets(task) {
+		return true, nil
+	}
+	updated, found, err := s.refreshTorrentTaskSeedStats(ctx, st, task)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return true, nil
+	}
+	return isTorrentSeedTargetMet(updated.SeedRatioTarget, updated.SeedMinMinutes, updated.SeedRatio, updated.SeedingSeconds), nil
+}
+
+func (s *Server) refreshTorrentTaskSeedStats(
+	ctx context.Context,
+	st *store.Store,
+	task store.TorrentTask,
+) (store.TorrentTask, bool, error) {
+	if task.QBTorrentHash == nil || strings.TrimSpace(*task.QBTorrentHash) == "" {
+		return task, false, nil
+	}
+	qbt, err := s.newQBittorrentClient(ctx)
+	if err != nil {
+		return task, false, err
+	}
+	if err := qbt.Authenticate(ctx); err != nil {
+		return task, false, err
+	}
+	info, err := qbt.GetTorrentInfo(ctx, strings.TrimSpace(*task.QBTorrentHash))
+	if err != nil {
+		return task, false, err
+	}
+	if info == nil {
+		return task, false, nil
+	}
+
+	now := time.Now()
+	if err := st.UpdateTorrentTaskSeedStats(ctx, task.ID, info.Ratio, info.SeedingTime, now); err != nil {
+		return task, false, err
+	}
+	task.SeedRatio = info.Ratio
+	task.SeedingSeconds = info.SeedingTime
+	task.SeedCheckedAt = &now
+	return task, true, nil
+}
+
+// shouldRefreshTorrentTaskSeedStats 仅对仍在本地做种的任务刷新，避免频繁请求 qBittorrent。
+func shouldRefreshTorrentTaskSeedStats(task store.TorrentTask, now time.Time) bool {
+	if task.SourceCleanupDone || task.QBTorrentHash == nil || strings.TrimSpace(*task.QBTorrentHash) == "" {
+		return false
+	}
+	switch task.Status {
+	case store.TorrentTaskStatusAwaitingSelection, store.TorrentTaskStatusUploading, store.TorrentTaskStatusCompleted:
+	default:
+		return false
+	}
+	return task.SeedCheckedAt == nil || now.Sub(*task.SeedCheckedAt) >= torrentSeedStatsRefreshInterval
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestIsTorrentSeedTargetMet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		ratioTarget    float64
+		minMinutes     int
+		ratio          float64
+		seedingSeconds int64
+		want           bool
+	}{
+		{name: "未配置目标", want: true},
+		{name: "分享率未达标", ratioTarget: 1, ratio: 0.5, want: false},
+		{name: "分享率达标", ratioTarget: 1, ratio: 1, want: true},
+		{name: "做种时长未达标", minMinutes: 60, seedingSeconds: 59 * 60, want: false},
+		{name: "做种时长达标", minMinutes: 60, seedingSeconds: 60 * 60, want: true},
+		{name: "任一目标达标即可", ratioTarget: 2, minMinutes: 60, ratio: 0.1, seedingSeconds: 3600, want: true},
+		{name: "两个目标都未达标", ratioTarget: 2, minMinutes: 60, ratio: 1.9, seedingSeconds: 60, want: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := isTorrentSeedTargetMet(tc.ratioTarget, tc.minMinutes, tc.ratio, tc.seedingSeconds)
+			if got != tc.want {
+				t.Fatalf("isTorrentSeedTargetMet() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShouldRefreshTorrentTaskSeedStats(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	hash := "abc"
+	recent := now.Add(-5 * time.Second)
+	stale := now.Add(-time.Minute)
+
+	tests := []struct {
+		name string
+		task store.TorrentTask
+		want bool
+	}{
+		{name: "已完成且未检查", task: store.TorrentTask{Status: store.TorrentTaskStatusCompleted, QBTorrentHash: &hash}, want: true},
+		{name: "检查时间较新", task: store.TorrentTask{Status: store.TorrentTaskStatusCompleted, QBTorrentHash: &hash, SeedCheckedAt: &recent}, want: false},
+		{name: "检查时间已过期", task: store.TorrentTask{Status: store.TorrentTaskStatusUploading, QBTorrentHash: &hash, SeedCheckedAt: &stale}, want: true},
+		{name: "源文件已清理", task: store.TorrentTask{Status: store.TorrentTaskStatusCompleted, QBTorrentHash: &hash, SourceCleanupDone: true}, want: false},
+		{name: "下载中不刷新", task: store.TorrentTask{Status: store.TorrentTaskStatusDownloading, QBTorrentHash: &hash}, want: false},
+		{name: "缺少 hash", task: store.TorrentTask{Status: store.TorrentTaskStatusCompleted}, want: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := shouldRefreshTorrentTaskSeedStats(tc.task, now); got != tc.want {
+				t.Fatalf("shouldRefreshTorrentTaskSeedStats() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+// recordTorrentTaskTraceParent 保存创建任务请求的链路标识，失败只记日志，不影响任务本身。
+func (s *Server) recordTorrentTaskTraceParent(ctx context.Context, st *store.Store, taskID uuid.UUID) {
+	traceParent := tracing.SpanContextFromContext(ctx).TraceParent()
+	if traceParent == "" {
+		return
+	}
+	if err := st.SetTorrentTaskTraceParent(ctx, taskID, traceParent); err != nil {
+		s.logger.Warn("record torrent task trace parent failed", "error", err.Error(), "task_id", taskID.String())
+	}
+}
+
+// runTracedTorrentTask 在后台循环中处理任务；任务创建时记录了 traceparent 的，span 挂在提交请求的链路下。
+func (s *Server) runTracedTorrentTask(
+	ctx context.Context,
+	st *store.Store,
+	task store.TorrentTask,
+	phase string,
+	process func(context.Context, store.TorrentTask) error,
+) error {
+	if !tracing.Enabled() {
+		return process(ctx, task)
+	}
+	if traceParent, err := st.GetTorrentTaskTraceParent(ctx, task.ID); err == nil {
+		if parent, ok := tracing.ParseTraceParent(traceParent); ok {
+			ctx = tracing.ContextWithSpanContext(ctx, parent)
+		}
+	}
+	ctx, span := tracing.Start(
+		ctx,
+		tracing.KindInternal,
+		"torrent."+phase,
+		tracing.String("torrent.task_id", task.ID.String()),
+		tracing.String("torrent.status", string(task.Status)),
+	)
+	defer span.End()
+	err := process(ctx, task)
+	span.RecordError(err)
+	return err
+}
@@ -154,7 +154,8 @@ func resolveTorrentTransferJobStatus(
 	case store.TorrentTaskStatusQueued,
 		store.TorrentTaskStatusDownloading,
 		store.TorrentTaskStatusAwaitingSelection,
-		store.TorrentTaskStatusUploading:
+		store.TorrentTaskStatusUploading,
+		store.TorrentTaskStatusPaused:
 		return store.TransferJobStatusRunning
 	case store.TorrentTaskStatusCompleted:
 		if errorCount > 0 {
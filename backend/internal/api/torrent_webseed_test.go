@@ -0,0 +1,112 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebSeedToken(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1_700_000_000, 0)
+	publicationID := uuid.New()
+
+	token := buildWebSeedToken(publicationID, now.Add(24*time.Hour), secret)
+	got, err := parseWebSeedToken(token, secret, now)
+	if err != nil || got != publicationID {
+		t.Fatalf("parseWebSeedToken() = %v, %v, want %v", got, err, publicationID)
+	}
+
+	if _, err := parseWebSeedToken(token, []byte("another-secret"), now); err == nil {
+		t.Fatalf("parseWebSeedToken() with wrong secret should fail")
+	}
+	if _, err := parseWebSeedToken(token+"x", secret, now); err == nil {
+		t.Fatalf("parseWebSeedToken() with tampered signature should fail")
+	}
+
+	expiring := buildWebSeedToken(publicationID, now.Add(time.Hour), secret)
+	if _, err := parseWebSeedToken(expiring, secret, now.Add(30*time.Minute)); err != nil {
+		t.Fatalf("parseWebSeedToken() before expiry error = %v", err)
+	}
+	if _, err := parseWebSeedToken(expiring, secret, now.Add(2*time.Hour)); err == nil {
+		t.Fatalf("parseWebSeedToken() after expiry should fail")
+	}
+	if _, err := parseWebSeedToken(publicationID.String()+".0."+signWebSeedPayload(publicationID.String()+".0", secret), secret, now); err == nil {
+		t.Fatalf("parseWebSeedToken() without expiry should fail")
+	}
+}
+
+func TestParseTorrentPublishExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_700_000_000, 0)
+	got, err := parseTorrentPublishExpiry(nil, now)
+	if err != nil || !got.Equal(now.Add(torrentPublishDefaultExpiry)) {
+		t.Fatalf("parseTorrentPublishExpiry(nil) = %v, %v", got, err)
+	}
+	hours := 48
+	got, err = parseTorrentPublishExpiry(&hours, now)
+	if err != nil || !got.Equal(now.Add(48*time.Hour)) {
+		t.Fatalf("parseTorrentPublishExpiry(48) = %v, %v", got, err)
+	}
+	for _, invalid := range []int{0, -1, torrentPublishMaxExpiryHours + 1} {
+		invalid := invalid
+		if _, err := parseTorrentPublishExpiry(&invalid, now); err == nil {
+			t.Fatalf("parseTorrentPublishExpiry(%d) should fail", invalid)
+		}
+	}
+}
+
+func TestResolveWebSeedRelativePath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		raw    string
+		root   string
+		want   string
+		wantOK bool
+	}{
+		{name: "多级路径", raw: "album/disc1/01.flac", root: "album", want: "disc1/01.flac", wantOK: true},
+		{name: "名称不匹配", raw: "other/01.flac", root: "album", wantOK: false},
+		{name: "缺少文件路径", raw: "album", root: "album", wantOK: false},
+		{name: "拒绝上级目录", raw: "album/../secret.txt", root: "album", wantOK: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := resolveWebSeedRelativePath(tc.raw, tc.root)
+			if ok != tc.wantOK || got != tc.want {
+				t.Fatalf("resolveWebSeedRelativePath() = %q, %v, want %q, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestBuildWebSeedURL(t *testing.T) {
+	t.Parallel()
+
+	if got := buildWebSeedURL("https://drive.example.com/", "tok", "My File.mkv", false); got != "https://drive.example.com/ws/tok/My%20File.mkv" {
+		t.Fatalf("buildWebSeedURL(single) = %q", got)
+	}
+	if got := buildWebSeedURL("https://drive.example.com", "tok", "album", true); got != "https://drive.example.com/ws/tok/" {
+		t.Fatalf("buildWebSeedURL(multi) = %q", got)
+	}
+}
+
+func TestSplitTorrentPublishRelativePath(t *testing.T) {
+	t.Parallel()
+
+	got := splitTorrentPublishRelativePath("/music/album", "/music/album/disc1/01.flac")
+	if !reflect.DeepEqual(got, []string{"disc1", "01.flac"}) {
+		t.Fatalf("splitTorrentPublishRelativePath() = %v", got)
+	}
+	if got := splitTorrentPublishRelativePath("/music/album", "/music/albumx/01.flac"); got != nil {
+		t.Fatalf("splitTorrentPublishRelativePath() outside root = %v, want nil", got)
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var errInvalidWebSeedToken = errors.New("invalid webseed token")
+
+// buildWebSeedToken 生成绑定发布记录的 webseed 签名令牌；撤销发布记录即可让令牌失效。
+func buildWebSeedToken(publicationID uuid.UUID, expiresAt time.Time, secret []byte) string {
+	payload := publicationID.String() + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + signWebSeedPayload(payload, secret)
+}
+
+// parseWebSeedToken 校验签名与有效期并返回发布记录 ID，撤销状态由调用方查库判断。
+func parseWebSeedToken(token string, secret []byte, now time.Time) (uuid.UUID, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return uuid.Nil, errInvalidWebSeedToken
+	}
+	publicationID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, errInvalidWebSeedToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || exp <= 0 {
+		return uuid.Nil, errInvalidWebSeedToken
+	}
+
+	expected := signWebSeedPayload(parts[0]+"."+parts[1], secret)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(parts[2])) != 1 {
+		return uuid.Nil, errInvalidWebSeedToken
+	}
+	if now.Unix() > exp {
+		return uuid.Nil, errInvalidWebSeedToken
+	}
+	return publicationID, nil
+}
+
+func signWebSeedPayload(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte("webseed.v2." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -20,27 +20,30 @@ func (s *Server) startTorrentTaskWorkerLoop() {
 	if !s.cfg.TorrentEnabled {
 		return
 	}
-	go func() {
+	s.goBackground(func() {
 		pollInterval := s.cfg.TorrentWorkerPollInterval
 		if pollInterval <= 0 {
 			pollInterval = 3 * time.Second
 		}
-		for {
-			processed, err := s.runOneTorrentTaskCycle(context.Background())
+		for !s.isShuttingDown() {
+			s.markLoopHeartbeat(loopTorrentWorker)
+			processed, err := s.runOneTorrentTaskCycle(s.backgroundCtx)
 			if err != nil {
 				s.logger.Warn("torrent worker cycle failed", "error", err.Error())
-				time.Sleep(pollInterval)
+				if !s.waitLoopInterval(pollInterval) {
+					return
+				}
 				continue
 			}
-			if !processed {
-				time.Sleep(pollInterval)
+			if !processed && !s.waitLoopInterval(pollInterval) {
+				return
 			}
 		}
-	}()
+	})
 }
 
 func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
-	if !s.isSystemInitialized() || s.db == nil || !s.cfg.TorrentEnabled {
+	if !s.isSystemInitialized() || s.db == nil || !s.cfg.TorrentEnabled || s.isShuttingDown() || !s.isLeader() {
 		return false, nil
 	}
 	if err := os.MkdirAll(s.cfg.TorrentWorkDir, 0o755); err != nil {
@@ -60,13 +63,14 @@ func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
 		now,
 	)
 	if err == nil {
-		if runErr := s.processDownloadingTorrentTask(ctx, queuedTask); runErr != nil {
+		if runErr := s.runTorrentTaskPhase(ctx, st, queuedTask, "download", s.processDownloadingTorrentTask); runErr != nil {
 			msg := strings.TrimSpace(runErr.Error())
 			if msg == "" {
 				msg = "下载任务处理失败"
 			}
 			_ = st.FinishTorrentTask(context.Background(), queuedTask.ID, store.TorrentTaskStatusError, &msg, time.Now())
 			s.refreshTorrentTransferJobByTaskID(context.Background(), queuedTask.ID, store.TransferJobStatusError, msg)
+			s.recordTorrentAudit(context.Background(), auditActionTorrentFailed, queuedTask, runErr, map[string]any{"phase": "download"})
 			return true, runErr
 		}
 		return true, nil
@@ -82,13 +86,14 @@ func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
 		now,
 	)
 	if err == nil {
-		if runErr := s.processDownloadingTorrentTask(ctx, downloadingTask); runErr != nil {
+		if runErr := s.runTorrentTaskPhase(ctx, st, downloadingTask, "download", s.processDownloadingTorrentTask); runErr != nil {
 			msg := strings.TrimSpace(runErr.Error())
 			if msg == "" {
 				msg = "下载任务处理失败"
 			}
 			_ = st.FinishTorrentTask(context.Background(), downloadingTask.ID, store.TorrentTaskStatusError, &msg, time.Now())
 			s.refreshTorrentTransferJobByTaskID(context.Background(), downloadingTask.ID, store.TransferJobStatusError, msg)
+			s.recordTorrentAudit(context.Background(), auditActionTorrentFailed, downloadingTask, runErr, map[string]any{"phase": "download"})
 			return true, runErr
 		}
 		return true, nil
@@ -107,13 +112,14 @@ func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
 		now,
 	)
 	if err == nil {
-		if runErr := s.processUploadingTorrentTask(ctx, uploadingTask); runErr != nil {
+		if runErr := s.runTorrentTaskPhase(ctx, st, uploadingTask, "upload", s.processUploadingTorrentTask); runErr != nil {
 			msg := strings.TrimSpace(runErr.Error())
 			if msg == "" {
 				msg = "上传任务处理失败"
 			}
 			_ = st.FinishTorrentTask(context.Background(), uploadingTask.ID, store.TorrentTaskStatusError, &msg, time.Now())
 			s.refreshTorrentTransferJobByTaskID(context.Background(), uploadingTask.ID, store.TransferJobStatusError, msg)
+			s.recordTorrentAudit(context.Background(), auditActionTorrentFailed, uploadingTask, runErr, map[string]any{"phase": "upload"})
 			return true, runErr
 		}
 		return true, nil
@@ -124,7 +130,10 @@ func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
 
 	cleanupTask, err := st.ClaimNextDueTorrentCleanupTask(ctx, now)
 	if err == nil {
-		s.processDueTorrentCleanupTask(ctx, st, cleanupTask)
+		_ = s.runTracedTorrentTask(ctx, st, cleanupTask, "cleanup", func(ctx context.Context, task store.TorrentTask) error {
+			s.processDueTorrentCleanupTask(ctx, st, task)
+			return nil
+		})
 		return true, nil
 	}
 	if err != nil && !errors.Is(err, store.ErrNotFound) {
@@ -134,6 +143,22 @@ func (s *Server) runOneTorrentTaskCycle(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// runTorrentTaskPhase 执行一个任务阶段；因服务关闭或失去主副本身份而中断时保存断点并视为正常结束，不把任务标记为失败。
+func (s *Server) runTorrentTaskPhase(
+	ctx context.Context,
+	st *store.Store,
+	task store.TorrentTask,
+	phase string,
+	process func(context.Context, store.TorrentTask) error,
+) error {
+	err := s.runTracedTorrentTask(ctx, st, task, phase, process)
+	if errors.Is(err, errLeadershipLost) || (err != nil && s.isShuttingDown() && (errors.Is(err, errServerShuttingDown) || ctx.Err() != nil)) {
+		s.checkpointTorrentTask(task, phase)
+		return nil
+	}
+	return err
+}
+
 func (s *Server) processDownloadingTorrentTask(ctx context.Context, task store.TorrentTask) error {
 	st := store.New(s.db)
 	qbt, err := s.newQBittorrentClient(ctx)
@@ -166,6 +191,12 @@ func (s *Server) processDownloadingTorrentTask(ctx context.Context, task store.T
 		}
 		hashCopy := normalizedHash
 		task.QBTorrentHash = &hashCopy
+		// 添加到 qBittorrent 期间任务可能已被暂停，此时补发暂停
+		if s.isTorrentTaskPaused(ctx, st, task.ID) {
+			if err := qbt.PauseTorrent(ctx, normalizedHash); err != nil {
+				s.logger.Warn("pause qBittorrent torrent failed", "task_id", task.ID.String(), "error", err.Error())
+			}
+		}
 	}
 
 	info, resolvedHash, err := s.resolveTorrentTaskInfo(ctx, st, qbt, task)
@@ -254,20 +285,27 @@ func (s *Server) processDownloadingTorrentTask(ctx context.Context, task store.T
 			}
 		}
 		fileRows = append(fileRows, store.TorrentTaskFile{
-			TaskID:    task.ID,
-			FileIndex: file.Index,
-			FilePath:  absPath,
-			FileName:  filepath.Base(absPath),
-			FileSize:  file.Size,
-			Selected:  selected,
-			Uploaded:  false,
+			TaskID:       task.ID,
+			FileIndex:    file.Index,
+			FilePath:     absPath,
+			RelativePath: file.Name,
+			FileName:     filepath.Base(absPath),
+			FileSize:     file.Size,
+			Selected:     selected,
+			Uploaded:     false,
 		})
 	}
 	if err := st.ReplaceTorrentTaskFiles(ctx, task.ID, fileRows, time.Now()); err != nil {
 		return err
 	}
 
-	if err := st.SetTorrentTaskStatus(ctx, task.ID, store.TorrentTaskStatusUploading, nil, time.Now()); err != nil {
+	if err := st.AdvanceTorrentTaskStatus(
+		ctx,
+		task.ID,
+		store.TorrentTaskStatusDownloading,
+		store.TorrentTaskStatusUploading,
+		time.Now(),
+	); err != nil {
 		return err
 	}
 	_ = st.UpdateTorrentTaskProgress(ctx, task.ID, estimated, estimated, 1, time.Now())
@@ -462,7 +500,36 @@ func (s *Server) processUploadingTorrentTask(ctx context.Context, task store.Tor
 	}
 	s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusRunning, "")
 
+	var folders *torrentTaskFolderResolver
+	if task.PreserveDirectoryStructure {
+		folders, err = s.newTorrentTaskFolderResolver(ctx, st, task)
+		if err != nil {
+			s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusError, err.Error())
+			return err
+		}
+	}
+
 	for _, file := range files {
+		if s.isTorrentTaskPaused(ctx, st, task.ID) {
+			s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusRunning, "")
+			return nil
+		}
+		if s.isShuttingDown() {
+			return errServerShuttingDown
+		}
+		if !s.isLeader() {
+			return errLeadershipLost
+		}
+		parentID := task.TargetParentID
+		if folders != nil {
+			resolved, resolveErr := folders.resolveParent(ctx, file)
+			if resolveErr != nil {
+				_ = st.MarkTorrentTaskFileError(ctx, task.ID, file.FileIndex, resolveErr.Error(), time.Now())
+				s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusError, resolveErr.Error())
+				return resolveErr
+			}
+			parentID = resolved
+		}
 		settings, setErr := s.getRuntimeSettings(ctx)
 		if setErr != nil {
 			return setErr
@@ -471,10 +538,14 @@ func (s *Server) processUploadingTorrentTask(ctx context.Context, task store.Tor
 			return err
 		}
 
-		item, _, uploadErr := s.uploadTorrentTaskFileToTelegram(ctx, task, file)
+		item, _, uploadErr := s.uploadTorrentTaskFileToTelegram(ctx, task, file, parentID)
 		s.releaseUpload()
 
 		finishedAt := time.Now()
+		if uploadErr != nil && s.isShuttingDown() && ctx.Err() != nil {
+			// 关闭期限已到被取消：文件保持待上传，下次启动重新上传。
+			return errServerShuttingDown
+		}
 		if uploadErr != nil {
 			_ = st.MarkTorrentTaskFileError(ctx, task.ID, file.FileIndex, uploadErr.Error(), finishedAt)
 			s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusError, uploadErr.Error())
@@ -484,6 +555,7 @@ func (s *Server) processUploadingTorrentTask(ctx context.Context, task store.Tor
 		if err := st.MarkTorrentTaskFileUploaded(ctx, task.ID, file.FileIndex, item.ID, finishedAt); err != nil {
 			return err
 		}
+		s.emitItemWebhookEvent(webhookEventItemCreated, item, "torrent")
 		s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusRunning, "")
 	}
 
@@ -492,10 +564,19 @@ func (s *Server) processUploadingTorrentTask(ctx context.Context, task store.Tor
 		return err
 	}
 	s.refreshTorrentTransferJobByTaskID(ctx, task.ID, store.TransferJobStatusCompleted, "")
+	s.recordTorrentAudit(ctx, auditActionTorrentComplete, task, nil, nil)
 	s.scheduleTorrentTaskSourceCleanup(ctx, st, task)
 	return nil
 }
 
+func (s *Server) isTorrentTaskPaused(ctx context.Context, st *store.Store, taskID uuid.UUID) bool {
+	current, err := st.GetTorrentTask(ctx, taskID)
+	if err != nil {
+		return false
+	}
+	return current.Status == store.TorrentTaskStatusPaused
+}
+
 func (s *Server) ensureTorrentTaskPendingUploadFiles(
 	ctx context.Context,
 	st *store.Store,
@@ -574,7 +655,8 @@ func (s *Server) scheduleTorrentTaskSourceCleanup(
 	}
 
 	dueAt := resolveTorrentSourceCleanupDueAt(settings, time.Now())
-	if mode == torrentSourceDeleteModeImmediate {
+	// 配置了做种目标时不立即清理，交由到期清理流程检查是否达标
+	if mode == torrentSourceDeleteModeImmediate && !hasTorrentSeedTargets(task) {
 		cleaned := s.executeTorrentTaskSourceCleanup(ctx, st, task)
 		if cleaned {
 			if err := st.MarkTorrentTaskSourceCleanupDone(ctx, task.ID, time.Now()); err != nil {
@@ -595,6 +677,18 @@ func (s *Server) scheduleTorrentTaskSourceCleanup(
 }
 
 func (s *Server) processDueTorrentCleanupTask(ctx context.Context, st *store.Store, task store.TorrentTask) {
+	seedMet, err := s.checkTorrentTaskSeedTargets(ctx, st, task)
+	if err != nil {
+		s.logger.Warn("check torrent seed targets failed", "error", err.Error(), "task_id", task.ID.String())
+	}
+	if !seedMet {
+		retryAt := time.Now().Add(store.TorrentSeedCheckInterval)
+		if err := st.SetTorrentTaskSourceCleanupSchedule(ctx, task.ID, retryAt, task.SourceCleanupPolicy, time.Now()); err != nil {
+			s.logger.Warn("set torrent source cleanup seed wait schedule failed", "error", err.Error(), "task_id", task.ID.String())
+		}
+		return
+	}
+
 	cleaned := s.executeTorrentTaskSourceCleanup(ctx, st, task)
 	if cleaned {
 		if err := st.MarkTorrentTaskSourceCleanupDone(ctx, task.ID, time.Now()); err != nil {
@@ -660,6 +754,7 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 	ctx context.Context,
 	task store.TorrentTask,
 	file store.TorrentTaskFile,
+	parentID *uuid.UUID,
 ) (store.Item, *videoUploadProcessMeta, error) {
 	filePath := strings.TrimSpace(file.FilePath)
 	if filePath == "" {
@@ -676,7 +771,6 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 		return store.Item{}, nil, errors.New("空文件不支持上传")
 	}
 
-	mimeType := inferMimeTypeByPath(filePath)
 	fileName := strings.TrimSpace(file.FileName)
 	if fileName == "" {
 		fileName = filepath.Base(filePath)
@@ -684,7 +778,18 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 	if fileName == "" {
 		fileName = fmt.Sprintf("torrent-%d.bin", file.FileIndex)
 	}
+	return s.uploadLocalFileAsItem(ctx, parentID, fileName, filePath, info.Size())
+}
 
+// uploadLocalFileAsItem 在 parentID 下创建文件并把本地文件上传到 Telegram，失败时回滚已创建的记录与消息。
+func (s *Server) uploadLocalFileAsItem(
+	ctx context.Context,
+	parentID *uuid.UUID,
+	fileName string,
+	filePath string,
+	fileSize int64,
+) (store.Item, *videoUploadProcessMeta, error) {
+	mimeType := inferMimeTypeByPath(filePath)
 	now := time.Now()
 	st := store.New(s.db)
 	itemType := store.GuessItemType(fileName, mimeType)
@@ -693,13 +798,14 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 		mimePtr = &mimeType
 	}
 
-	it, err := st.CreateFileItem(ctx, task.TargetParentID, itemType, fileName, 0, mimePtr, now)
+	it, err := st.CreateFileItem(ctx, parentID, itemType, fileName, 0, mimePtr, now)
 	if err != nil {
 		return store.Item{}, nil, err
 	}
 
+	// 回滚不跟随 ctx 取消，避免关闭期间中断的上传留下空文件记录。
 	cleanupItem := func() {
-		_ = st.DeleteItemsByPathPrefix(ctx, it.Path)
+		_ = st.DeleteItemsByPathPrefix(context.WithoutCancel(ctx), it.Path)
 	}
 
 	accessMethod, err := s.resolveUploadAccessMethod(ctx)
@@ -731,7 +837,7 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 			cleanupItem()
 			return store.Item{}, processMeta, docErr
 		}
-		storedSize := resolveStoredSizeByTelegramSize(resolvedDoc.FileSize, info.Size())
+		storedSize := resolveStoredSizeByTelegramSize(resolvedDoc.FileSize, fileSize)
 		ch := store.Chunk{
 			ID:             uuid.New(),
 			ItemID:         it.ID,
@@ -757,11 +863,12 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 		if err != nil {
 			return store.Item{}, processMeta, err
 		}
+		s.recordUploadedItemMetadata(ctx, updated, processMeta, filePath)
 		return updated, processMeta, nil
 	}
 
 	singleLimit := officialBotAPISingleUploadLimitBytes(fileName, mimeType)
-	if info.Size() <= singleLimit {
+	if fileSize <= singleLimit {
 		msg, processMeta, sendErr := s.sendMediaFromPathWithRetry(
 			ctx,
 			s.cfg.TGStorageChatID,
@@ -782,7 +889,7 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 			cleanupItem()
 			return store.Item{}, processMeta, docErr
 		}
-		storedSize := resolveStoredSizeByTelegramSize(resolvedDoc.FileSize, info.Size())
+		storedSize := resolveStoredSizeByTelegramSize(resolvedDoc.FileSize, fileSize)
 		ch := store.Chunk{
 			ID:             uuid.New(),
 			ItemID:         it.ID,
@@ -808,6 +915,7 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 		if err != nil {
 			return store.Item{}, processMeta, err
 		}
+		s.recordUploadedItemMetadata(ctx, updated, processMeta, filePath)
 		return updated, processMeta, nil
 	}
 
@@ -842,6 +950,7 @@ func (s *Server) uploadTorrentTaskFileToTelegram(
 	if err != nil {
 		return store.Item{}, nil, err
 	}
+	s.recordItemMetadataFromFile(ctx, updated, filePath)
 	return updated, nil, nil
 }
 
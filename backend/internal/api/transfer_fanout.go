@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	transferFanoutChannel   = "tgcd_transfer_events"
+	transferFanoutQueueSize = 512
+	// PostgreSQL NOTIFY 的 payload 上限为 8000 字节，留出余量。
+	transferFanoutMaxPayload     = 7900
+	transferFanoutListenTimeout  = 30 * time.Second
+	transferFanoutReconnectDelay = 5 * time.Second
+	transferFanoutPublishTimeout = 5 * time.Second
+)
+
+// transferFanoutEnvelope 为副本之间通过 LISTEN/NOTIFY 转发的传输事件。
+type transferFanoutEnvelope struct {
+	Origin uuid.UUID           `json:"origin"`
+	Event  transferStreamEvent `json:"event"`
+}
+
+// encodeTransferFanoutPayload 编码转发事件；批量任务事件过大时依次去掉逐项结果与失败明细，仍超限则放弃转发。
+func encodeTransferFanoutPayload(origin uuid.UUID, event transferStreamEvent) ([]byte, bool) {
+	payload, err := json.Marshal(transferFanoutEnvelope{Origin: origin, Event: event})
+	if err != nil {
+		return nil, false
+	}
+	if len(payload) <= transferFanoutMaxPayload {
+		return payload, true
+	}
+	if event.Batch == nil {
+		return nil, false
+	}
+
+	batch := *event.Batch
+	event.Batch = &batch
+	for _, trim := range []func(){
+		func() { batch.Results = nil },
+		func() { batch.Failures = nil },
+	} {
+		trim()
+		payload, err = json.Marshal(transferFanoutEnvelope{Origin: origin, Event: event})
+		if err != nil {
+			return nil, false
+		}
+		if len(payload) <= transferFanoutMaxPayload {
+			return payload, true
+		}
+	}
+	return nil, false
+}
+
+// enqueueTransferFanout 把本副本产生的事件排队转发给其他副本；队列已满时直接丢弃，不阻塞调用方。
+func (s *Server) enqueueTransferFanout(event transferStreamEvent) {
+	if s.db == nil {
+		return
+	}
+	payload, ok := encodeTransferFanoutPayload(s.instanceID, event)
+	if !ok {
+		return
+	}
+	select {
+	case s.transferFanout <- payload:
+	default:
+	}
+}
+
+// startTransferFanoutLoops 启动事件转发的发布与监听循环，使任一副本上的 /api/transfers/stream 都能收到全部传输事件。
+func (s *Server) startTransferFanoutLoops() {
+	if s.db == nil {
+		return
+	}
+	s.goBackground(s.runTransferFanoutPublisher)
+	s.goBackground(s.runTransferFanoutListener)
+}
+
+func (s *Server) runTransferFanoutPublisher() {
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case payload := <-s.transferFanout:
+			ctx, cancel := context.WithTimeout(s.backgroundCtx, transferFanoutPublishTimeout)
+			_, err := s.db.Exec(ctx, `SELECT pg_notify($1, $2)`, transferFanoutChannel, string(payload))
+			cancel()
+			if err != nil {
+				s.logger.Warn("publish transfer event failed", "error", err.Error())
+			}
+		}
+	}
+}
+
+func (s *Server) runTransferFanoutListener() {
+	// backgroundCtx 要等后台任务排空后才取消，监听循环需要在关闭开始时就退出。
+	ctx, cancel := context.WithCancel(s.backgroundCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-s.shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		s.markLoopHeartbeat(loopTransferFanout)
+		if err := s.listenTransferFanout(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Warn("transfer event listener disconnected", "error", err.Error())
+		}
+		if !s.waitLoopInterval(transferFanoutReconnectDelay) {
+			return
+		}
+	}
+}
+
+func (s *Server) listenTransferFanout(ctx context.Context) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, `LISTEN `+transferFanoutChannel); err != nil {
+		return err
+	}
+	defer func() {
+		unlistenCtx, cancel := context.WithTimeout(context.Background(), transferFanoutPublishTimeout)
+		defer cancel()
+		if !conn.Conn().IsClosed() {
+			_, _ = conn.Exec(unlistenCtx, `UNLISTEN `+transferFanoutChannel)
+		}
+	}()
+
+	for {
+		s.markLoopHeartbeat(loopTransferFanout)
+		waitCtx, cancel := context.WithTimeout(ctx, transferFanoutListenTimeout)
+		notification, err := conn.Conn().WaitForNotification(waitCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, context.DeadlineExceeded) && !conn.Conn().IsClosed() {
+				continue
+			}
+			return err
+		}
+
+		var envelope transferFanoutEnvelope
+		if err := json.Unmarshal([]byte(notification.Payload), &envelope); err != nil {
+			s.logger.Warn("decode transfer event failed", "error", err.Error())
+			continue
+		}
+		if envelope.Origin == s.instanceID {
+			continue
+		}
+		s.deliverTransferEventLocally(envelope.Event)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeTransferFanoutPayload(t *testing.T) {
+	t.Parallel()
+
+	origin := uuid.New()
+	id := "job-1"
+	results := func(n int) []itemBatchResultDTO {
+		out := make([]itemBatchResultDTO, n)
+		for i := range out {
+			out[i] = itemBatchResultDTO{ItemID: uuid.NewString(), Name: strings.Repeat("n", 40), Status: "success"}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name         string
+		event        transferStreamEvent
+		wantOK       bool
+		wantResults  bool
+		wantFailures bool
+	}{
+		{
+			name:   "small event",
+			event:  transferStreamEvent{Type: "job_remove", ID: &id},
+			wantOK: true,
+		},
+		{
+			name: "batch results trimmed",
+			event: transferStreamEvent{Type: "batch_upsert", Batch: &itemBatchJobDTO{
+				ID:       id,
+				Results:  results(200),
+				Failures: results(2),
+			}},
+			wantOK:       true,
+			wantFailures: true,
+		},
+		{
+			name: "batch failures trimmed",
+			event: transferStreamEvent{Type: "batch_upsert", Batch: &itemBatchJobDTO{
+				ID:       id,
+				Results:  results(200),
+				Failures: results(200),
+			}},
+			wantOK: true,
+		},
+		{
+			name: "oversized non batch event dropped",
+			event: transferStreamEvent{Type: "job_upsert", Item: &transferJobViewDTO{
+				Name: strings.Repeat("x", transferFanoutMaxPayload),
+			}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			payload, ok := encodeTransferFanoutPayload(origin, tc.event)
+			if ok != tc.wantOK {
+				t.Fatalf("encodeTransferFanoutPayload() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if len(payload) > transferFanoutMaxPayload {
+				t.Fatalf("payload size = %d, exceeds %d", len(payload), transferFanoutMaxPayload)
+			}
+			var envelope transferFanoutEnvelope
+			if err := json.Unmarshal(payload, &envelope); err != nil {
+				t.Fatalf("decode payload: %v", err)
+			}
+			if envelope.Origin != origin || envelope.Event.Type != tc.event.Type {
+				t.Fatalf("envelope = %+v, want origin %s type %q", envelope, origin, tc.event.Type)
+			}
+			if batch := envelope.Event.Batch; batch != nil {
+				if (len(batch.Results) > 0) != tc.wantResults || (len(batch.Failures) > 0) != tc.wantFailures {
+					t.Fatalf("batch results=%d failures=%d, want results=%v failures=%v", len(batch.Results), len(batch.Failures), tc.wantResults, tc.wantFailures)
+				}
+				if len(tc.event.Batch.Results) == 0 {
+					t.Fatalf("original event should not be modified")
+				}
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ type transferStreamEvent struct {
 	ID    *string              `json:"id,omitempty"`
 	Item  *transferJobViewDTO  `json:"item,omitempty"`
 	Items []transferJobViewDTO `json:"items,omitempty"`
+	Batch *itemBatchJobDTO     `json:"batch,omitempty"`
 }
 
 type downloadTransferProgress struct {
@@ -55,6 +56,13 @@ func (s *Server) unsubscribeTransferEvents(id uint64) {
 }
 
 func (s *Server) publishTransferEvent(event transferStreamEvent) {
+	s.observeTransferEventForNotifications(event)
+	s.deliverTransferEventLocally(event)
+	s.enqueueTransferFanout(event)
+}
+
+// deliverTransferEventLocally 只推送给本副本的订阅者，不触发 Webhook 与通知。
+func (s *Server) deliverTransferEventLocally(event transferStreamEvent) {
 	s.transferEventsMu.RLock()
 	defer s.transferEventsMu.RUnlock()
 
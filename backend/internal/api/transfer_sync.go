@@ -2,11 +2,15 @@ package api
 
 import (
 	"context"
+	"sync"
 
 	"tg-cloud-drive-api/internal/store"
 	"github.com/google/uuid"
 )
 
+// recentKeySetDefaultLimit 与 metricsRecordedJobsLimit 一致，足以覆盖同一任务被重复同步的时间窗口。
+const recentKeySetDefaultLimit = 4096
+
 func (s *Server) syncTransferJobEvent(ctx context.Context, job store.TransferJob) {
 	if job.Status == store.TransferJobStatusRunning {
 		s.publishRunningTransferJob(ctx, job)
@@ -31,7 +35,10 @@ func (s *Server) publishRunningTransferJob(ctx context.Context, job store.Transf
 	s.publishTransferEvent(transferStreamEvent{Type: "job_upsert", Item: &item})
 }
 
+// publishFinishedTransferJob 推送任务结束事件。同一任务可能以相同的结束状态被多次同步
+// （如 Torrent worker 标记失败后领取循环再次刷新），Webhook 与管理员通知对每个结束状态只发送一次。
 func (s *Server) publishFinishedTransferJob(ctx context.Context, job store.TransferJob) {
+	s.metrics.recordFinishedTransferJob(job)
 	item, err := s.buildTransferJobViewDTO(ctx, job)
 	if err != nil {
 		return
@@ -39,6 +46,50 @@ func (s *Server) publishFinishedTransferJob(ctx context.Context, job store.Trans
 	id := item.ID
 	s.publishTransferEvent(transferStreamEvent{Type: "job_remove", ID: &id})
 	s.publishTransferEvent(transferStreamEvent{Type: "history_upsert", Item: &item})
+	if !s.finishedJobEvents.add(finishedTransferJobKey{id: job.ID, status: job.Status}) {
+		return
+	}
+	s.emitTransferWebhookEvent(job.Status, item)
+	if job.Status == store.TransferJobStatusError {
+		s.notifyTransferFailed(item)
+	}
+}
+
+type finishedTransferJobKey struct {
+	id     uuid.UUID
+	status store.TransferJobStatus
+}
+
+// recentKeySet 记录最近出现过的键，超过 limit 时淘汰最早的记录；零值可直接使用。
+type recentKeySet[K comparable] struct {
+	mu    sync.Mutex
+	limit int
+	seen  map[K]struct{}
+	order []K
+}
+
+// add 在键首次出现时记录并返回 true。
+func (r *recentKeySet[K]) add(key K) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[key]; ok {
+		return false
+	}
+	if r.seen == nil {
+		r.seen = map[K]struct{}{}
+	}
+	limit := r.limit
+	if limit <= 0 {
+		limit = recentKeySetDefaultLimit
+	}
+	if len(r.order) >= limit {
+		oldest := r.order[0]
+		r.order = r.order[1:]
+		delete(r.seen, oldest)
+	}
+	r.seen[key] = struct{}{}
+	r.order = append(r.order, key)
+	return true
 }
 
 func (s *Server) publishTransferDeletion(id uuid.UUID) {
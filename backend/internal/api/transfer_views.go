@@ -17,6 +17,10 @@ const (
 	transferPhaseTorrentDownloading        = "torrent_downloading"
 	transferPhaseAwaitingSelection         = "awaiting_selection"
 	transferPhaseTorrentUploading          = "torrent_uploading"
+	transferPhasePaused                    = "paused"
+	transferPhaseArchiveExtracting         = "archive_extracting"
+	transferPhaseArchiveCreating           = "archive_creating"
+	transferPhaseTorrentHashing            = "torrent_hashing"
 	transferPhaseDetailLocalChunkUploading = "local_chunk_uploading"
 	transferPhaseDetailChunkProcessing     = "chunk_processing"
 	transferPhaseDetailAssemblingFile      = "assembling_file"
@@ -174,6 +178,12 @@ func (s *Server) buildTransferJobViewDTO(ctx context.Context, job store.Transfer
 		return s.buildTorrentTransferJobView(ctx, job)
 	case store.TransferSourceKindDownloadTask:
 		return s.buildDownloadTransferJobView(job), nil
+	case store.TransferSourceKindArchiveExtract:
+		return buildArchiveTransferJobView(job, transferPhaseArchiveExtracting), nil
+	case store.TransferSourceKindArchiveCreate:
+		return buildArchiveTransferJobView(job, transferPhaseArchiveCreating), nil
+	case store.TransferSourceKindTorrentPublish:
+		return buildArchiveTransferJobView(job, transferPhaseTorrentHashing), nil
 	default:
 		return toTransferJobViewDTO(job), nil
 	}
@@ -197,6 +207,9 @@ func (s *Server) buildUploadSessionTransferJobView(
 		return dto, nil
 	}
 	dto.Phase = resolveUploadSessionPhase(job.Status, uploadedCount, session.TotalChunks)
+	if dto.Phase != "" && session.Status == store.UploadSessionStatusPaused {
+		dto.Phase = transferPhasePaused
+	}
 	phaseView := s.resolveUploadSessionPhaseView(ctx, session, dto.Phase, uploadedCount)
 	dto.PhaseDetail = phaseView.Detail
 	dto.PhaseSteps = phaseView.Steps
@@ -412,6 +425,9 @@ func resolveUploadSessionPhase(status store.TransferJobStatus, uploadedCount int
 }
 
 func resolveUploadSessionStatusPhase(status store.UploadSessionStatus, uploadedCount int, totalChunks int) string {
+	if status == store.UploadSessionStatusPaused {
+		return transferPhasePaused
+	}
 	return resolveUploadPhase(status == store.UploadSessionStatusUploading, uploadedCount, totalChunks)
 }
 
@@ -445,6 +461,8 @@ func resolveTorrentTransferPhase(status store.TorrentTaskStatus) string {
 		return transferPhaseAwaitingSelection
 	case store.TorrentTaskStatusUploading:
 		return transferPhaseTorrentUploading
+	case store.TorrentTaskStatusPaused:
+		return transferPhasePaused
 	default:
 		return ""
 	}
@@ -1,10 +1,14 @@
 package api
 
+import "tg-cloud-drive-api/internal/store"
+
 type videoUploadProcessMeta struct {
 	FaststartApplied  bool
 	FaststartFallback bool
 	PreviewAttached   bool
 	PreviewFallback   bool
+	// MediaMetadata 为发送前 ffprobe 的完整结果，上传完成后直接写入条目元数据。
+	MediaMetadata *store.ItemMetadata
 }
 
 type uploadProcessDTO struct {
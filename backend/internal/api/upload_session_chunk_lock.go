@@ -2,43 +2,144 @@ package api
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
 )
 
-const uploadChunkLockPollInterval = 80 * time.Millisecond
+const (
+	uploadChunkLockPollInterval = 250 * time.Millisecond
+	// 租约在持有期间按 1/3 周期续期；持有进程异常退出后最多 uploadChunkLockLease 即可被其他请求接管。
+	uploadChunkLockLease = 2 * time.Minute
+)
+
+var errUploadChunkLockLost = errors.New("分片锁租约已失效")
+
+// uploadChunkLock 为同一分片的互斥锁，记录在数据库中，多个副本之间共享。
+// 租约丢失（被接管或续期持续失败到租约到期）时取消 ctx，持有者必须放弃本次分片上传。
+type uploadChunkLock struct {
+	sessionID  uuid.UUID
+	chunkIndex int
+	holder     uuid.UUID
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	stopRenew  context.CancelFunc
+	renewDone  chan struct{}
+}
+
+// Context 派生自获取锁时的请求 ctx，租约丢失后被取消。
+func (l *uploadChunkLock) Context() context.Context {
+	return l.ctx
+}
 
-func buildUploadSessionChunkLockKey(sessionID uuid.UUID, chunkIndex int) string {
-	return fmt.Sprintf("%s:%d", sessionID.String(), chunkIndex)
+func (l *uploadChunkLock) Done() <-chan struct{} {
+	return l.ctx.Done()
 }
 
-func (s *Server) acquireUploadSessionChunkLock(ctx context.Context, sessionID uuid.UUID, chunkIndex int) error {
-	key := buildUploadSessionChunkLockKey(sessionID, chunkIndex)
+// Lost 表示 ctx 是因租约丢失而取消，而不是请求本身结束。
+func (l *uploadChunkLock) Lost() bool {
+	return errors.Is(context.Cause(l.ctx), errUploadChunkLockLost)
+}
+
+func (s *Server) acquireUploadSessionChunkLock(ctx context.Context, sessionID uuid.UUID, chunkIndex int) (*uploadChunkLock, error) {
+	st := store.New(s.db)
+	holder := uuid.New()
 	for {
-		s.chunkUploadMu.Lock()
-		if s.chunkUploadInFlight == nil {
-			s.chunkUploadInFlight = map[string]struct{}{}
+		acquired, err := st.TryAcquireUploadChunkLock(ctx, sessionID, chunkIndex, holder, uploadChunkLockLease)
+		if err != nil {
+			return nil, err
 		}
-		if _, exists := s.chunkUploadInFlight[key]; !exists {
-			s.chunkUploadInFlight[key] = struct{}{}
-			s.chunkUploadMu.Unlock()
-			return nil
+		if acquired {
+			break
 		}
-		s.chunkUploadMu.Unlock()
 
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return nil, ctx.Err()
 		case <-time.After(uploadChunkLockPollInterval):
 		}
 	}
+
+	lock := newUploadChunkLock(ctx, sessionID, chunkIndex, holder)
+	renewCtx, stopRenew := context.WithCancel(context.Background())
+	lock.stopRenew = stopRenew
+	go s.renewUploadSessionChunkLock(renewCtx, lock, uploadChunkLockLease/3, func(ctx context.Context) error {
+		return st.ExtendUploadChunkLock(ctx, sessionID, chunkIndex, holder, uploadChunkLockLease)
+	})
+	return lock, nil
+}
+
+func newUploadChunkLock(parent context.Context, sessionID uuid.UUID, chunkIndex int, holder uuid.UUID) *uploadChunkLock {
+	ctx, cancel := context.WithCancelCause(parent)
+	return &uploadChunkLock{
+		sessionID:  sessionID,
+		chunkIndex: chunkIndex,
+		holder:     holder,
+		ctx:        ctx,
+		cancel:     cancel,
+		stopRenew:  func() {},
+		renewDone:  make(chan struct{}),
+	}
+}
+
+// renewUploadSessionChunkLock 每个 interval 续期一次。锁已被他人接管时立即判定丢失；
+// 其他错误（如数据库暂时不可用）继续重试，直到上次成功续期的租约到期仍未恢复才判定丢失。
+func (s *Server) renewUploadSessionChunkLock(
+	ctx context.Context,
+	lock *uploadChunkLock,
+	interval time.Duration,
+	extend func(context.Context) error,
+) {
+	defer close(lock.renewDone)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	leaseUntil := time.Now().Add(uploadChunkLockLease)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			startedAt := time.Now()
+			err := extend(ctx)
+			if err == nil {
+				leaseUntil = startedAt.Add(uploadChunkLockLease)
+				continue
+			}
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			s.logger.Warn(
+				"renew upload chunk lock failed",
+				"error", err.Error(),
+				"session_id", lock.sessionID.String(),
+				"chunk_index", lock.chunkIndex,
+			)
+			if errors.Is(err, store.ErrNotFound) || !time.Now().Before(leaseUntil) {
+				lock.cancel(errUploadChunkLockLost)
+				return
+			}
+		}
+	}
 }
 
-func (s *Server) releaseUploadSessionChunkLock(sessionID uuid.UUID, chunkIndex int) {
-	key := buildUploadSessionChunkLockKey(sessionID, chunkIndex)
-	s.chunkUploadMu.Lock()
-	delete(s.chunkUploadInFlight, key)
-	s.chunkUploadMu.Unlock()
+func (s *Server) releaseUploadSessionChunkLock(lock *uploadChunkLock) {
+	if lock == nil {
+		return
+	}
+	lock.stopRenew()
+	<-lock.renewDone
+	lock.cancel(context.Canceled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := store.New(s.db).ReleaseUploadChunkLock(ctx, lock.sessionID, lock.chunkIndex, lock.holder); err != nil {
+		s.logger.Warn(
+			"release upload chunk lock failed",
+			"error", err.Error(),
+			"session_id", lock.sessionID.String(),
+			"chunk_index", lock.chunkIndex,
+		)
+	}
 }
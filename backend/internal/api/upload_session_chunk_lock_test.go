@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestRenewUploadSessionChunkLockCancelsOnLostLease(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	lock := newUploadChunkLock(context.Background(), uuid.New(), 3, uuid.New())
+	var calls atomic.Int32
+	go srv.renewUploadSessionChunkLock(context.Background(), lock, time.Millisecond, func(context.Context) error {
+		if calls.Add(1) < 3 {
+			return nil
+		}
+		return store.ErrNotFound
+	})
+
+	select {
+	case <-lock.Done():
+	case <-time.After(time.Second):
+		t.Fatalf("lock context should be canceled once the lease is taken over")
+	}
+	<-lock.renewDone
+	if !lock.Lost() {
+		t.Fatalf("Lost() = false, cause = %v", context.Cause(lock.Context()))
+	}
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("extend calls = %d, want 3", got)
+	}
+}
+
+func TestRenewUploadSessionChunkLockRetriesTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	lock := newUploadChunkLock(context.Background(), uuid.New(), 0, uuid.New())
+	renewCtx, stopRenew := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	go srv.renewUploadSessionChunkLock(renewCtx, lock, time.Millisecond, func(context.Context) error {
+		calls.Add(1)
+		return errors.New("connection refused")
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	stopRenew()
+	<-lock.renewDone
+	if calls.Load() < 5 {
+		t.Fatalf("extend calls = %d, renewal should keep retrying", calls.Load())
+	}
+	if lock.Lost() || lock.Context().Err() != nil {
+		t.Fatalf("transient errors within the lease should not cancel the lock")
+	}
+}
+
+func TestUploadChunkLockLostIgnoresRequestCancel(t *testing.T) {
+	t.Parallel()
+
+	parent, cancel := context.WithCancel(context.Background())
+	lock := newUploadChunkLock(parent, uuid.New(), 0, uuid.New())
+	cancel()
+	<-lock.Done()
+	if lock.Lost() {
+		t.Fatalf("request cancellation should not be reported as a lost lease")
+	}
+}
@@ -20,21 +20,26 @@ const (
 )
 
 func (s *Server) startUploadSessionCleanupLoop() {
-	go func() {
+	s.goBackground(func() {
 		for {
+			s.markLoopHeartbeat(loopUploadSessionCleanup)
 			settings, err := s.getRuntimeSettings(context.Background())
 			if err != nil {
 				s.logger.Warn("load runtime settings for cleanup failed", "error", err.Error())
 				settings = s.defaultRuntimeSettings()
 			}
 
-			ttl := uploadSessionTTLFromHours(settings.UploadSessionTTLHours)
-			s.runExpiredUploadSessionCleanup(ttl)
+			if s.isLeader() {
+				ttl := uploadSessionTTLFromHours(settings.UploadSessionTTLHours)
+				s.runExpiredUploadSessionCleanup(ttl)
+			}
 
 			interval := uploadSessionCleanupIntervalFromMins(settings.UploadSessionCleanupIntervalMins)
-			time.Sleep(interval)
+			if !s.waitLoopInterval(interval) {
+				return
+			}
 		}
-	}()
+	})
 }
 
 func uploadSessionTTLFromHours(hours int) time.Duration {
@@ -76,6 +81,7 @@ func (s *Server) runExpiredUploadSessionCleanup(ttl time.Duration) {
 
 	st := store.New(s.db)
 	totalCleaned := 0
+	totalFailed := 0
 
 	for round := 0; round < expiredUploadSessionCleanupMaxRounds; round++ {
 		if ctx.Err() != nil {
@@ -102,6 +108,7 @@ func (s *Server) runExpiredUploadSessionCleanup(ttl time.Duration) {
 					"session_id", session.ID.String(),
 					"item_id", session.ItemID.String(),
 				)
+				totalFailed++
 				continue
 			}
 			totalCleaned++
@@ -119,6 +126,10 @@ func (s *Server) runExpiredUploadSessionCleanup(ttl time.Duration) {
 	if orphanCleaned > 0 {
 		s.logger.Info("orphan local upload session dirs cleaned", "count", orphanCleaned)
 	}
+	if _, err := st.PurgeExpiredUploadChunkLocks(ctx); err != nil {
+		s.logger.Warn("purge expired upload chunk locks failed", "error", err.Error())
+	}
+	s.metrics.recordUploadSessionCleanup(totalCleaned, totalFailed, orphanCleaned)
 }
 
 func (s *Server) cleanupExpiredUploadSession(ctx context.Context, st *store.Store, session store.UploadSession) error {
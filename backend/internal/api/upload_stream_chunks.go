@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+// uploadChunksFromStream 按分块大小读取 src，每次只在磁盘上暂存一个分块再上传，
+// 适用于总大小事先未知、也不希望落地完整文件的内容（如服务端生成的压缩包）。
+func (s *Server) uploadChunksFromStream(
+	ctx context.Context,
+	st *store.Store,
+	itemID uuid.UUID,
+	originalFileName string,
+	src io.Reader,
+	chunkSizeLimit int64,
+	reservedBytes int64,
+	now time.Time,
+) ([]store.Chunk, int64, error) {
+	if chunkSizeLimit <= 0 {
+		chunkSizeLimit = 20 * 1024 * 1024
+	}
+
+	tmpFile, err := os.CreateTemp("", "tgcd-stream-chunk-*.bin")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+	}()
+
+	var (
+		uploaded   []store.Chunk
+		offset     int64
+		chunkIndex int
+	)
+	for {
+		if err := tmpFile.Truncate(0); err != nil {
+			return uploaded, offset, err
+		}
+		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+			return uploaded, offset, err
+		}
+		chunkLen, err := copyPartToTempFileWithReserve(io.LimitReader(src, chunkSizeLimit), tmpFile, reservedBytes)
+		if err != nil {
+			if errors.Is(err, errInsufficientTempSpace) {
+				s.notifyLowDisk(os.TempDir(), reservedBytes)
+			}
+			return uploaded, offset, err
+		}
+		if chunkLen == 0 {
+			return uploaded, offset, nil
+		}
+
+		chunkFileName := buildChunkFileName(originalFileName, itemID, chunkIndex)
+		caption := fmt.Sprintf("tgcd:%s:%d", itemID.String(), chunkIndex)
+		section := io.NewSectionReader(tmpFile, 0, chunkLen)
+		msg, sendErr := s.sendDocumentFromReadSeekerWithRetry(ctx, s.cfg.TGStorageChatID, chunkFileName, section, caption)
+		if sendErr != nil {
+			return uploaded, offset, sendErr
+		}
+		resolvedDoc, docErr := s.resolveMessageDocument(ctx, msg)
+		if docErr != nil {
+			if msg.MessageID > 0 {
+				_ = s.deleteMessageWithRetry(ctx, s.cfg.TGStorageChatID, msg.MessageID)
+			}
+			return uploaded, offset, errUploadMissingFileID
+		}
+
+		chunk := store.Chunk{
+			ID:             uuid.New(),
+			ItemID:         itemID,
+			ChunkIndex:     chunkIndex,
+			ChunkSize:      int(chunkLen),
+			TGChatID:       s.cfg.TGStorageChatID,
+			TGMessageID:    msg.MessageID,
+			TGFileID:       resolvedDoc.FileID,
+			TGFileUniqueID: resolvedDoc.FileUniqueID,
+			CreatedAt:      now,
+		}
+		if err := st.InsertChunk(ctx, chunk); err != nil {
+			_ = s.deleteMessageWithRetry(ctx, chunk.TGChatID, chunk.TGMessageID)
+			return uploaded, offset, err
+		}
+
+		uploaded = append(uploaded, chunk)
+		offset += chunkLen
+		chunkIndex++
+		if chunkLen < chunkSizeLimit {
+			return uploaded, offset, nil
+		}
+	}
+}
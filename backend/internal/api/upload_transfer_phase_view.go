@@ -195,7 +195,7 @@ func (s *Server) resolveUploadSessionPhaseView(
 ) uploadPhaseView {
 	mode := resolveUploadSessionModeSafe(ctx, s, session)
 	steps := buildUploadPhaseSteps(mode)
-	if phase == "" {
+	if phase == "" || phase == transferPhasePaused {
 		return uploadPhaseView{Steps: steps}
 	}
 	if phase == transferPhaseUploadingChunks {
@@ -11,8 +11,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
+	"tg-cloud-drive-api/internal/store"
 	"tg-cloud-drive-api/internal/telegram"
 )
 
@@ -75,7 +75,7 @@ func (s *Server) prepareVideoUploadAssets(ctx context.Context, fileName string,
 		cleanupPaths = append(cleanupPaths, previewPath)
 	}
 
-	meta, metaErr := s.probeVideoSendMeta(ctx, result.filePath)
+	meta, probed, metaErr := s.probeVideoSendMeta(ctx, result.filePath)
 	if metaErr != nil {
 		s.logger.Warn(
 			"probe video metadata failed, continue with default sendVideo options",
@@ -86,6 +86,7 @@ func (s *Server) prepareVideoUploadAssets(ctx context.Context, fileName string,
 		result.options.DurationSeconds = meta.DurationSeconds
 		result.options.Width = meta.Width
 		result.options.Height = meta.Height
+		result.process.MediaMetadata = &probed
 	}
 
 	result.cleanup = func() {
@@ -100,80 +101,37 @@ func (s *Server) prepareVideoUploadAssets(ctx context.Context, fileName string,
 	return result
 }
 
-func (s *Server) probeVideoSendMeta(ctx context.Context, inputPath string) (videoSendMeta, error) {
+// probeVideoSendMeta 对待发送的视频做一次完整 ffprobe：sendVideo 参数取自其中，完整结果随上传流程带回用于保存元数据。
+func (s *Server) probeVideoSendMeta(ctx context.Context, inputPath string) (videoSendMeta, store.ItemMetadata, error) {
 	trimmedPath := strings.TrimSpace(inputPath)
 	if trimmedPath == "" {
-		return videoSendMeta{}, errors.New("视频文件路径为空")
+		return videoSendMeta{}, store.ItemMetadata{}, errors.New("视频文件路径为空")
 	}
-	ffprobeBinary, err := s.resolveFFprobeBinary()
+	probed, err := s.probeMediaMetadata(ctx, trimmedPath)
 	if err != nil {
-		return videoSendMeta{}, err
+		return videoSendMeta{}, store.ItemMetadata{}, err
 	}
-
-	type ffprobeOutput struct {
-		Streams []struct {
-			Width        int               `json:"width"`
-			Height       int               `json:"height"`
-			Tags         map[string]string `json:"tags"`
-			SideDataList []map[string]any  `json:"side_data_list"`
-		} `json:"streams"`
-		Format struct {
-			Duration string `json:"duration"`
-		} `json:"format"`
-	}
-
-	probeCtx, cancel := context.WithTimeout(ctx, 8*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(
-		probeCtx,
-		ffprobeBinary,
-		"-v", "error",
-		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height,tags,side_data_list:format=duration",
-		"-of", "json",
-		trimmedPath,
-	)
-	raw, runErr := cmd.CombinedOutput()
-	if runErr != nil {
-		msg := strings.TrimSpace(string(raw))
-		if msg == "" {
-			return videoSendMeta{}, runErr
-		}
-		return videoSendMeta{}, fmt.Errorf("ffprobe 失败: %w: %s", runErr, msg)
-	}
-
-	var out ffprobeOutput
-	if err := json.Unmarshal(raw, &out); err != nil {
-		return videoSendMeta{}, fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	meta := videoSendMetaFromItemMetadata(probed)
+	if meta.DurationSeconds <= 0 && (meta.Width <= 0 || meta.Height <= 0) {
+		return videoSendMeta{}, store.ItemMetadata{}, errors.New("ffprobe 未返回有效的视频发送元数据")
 	}
+	return meta, probed, nil
+}
 
+// videoSendMetaFromItemMetadata 宽高已按旋转角交换，时长取整且不足 1 秒按 1 秒计。
+func videoSendMetaFromItemMetadata(probed store.ItemMetadata) videoSendMeta {
 	meta := videoSendMeta{}
-	if len(out.Streams) > 0 {
-		stream := out.Streams[0]
-		width := stream.Width
-		height := stream.Height
-		if width > 0 && height > 0 {
-			if shouldSwapVideoDimensionsByRotation(parseVideoRotation(stream.Tags, stream.SideDataList)) {
-				width, height = height, width
-			}
-			meta.Width = width
-			meta.Height = height
+	if probed.Width != nil && probed.Height != nil && *probed.Width > 0 && *probed.Height > 0 {
+		meta.Width = *probed.Width
+		meta.Height = *probed.Height
+	}
+	if probed.DurationSeconds != nil && *probed.DurationSeconds > 0 {
+		meta.DurationSeconds = int(math.Round(*probed.DurationSeconds))
+		if meta.DurationSeconds <= 0 {
+			meta.DurationSeconds = 1
 		}
 	}
-
-	if trimmedDuration := strings.TrimSpace(out.Format.Duration); trimmedDuration != "" {
-		if seconds, parseErr := strconv.ParseFloat(trimmedDuration, 64); parseErr == nil && seconds > 0 {
-			meta.DurationSeconds = int(math.Round(seconds))
-			if meta.DurationSeconds <= 0 {
-				meta.DurationSeconds = 1
-			}
-		}
-	}
-
-	if meta.DurationSeconds <= 0 && (meta.Width <= 0 || meta.Height <= 0) {
-		return videoSendMeta{}, errors.New("ffprobe 未返回有效的视频发送元数据")
-	}
-	return meta, nil
+	return meta
 }
 
 func parseVideoRotation(tags map[string]string, sideDataList []map[string]any) float64 {
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"tg-cloud-drive-api/internal/config"
+	"tg-cloud-drive-api/internal/store"
 )
 
 func TestCreatePreprocessTempFilePreferInputDir(t *testing.T) {
@@ -120,3 +121,19 @@ func TestPrepareVideoUploadAssetsLocalPathCompatibility(t *testing.T) {
 		t.Fatalf("preview output perm mismatch: got=%#o want=0644", perm)
 	}
 }
+
+func TestVideoSendMetaFromItemMetadata(t *testing.T) {
+	t.Parallel()
+
+	width, height := 1080, 1920
+	duration := 0.4
+	got := videoSendMetaFromItemMetadata(store.ItemMetadata{Width: &width, Height: &height, DurationSeconds: &duration})
+	if got.Width != 1080 || got.Height != 1920 || got.DurationSeconds != 1 {
+		t.Fatalf("videoSendMetaFromItemMetadata() = %+v, want 1080x1920 1s", got)
+	}
+	duration = 62.6
+	got = videoSendMetaFromItemMetadata(store.ItemMetadata{DurationSeconds: &duration})
+	if got.Width != 0 || got.Height != 0 || got.DurationSeconds != 63 {
+		t.Fatalf("videoSendMetaFromItemMetadata() = %+v, want 0x0 63s", got)
+	}
+}
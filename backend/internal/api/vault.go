@@ -53,6 +53,7 @@ func (s *Server) handleVaultUnlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(settings.VaultPasswordHash), []byte(password)); err != nil {
+		s.recordAudit(r.Context(), auditEntry{Action: auditActionVaultUnlock, Failed: true})
 		writeError(w, http.StatusUnauthorized, "unauthorized", "密码箱密码错误")
 		return
 	}
@@ -60,6 +61,7 @@ func (s *Server) handleVaultUnlock(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	expireAt := now.Add(vaultSessionTTL(settings))
 	s.setVaultCookie(w, now, settings)
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionVaultUnlock})
 	writeJSON(w, http.StatusOK, map[string]any{
 		"ok":        true,
 		"expiresAt": expireAt.Format(time.RFC3339),
@@ -68,6 +70,7 @@ func (s *Server) handleVaultUnlock(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleVaultLock(w http.ResponseWriter, r *http.Request) {
 	s.clearVaultCookie(w)
+	s.recordAudit(r.Context(), auditEntry{Action: auditActionVaultLock})
 	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 }
 
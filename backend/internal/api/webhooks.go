@@ -0,0 +1,401 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+const (
+	webhookEventItemCreated              = "item.created"
+	webhookEventItemDeleted              = "item.deleted"
+	webhookEventTransferCompleted        = "transfer.completed"
+	webhookEventTransferFailed           = "transfer.failed"
+	webhookEventTorrentAwaitingSelection = "torrent.awaiting_selection"
+	webhookEventShareAccessed            = "share.accessed"
+	// webhookEventPing 仅用于测试投递，不能被订阅。
+	webhookEventPing = "ping"
+)
+
+var webhookEventTypes = []string{
+	webhookEventItemCreated,
+	webhookEventItemDeleted,
+	webhookEventTransferCompleted,
+	webhookEventTransferFailed,
+	webhookEventTorrentAwaitingSelection,
+	webhookEventShareAccessed,
+}
+
+const (
+	webhookDeliveryInterval      = 15 * time.Second
+	webhookDeliveryBatchSize     = 20
+	webhookDeliveryLease         = 2 * time.Minute
+	webhookDeliveryTimeout       = 10 * time.Second
+	webhookRetryBaseDelay        = 30 * time.Second
+	webhookRetryMaxDelay         = 6 * time.Hour
+	webhookMaxAttempts           = 8
+	webhookDeliveryRetention     = 30 * 24 * time.Hour
+	webhookPurgeInterval         = time.Hour
+	webhookEmitTimeout           = 5 * time.Second
+	webhookResponseErrorMaxBytes = 512
+	webhookSecretPrefix          = "whsec_"
+	webhookSecretMinLength       = 16
+)
+
+const (
+	webhookHeaderEvent     = "X-TGCD-Event"
+	webhookHeaderDelivery  = "X-TGCD-Delivery"
+	webhookHeaderSignature = "X-TGCD-Signature"
+)
+
+// webhookPayload 是投递给订阅方的请求体；同一事件对所有订阅使用相同的 ID 与内容。
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+var webhookHTTPClient = &http.Client{
+	Timeout: webhookDeliveryTimeout,
+	// 重定向视为投递失败，避免把签名请求转发到订阅方未声明的地址。
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, known := range webhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// signWebhookPayload 生成签名头：t 为 Unix 秒级时间戳，v1 为 HMAC-SHA256(secret, "<t>.<body>") 的十六进制值。
+func signWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func generateWebhookSecret() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(raw), nil
+}
+
+// webhookRetryBackoff 返回第 attempts 次投递失败后的等待时间：30 秒起按 2 倍递增，最长 6 小时。
+func webhookRetryBackoff(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMaxDelay {
+		delay = webhookRetryMaxDelay
+	}
+	return delay
+}
+
+func (s *Server) wakeWebhookDelivery() {
+	select {
+	case s.webhookWake <- struct{}{}:
+	default:
+	}
+}
+
+// emitWebhookEvent 为所有订阅了该事件的订阅生成待投递记录。
+// 写库在后台完成，调用方（包括上传、传输等热路径）不会因订阅查询或 Webhook 故障而阻塞；
+// 后台写入登记到关闭流程的等待列表，进入关闭流程后（如关闭时刷新传输进度）改为同步写入，确保不丢事件。
+func (s *Server) emitWebhookEvent(eventType string, data any) {
+	if s.db == nil {
+		return
+	}
+	event := webhookPayload{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+	enqueue := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookEmitTimeout)
+		defer cancel()
+		st := store.New(s.db)
+		subs, err := st.ListWebhookSubscriptionsForEvent(ctx, eventType)
+		if err != nil {
+			s.logger.Warn("list webhook subscriptions failed", "error", err.Error(), "event", eventType)
+			return
+		}
+		if len(subs) == 0 {
+			return
+		}
+		deliveries, err := buildWebhookDeliveries(event, subs)
+		if err != nil {
+			s.logger.Warn("encode webhook payload failed", "error", err.Error(), "event", eventType)
+			return
+		}
+		if err := st.InsertWebhookDeliveries(ctx, deliveries); err != nil {
+			s.logger.Warn("enqueue webhook deliveries failed", "error", err.Error(), "event", eventType)
+			return
+		}
+		s.wakeWebhookDelivery()
+	}
+	if s.isShuttingDown() {
+		enqueue()
+		return
+	}
+	s.goBackground(enqueue)
+}
+
+func buildWebhookDeliveries(event webhookPayload, subs []store.WebhookSubscription) ([]store.WebhookDelivery, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	eventID, err := uuid.Parse(event.ID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]store.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		out = append(out, store.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        eventID,
+			EventType:      event.Type,
+			Payload:        body,
+			Status:         store.WebhookDeliveryStatusPending,
+			CreatedAt:      event.CreatedAt,
+		})
+	}
+	return out, nil
+}
+
+// emitItemWebhookEvent 发送文件/文件夹事件；密码箱中的条目不对外通知。
+func (s *Server) emitItemWebhookEvent(eventType string, item store.Item, source string) {
+	if item.InVault {
+		return
+	}
+	data := map[string]any{"item": toItemDTO(item)}
+	if source != "" {
+		data["source"] = source
+	}
+	s.emitWebhookEvent(eventType, data)
+}
+
+// emitTransferWebhookEvent 在传输任务结束时发送 transfer.completed / transfer.failed，已取消的任务不通知。
+func (s *Server) emitTransferWebhookEvent(status store.TransferJobStatus, item transferJobViewDTO) {
+	switch status {
+	case store.TransferJobStatusCompleted:
+		s.emitWebhookEvent(webhookEventTransferCompleted, map[string]any{"transfer": item})
+	case store.TransferJobStatusError:
+		s.emitWebhookEvent(webhookEventTransferFailed, map[string]any{"transfer": item})
+	}
+}
+
+// observeTransferEventForNotifications 从传输事件流中识别种子任务进入“待选择文件”阶段，
+// 每个任务只发送一次 Webhook 事件与管理员通知。
+func (s *Server) observeTransferEventForNotifications(event transferStreamEvent) {
+	switch event.Type {
+	case "job_upsert":
+		if event.Item == nil {
+			return
+		}
+		id := event.Item.ID
+		if event.Item.Phase != transferPhaseAwaitingSelection {
+			s.awaitingSelectionMu.Lock()
+			delete(s.awaitingSelectionNotified, id)
+			s.awaitingSelectionMu.Unlock()
+			return
+		}
+		s.awaitingSelectionMu.Lock()
+		_, notified := s.awaitingSelectionNotified[id]
+		s.awaitingSelectionNotified[id] = struct{}{}
+		s.awaitingSelectionMu.Unlock()
+		if !notified {
+			s.emitWebhookEvent(webhookEventTorrentAwaitingSelection, map[string]any{"transfer": *event.Item})
+			s.notifyAdmins(adminNotifyKindTorrentAwaitingSelection, id, fmt.Sprintf("Torrent 任务「%s」等待选择要上传的文件", event.Item.Name))
+		}
+	case "job_remove":
+		if event.ID == nil {
+			return
+		}
+		s.awaitingSelectionMu.Lock()
+		delete(s.awaitingSelectionNotified, *event.ID)
+		s.awaitingSelectionMu.Unlock()
+	}
+}
+
+// isInitialRangeRequest 判断下载请求是否从文件开头读取；播放器的后续分段请求不重复计为一次分享访问。
+func isInitialRangeRequest(rangeHeader string) bool {
+	rangeHeader = strings.TrimSpace(rangeHeader)
+	if rangeHeader == "" {
+		return true
+	}
+	return strings.HasPrefix(strings.ReplaceAll(rangeHeader, " ", ""), "bytes=0-")
+}
+
+func (s *Server) emitShareAccessedWebhookEvent(r *http.Request, item store.Item, via string) {
+	s.emitWebhookEvent(webhookEventShareAccessed, map[string]any{
+		"item":      toItemDTO(item),
+		"via":       via,
+		"ip":        requestClientIP(r),
+		"userAgent": truncateAuditText(r.UserAgent(), auditUserAgentMaxBytes),
+	})
+}
+
+func (s *Server) startWebhookDeliveryLoop() {
+	s.goBackground(func() {
+		var lastPurge time.Time
+		for {
+			s.markLoopHeartbeat(loopWebhookDelivery)
+			s.runWebhookDeliveryPass(s.backgroundCtx)
+			if time.Since(lastPurge) >= webhookPurgeInterval {
+				s.purgeWebhookDeliveries(s.backgroundCtx)
+				lastPurge = time.Now()
+			}
+			select {
+			case <-s.shutdownCh:
+				return
+			case <-s.webhookWake:
+			case <-time.After(webhookDeliveryInterval):
+			}
+		}
+	})
+}
+
+// runWebhookDeliveryPass 投递所有到期的记录；一批领满时继续领取下一批。
+func (s *Server) runWebhookDeliveryPass(ctx context.Context) {
+	st := store.New(s.db)
+	subs := map[uuid.UUID]*store.WebhookSubscription{}
+	for {
+		if s.isShuttingDown() {
+			return
+		}
+		deliveries, err := st.ClaimDueWebhookDeliveries(ctx, time.Now(), webhookDeliveryLease, webhookDeliveryBatchSize)
+		if err != nil {
+			s.logger.Warn("claim webhook deliveries failed", "error", err.Error())
+			return
+		}
+		for _, delivery := range deliveries {
+			sub, ok := subs[delivery.SubscriptionID]
+			if !ok {
+				loaded, err := st.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+				if err != nil {
+					if !errors.Is(err, store.ErrNotFound) {
+						s.logger.Warn("get webhook subscription failed", "error", err.Error(), "subscription_id", delivery.SubscriptionID.String())
+					}
+					continue
+				}
+				sub = &loaded
+				subs[delivery.SubscriptionID] = sub
+			}
+			if _, err := s.attemptWebhookDelivery(ctx, st, *sub, delivery); err != nil {
+				s.logger.Warn("record webhook delivery attempt failed", "error", err.Error(), "delivery_id", delivery.ID.String())
+			}
+		}
+		if len(deliveries) < webhookDeliveryBatchSize {
+			return
+		}
+	}
+}
+
+// attemptWebhookDelivery 发送一次并记录结果：2xx 视为成功，其余按退避重新排队，达到最大次数后标记失败。
+func (s *Server) attemptWebhookDelivery(
+	ctx context.Context,
+	st *store.Store,
+	sub store.WebhookSubscription,
+	delivery store.WebhookDelivery,
+) (store.WebhookDelivery, error) {
+	var (
+		statusCode *int
+		sendErr    error
+	)
+	if sub.Enabled {
+		statusCode, sendErr = sendWebhookRequest(ctx, webhookHTTPClient, sub, delivery, time.Now())
+	} else {
+		sendErr = errors.New("订阅已停用")
+	}
+
+	now := time.Now()
+	attempt := store.WebhookDeliveryAttempt{
+		Status:     store.WebhookDeliveryStatusSucceeded,
+		StatusCode: statusCode,
+		At:         now,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+		attempt.Status = store.WebhookDeliveryStatusFailed
+		if attempts := delivery.Attempts + 1; sub.Enabled && attempts < webhookMaxAttempts {
+			next := now.Add(webhookRetryBackoff(attempts))
+			attempt.Status = store.WebhookDeliveryStatusPending
+			attempt.NextAttemptAt = &next
+		}
+	}
+	return st.RecordWebhookDeliveryAttempt(context.WithoutCancel(ctx), delivery.ID, attempt)
+}
+
+func sendWebhookRequest(
+	ctx context.Context,
+	client *http.Client,
+	sub store.WebhookSubscription,
+	delivery store.WebhookDelivery,
+	now time.Time,
+) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tg-cloud-drive-webhook/1")
+	req.Header.Set(webhookHeaderEvent, delivery.EventType)
+	req.Header.Set(webhookHeaderDelivery, delivery.ID.String())
+	req.Header.Set(webhookHeaderSignature, signWebhookPayload(sub.Secret, now.Unix(), delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	statusCode := resp.StatusCode
+	if statusCode >= 200 && statusCode < 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseErrorMaxBytes))
+		return &statusCode, nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseErrorMaxBytes))
+	msg := fmt.Sprintf("HTTP %d", statusCode)
+	if text := strings.TrimSpace(truncateAuditText(string(snippet), webhookResponseErrorMaxBytes)); text != "" {
+		msg += ": " + text
+	}
+	return &statusCode, errors.New(msg)
+}
+
+func (s *Server) purgeWebhookDeliveries(ctx context.Context) {
+	deleted, err := store.New(s.db).PurgeWebhookDeliveriesBefore(ctx, time.Now().Add(-webhookDeliveryRetention))
+	if err != nil {
+		s.logger.Warn("purge webhook deliveries failed", "error", err.Error())
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("webhook deliveries purged", "deleted", deleted)
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"tg-cloud-drive-api/internal/store"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"id":"1","type":"ping"}`)
+	got := signWebhookPayload("whsec_test", 1700000000, body)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "t=1700000000,v1=" + hex.EncodeToString(mac.Sum(nil))
+	if got != want {
+		t.Fatalf("signWebhookPayload() = %q, want %q", got, want)
+	}
+	if other := signWebhookPayload("whsec_other", 1700000000, body); other == got {
+		t.Fatalf("signature should depend on secret")
+	}
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 20, want: webhookRetryMaxDelay},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(strconv.Itoa(tc.attempts), func(t *testing.T) {
+			t.Parallel()
+			if got := webhookRetryBackoff(tc.attempts); got != tc.want {
+				t.Fatalf("webhookRetryBackoff(%d) = %s, want %s", tc.attempts, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWebhookEvents(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		in      []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", in: nil, wantErr: true},
+		{name: "unknown", in: []string{"item.created", "item.updated"}, wantErr: true},
+		{name: "ping not subscribable", in: []string{webhookEventPing}, wantErr: true},
+		{
+			name: "dedupe and order",
+			in:   []string{"share.accessed", " item.created ", "share.accessed"},
+			want: []string{webhookEventItemCreated, webhookEventShareAccessed},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := normalizeWebhookEvents(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("normalizeWebhookEvents() err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("normalizeWebhookEvents() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsInitialRangeRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: true},
+		{header: "bytes=0-", want: true},
+		{header: "bytes=0-1023", want: true},
+		{header: "bytes = 0-", want: true},
+		{header: "bytes=1024-", want: false},
+		{header: "bytes=-500", want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.header, func(t *testing.T) {
+			t.Parallel()
+			if got := isInitialRangeRequest(tc.header); got != tc.want {
+				t.Fatalf("isInitialRangeRequest(%q) = %v, want %v", tc.header, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSendWebhookRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		status     int
+		wantErr    string
+		wantStatus int
+	}{
+		{name: "accepted", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "server error", status: http.StatusBadGateway, wantErr: "HTTP 502: upstream down", wantStatus: http.StatusBadGateway},
+		{name: "redirect is failure", status: http.StatusFound, wantErr: "HTTP 302", wantStatus: http.StatusFound},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			delivery := store.WebhookDelivery{
+				ID:        uuid.New(),
+				EventType: webhookEventItemCreated,
+				Payload:   []byte(`{"type":"item.created"}`),
+			}
+			now := time.Unix(1700000000, 0)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if got := r.Header.Get(webhookHeaderSignature); got != signWebhookPayload("whsec_test", now.Unix(), body) {
+					t.Errorf("signature header = %q", got)
+				}
+				if got := r.Header.Get(webhookHeaderEvent); got != webhookEventItemCreated {
+					t.Errorf("event header = %q", got)
+				}
+				if got := r.Header.Get(webhookHeaderDelivery); got != delivery.ID.String() {
+					t.Errorf("delivery header = %q", got)
+				}
+				if tc.status == http.StatusFound {
+					w.Header().Set("Location", "/elsewhere")
+				}
+				w.WriteHeader(tc.status)
+				if tc.status == http.StatusBadGateway {
+					_, _ = w.Write([]byte("upstream down\n"))
+				}
+			}))
+			defer srv.Close()
+
+			client := srv.Client()
+			client.CheckRedirect = webhookHTTPClient.CheckRedirect
+			sub := store.WebhookSubscription{URL: srv.URL, Secret: "whsec_test"}
+			status, err := sendWebhookRequest(context.Background(), client, sub, delivery, now)
+			if status == nil || *status != tc.wantStatus {
+				t.Fatalf("status = %v, want %d", status, tc.wantStatus)
+			}
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+// 同一任务以相同结束状态重复同步时，Webhook 与管理员通知只在第一次发送。
+func TestPublishFinishedTransferJobEmitsOncePerStatus(t *testing.T) {
+	t.Parallel()
+
+	srv, err := NewServer(ServerDeps{})
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	now := time.Now()
+	job := store.TransferJob{
+		ID:         uuid.New(),
+		Direction:  store.TransferDirectionUpload,
+		SourceKind: store.TransferSourceKindArchiveCreate,
+		Name:       "a.zip",
+		ItemCount:  1,
+		Status:     store.TransferJobStatusError,
+		StartedAt:  now,
+		FinishedAt: now,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	srv.publishFinishedTransferJob(context.Background(), job)
+	srv.publishFinishedTransferJob(context.Background(), job)
+
+	if srv.finishedJobEvents.add(finishedTransferJobKey{id: job.ID, status: store.TransferJobStatusError}) {
+		t.Fatalf("error status should already be recorded after the first publish")
+	}
+	if !srv.finishedJobEvents.add(finishedTransferJobKey{id: job.ID, status: store.TransferJobStatusCompleted}) {
+		t.Fatalf("a later transition to another final status should still be emitted")
+	}
+}
+
+func TestRecentKeySetEvictsOldest(t *testing.T) {
+	t.Parallel()
+
+	set := recentKeySet[int]{limit: 2}
+	if !set.add(1) || !set.add(2) || set.add(1) {
+		t.Fatalf("keys should be recorded once")
+	}
+	if !set.add(3) {
+		t.Fatalf("new key should be recorded")
+	}
+	if !set.add(1) {
+		t.Fatalf("oldest key should have been evicted")
+	}
+}
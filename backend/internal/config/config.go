@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -46,7 +47,12 @@ type Config struct {
 	ThumbnailCacheTTL                time.Duration
 	ThumbnailGenerateConcurrency     int
 	ThumbnailCacheDir                string
+	HLSCacheDir                      string
+	HLSCacheMaxBytes                 int64
+	HLSTranscodeConcurrency          int
 	FFmpegBinary                     string
+	PDFToPPMBinary                   string
+	ZstdBinary                       string
 
 	BaseURL         string
 	FrontendOrigin  string
@@ -54,6 +60,18 @@ type Config struct {
 	ListenHost      string
 	ListenPort      int
 	PublicURLHeader string
+
+	// ShutdownDrainTimeout 为收到退出信号后等待进行中的请求与后台任务结束的期限。
+	ShutdownDrainTimeout time.Duration
+
+	// MetricsToken 为空时不开放 /metrics。
+	MetricsToken string
+
+	// TracingEndpoint 为 OTLP/HTTP traces 完整地址，为空时不启用链路追踪。
+	TracingEndpoint    string
+	TracingHeaders     map[string]string
+	TracingServiceName string
+	TracingSampleRatio float64
 }
 
 func Load() (Config, error) {
@@ -132,12 +150,41 @@ func Load() (Config, error) {
 	cfg.ThumbnailCacheTTL = time.Duration(intFromEnv("THUMBNAIL_CACHE_TTL_HOURS", 30*24)) * time.Hour
 	cfg.ThumbnailGenerateConcurrency = intFromEnv("THUMBNAIL_GENERATE_CONCURRENCY", 1)
 	cfg.ThumbnailCacheDir = strings.TrimSpace(os.Getenv("THUMBNAIL_CACHE_DIR"))
+	cfg.HLSCacheDir = strings.TrimSpace(os.Getenv("HLS_CACHE_DIR"))
+	cfg.HLSCacheMaxBytes = int64FromEnv("HLS_CACHE_MAX_BYTES", 4*1024*1024*1024)
+	cfg.HLSTranscodeConcurrency = intFromEnv("HLS_TRANSCODE_CONCURRENCY", 1)
 	cfg.FFmpegBinary = strings.TrimSpace(os.Getenv("FFMPEG_BINARY"))
 	if cfg.FFmpegBinary == "" {
 		cfg.FFmpegBinary = "ffmpeg"
 	}
+	cfg.PDFToPPMBinary = strings.TrimSpace(os.Getenv("PDFTOPPM_BINARY"))
+	if cfg.PDFToPPMBinary == "" {
+		cfg.PDFToPPMBinary = "pdftoppm"
+	}
+	cfg.ZstdBinary = strings.TrimSpace(os.Getenv("ZSTD_BINARY"))
+	if cfg.ZstdBinary == "" {
+		cfg.ZstdBinary = "zstd"
+	}
 	cfg.AllowDevNoAuth = boolFromEnv("ALLOW_DEV_NO_AUTH", false)
 	cfg.PublicURLHeader = strings.TrimSpace(os.Getenv("PUBLIC_URL_HEADER"))
+	cfg.MetricsToken = strings.TrimSpace(os.Getenv("METRICS_TOKEN"))
+	cfg.ShutdownDrainTimeout = time.Duration(intFromEnv("SHUTDOWN_DRAIN_TIMEOUT_SECONDS", 30)) * time.Second
+	if cfg.ShutdownDrainTimeout <= 0 {
+		cfg.ShutdownDrainTimeout = 30 * time.Second
+	}
+	cfg.TracingEndpoint = tracingEndpointFromEnv()
+	cfg.TracingHeaders = otlpHeadersFromEnv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")
+	if cfg.TracingHeaders == nil {
+		cfg.TracingHeaders = otlpHeadersFromEnv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	cfg.TracingServiceName = strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+	if cfg.TracingServiceName == "" {
+		cfg.TracingServiceName = "tg-cloud-drive-api"
+	}
+	cfg.TracingSampleRatio = float64FromEnv("OTEL_TRACES_SAMPLER_ARG", 1)
+	if cfg.TracingSampleRatio < 0 || cfg.TracingSampleRatio > 1 {
+		cfg.TracingSampleRatio = 1
+	}
 
 	if cfg.UploadConcurrencyDefault < 1 {
 		cfg.UploadConcurrencyDefault = 1
@@ -166,6 +213,12 @@ func Load() (Config, error) {
 	if cfg.ThumbnailGenerateConcurrency < 1 {
 		cfg.ThumbnailGenerateConcurrency = 1
 	}
+	if cfg.HLSCacheMaxBytes < 0 {
+		cfg.HLSCacheMaxBytes = 0
+	}
+	if cfg.HLSTranscodeConcurrency < 1 {
+		cfg.HLSTranscodeConcurrency = 1
+	}
 
 	secretB64 := strings.TrimSpace(os.Getenv("COOKIE_SECRET_B64"))
 	if secretB64 != "" {
@@ -217,6 +270,18 @@ func int64FromEnv(key string, def int64) int64 {
 	return v
 }
 
+func float64FromEnv(key string, def float64) float64 {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
 func boolFromEnv(key string, def bool) bool {
 	raw := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	if raw == "" {
@@ -248,3 +313,44 @@ func csvFromEnv(key string) []string {
 	}
 	return out
 }
+
+// tracingEndpointFromEnv 遵循 OpenTelemetry 环境变量约定：TRACES_ENDPOINT 为完整地址，
+// 通用 ENDPOINT 为基础地址并追加 /v1/traces；OTEL_SDK_DISABLED 或 OTEL_TRACES_EXPORTER=none 时关闭。
+func tracingEndpointFromEnv() string {
+	if boolFromEnv("OTEL_SDK_DISABLED", false) {
+		return ""
+	}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("OTEL_TRACES_EXPORTER")), "none") {
+		return ""
+	}
+	if endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); endpoint != "" {
+		return endpoint
+	}
+	base := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/v1/traces"
+}
+
+// otlpHeadersFromEnv 解析 key1=value1,key2=value2 形式的请求头，值允许 URL 编码。
+func otlpHeadersFromEnv(key string) map[string]string {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return nil
+	}
+	out := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+		out[name] = value
+	}
+	return out
+}
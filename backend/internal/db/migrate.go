@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,12 +22,47 @@ type migration struct {
 	SQL  string
 }
 
+// migrateAdvisoryLockKey 为迁移专用的 PostgreSQL 会话级 advisory lock 键（"tgcd" + 2），
+// 与单例后台任务的主锁（"tgcd" + 1）区分。
+const migrateAdvisoryLockKey int64 = 0x7467636400000002
+
+// migrationConn 为 *pgxpool.Pool 与 *pgxpool.Conn 的公共子集。
+type migrationConn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Migrate 在专用连接上持有 advisory lock 期间应用迁移，多个副本同时启动时依次执行；
+// 已应用集合在取得锁之后读取，后启动的副本不会重复执行前一个副本刚应用的迁移。
 func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
 	if pool == nil {
 		return errors.New("pool 为空")
 	}
 
-	if _, err := pool.Exec(ctx, `
+	migs, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("获取迁移连接失败: %w", err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrateAdvisoryLockKey); err != nil {
+		return fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+	defer func() {
+		// ctx 可能已取消，解锁使用独立的超时；解锁失败时关闭连接，由会话结束释放锁。
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := conn.Exec(unlockCtx, `SELECT pg_advisory_unlock($1)`, migrateAdvisoryLockKey); err != nil {
+			_ = conn.Conn().Close(unlockCtx)
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `
 CREATE TABLE IF NOT EXISTS schema_migrations (
   name TEXT PRIMARY KEY,
   applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
@@ -32,9 +70,48 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 		return fmt.Errorf("创建 schema_migrations 失败: %w", err)
 	}
 
+	applied, err := appliedMigrationNames(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migs {
+		if applied[m.Name] {
+			continue
+		}
+		if err := applyOne(ctx, conn, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PendingMigrations 返回已内置但尚未应用到数据库的迁移文件名，按执行顺序排列。
+func PendingMigrations(ctx context.Context, pool *pgxpool.Pool) ([]string, error) {
+	if pool == nil {
+		return nil, errors.New("pool 为空")
+	}
+	migs, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrationNames(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, m := range migs {
+		if !applied[m.Name] {
+			pending = append(pending, m.Name)
+		}
+	}
+	return pending, nil
+}
+
+func loadMigrations() ([]migration, error) {
 	entries, err := migrationsFS.ReadDir("migrations")
 	if err != nil {
-		return fmt.Errorf("读取 migrations 目录失败: %w", err)
+		return nil, fmt.Errorf("读取 migrations 目录失败: %w", err)
 	}
 
 	var migs []migration
@@ -47,40 +124,33 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 		}
 		b, err := migrationsFS.ReadFile("migrations/" + e.Name())
 		if err != nil {
-			return fmt.Errorf("读取迁移文件失败 %s: %w", e.Name(), err)
+			return nil, fmt.Errorf("读取迁移文件失败 %s: %w", e.Name(), err)
 		}
 		migs = append(migs, migration{Name: e.Name(), SQL: string(b)})
 	}
 	sort.Slice(migs, func(i, j int) bool { return migs[i].Name < migs[j].Name })
+	return migs, nil
+}
 
+func appliedMigrationNames(ctx context.Context, conn migrationConn) (map[string]bool, error) {
 	applied := map[string]bool{}
-	rows, err := pool.Query(ctx, `SELECT name FROM schema_migrations`)
+	rows, err := conn.Query(ctx, `SELECT name FROM schema_migrations`)
 	if err != nil {
-		return fmt.Errorf("查询已应用迁移失败: %w", err)
+		return nil, fmt.Errorf("查询已应用迁移失败: %w", err)
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var name string
 		if err := rows.Scan(&name); err != nil {
-			rows.Close()
-			return fmt.Errorf("读取已应用迁移失败: %w", err)
+			return nil, fmt.Errorf("读取已应用迁移失败: %w", err)
 		}
 		applied[name] = true
 	}
-	rows.Close()
-
-	for _, m := range migs {
-		if applied[m.Name] {
-			continue
-		}
-		if err := applyOne(ctx, pool, m); err != nil {
-			return err
-		}
-	}
-	return nil
+	return applied, rows.Err()
 }
 
-func applyOne(ctx context.Context, pool *pgxpool.Pool, m migration) error {
-	tx, err := pool.Begin(ctx)
+func applyOne(ctx context.Context, conn migrationConn, m migration) error {
+	tx, err := conn.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("开启迁移事务失败: %w", err)
 	}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// 多个副本同时启动时迁移依次执行，全部成功且每个迁移只记录一次。
+func TestMigrateConcurrentReplicas(t *testing.T) {
+	dsn := strings.TrimSpace(os.Getenv("TGCD_TEST_DATABASE_URL"))
+	if dsn == "" {
+		t.Skip("TGCD_TEST_DATABASE_URL 未设置")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	const replicas = 3
+	pools := make([]*pgxpool.Pool, replicas)
+	for i := range pools {
+		pool, err := pgxpool.New(ctx, dsn)
+		if err != nil {
+			t.Fatalf("connect test database: %v", err)
+		}
+		defer pool.Close()
+		pools[i] = pool
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, replicas)
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, pool *pgxpool.Pool) {
+			defer wg.Done()
+			errs[i] = Migrate(ctx, pool)
+		}(i, pool)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("replica %d Migrate: %v", i, err)
+		}
+	}
+
+	pending, err := PendingMigrations(ctx, pools[0])
+	if err != nil {
+		t.Fatalf("PendingMigrations: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("pending migrations = %v", pending)
+	}
+	migs, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	var recorded int
+	if err := pools[0].QueryRow(ctx, `SELECT count(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		t.Fatalf("count schema_migrations: %v", err)
+	}
+	if recorded < len(migs) {
+		t.Fatalf("schema_migrations rows = %d, want at least %d", recorded, len(migs))
+	}
+}
+
+func TestMigrateNilPool(t *testing.T) {
+	t.Parallel()
+
+	if err := Migrate(context.Background(), nil); err == nil {
+		t.Fatalf("Migrate(nil) should fail")
+	}
+}
@@ -0,0 +1,6 @@
+ALTER TABLE torrent_tasks
+ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS paused_from_status TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_torrent_tasks_status_priority
+ON torrent_tasks(status, priority DESC, updated_at ASC);
@@ -0,0 +1,5 @@
+ALTER TABLE torrent_tasks
+ADD COLUMN IF NOT EXISTS preserve_directory_structure BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE system_config
+ADD COLUMN IF NOT EXISTS torrent_preserve_directory_structure BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+ALTER TABLE torrent_tasks
+ADD COLUMN IF NOT EXISTS seed_ratio_target DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS seed_min_minutes INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS seed_ratio DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS seeding_seconds BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS seed_checked_at TIMESTAMPTZ NULL;
+
+ALTER TABLE system_config
+ADD COLUMN IF NOT EXISTS torrent_seed_ratio_target DOUBLE PRECISION NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS torrent_seed_min_minutes INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE system_config
+ADD COLUMN IF NOT EXISTS hls_transcode_concurrency INT NOT NULL DEFAULT 1,
+ADD COLUMN IF NOT EXISTS hls_cache_max_bytes BIGINT NOT NULL DEFAULT 4294967296;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS item_metadata (
+  item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'ok',
+  error TEXT NULL,
+  duration_seconds DOUBLE PRECISION NULL,
+  width INT NULL,
+  height INT NULL,
+  rotation INT NULL,
+  video_codec TEXT NULL,
+  audio_codec TEXT NULL,
+  bit_rate BIGINT NULL,
+  audio_title TEXT NULL,
+  audio_artist TEXT NULL,
+  audio_album TEXT NULL,
+  camera_make TEXT NULL,
+  camera_model TEXT NULL,
+  taken_at TIMESTAMPTZ NULL,
+  gps_latitude DOUBLE PRECISION NULL,
+  gps_longitude DOUBLE PRECISION NULL,
+  extracted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_metadata_duration ON item_metadata(duration_seconds);
+CREATE INDEX IF NOT EXISTS idx_item_metadata_taken_at ON item_metadata(taken_at);
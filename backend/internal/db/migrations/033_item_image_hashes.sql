@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS item_image_hashes (
+  item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'ok',
+  error TEXT NULL,
+  dhash BIGINT NULL,
+  phash BIGINT NULL,
+  computed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_image_hashes_status ON item_image_hashes(status);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS item_content_hashes (
+  item_id UUID PRIMARY KEY REFERENCES items(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'ok',
+  error TEXT NULL,
+  sha256 BYTEA NULL,
+  size BIGINT NOT NULL,
+  computed_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_content_hashes_sha256 ON item_content_hashes(sha256) WHERE sha256 IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_items_file_size ON items(size) WHERE type <> 'folder';
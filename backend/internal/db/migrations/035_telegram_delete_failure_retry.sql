@@ -0,0 +1,7 @@
+ALTER TABLE telegram_delete_failures
+  ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMPTZ NULL,
+  ADD COLUMN IF NOT EXISTS resolution TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tg_delete_failures_due
+ON telegram_delete_failures(next_retry_at)
+WHERE resolved = FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE torrent_tasks
+  ADD COLUMN IF NOT EXISTS trace_parent TEXT NULL;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL,
+  result TEXT NOT NULL,
+  ip TEXT NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  item_id UUID NULL,
+  item_path TEXT NULL,
+  target_id TEXT NULL,
+  detail JSONB NOT NULL DEFAULT '{}'::jsonb
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at ON audit_log(occurred_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_item_id ON audit_log(item_id) WHERE item_id IS NOT NULL;
+
+-- 审计记录只允许追加；DELETE 保留给按保留期清理的后台任务。
+CREATE OR REPLACE FUNCTION audit_log_reject_update() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_log_reject_update ON audit_log;
+CREATE TRIGGER trg_audit_log_reject_update
+BEFORE UPDATE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_reject_update();
+
+ALTER TABLE system_config
+ADD COLUMN IF NOT EXISTS audit_log_retention_days INT NOT NULL DEFAULT 180;
@@ -0,0 +1,5 @@
+ALTER TABLE system_config
+ADD COLUMN IF NOT EXISTS notify_chat_ids TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS notify_torrent_awaiting_selection BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS notify_transfer_failed BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS notify_low_disk BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,12 @@
+-- 分片上传互斥锁：多副本部署时同一分片只允许一个请求处理，租约过期后可被其他请求接管。
+CREATE TABLE IF NOT EXISTS upload_chunk_locks (
+  session_id UUID NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+  chunk_index INT NOT NULL,
+  holder UUID NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  acquired_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (session_id, chunk_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_upload_chunk_locks_expires_at
+ON upload_chunk_locks(expires_at);
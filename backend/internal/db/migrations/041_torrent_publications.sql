@@ -0,0 +1,19 @@
+-- 发布的 torrent：webseed 令牌绑定发布记录，可单独撤销；生成完成后保存 .torrent 内容。
+CREATE TABLE IF NOT EXISTS torrent_publications (
+  id UUID PRIMARY KEY,
+  item_id UUID NOT NULL REFERENCES items(id) ON DELETE CASCADE,
+  transfer_job_id UUID NULL,
+  announce TEXT[] NOT NULL DEFAULT '{}',
+  info_hash TEXT NULL,
+  torrent BYTEA NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_torrent_publications_item
+ON torrent_publications(item_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_torrent_publications_expires_at
+ON torrent_publications(expires_at);
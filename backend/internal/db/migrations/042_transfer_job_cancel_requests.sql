@@ -0,0 +1,5 @@
+-- 进程内任务（压缩/解压/torrent 生成）只能由执行它的副本取消：其他副本收到取消或删除请求时记录在任务行上，
+-- 执行副本在心跳时读取并取消；delete_on_cancel 表示任务结束后由执行副本清理产物并删除任务记录。
+ALTER TABLE transfer_jobs
+  ADD COLUMN IF NOT EXISTS cancel_requested_at TIMESTAMPTZ NULL,
+  ADD COLUMN IF NOT EXISTS delete_on_cancel BOOLEAN NOT NULL DEFAULT FALSE;
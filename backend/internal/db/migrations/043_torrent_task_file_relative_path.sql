@@ -0,0 +1,4 @@
+-- 记录文件在 torrent 内的相对路径（来自 qBittorrent 的文件名），保留目录结构时据此创建目录，
+-- 不再依赖 qBittorrent 的保存目录与 TORRENT_DOWNLOAD_DIR 一致。升级前写入的记录为空字符串。
+ALTER TABLE torrent_task_files
+  ADD COLUMN IF NOT EXISTS relative_path TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+const maxTracedStatementBytes = 1024
+
+// QueryTracer 为 Query/QueryRow/Exec 创建 span；只记录 SQL 文本，不记录参数，避免把用户数据写入链路。
+type QueryTracer struct{}
+
+type querySpanKey struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	statement := strings.Join(strings.Fields(data.SQL), " ")
+	ctx, span := tracing.StartChild(
+		ctx,
+		tracing.KindClient,
+		"db "+statementOperation(statement),
+		tracing.String("db.system", "postgresql"),
+		tracing.String("db.statement", truncateStatement(statement)),
+	)
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, _ := ctx.Value(querySpanKey{}).(*tracing.Span)
+	if span == nil {
+		return
+	}
+	if data.Err != nil && !errors.Is(data.Err, pgx.ErrNoRows) {
+		span.RecordError(data.Err)
+	} else {
+		span.SetAttributes(tracing.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	span.End()
+}
+
+func statementOperation(statement string) string {
+	op, _, _ := strings.Cut(statement, " ")
+	op = strings.ToUpper(strings.TrimLeft(op, "("))
+	if op == "" {
+		return "query"
+	}
+	return op
+}
+
+func truncateStatement(statement string) string {
+	if len(statement) <= maxTracedStatementBytes {
+		return statement
+	}
+	cut := maxTracedStatementBytes
+	for cut > 0 && statement[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return statement[:cut] + "…"
+}
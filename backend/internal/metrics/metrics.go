@@ -0,0 +1,348 @@
+// Package metrics 实现 Prometheus 文本格式（0.0.4）所需的最小指标集合：计数器、仪表盘与直方图，
+// 以及在抓取时才计算取值的采集函数。
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sample 是采集函数返回的一条带标签取值，LabelValues 与注册时的标签名一一对应。
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type collector interface {
+	write(ctx context.Context, w io.Writer) error
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	names      map[string]struct{}
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]struct{}{}}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.names[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.names[name] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// WriteText 按注册顺序输出全部指标；单个采集函数出错时跳过该指标并在最后返回第一个错误。
+func (r *Registry) WriteText(ctx context.Context, w io.Writer) error {
+	r.mu.Lock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	var firstErr error
+	for _, c := range collectors {
+		if err := c.write(ctx, w); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.kind)
+	return err
+}
+
+// series 保存一个指标下按标签值区分的取值，key 为标签值以 \xff 拼接。
+type series[T any] struct {
+	mu     sync.Mutex
+	values map[string]*T
+	labels map[string][]string
+}
+
+func newSeries[T any]() series[T] {
+	return series[T]{values: map[string]*T{}, labels: map[string][]string{}}
+}
+
+func (s *series[T]) get(d desc, labelValues []string, init func() *T) *T {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		v = init()
+		s.values[key] = v
+		s.labels[key] = append([]string(nil), labelValues...)
+	}
+	return v
+}
+
+func (s *series[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// CounterVec 是只增不减的计数器。
+type CounterVec struct {
+	desc   desc
+	series series[value]
+}
+
+func (r *Registry) NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{desc: desc{name: name, help: help, kind: "counter", labels: labels}, series: newSeries[value]()}
+	r.register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 忽略负数增量，保证计数器单调。
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if c == nil || delta < 0 {
+		return
+	}
+	v := c.series.get(c.desc, labelValues, func() *value { return &value{} })
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (c *CounterVec) write(_ context.Context, w io.Writer) error {
+	return writeValueSeries(w, c.desc, &c.series)
+}
+
+// GaugeVec 是可增可减的仪表盘。
+type GaugeVec struct {
+	desc   desc
+	series series[value]
+}
+
+func (r *Registry) NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, series: newSeries[value]()}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	if g == nil {
+		return
+	}
+	entry := g.series.get(g.desc, labelValues, func() *value { return &value{} })
+	entry.mu.Lock()
+	entry.v = v
+	entry.mu.Unlock()
+}
+
+func (g *GaugeVec) write(_ context.Context, w io.Writer) error {
+	return writeValueSeries(w, g.desc, &g.series)
+}
+
+func writeValueSeries(w io.Writer, d desc, s *series[value]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := d.writeHeader(w); err != nil {
+		return err
+	}
+	for _, key := range s.sortedKeys() {
+		entry := s.values[key]
+		entry.mu.Lock()
+		v := entry.v
+		entry.mu.Unlock()
+		if err := writeSample(w, d.name, d.labels, s.labels[key], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec 按固定桶累计观测值。
+type HistogramVec struct {
+	desc    desc
+	buckets []float64
+	series  series[histogram]
+}
+
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: sorted,
+		series:  newSeries[histogram](),
+	}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	if h == nil || math.IsNaN(v) {
+		return
+	}
+	entry := h.series.get(h.desc, labelValues, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	for idx, bound := range h.buckets {
+		if v <= bound {
+			entry.counts[idx]++
+		}
+	}
+	entry.sum += v
+	entry.count++
+}
+
+func (h *HistogramVec) write(_ context.Context, w io.Writer) error {
+	h.series.mu.Lock()
+	defer h.series.mu.Unlock()
+	if err := h.desc.writeHeader(w); err != nil {
+		return err
+	}
+	bucketLabels := append(append([]string(nil), h.desc.labels...), "le")
+	for _, key := range h.series.sortedKeys() {
+		entry := h.series.values[key]
+		labelValues := h.series.labels[key]
+		entry.mu.Lock()
+		counts := append([]uint64(nil), entry.counts...)
+		sum, count := entry.sum, entry.count
+		entry.mu.Unlock()
+
+		for idx, bound := range h.buckets {
+			values := append(append([]string(nil), labelValues...), formatFloat(bound))
+			if err := writeSample(w, h.desc.name+"_bucket", bucketLabels, values, float64(counts[idx])); err != nil {
+				return err
+			}
+		}
+		values := append(append([]string(nil), labelValues...), "+Inf")
+		if err := writeSample(w, h.desc.name+"_bucket", bucketLabels, values, float64(count)); err != nil {
+			return err
+		}
+		if err := writeSample(w, h.desc.name+"_sum", h.desc.labels, labelValues, sum); err != nil {
+			return err
+		}
+		if err := writeSample(w, h.desc.name+"_count", h.desc.labels, labelValues, float64(count)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type funcCollector struct {
+	desc    desc
+	collect func(ctx context.Context) ([]Sample, error)
+}
+
+// NewGaugeFunc 注册在每次抓取时调用 collect 取值的仪表盘，适合活跃数、数据库统计等现算的数据。
+func (r *Registry) NewGaugeFunc(
+	name string,
+	help string,
+	labels []string,
+	collect func(ctx context.Context) ([]Sample, error),
+) {
+	r.register(name, &funcCollector{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect})
+}
+
+func (f *funcCollector) write(ctx context.Context, w io.Writer) error {
+	samples, err := f.collect(ctx)
+	if err != nil {
+		return fmt.Errorf("collect %s: %w", f.desc.name, err)
+	}
+	if err := f.desc.writeHeader(w); err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		if len(sample.LabelValues) != len(f.desc.labels) {
+			return fmt.Errorf("collect %s: expects %d label values, got %d", f.desc.name, len(f.desc.labels), len(sample.LabelValues))
+		}
+		if err := writeSample(w, f.desc.name, f.desc.labels, sample.LabelValues, sample.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSample(w io.Writer, name string, labels []string, labelValues []string, v float64) error {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for idx, label := range labels {
+			if idx > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(label)
+			b.WriteString(`="`)
+			b.WriteString(escapeLabelValue(labelValues[idx]))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(v))
+	b.WriteByte('\n')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
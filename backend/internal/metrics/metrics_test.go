@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	calls := reg.NewCounterVec("test_calls_total", "Calls by method.", "method", "result")
+	calls.Inc("getMe", "ok")
+	calls.Add(2, "sendDocument", "error")
+	calls.Add(-5, "sendDocument", "error")
+	active := reg.NewGaugeVec("test_active", "Active jobs.")
+	active.Set(3)
+	durations := reg.NewHistogramVec("test_duration_seconds", "Durations.", []float64{10, 1}, "kind")
+	durations.Observe(0.5, "upload")
+	durations.Observe(5, "upload")
+	reg.NewGaugeFunc("test_tasks", "Tasks by status.", []string{"status"}, func(context.Context) ([]Sample, error) {
+		return []Sample{{LabelValues: []string{`a"b\c`}, Value: 7}}, nil
+	})
+
+	var out strings.Builder
+	if err := reg.WriteText(context.Background(), &out); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	want := `# HELP test_calls_total Calls by method.
+# TYPE test_calls_total counter
+test_calls_total{method="getMe",result="ok"} 1
+test_calls_total{method="sendDocument",result="error"} 2
+# HELP test_active Active jobs.
+# TYPE test_active gauge
+test_active 3
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{kind="upload",le="1"} 1
+test_duration_seconds_bucket{kind="upload",le="10"} 2
+test_duration_seconds_bucket{kind="upload",le="+Inf"} 2
+test_duration_seconds_sum{kind="upload"} 5.5
+test_duration_seconds_count{kind="upload"} 2
+# HELP test_tasks Tasks by status.
+# TYPE test_tasks gauge
+test_tasks{status="a\"b\\c"} 7
+`
+	if out.String() != want {
+		t.Fatalf("WriteText() output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRegistryWriteTextSkipsFailedCollector(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	reg.NewGaugeFunc("test_broken", "Broken.", nil, func(context.Context) ([]Sample, error) {
+		return nil, errors.New("db down")
+	})
+	reg.NewGaugeVec("test_ok", "OK.").Set(1)
+
+	var out strings.Builder
+	err := reg.WriteText(context.Background(), &out)
+	if err == nil || !strings.Contains(err.Error(), "test_broken") {
+		t.Fatalf("WriteText() error = %v, want collector error", err)
+	}
+	if strings.Contains(out.String(), "test_broken") || !strings.Contains(out.String(), "test_ok 1\n") {
+		t.Fatalf("WriteText() output = %q", out.String())
+	}
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type AuditLogEntry struct {
+	ID         int64
+	OccurredAt time.Time
+	Actor      string
+	Action     string
+	Result     string
+	IP         string
+	UserAgent  string
+	ItemID     *uuid.UUID
+	ItemPath   *string
+	TargetID   *string
+	Detail     map[string]any
+}
+
+type AuditLogListParams struct {
+	Actions    []string
+	Actor      string
+	Result     string
+	ItemID     *uuid.UUID
+	PathPrefix string
+	Query      string
+	Since      *time.Time
+	Until      *time.Time
+	Page       int
+	PageSize   int
+}
+
+const auditLogColumns = `
+  id,
+  occurred_at,
+  actor,
+  action,
+  result,
+  ip,
+  user_agent,
+  item_id,
+  item_path,
+  target_id,
+  detail`
+
+func scanAuditLogEntry(row pgx.Row) (AuditLogEntry, error) {
+	var (
+		out    AuditLogEntry
+		detail []byte
+	)
+	if err := row.Scan(
+		&out.ID,
+		&out.OccurredAt,
+		&out.Actor,
+		&out.Action,
+		&out.Result,
+		&out.IP,
+		&out.UserAgent,
+		&out.ItemID,
+		&out.ItemPath,
+		&out.TargetID,
+		&detail,
+	); err != nil {
+		return AuditLogEntry{}, err
+	}
+	if len(detail) > 0 {
+		if err := json.Unmarshal(detail, &out.Detail); err != nil {
+			return AuditLogEntry{}, err
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) InsertAuditLog(ctx context.Context, entry AuditLogEntry) error {
+	detail := []byte("{}")
+	if len(entry.Detail) > 0 {
+		encoded, err := json.Marshal(entry.Detail)
+		if err != nil {
+			return err
+		}
+		detail = encoded
+	}
+	if entry.OccurredAt.IsZero() {
+		entry.OccurredAt = time.Now()
+	}
+	_, err := s.db.Exec(ctx, `
+INSERT INTO audit_log (occurred_at, actor, action, result, ip, user_agent, item_id, item_path, target_id, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		entry.OccurredAt,
+		entry.Actor,
+		entry.Action,
+		entry.Result,
+		entry.IP,
+		entry.UserAgent,
+		entry.ItemID,
+		entry.ItemPath,
+		entry.TargetID,
+		detail,
+	)
+	return err
+}
+
+func (s *Store) ListAuditLog(ctx context.Context, params AuditLogListParams) ([]AuditLogEntry, int64, error) {
+	page := normalizeTransferQueryPage(params.Page)
+	pageSize := normalizeTransferQueryPageSize(params.PageSize)
+
+	where := []string{"1=1"}
+	args := make([]any, 0, 10)
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if len(params.Actions) > 0 {
+		exact := make([]string, 0, len(params.Actions))
+		prefixes := make([]string, 0)
+		for _, action := range params.Actions {
+			action = strings.TrimSpace(action)
+			if action == "" {
+				continue
+			}
+			// "item.*" 形式按前缀匹配一类操作
+			if prefix, ok := strings.CutSuffix(action, "*"); ok {
+				prefixes = append(prefixes, prefix+"%")
+				continue
+			}
+			exact = append(exact, action)
+		}
+		if len(exact) > 0 || len(prefixes) > 0 {
+			where = append(where, "(action = ANY("+addArg(exact)+") OR action LIKE ANY("+addArg(prefixes)+"))")
+		}
+	}
+	if actor := strings.TrimSpace(params.Actor); actor != "" {
+		where = append(where, "actor = "+addArg(actor))
+	}
+	if result := strings.TrimSpace(params.Result); result != "" {
+		where = append(where, "result = "+addArg(result))
+	}
+	if params.ItemID != nil {
+		where = append(where, "item_id = "+addArg(*params.ItemID))
+	}
+	if prefix := strings.TrimSpace(params.PathPrefix); prefix != "" {
+		prefix = strings.TrimRight(prefix, "/")
+		exactArg := addArg(prefix)
+		where = append(where, "(item_path = "+exactArg+" OR item_path LIKE "+addArg(prefix+"/%")+")")
+	}
+	if query := strings.ToLower(strings.TrimSpace(params.Query)); query != "" {
+		placeholder := addArg("%" + query + "%")
+		where = append(where, "(LOWER(COALESCE(item_path, '')) LIKE "+placeholder+" OR LOWER(ip) LIKE "+placeholder+" OR LOWER(COALESCE(target_id, '')) LIKE "+placeholder+")")
+	}
+	if params.Since != nil {
+		where = append(where, "occurred_at >= "+addArg(*params.Since))
+	}
+	if params.Until != nil {
+		where = append(where, "occurred_at < "+addArg(*params.Until))
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int64
+	if err := s.db.QueryRow(ctx, `SELECT count(*) FROM audit_log WHERE `+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limitArg := addArg(pageSize)
+	offsetArg := addArg((page - 1) * pageSize)
+	rows, err := s.db.Query(ctx, `SELECT`+auditLogColumns+`
+FROM audit_log
+WHERE `+whereSQL+`
+ORDER BY occurred_at DESC, id DESC
+LIMIT `+limitArg+` OFFSET `+offsetArg, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]AuditLogEntry, 0)
+	for rows.Next() {
+		entry, err := scanAuditLogEntry(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, entry)
+	}
+	return out, total, rows.Err()
+}
+
+// PurgeAuditLogBefore 删除早于 cutoff 的审计记录，返回删除条数。
+func (s *Store) PurgeAuditLogBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM audit_log WHERE occurred_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,190 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ItemContentHashStatus string
+
+const (
+	ItemContentHashStatusOK     ItemContentHashStatus = "ok"
+	ItemContentHashStatusFailed ItemContentHashStatus = "failed"
+)
+
+// ItemContentHash 记录文件完整内容的 SHA-256；Size 为计算时的文件大小，与当前大小不一致时视为过期。
+type ItemContentHash struct {
+	ItemID     uuid.UUID
+	Status     ItemContentHashStatus
+	Error      *string
+	SHA256     []byte
+	Size       int64
+	ComputedAt time.Time
+}
+
+// DuplicateCandidate 是可能重复的文件：ContentHash 为十六进制 SHA-256（未计算时为 nil），
+// FileSignature 为“大小:按分块顺序拼接的 tg_file_unique_id”（有分块缺少标识时为 nil）。
+type DuplicateCandidate struct {
+	Item          Item
+	ContentHash   *string
+	FileSignature *string
+}
+
+func (s *Store) UpsertItemContentHash(ctx context.Context, hash ItemContentHash) error {
+	if hash.ItemID == uuid.Nil {
+		return ErrBadInput
+	}
+	if hash.Status == "" {
+		hash.Status = ItemContentHashStatusOK
+	}
+	tag, err := s.db.Exec(ctx, `
+INSERT INTO item_content_hashes(item_id, status, error, sha256, size, computed_at)
+SELECT $1, $2, $3, $4, $5, $6
+WHERE EXISTS (SELECT 1 FROM items WHERE id = $1)
+ON CONFLICT (item_id) DO UPDATE
+SET status = EXCLUDED.status,
+    error = EXCLUDED.error,
+    sha256 = EXCLUDED.sha256,
+    size = EXCLUDED.size,
+    computed_at = EXCLUDED.computed_at`,
+		hash.ItemID,
+		hash.Status,
+		hash.Error,
+		hash.SHA256,
+		hash.Size,
+		hash.ComputedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// 缺少有效哈希：从未计算、大小已变化，或（includeFailed 时）上次失败。
+// sizeCandidatesOnly 时只返回与其他文件大小相同的文件，大小唯一的文件不可能重复。
+const itemsMissingContentHashWhere = `
+WHERE i.type <> 'folder'
+  AND i.size > 0
+  AND (h.item_id IS NULL OR h.size <> i.size OR ($1 AND h.status = 'failed'))
+  AND (NOT $2 OR EXISTS (
+    SELECT 1 FROM items o WHERE o.size = i.size AND o.type <> 'folder' AND o.id <> i.id
+  ))`
+
+// ListItemsMissingContentHash 按创建时间返回尚未计算内容哈希的文件。
+func (s *Store) ListItemsMissingContentHash(
+	ctx context.Context,
+	includeFailed bool,
+	sizeCandidatesOnly bool,
+	after time.Time,
+	afterID uuid.UUID,
+	limit int,
+) ([]Item, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at
+FROM items i
+LEFT JOIN item_content_hashes h ON h.item_id = i.id`+itemsMissingContentHashWhere+`
+  AND (i.created_at, i.id) > ($3, $4)
+ORDER BY i.created_at ASC, i.id ASC
+LIMIT $5`,
+		includeFailed,
+		sizeCandidatesOnly,
+		after,
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanItems(rows)
+}
+
+func (s *Store) CountItemsMissingContentHash(ctx context.Context, includeFailed bool, sizeCandidatesOnly bool) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(ctx, `
+SELECT count(*)
+FROM items i
+LEFT JOIN item_content_hashes h ON h.item_id = i.id`+itemsMissingContentHashWhere,
+		includeFailed,
+		sizeCandidatesOnly,
+	).Scan(&total)
+	return total, err
+}
+
+// ListDuplicateCandidates 返回 prefix 子树（"/" 表示全部）内内容哈希或 Telegram 文件签名与其他文件相同的文件，
+// 分组由调用方完成。只有按分块顺序完全相同的 tg_file_unique_id 才认为是同一份内容。
+func (s *Store) ListDuplicateCandidates(ctx context.Context, prefix string, includeVault bool, minSize int64) ([]DuplicateCandidate, error) {
+	filter, err := newPathPrefixFilter(prefix)
+	if err != nil {
+		return nil, ErrBadInput
+	}
+	if minSize < 1 {
+		minSize = 1
+	}
+	rows, err := s.db.Query(ctx, `
+WITH scoped AS (
+  SELECT i.id, i.size, encode(h.sha256, 'hex') AS content_hash
+  FROM items i
+  LEFT JOIN item_content_hashes h ON h.item_id = i.id AND h.status = 'ok' AND h.size = i.size
+  WHERE i.type <> 'folder'
+    AND i.size >= $4
+    AND (i.path = ANY($1) OR i.path LIKE ANY($2))
+    AND ($3 OR NOT i.in_vault)
+),
+sigs AS (
+  SELECT c.item_id, sc.size::text || ':' || string_agg(c.tg_file_unique_id, ',' ORDER BY c.chunk_index) AS file_sig
+  FROM telegram_chunks c
+  JOIN scoped sc ON sc.id = c.item_id
+  GROUP BY c.item_id, sc.size
+  HAVING bool_and(c.tg_file_unique_id <> '')
+),
+keyed AS (
+  SELECT sc.id, sc.content_hash, g.file_sig
+  FROM scoped sc
+  LEFT JOIN sigs g ON g.item_id = sc.id
+)
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at, k.content_hash, k.file_sig
+FROM keyed k
+JOIN items i ON i.id = k.id
+WHERE k.content_hash IN (
+    SELECT content_hash FROM keyed WHERE content_hash IS NOT NULL GROUP BY content_hash HAVING count(*) > 1
+  )
+  OR k.file_sig IN (
+    SELECT file_sig FROM keyed WHERE file_sig IS NOT NULL GROUP BY file_sig HAVING count(*) > 1
+  )
+ORDER BY i.created_at ASC, i.id ASC`,
+		filter.exact,
+		filter.like,
+		includeVault,
+		minSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DuplicateCandidate
+	for rows.Next() {
+		var c DuplicateCandidate
+		it := &c.Item
+		if err := rows.Scan(
+			&it.ID, &it.Type, &it.Name, &it.ParentID, &it.Path, &it.Size, &it.MimeType, &it.InVault,
+			&it.Starred, &it.LastAccessedAt, &it.SharedCode, &it.SharedEnabled, &it.CreatedAt, &it.UpdatedAt,
+			&c.ContentHash, &c.FileSignature,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ItemImageHashStatus string
+
+const (
+	ItemImageHashStatusOK     ItemImageHashStatus = "ok"
+	ItemImageHashStatusFailed ItemImageHashStatus = "failed"
+)
+
+// ItemImageHash 保存图片的感知指纹（64 位 dHash / pHash），按位原样存入 BIGINT。
+type ItemImageHash struct {
+	ItemID     uuid.UUID
+	Status     ItemImageHashStatus
+	Error      *string
+	DHash      uint64
+	PHash      uint64
+	ComputedAt time.Time
+}
+
+// ImageHashEntry 是参与相似度比对的图片及其指纹。
+type ImageHashEntry struct {
+	Item  Item
+	DHash uint64
+	PHash uint64
+}
+
+func (s *Store) UpsertItemImageHash(ctx context.Context, hash ItemImageHash) error {
+	if hash.ItemID == uuid.Nil {
+		return ErrBadInput
+	}
+	if hash.Status == "" {
+		hash.Status = ItemImageHashStatusOK
+	}
+	var dhash, phash *int64
+	if hash.Status == ItemImageHashStatusOK {
+		d, p := int64(hash.DHash), int64(hash.PHash)
+		dhash, phash = &d, &p
+	}
+	tag, err := s.db.Exec(ctx, `
+INSERT INTO item_image_hashes(item_id, status, error, dhash, phash, computed_at)
+SELECT $1, $2, $3, $4, $5, $6
+WHERE EXISTS (SELECT 1 FROM items WHERE id = $1)
+ON CONFLICT (item_id) DO UPDATE
+SET status = EXCLUDED.status,
+    error = EXCLUDED.error,
+    dhash = EXCLUDED.dhash,
+    phash = EXCLUDED.phash,
+    computed_at = EXCLUDED.computed_at`,
+		hash.ItemID,
+		hash.Status,
+		hash.Error,
+		dhash,
+		phash,
+		hash.ComputedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListImageHashes 返回 prefix 子树（"/" 表示全部）下已有指纹的图片，按创建时间排序；最多 limit 条。
+func (s *Store) ListImageHashes(ctx context.Context, prefix string, includeVault bool, limit int) ([]ImageHashEntry, error) {
+	filter, err := newPathPrefixFilter(prefix)
+	if err != nil {
+		return nil, ErrBadInput
+	}
+	if limit <= 0 {
+		limit = 1000
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at, h.dhash, h.phash
+FROM items i
+JOIN item_image_hashes h ON h.item_id = i.id
+WHERE i.type = $1
+  AND h.status = 'ok'
+  AND (i.path = ANY($2) OR i.path LIKE ANY($3))
+  AND ($4 OR NOT i.in_vault)
+ORDER BY i.created_at ASC, i.id ASC
+LIMIT $5`,
+		string(ItemTypeImage),
+		filter.exact,
+		filter.like,
+		includeVault,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ImageHashEntry
+	for rows.Next() {
+		var entry ImageHashEntry
+		var dhash, phash int64
+		it := &entry.Item
+		if err := rows.Scan(
+			&it.ID, &it.Type, &it.Name, &it.ParentID, &it.Path, &it.Size, &it.MimeType, &it.InVault,
+			&it.Starred, &it.LastAccessedAt, &it.SharedCode, &it.SharedEnabled, &it.CreatedAt, &it.UpdatedAt,
+			&dhash, &phash,
+		); err != nil {
+			return nil, err
+		}
+		entry.DHash, entry.PHash = uint64(dhash), uint64(phash)
+		out = append(out, entry)
+	}
+	return out, rows.Err()
+}
+
+// CountImagesInPrefix 统计 prefix 子树下的图片总数与尚无指纹（含失败）的数量。
+func (s *Store) CountImagesInPrefix(ctx context.Context, prefix string, includeVault bool) (int64, int64, error) {
+	filter, err := newPathPrefixFilter(prefix)
+	if err != nil {
+		return 0, 0, ErrBadInput
+	}
+	var total, missing int64
+	err = s.db.QueryRow(ctx, `
+SELECT count(*), count(*) FILTER (WHERE h.item_id IS NULL OR h.status <> 'ok')
+FROM items i
+LEFT JOIN item_image_hashes h ON h.item_id = i.id
+WHERE i.type = $1
+  AND (i.path = ANY($2) OR i.path LIKE ANY($3))
+  AND ($4 OR NOT i.in_vault)`,
+		string(ItemTypeImage),
+		filter.exact,
+		filter.like,
+		includeVault,
+	).Scan(&total, &missing)
+	return total, missing, err
+}
+
+// ListImagesMissingHash 按创建时间返回尚未计算指纹的图片；includeFailed 时也返回上次失败的图片。
+func (s *Store) ListImagesMissingHash(
+	ctx context.Context,
+	includeFailed bool,
+	after time.Time,
+	afterID uuid.UUID,
+	limit int,
+) ([]Item, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at
+FROM items i
+LEFT JOIN item_image_hashes h ON h.item_id = i.id
+WHERE i.type = $1
+  AND i.size > 0
+  AND (h.item_id IS NULL OR ($2 AND h.status = 'failed'))
+  AND (i.created_at, i.id) > ($3, $4)
+ORDER BY i.created_at ASC, i.id ASC
+LIMIT $5`,
+		string(ItemTypeImage),
+		includeFailed,
+		after,
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanItems(rows)
+}
+
+func (s *Store) CountImagesMissingHash(ctx context.Context, includeFailed bool) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(ctx, `
+SELECT count(*)
+FROM items i
+LEFT JOIN item_image_hashes h ON h.item_id = i.id
+WHERE i.type = $1
+  AND i.size > 0
+  AND (h.item_id IS NULL OR ($2 AND h.status = 'failed'))`,
+		string(ItemTypeImage),
+		includeFailed,
+	).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ItemMetadataStatus string
+
+const (
+	ItemMetadataStatusOK     ItemMetadataStatus = "ok"
+	ItemMetadataStatusFailed ItemMetadataStatus = "failed"
+)
+
+// ItemMetadata 记录媒体文件的探测结果；宽高均为校正旋转后的显示尺寸。
+type ItemMetadata struct {
+	ItemID          uuid.UUID
+	Status          ItemMetadataStatus
+	Error           *string
+	DurationSeconds *float64
+	Width           *int
+	Height          *int
+	Rotation        *int
+	VideoCodec      *string
+	AudioCodec      *string
+	BitRate         *int64
+	AudioTitle      *string
+	AudioArtist     *string
+	AudioAlbum      *string
+	CameraMake      *string
+	CameraModel     *string
+	TakenAt         *time.Time
+	GPSLatitude     *float64
+	GPSLongitude    *float64
+	ExtractedAt     time.Time
+}
+
+const itemMetadataColumns = `
+  item_id,
+  status,
+  error,
+  duration_seconds,
+  width,
+  height,
+  rotation,
+  video_codec,
+  audio_codec,
+  bit_rate,
+  audio_title,
+  audio_artist,
+  audio_album,
+  camera_make,
+  camera_model,
+  taken_at,
+  gps_latitude,
+  gps_longitude,
+  extracted_at`
+
+func (s *Store) GetItemMetadata(ctx context.Context, itemID uuid.UUID) (ItemMetadata, error) {
+	row := s.db.QueryRow(ctx, `SELECT`+itemMetadataColumns+`
+FROM item_metadata
+WHERE item_id = $1`, itemID)
+	var out ItemMetadata
+	err := row.Scan(
+		&out.ItemID,
+		&out.Status,
+		&out.Error,
+		&out.DurationSeconds,
+		&out.Width,
+		&out.Height,
+		&out.Rotation,
+		&out.VideoCodec,
+		&out.AudioCodec,
+		&out.BitRate,
+		&out.AudioTitle,
+		&out.AudioArtist,
+		&out.AudioAlbum,
+		&out.CameraMake,
+		&out.CameraModel,
+		&out.TakenAt,
+		&out.GPSLatitude,
+		&out.GPSLongitude,
+		&out.ExtractedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ItemMetadata{}, ErrNotFound
+		}
+		return ItemMetadata{}, err
+	}
+	return out, nil
+}
+
+func (s *Store) UpsertItemMetadata(ctx context.Context, meta ItemMetadata) error {
+	if meta.ItemID == uuid.Nil {
+		return ErrBadInput
+	}
+	if meta.Status == "" {
+		meta.Status = ItemMetadataStatusOK
+	}
+	tag, err := s.db.Exec(ctx, `
+INSERT INTO item_metadata(`+itemMetadataColumns+`
+)
+SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+WHERE EXISTS (SELECT 1 FROM items WHERE id = $1)
+ON CONFLICT (item_id) DO UPDATE
+SET status = EXCLUDED.status,
+    error = EXCLUDED.error,
+    duration_seconds = EXCLUDED.duration_seconds,
+    width = EXCLUDED.width,
+    height = EXCLUDED.height,
+    rotation = EXCLUDED.rotation,
+    video_codec = EXCLUDED.video_codec,
+    audio_codec = EXCLUDED.audio_codec,
+    bit_rate = EXCLUDED.bit_rate,
+    audio_title = EXCLUDED.audio_title,
+    audio_artist = EXCLUDED.audio_artist,
+    audio_album = EXCLUDED.audio_album,
+    camera_make = EXCLUDED.camera_make,
+    camera_model = EXCLUDED.camera_model,
+    taken_at = EXCLUDED.taken_at,
+    gps_latitude = EXCLUDED.gps_latitude,
+    gps_longitude = EXCLUDED.gps_longitude,
+    extracted_at = EXCLUDED.extracted_at`,
+		meta.ItemID,
+		meta.Status,
+		meta.Error,
+		meta.DurationSeconds,
+		meta.Width,
+		meta.Height,
+		meta.Rotation,
+		meta.VideoCodec,
+		meta.AudioCodec,
+		meta.BitRate,
+		meta.AudioTitle,
+		meta.AudioArtist,
+		meta.AudioAlbum,
+		meta.CameraMake,
+		meta.CameraModel,
+		meta.TakenAt,
+		meta.GPSLatitude,
+		meta.GPSLongitude,
+		meta.ExtractedAt,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListItemsMissingMetadata 按创建时间返回尚未提取元数据的媒体文件；includeFailed 时也返回上次失败的文件。
+func (s *Store) ListItemsMissingMetadata(
+	ctx context.Context,
+	types []ItemType,
+	includeFailed bool,
+	after time.Time,
+	afterID uuid.UUID,
+	limit int,
+) ([]Item, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	typeNames := make([]string, 0, len(types))
+	for _, t := range types {
+		typeNames = append(typeNames, string(t))
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at
+FROM items i
+LEFT JOIN item_metadata m ON m.item_id = i.id
+WHERE i.type = ANY($1)
+  AND i.size > 0
+  AND (m.item_id IS NULL OR ($2 AND m.status = 'failed'))
+  AND (i.created_at, i.id) > ($3, $4)
+ORDER BY i.created_at ASC, i.id ASC
+LIMIT $5`,
+		typeNames,
+		includeFailed,
+		after,
+		afterID,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanItems(rows)
+}
+
+func (s *Store) CountItemsMissingMetadata(ctx context.Context, types []ItemType, includeFailed bool) (int64, error) {
+	typeNames := make([]string, 0, len(types))
+	for _, t := range types {
+		typeNames = append(typeNames, string(t))
+	}
+	var total int64
+	err := s.db.QueryRow(ctx, `
+SELECT count(*)
+FROM items i
+LEFT JOIN item_metadata m ON m.item_id = i.id
+WHERE i.type = ANY($1)
+  AND i.size > 0
+  AND (m.item_id IS NULL OR ($2 AND m.status = 'failed'))`,
+		typeNames,
+		includeFailed,
+	).Scan(&total)
+	return total, err
+}
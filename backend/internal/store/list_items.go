@@ -19,11 +19,12 @@ const (
 )
 
 const (
-	listItemsCountSQL = `SELECT count(*) FROM items i WHERE %s`
+	listItemsCountSQL = `SELECT count(*) FROM items i LEFT JOIN item_metadata m ON m.item_id = i.id WHERE %s`
 	listItemsSelectSQL = `
-SELECT id, type, name, parent_id, path, size, mime_type, in_vault, starred, last_accessed_at,
-       shared_code, shared_enabled, created_at, updated_at
+SELECT i.id, i.type, i.name, i.parent_id, i.path, i.size, i.mime_type, i.in_vault, i.starred, i.last_accessed_at,
+       i.shared_code, i.shared_enabled, i.created_at, i.updated_at
 FROM items i
+LEFT JOIN item_metadata m ON m.item_id = i.id
 WHERE %s
 ORDER BY %s
 LIMIT $%d OFFSET $%d
@@ -39,6 +40,7 @@ type normalizedListParams struct {
 	sortOrder SortOrder
 	page      int
 	pageSize  int
+	metadata  MetadataFilter
 }
 
 type listItemsSpec struct {
@@ -90,6 +92,7 @@ func normalizeListParams(in ListParams) (normalizedListParams, error) {
 		sortOrder: sortOrder,
 		page:      normalizePage(in.Page),
 		pageSize:  normalizePageSize(in.PageSize),
+		metadata:  in.Metadata,
 	}, nil
 }
 
@@ -110,7 +113,7 @@ func normalizeSortBy(sortBy SortBy) (SortBy, error) {
 		return SortByName, nil
 	}
 	switch sortBy {
-	case SortByName, SortByDate, SortBySize, SortByType:
+	case SortByName, SortByDate, SortBySize, SortByType, SortByDuration, SortByResolution, SortByTakenAt:
 		return sortBy, nil
 	default:
 		return "", ErrBadInput
@@ -185,6 +188,8 @@ func buildListItemsWhere(params normalizedListParams) (string, []any, error) {
 		clauses = append(clauses, fmt.Sprintf("i.name ILIKE $%d", len(args)))
 	}
 
+	clauses, args = appendMetadataFilterClauses(clauses, args, params.metadata)
+
 	return strings.Join(clauses, " AND "), args, nil
 }
 
@@ -209,6 +214,10 @@ func buildOrderBySQL(sortBy SortBy, order SortOrder) (string, error) {
 		return "", err
 	}
 	folderRank := fmt.Sprintf(listFolderRankSQL, listFolderRank, listFileRank)
+	if isMetadataSortBy(sortBy) {
+		// 未提取元数据的文件始终排在最后
+		orderSQL += " NULLS LAST"
+	}
 	return fmt.Sprintf("%s ASC, %s %s, i.name ASC, i.id ASC", folderRank, column, orderSQL), nil
 }
 
@@ -222,11 +231,60 @@ func sortColumnFor(sortBy SortBy) (string, error) {
 		return "i.size", nil
 	case SortByType:
 		return "i.type", nil
+	case SortByDuration:
+		return "m.duration_seconds", nil
+	case SortByResolution:
+		return "(m.width::BIGINT * m.height::BIGINT)", nil
+	case SortByTakenAt:
+		return "m.taken_at", nil
 	default:
 		return "", ErrBadInput
 	}
 }
 
+func isMetadataSortBy(sortBy SortBy) bool {
+	return sortBy == SortByDuration || sortBy == SortByResolution || sortBy == SortByTakenAt
+}
+
+func appendMetadataFilterClauses(clauses []string, args []any, filter MetadataFilter) ([]string, []any) {
+	add := func(format string, value any) {
+		args = append(args, value)
+		clauses = append(clauses, fmt.Sprintf(format, len(args)))
+	}
+	if filter.MinDurationSeconds != nil {
+		add("m.duration_seconds >= $%d", *filter.MinDurationSeconds)
+	}
+	if filter.MaxDurationSeconds != nil {
+		add("m.duration_seconds <= $%d", *filter.MaxDurationSeconds)
+	}
+	if filter.MinWidth != nil {
+		add("m.width >= $%d", *filter.MinWidth)
+	}
+	if filter.MinHeight != nil {
+		add("m.height >= $%d", *filter.MinHeight)
+	}
+	if codec := strings.TrimSpace(filter.VideoCodec); codec != "" {
+		add("m.video_codec = lower($%d)", codec)
+	}
+	if camera := strings.TrimSpace(filter.Camera); camera != "" {
+		add("concat_ws(' ', m.camera_make, m.camera_model) ILIKE $%d", wrapSearch(camera))
+	}
+	if filter.TakenFrom != nil {
+		add("m.taken_at >= $%d", *filter.TakenFrom)
+	}
+	if filter.TakenTo != nil {
+		add("m.taken_at < $%d", *filter.TakenTo)
+	}
+	if filter.HasGPS != nil {
+		if *filter.HasGPS {
+			clauses = append(clauses, "m.gps_latitude IS NOT NULL AND m.gps_longitude IS NOT NULL")
+		} else {
+			clauses = append(clauses, "(m.gps_latitude IS NULL OR m.gps_longitude IS NULL)")
+		}
+	}
+	return clauses, args
+}
+
 func sortOrderSQL(order SortOrder) (string, error) {
 	switch order {
 	case SortOrderAsc:
@@ -10,42 +10,62 @@ import (
 )
 
 type RuntimeSettings struct {
-	UploadConcurrency                int
-	DownloadConcurrency              int
-	TelegramDeleteConcurrency        int
-	ReservedDiskBytes                int64
-	UploadSessionTTLHours            int
-	UploadSessionCleanupIntervalMins int
-	ThumbnailCacheMaxBytes           int64
-	ThumbnailCacheTTLHours           int
-	ThumbnailGenerateConcurrency     int
-	VaultPasswordHash                string
-	VaultSessionTTLMins              int
-	TorrentQBTPassword               string
-	TorrentSourceDeleteMode          string
-	TorrentSourceDeleteFixedMinutes  int
-	TorrentSourceDeleteRandomMinMins int
-	TorrentSourceDeleteRandomMaxMins int
-	UpdatedAt                        time.Time
+	UploadConcurrency                 int
+	DownloadConcurrency               int
+	TelegramDeleteConcurrency         int
+	ReservedDiskBytes                 int64
+	UploadSessionTTLHours             int
+	UploadSessionCleanupIntervalMins  int
+	ThumbnailCacheMaxBytes            int64
+	ThumbnailCacheTTLHours            int
+	ThumbnailGenerateConcurrency      int
+	VaultPasswordHash                 string
+	VaultSessionTTLMins               int
+	TorrentQBTPassword                string
+	TorrentSourceDeleteMode           string
+	TorrentSourceDeleteFixedMinutes   int
+	TorrentSourceDeleteRandomMinMins  int
+	TorrentSourceDeleteRandomMaxMins  int
+	TorrentPreserveDirectoryStructure bool
+	TorrentSeedRatioTarget            float64
+	TorrentSeedMinMinutes             int
+	HLSTranscodeConcurrency           int
+	HLSCacheMaxBytes                  int64
+	AuditLogRetentionDays             int
+	NotifyChatIDs                     []string
+	NotifyTorrentAwaitingSelection    bool
+	NotifyTransferFailed              bool
+	NotifyLowDisk                     bool
+	UpdatedAt                         time.Time
 }
 
 type RuntimeSettingsPatch struct {
-	UploadConcurrency                *int
-	DownloadConcurrency              *int
-	TelegramDeleteConcurrency        *int
-	ReservedDiskBytes                *int64
-	UploadSessionTTLHours            *int
-	UploadSessionCleanupIntervalMins *int
-	ThumbnailCacheMaxBytes           *int64
-	ThumbnailCacheTTLHours           *int
-	ThumbnailGenerateConcurrency     *int
-	VaultPasswordHash                *string
-	VaultSessionTTLMins              *int
-	TorrentQBTPassword               *string
-	TorrentSourceDeleteMode          *string
-	TorrentSourceDeleteFixedMinutes  *int
-	TorrentSourceDeleteRandomMinMins *int
-	TorrentSourceDeleteRandomMaxMins *int
+	UploadConcurrency                 *int
+	DownloadConcurrency               *int
+	TelegramDeleteConcurrency         *int
+	ReservedDiskBytes                 *int64
+	UploadSessionTTLHours             *int
+	UploadSessionCleanupIntervalMins  *int
+	ThumbnailCacheMaxBytes            *int64
+	ThumbnailCacheTTLHours            *int
+	ThumbnailGenerateConcurrency      *int
+	VaultPasswordHash                 *string
+	VaultSessionTTLMins               *int
+	TorrentQBTPassword                *string
+	TorrentSourceDeleteMode           *string
+	TorrentSourceDeleteFixedMinutes   *int
+	TorrentSourceDeleteRandomMinMins  *int
+	TorrentSourceDeleteRandomMaxMins  *int
+	TorrentPreserveDirectoryStructure *bool
+	TorrentSeedRatioTarget            *float64
+	TorrentSeedMinMinutes             *int
+	HLSTranscodeConcurrency           *int
+	HLSCacheMaxBytes                  *int64
+	AuditLogRetentionDays             *int
+	NotifyChatIDs                     *[]string
+	NotifyTorrentAwaitingSelection    *bool
+	NotifyTransferFailed              *bool
+	NotifyLowDisk                     *bool
 }
 
 func normalizeRuntimeDefaults(defaults *RuntimeSettings) {
@@ -95,6 +115,21 @@ func normalizeRuntimeDefaults(defaults *RuntimeSettings) {
 	if defaults.TorrentSourceDeleteRandomMaxMins < defaults.TorrentSourceDeleteRandomMinMins {
 		defaults.TorrentSourceDeleteRandomMaxMins = defaults.TorrentSourceDeleteRandomMinMins
 	}
+	if defaults.TorrentSeedRatioTarget < 0 {
+		defaults.TorrentSeedRatioTarget = 0
+	}
+	if defaults.TorrentSeedMinMinutes < 0 {
+		defaults.TorrentSeedMinMinutes = 0
+	}
+	if defaults.HLSTranscodeConcurrency <= 0 {
+		defaults.HLSTranscodeConcurrency = 1
+	}
+	if defaults.HLSCacheMaxBytes < 0 {
+		defaults.HLSCacheMaxBytes = 0
+	}
+	if defaults.AuditLogRetentionDays < 0 {
+		defaults.AuditLogRetentionDays = 0
+	}
 }
 
 func normalizeRuntimeSettingsValue(out *RuntimeSettings, defaults RuntimeSettings) {
@@ -145,6 +180,40 @@ func normalizeRuntimeSettingsValue(out *RuntimeSettings, defaults RuntimeSetting
 	if out.TorrentSourceDeleteRandomMaxMins < out.TorrentSourceDeleteRandomMinMins {
 		out.TorrentSourceDeleteRandomMaxMins = out.TorrentSourceDeleteRandomMinMins
 	}
+	if out.TorrentSeedRatioTarget < 0 {
+		out.TorrentSeedRatioTarget = defaults.TorrentSeedRatioTarget
+	}
+	if out.TorrentSeedMinMinutes < 0 {
+		out.TorrentSeedMinMinutes = defaults.TorrentSeedMinMinutes
+	}
+	if out.HLSTranscodeConcurrency <= 0 {
+		out.HLSTranscodeConcurrency = defaults.HLSTranscodeConcurrency
+	}
+	if out.HLSCacheMaxBytes < 0 {
+		out.HLSCacheMaxBytes = defaults.HLSCacheMaxBytes
+	}
+	if out.AuditLogRetentionDays < 0 {
+		out.AuditLogRetentionDays = defaults.AuditLogRetentionDays
+	}
+	out.NotifyChatIDs = normalizeNotifyChatIDs(out.NotifyChatIDs)
+}
+
+// normalizeNotifyChatIDs 去除空白与重复的通知会话 ID，保持原有顺序。
+func normalizeNotifyChatIDs(ids []string) []string {
+	out := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
 }
 
 func scanRuntimeSettingsRow(scanner interface {
@@ -167,6 +236,16 @@ func scanRuntimeSettingsRow(scanner interface {
 		&out.TorrentSourceDeleteFixedMinutes,
 		&out.TorrentSourceDeleteRandomMinMins,
 		&out.TorrentSourceDeleteRandomMaxMins,
+		&out.TorrentPreserveDirectoryStructure,
+		&out.TorrentSeedRatioTarget,
+		&out.TorrentSeedMinMinutes,
+		&out.HLSTranscodeConcurrency,
+		&out.HLSCacheMaxBytes,
+		&out.AuditLogRetentionDays,
+		&out.NotifyChatIDs,
+		&out.NotifyTorrentAwaitingSelection,
+		&out.NotifyTransferFailed,
+		&out.NotifyLowDisk,
 		&out.UpdatedAt,
 	)
 }
@@ -194,6 +273,16 @@ func (s *Store) GetRuntimeSettings(ctx context.Context, defaults RuntimeSettings
   torrent_source_delete_fixed_minutes,
   torrent_source_delete_random_min_minutes,
   torrent_source_delete_random_max_minutes,
+  torrent_preserve_directory_structure,
+  torrent_seed_ratio_target,
+  torrent_seed_min_minutes,
+  hls_transcode_concurrency,
+  hls_cache_max_bytes,
+  audit_log_retention_days,
+  notify_chat_ids,
+  notify_torrent_awaiting_selection,
+  notify_transfer_failed,
+  notify_low_disk,
   updated_at
 FROM system_config
 WHERE singleton = TRUE`,
@@ -238,6 +327,16 @@ func (s *Store) UpdateRuntimeSettings(ctx context.Context, patch RuntimeSettings
   torrent_source_delete_fixed_minutes,
   torrent_source_delete_random_min_minutes,
   torrent_source_delete_random_max_minutes,
+  torrent_preserve_directory_structure,
+  torrent_seed_ratio_target,
+  torrent_seed_min_minutes,
+  hls_transcode_concurrency,
+  hls_cache_max_bytes,
+  audit_log_retention_days,
+  notify_chat_ids,
+  notify_torrent_awaiting_selection,
+  notify_transfer_failed,
+  notify_low_disk,
   updated_at
 FROM system_config
 WHERE singleton = TRUE
@@ -300,6 +399,36 @@ FOR UPDATE`,
 	if patch.TorrentSourceDeleteRandomMaxMins != nil {
 		next.TorrentSourceDeleteRandomMaxMins = *patch.TorrentSourceDeleteRandomMaxMins
 	}
+	if patch.TorrentPreserveDirectoryStructure != nil {
+		next.TorrentPreserveDirectoryStructure = *patch.TorrentPreserveDirectoryStructure
+	}
+	if patch.TorrentSeedRatioTarget != nil {
+		next.TorrentSeedRatioTarget = *patch.TorrentSeedRatioTarget
+	}
+	if patch.TorrentSeedMinMinutes != nil {
+		next.TorrentSeedMinMinutes = *patch.TorrentSeedMinMinutes
+	}
+	if patch.HLSTranscodeConcurrency != nil {
+		next.HLSTranscodeConcurrency = *patch.HLSTranscodeConcurrency
+	}
+	if patch.HLSCacheMaxBytes != nil {
+		next.HLSCacheMaxBytes = *patch.HLSCacheMaxBytes
+	}
+	if patch.AuditLogRetentionDays != nil {
+		next.AuditLogRetentionDays = *patch.AuditLogRetentionDays
+	}
+	if patch.NotifyChatIDs != nil {
+		next.NotifyChatIDs = *patch.NotifyChatIDs
+	}
+	if patch.NotifyTorrentAwaitingSelection != nil {
+		next.NotifyTorrentAwaitingSelection = *patch.NotifyTorrentAwaitingSelection
+	}
+	if patch.NotifyTransferFailed != nil {
+		next.NotifyTransferFailed = *patch.NotifyTransferFailed
+	}
+	if patch.NotifyLowDisk != nil {
+		next.NotifyLowDisk = *patch.NotifyLowDisk
+	}
 
 	normalizeRuntimeSettingsValue(&next, defaults)
 
@@ -322,6 +451,16 @@ SET upload_concurrency = $1,
     torrent_source_delete_fixed_minutes = $14,
     torrent_source_delete_random_min_minutes = $15,
     torrent_source_delete_random_max_minutes = $16,
+    torrent_preserve_directory_structure = $17,
+    torrent_seed_ratio_target = $18,
+    torrent_seed_min_minutes = $19,
+    hls_transcode_concurrency = $20,
+    hls_cache_max_bytes = $21,
+    audit_log_retention_days = $22,
+    notify_chat_ids = $23,
+    notify_torrent_awaiting_selection = $24,
+    notify_transfer_failed = $25,
+    notify_low_disk = $26,
     updated_at = now()
 WHERE singleton = TRUE`,
 		next.UploadConcurrency,
@@ -340,6 +479,16 @@ WHERE singleton = TRUE`,
 		next.TorrentSourceDeleteFixedMinutes,
 		next.TorrentSourceDeleteRandomMinMins,
 		next.TorrentSourceDeleteRandomMaxMins,
+		next.TorrentPreserveDirectoryStructure,
+		next.TorrentSeedRatioTarget,
+		next.TorrentSeedMinMinutes,
+		next.HLSTranscodeConcurrency,
+		next.HLSCacheMaxBytes,
+		next.AuditLogRetentionDays,
+		next.NotifyChatIDs,
+		next.NotifyTorrentAwaitingSelection,
+		next.NotifyTransferFailed,
+		next.NotifyLowDisk,
 	)
 	if err != nil {
 		return RuntimeSettings{}, err
@@ -364,6 +513,16 @@ WHERE singleton = TRUE`,
   torrent_source_delete_fixed_minutes,
   torrent_source_delete_random_min_minutes,
   torrent_source_delete_random_max_minutes,
+  torrent_preserve_directory_structure,
+  torrent_seed_ratio_target,
+  torrent_seed_min_minutes,
+  hls_transcode_concurrency,
+  hls_cache_max_bytes,
+  audit_log_retention_days,
+  notify_chat_ids,
+  notify_torrent_awaiting_selection,
+  notify_transfer_failed,
+  notify_low_disk,
   updated_at
 FROM system_config
 WHERE singleton = TRUE`,
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ListSiblingFilesByExtensions 返回同一目录下扩展名匹配的文件（不含 excludeID 自身），扩展名需带点且小写。
+func (s *Store) ListSiblingFilesByExtensions(
+	ctx context.Context,
+	parentID *uuid.UUID,
+	excludeID uuid.UUID,
+	exts []string,
+	limit int,
+) ([]Item, error) {
+	if len(exts) == 0 {
+		return []Item{}, nil
+	}
+	if limit <= 0 {
+		limit = 200
+	}
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		normalized = append(normalized, strings.ToLower(strings.TrimSpace(ext)))
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT id, type, name, parent_id, path, size, mime_type, in_vault, starred, last_accessed_at,
+       shared_code, shared_enabled, created_at, updated_at
+FROM items
+WHERE parent_id IS NOT DISTINCT FROM $1
+  AND id <> $2
+  AND type <> 'folder'
+  AND lower(substring(name from '\.[^.]+$')) = ANY($3)
+ORDER BY name ASC
+LIMIT $4`,
+		parentID,
+		excludeID,
+		normalized,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanItems(rows)
+}
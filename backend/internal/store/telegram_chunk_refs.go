@@ -0,0 +1,83 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// TelegramChunkMessageRef 是 telegram_chunks 中一条消息引用及其所属文件。
+type TelegramChunkMessageRef struct {
+	TGMessageID int64
+	ChunkID     uuid.UUID
+	ChunkIndex  int
+	ItemID      uuid.UUID
+	ItemPath    string
+}
+
+// MaxTelegramChunkMessageID 返回 chatID 中被引用的最大消息 ID；没有任何引用时返回 0。
+func (s *Store) MaxTelegramChunkMessageID(ctx context.Context, chatID string) (int64, error) {
+	var out int64
+	err := s.db.QueryRow(ctx, `
+SELECT COALESCE(MAX(tg_message_id), 0)
+FROM telegram_chunks
+WHERE tg_chat_id = $1`, chatID).Scan(&out)
+	return out, err
+}
+
+// ListTelegramChunkMessageRefs 返回 chatID 中消息 ID 位于 [fromID, toID] 的全部分块引用，按消息 ID 升序。
+func (s *Store) ListTelegramChunkMessageRefs(
+	ctx context.Context,
+	chatID string,
+	fromID int64,
+	toID int64,
+) ([]TelegramChunkMessageRef, error) {
+	rows, err := s.db.Query(ctx, `
+SELECT tc.tg_message_id, tc.id, tc.chunk_index, i.id, i.path
+FROM telegram_chunks tc
+JOIN items i ON i.id = tc.item_id
+WHERE tc.tg_chat_id = $1
+  AND tc.tg_message_id BETWEEN $2 AND $3
+ORDER BY tc.tg_message_id ASC, tc.id ASC`, chatID, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TelegramChunkMessageRef, 0)
+	for rows.Next() {
+		var ref TelegramChunkMessageRef
+		if err := rows.Scan(&ref.TGMessageID, &ref.ChunkID, &ref.ChunkIndex, &ref.ItemID, &ref.ItemPath); err != nil {
+			return nil, err
+		}
+		out = append(out, ref)
+	}
+	return out, rows.Err()
+}
+
+// ListReferencedTelegramMessageIDs 返回 messageIDs 中仍被分块引用的消息 ID。
+func (s *Store) ListReferencedTelegramMessageIDs(ctx context.Context, chatID string, messageIDs []int64) ([]int64, error) {
+	if len(messageIDs) == 0 {
+		return []int64{}, nil
+	}
+	rows, err := s.db.Query(ctx, `
+SELECT DISTINCT tg_message_id
+FROM telegram_chunks
+WHERE tg_chat_id = $1
+  AND tg_message_id = ANY($2)
+ORDER BY tg_message_id ASC`, chatID, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
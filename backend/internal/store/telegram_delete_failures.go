@@ -53,6 +53,8 @@ SET item_id = EXCLUDED.item_id,
     retry_count = telegram_delete_failures.retry_count + 1,
     resolved = FALSE,
     resolved_at = NULL,
+    resolution = NULL,
+    next_retry_at = NULL,
     last_retry_at = EXCLUDED.last_retry_at
 `
 
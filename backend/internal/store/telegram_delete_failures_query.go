@@ -0,0 +1,230 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TelegramDeleteFailureState string
+
+const (
+	TelegramDeleteFailureStateUnresolved TelegramDeleteFailureState = "unresolved"
+	TelegramDeleteFailureStateResolved   TelegramDeleteFailureState = "resolved"
+	TelegramDeleteFailureStateDismissed  TelegramDeleteFailureState = "dismissed"
+)
+
+type TelegramDeleteFailureListParams struct {
+	State    *TelegramDeleteFailureState
+	ChatID   string
+	Query    string
+	Page     int
+	PageSize int
+}
+
+const telegramDeleteFailureColumns = `
+  id,
+  item_id,
+  item_path,
+  tg_chat_id,
+  tg_message_id,
+  error_message,
+  retry_count,
+  resolved,
+  failed_at,
+  last_retry_at,
+  next_retry_at,
+  resolved_at,
+  resolution`
+
+func scanTelegramDeleteFailure(row pgx.Row) (TelegramDeleteFailure, error) {
+	var out TelegramDeleteFailure
+	err := row.Scan(
+		&out.ID,
+		&out.ItemID,
+		&out.ItemPath,
+		&out.TGChatID,
+		&out.TGMessageID,
+		&out.Error,
+		&out.RetryCount,
+		&out.Resolved,
+		&out.FailedAt,
+		&out.LastRetryAt,
+		&out.NextRetryAt,
+		&out.ResolvedAt,
+		&out.Resolution,
+	)
+	return out, err
+}
+
+func (s *Store) ListTelegramDeleteFailures(
+	ctx context.Context,
+	params TelegramDeleteFailureListParams,
+) ([]TelegramDeleteFailure, int64, error) {
+	page := normalizeTransferQueryPage(params.Page)
+	pageSize := normalizeTransferQueryPageSize(params.PageSize)
+
+	where := []string{"1=1"}
+	args := make([]any, 0, 5)
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if params.State != nil {
+		switch *params.State {
+		case TelegramDeleteFailureStateUnresolved:
+			where = append(where, "resolved = FALSE")
+		case TelegramDeleteFailureStateResolved:
+			where = append(where, "resolved = TRUE AND resolution IS DISTINCT FROM "+addArg(string(TelegramDeleteResolutionDismissed)))
+		case TelegramDeleteFailureStateDismissed:
+			where = append(where, "resolution = "+addArg(string(TelegramDeleteResolutionDismissed)))
+		default:
+			return nil, 0, ErrBadInput
+		}
+	}
+	if chatID := strings.TrimSpace(params.ChatID); chatID != "" {
+		where = append(where, "tg_chat_id = "+addArg(chatID))
+	}
+	if query := strings.ToLower(strings.TrimSpace(params.Query)); query != "" {
+		placeholder := addArg("%" + query + "%")
+		where = append(where, "(LOWER(item_path) LIKE "+placeholder+" OR LOWER(error_message) LIKE "+placeholder+")")
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int64
+	if err := s.db.QueryRow(ctx, `SELECT count(*) FROM telegram_delete_failures WHERE `+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limitArg := addArg(pageSize)
+	offsetArg := addArg((page - 1) * pageSize)
+	rows, err := s.db.Query(ctx, `SELECT`+telegramDeleteFailureColumns+`
+FROM telegram_delete_failures
+WHERE `+whereSQL+`
+ORDER BY last_retry_at DESC, id ASC
+LIMIT `+limitArg+` OFFSET `+offsetArg, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]TelegramDeleteFailure, 0)
+	for rows.Next() {
+		failure, err := scanTelegramDeleteFailure(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, failure)
+	}
+	return out, total, rows.Err()
+}
+
+func (s *Store) GetTelegramDeleteFailure(ctx context.Context, id uuid.UUID) (TelegramDeleteFailure, error) {
+	out, err := scanTelegramDeleteFailure(s.db.QueryRow(ctx, `SELECT`+telegramDeleteFailureColumns+`
+FROM telegram_delete_failures
+WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TelegramDeleteFailure{}, ErrNotFound
+		}
+		return TelegramDeleteFailure{}, err
+	}
+	return out, nil
+}
+
+// ListDueTelegramDeleteFailures 返回到达重试时间、且自动重试次数未用完的未解决失败项。
+func (s *Store) ListDueTelegramDeleteFailures(
+	ctx context.Context,
+	now time.Time,
+	maxRetries int,
+	limit int,
+) ([]TelegramDeleteFailure, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(ctx, `SELECT`+telegramDeleteFailureColumns+`
+FROM telegram_delete_failures
+WHERE resolved = FALSE
+  AND retry_count < $2
+  AND (next_retry_at IS NULL OR next_retry_at <= $1)
+ORDER BY COALESCE(next_retry_at, failed_at) ASC, id ASC
+LIMIT $3`, now, maxRetries, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TelegramDeleteFailure, 0)
+	for rows.Next() {
+		failure, err := scanTelegramDeleteFailure(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, failure)
+	}
+	return out, rows.Err()
+}
+
+// MarkTelegramDeleteFailureRetryFailed 记录一次失败的重试，并安排下一次重试时间（nil 表示不再自动重试）。
+func (s *Store) MarkTelegramDeleteFailureRetryFailed(
+	ctx context.Context,
+	id uuid.UUID,
+	errorMessage string,
+	now time.Time,
+	nextRetryAt *time.Time,
+) (TelegramDeleteFailure, error) {
+	errorMessage = strings.TrimSpace(errorMessage)
+	if errorMessage == "" {
+		errorMessage = "unknown error"
+	}
+	out, err := scanTelegramDeleteFailure(s.db.QueryRow(ctx, `
+UPDATE telegram_delete_failures
+SET error_message = $2,
+    retry_count = retry_count + 1,
+    last_retry_at = $3,
+    next_retry_at = $4
+WHERE id = $1 AND resolved = FALSE
+RETURNING`+telegramDeleteFailureColumns, id, errorMessage, now, nextRetryAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TelegramDeleteFailure{}, ErrNotFound
+		}
+		return TelegramDeleteFailure{}, err
+	}
+	return out, nil
+}
+
+// ResolveTelegramDeleteFailure 把失败项标记为已解决；已解决的项返回 ErrNotFound。
+func (s *Store) ResolveTelegramDeleteFailure(
+	ctx context.Context,
+	id uuid.UUID,
+	resolution TelegramDeleteResolution,
+	now time.Time,
+) (TelegramDeleteFailure, error) {
+	out, err := scanTelegramDeleteFailure(s.db.QueryRow(ctx, `
+UPDATE telegram_delete_failures
+SET resolved = TRUE,
+    resolved_at = $3,
+    resolution = $2,
+    next_retry_at = NULL
+WHERE id = $1 AND resolved = FALSE
+RETURNING`+telegramDeleteFailureColumns, id, string(resolution), now))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TelegramDeleteFailure{}, ErrNotFound
+		}
+		return TelegramDeleteFailure{}, err
+	}
+	return out, nil
+}
+
+func (s *Store) CountUnresolvedTelegramDeleteFailures(ctx context.Context) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(ctx, `SELECT count(*) FROM telegram_delete_failures WHERE resolved = FALSE`).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TorrentPublication struct {
+	ID            uuid.UUID
+	ItemID        uuid.UUID
+	TransferJobID *uuid.UUID
+	Announce      []string
+	InfoHash      *string
+	Torrent       []byte
+	ExpiresAt     time.Time
+	RevokedAt     *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Ready 表示 .torrent 内容已生成。
+func (p TorrentPublication) Ready() bool {
+	return len(p.Torrent) > 0
+}
+
+// Active 表示发布未撤销且未过期，webseed 令牌仍可使用。
+func (p TorrentPublication) Active(now time.Time) bool {
+	return p.RevokedAt == nil && now.Before(p.ExpiresAt)
+}
+
+const torrentPublicationColumns = `
+  id,
+  item_id,
+  transfer_job_id,
+  announce,
+  info_hash,
+  torrent,
+  expires_at,
+  revoked_at,
+  created_at,
+  updated_at`
+
+func scanTorrentPublication(row pgx.Row) (TorrentPublication, error) {
+	var out TorrentPublication
+	if err := row.Scan(
+		&out.ID,
+		&out.ItemID,
+		&out.TransferJobID,
+		&out.Announce,
+		&out.InfoHash,
+		&out.Torrent,
+		&out.ExpiresAt,
+		&out.RevokedAt,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TorrentPublication{}, ErrNotFound
+		}
+		return TorrentPublication{}, err
+	}
+	if out.Announce == nil {
+		out.Announce = []string{}
+	}
+	return out, nil
+}
+
+func (s *Store) CreateTorrentPublication(ctx context.Context, pub TorrentPublication) (TorrentPublication, error) {
+	if pub.ItemID == uuid.Nil || pub.ExpiresAt.IsZero() {
+		return TorrentPublication{}, ErrBadInput
+	}
+	if pub.ID == uuid.Nil {
+		pub.ID = uuid.New()
+	}
+	if pub.Announce == nil {
+		pub.Announce = []string{}
+	}
+	if pub.CreatedAt.IsZero() {
+		pub.CreatedAt = time.Now()
+	}
+	return scanTorrentPublication(s.db.QueryRow(ctx, `
+INSERT INTO torrent_publications (id, item_id, transfer_job_id, announce, expires_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6)
+RETURNING`+torrentPublicationColumns,
+		pub.ID,
+		pub.ItemID,
+		pub.TransferJobID,
+		pub.Announce,
+		pub.ExpiresAt,
+		pub.CreatedAt,
+	))
+}
+
+func (s *Store) GetTorrentPublication(ctx context.Context, id uuid.UUID) (TorrentPublication, error) {
+	return scanTorrentPublication(s.db.QueryRow(ctx, `SELECT`+torrentPublicationColumns+`
+FROM torrent_publications
+WHERE id = $1`, id))
+}
+
+// ListTorrentPublicationsByItem 按创建时间倒序返回条目的发布记录，不加载 .torrent 内容。
+func (s *Store) ListTorrentPublicationsByItem(ctx context.Context, itemID uuid.UUID) ([]TorrentPublication, error) {
+	rows, err := s.db.Query(ctx, `SELECT
+  id,
+  item_id,
+  transfer_job_id,
+  announce,
+  info_hash,
+  NULL::bytea,
+  expires_at,
+  revoked_at,
+  created_at,
+  updated_at
+FROM torrent_publications
+WHERE item_id = $1
+ORDER BY created_at DESC, id DESC`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TorrentPublication, 0)
+	for rows.Next() {
+		pub, err := scanTorrentPublication(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, pub)
+	}
+	return out, rows.Err()
+}
+
+// SetTorrentPublicationContent 保存生成完成的 .torrent；已撤销的发布不再写入。
+func (s *Store) SetTorrentPublicationContent(
+	ctx context.Context,
+	id uuid.UUID,
+	infoHash string,
+	torrent []byte,
+	now time.Time,
+) error {
+	tag, err := s.db.Exec(ctx, `
+UPDATE torrent_publications
+SET info_hash = $2,
+    torrent = $3,
+    updated_at = $4
+WHERE id = $1 AND revoked_at IS NULL`, id, infoHash, torrent, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RevokeTorrentPublication 撤销发布，已签发的 webseed 令牌随即失效；重复撤销保留首次时间。
+func (s *Store) RevokeTorrentPublication(ctx context.Context, id uuid.UUID, now time.Time) (TorrentPublication, error) {
+	return scanTorrentPublication(s.db.QueryRow(ctx, `
+UPDATE torrent_publications
+SET revoked_at = COALESCE(revoked_at, $2),
+    updated_at = $2
+WHERE id = $1
+RETURNING`+torrentPublicationColumns, id, now))
+}
+
+// PurgeTorrentPublicationsBefore 删除在 cutoff 之前已过期或已撤销的发布记录。
+func (s *Store) PurgeTorrentPublicationsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, `
+DELETE FROM torrent_publications
+WHERE expires_at < $1 OR revoked_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
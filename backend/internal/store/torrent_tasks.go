@@ -12,6 +12,14 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+const (
+	TorrentTaskPriorityMin = -10
+	TorrentTaskPriorityMax = 10
+
+	// TorrentSeedCheckInterval 为做种目标未达标时的重新检查间隔
+	TorrentSeedCheckInterval = 5 * time.Minute
+)
+
 const (
 	torrentCleanupPolicyNever     = "never"
 	torrentCleanupPolicyImmediate = "immediate"
@@ -26,6 +34,7 @@ func parseTorrentTaskStatus(raw string) (TorrentTaskStatus, error) {
 		TorrentTaskStatusDownloading,
 		TorrentTaskStatusAwaitingSelection,
 		TorrentTaskStatusUploading,
+		TorrentTaskStatusPaused,
 		TorrentTaskStatusCompleted,
 		TorrentTaskStatusError:
 		return v, nil
@@ -96,6 +105,25 @@ func decodeTrackerHosts(raw string) []string {
 	return normalized
 }
 
+func parsePausedFromStatus(raw *string) *TorrentTaskStatus {
+	if raw == nil {
+		return nil
+	}
+	status, err := parseTorrentTaskStatus(*raw)
+	if err != nil {
+		return nil
+	}
+	return &status
+}
+
+func pausedFromStatusValue(status *TorrentTaskStatus) *string {
+	if status == nil {
+		return nil
+	}
+	v := string(*status)
+	return &v
+}
+
 func scanTorrentTask(
 	id uuid.UUID,
 	sourceTypeRaw string,
@@ -119,6 +147,14 @@ func scanTorrentTask(
 	sourceCleanupPolicy string,
 	sourceCleanupDueAt *time.Time,
 	sourceCleanupDone bool,
+	priority int,
+	pausedFromStatus *string,
+	preserveDirectoryStructure bool,
+	seedRatioTarget float64,
+	seedMinMinutes int,
+	seedRatio float64,
+	seedingSeconds int64,
+	seedCheckedAt *time.Time,
 	createdAt time.Time,
 	updatedAt time.Time,
 ) (TorrentTask, error) {
@@ -131,30 +167,38 @@ func scanTorrentTask(
 		return TorrentTask{}, err
 	}
 	return TorrentTask{
-		ID:                  id,
-		SourceType:          sourceType,
-		SourceURL:           sourceURL,
-		TorrentName:         strings.TrimSpace(torrentName),
-		InfoHash:            strings.TrimSpace(strings.ToLower(infoHash)),
-		TorrentFilePath:     strings.TrimSpace(torrentFilePath),
-		QBTorrentHash:       qbTorrentHash,
-		TargetChatID:        strings.TrimSpace(targetChatID),
-		TargetParentID:      targetParentID,
-		SubmittedBy:         strings.TrimSpace(submittedBy),
-		EstimatedSize:       estimatedSize,
-		DownloadedBytes:     downloadedBytes,
-		Progress:            progress,
-		IsPrivate:           isPrivate,
-		TrackerHosts:        decodeTrackerHosts(trackerHostsJSON),
-		Status:              status,
-		Error:               errMsg,
-		StartedAt:           startedAt,
-		FinishedAt:          finishedAt,
-		SourceCleanupPolicy: normalizeTorrentCleanupPolicy(sourceCleanupPolicy),
-		SourceCleanupDueAt:  sourceCleanupDueAt,
-		SourceCleanupDone:   sourceCleanupDone,
-		CreatedAt:           createdAt,
-		UpdatedAt:           updatedAt,
+		ID:                         id,
+		SourceType:                 sourceType,
+		SourceURL:                  sourceURL,
+		TorrentName:                strings.TrimSpace(torrentName),
+		InfoHash:                   strings.TrimSpace(strings.ToLower(infoHash)),
+		TorrentFilePath:            strings.TrimSpace(torrentFilePath),
+		QBTorrentHash:              qbTorrentHash,
+		TargetChatID:               strings.TrimSpace(targetChatID),
+		TargetParentID:             targetParentID,
+		SubmittedBy:                strings.TrimSpace(submittedBy),
+		EstimatedSize:              estimatedSize,
+		DownloadedBytes:            downloadedBytes,
+		Progress:                   progress,
+		IsPrivate:                  isPrivate,
+		TrackerHosts:               decodeTrackerHosts(trackerHostsJSON),
+		Status:                     status,
+		Error:                      errMsg,
+		StartedAt:                  startedAt,
+		FinishedAt:                 finishedAt,
+		SourceCleanupPolicy:        normalizeTorrentCleanupPolicy(sourceCleanupPolicy),
+		SourceCleanupDueAt:         sourceCleanupDueAt,
+		SourceCleanupDone:          sourceCleanupDone,
+		Priority:                   priority,
+		PausedFromStatus:           parsePausedFromStatus(pausedFromStatus),
+		PreserveDirectoryStructure: preserveDirectoryStructure,
+		SeedRatioTarget:            seedRatioTarget,
+		SeedMinMinutes:             seedMinMinutes,
+		SeedRatio:                  seedRatio,
+		SeedingSeconds:             seedingSeconds,
+		SeedCheckedAt:              seedCheckedAt,
+		CreatedAt:                  createdAt,
+		UpdatedAt:                  updatedAt,
 	}, nil
 }
 
@@ -177,49 +221,64 @@ func (s *Store) CreateTorrentTask(ctx context.Context, task TorrentTask) (Torren
 		strings.TrimSpace(task.SubmittedBy) == "" {
 		return TorrentTask{}, ErrBadInput
 	}
+	if task.Priority < TorrentTaskPriorityMin || task.Priority > TorrentTaskPriorityMax {
+		return TorrentTask{}, ErrBadInput
+	}
 
 	const q = `
 	INSERT INTO torrent_tasks(
 	  id, source_type, source_url, torrent_name, info_hash, torrent_file_path, qb_torrent_hash,
 	  target_chat_id, target_parent_id, submitted_by, estimated_size, downloaded_bytes, progress,
 	  is_private, tracker_hosts_json, status, error, started_at, finished_at,
-	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done, created_at, updated_at
+	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done,
+	  priority, paused_from_status, preserve_directory_structure,
+	  seed_ratio_target, seed_min_minutes, seed_ratio, seeding_seconds, seed_checked_at, created_at, updated_at
 	)
 	VALUES (
-	  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24
+	  $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32
 	)
 	RETURNING
 	  id, source_type, source_url, torrent_name, info_hash, torrent_file_path, qb_torrent_hash,
 	  target_chat_id, target_parent_id, submitted_by, estimated_size, downloaded_bytes, progress,
 	  is_private, tracker_hosts_json, status, error, started_at, finished_at,
-	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done, created_at, updated_at
+	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done,
+	  priority, paused_from_status, preserve_directory_structure,
+	  seed_ratio_target, seed_min_minutes, seed_ratio, seeding_seconds, seed_checked_at, created_at, updated_at
 	`
 
 	var (
-		id                  uuid.UUID
-		sourceTypeRaw       string
-		sourceURL           *string
-		torrentName         string
-		infoHash            string
-		torrentFilePath     string
-		qbTorrentHash       *string
-		targetChatID        string
-		targetParentID      *uuid.UUID
-		submittedBy         string
-		estimatedSize       int64
-		downloadedBytes     int64
-		progress            float64
-		isPrivate           bool
-		trackerHostsJSON    string
-		statusRaw           string
-		errMsg              *string
-		startedAt           *time.Time
-		finishedAt          *time.Time
-		sourceCleanupPolicy string
-		sourceCleanupDueAt  *time.Time
-		sourceCleanupDone   bool
-		createdAt           time.Time
-		updatedAt           time.Time
+		id                         uuid.UUID
+		sourceTypeRaw              string
+		sourceURL                  *string
+		torrentName                string
+		infoHash                   string
+		torrentFilePath            string
+		qbTorrentHash              *string
+		targetChatID               string
+		targetParentID             *uuid.UUID
+		submittedBy                string
+		estimatedSize              int64
+		downloadedBytes            int64
+		progress                   float64
+		isPrivate                  bool
+		trackerHostsJSON           string
+		statusRaw                  string
+		errMsg                     *string
+		startedAt                  *time.Time
+		finishedAt                 *time.Time
+		sourceCleanupPolicy        string
+		sourceCleanupDueAt         *time.Time
+		sourceCleanupDone          bool
+		priority                   int
+		pausedFromStatus           *string
+		preserveDirectoryStructure bool
+		seedRatioTarget            float64
+		seedMinMinutes             int
+		seedRatio                  float64
+		seedingSeconds             int64
+		seedCheckedAt              *time.Time
+		createdAt                  time.Time
+		updatedAt                  time.Time
 	)
 	if err := s.db.QueryRow(
 		ctx,
@@ -246,6 +305,14 @@ func (s *Store) CreateTorrentTask(ctx context.Context, task TorrentTask) (Torren
 		normalizeTorrentCleanupPolicy(task.SourceCleanupPolicy),
 		task.SourceCleanupDueAt,
 		task.SourceCleanupDone,
+		task.Priority,
+		pausedFromStatusValue(task.PausedFromStatus),
+		task.PreserveDirectoryStructure,
+		task.SeedRatioTarget,
+		task.SeedMinMinutes,
+		task.SeedRatio,
+		task.SeedingSeconds,
+		task.SeedCheckedAt,
 		task.CreatedAt,
 		task.UpdatedAt,
 	).Scan(
@@ -271,6 +338,14 @@ func (s *Store) CreateTorrentTask(ctx context.Context, task TorrentTask) (Torren
 		&sourceCleanupPolicy,
 		&sourceCleanupDueAt,
 		&sourceCleanupDone,
+		&priority,
+		&pausedFromStatus,
+		&preserveDirectoryStructure,
+		&seedRatioTarget,
+		&seedMinMinutes,
+		&seedRatio,
+		&seedingSeconds,
+		&seedCheckedAt,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -299,6 +374,14 @@ func (s *Store) CreateTorrentTask(ctx context.Context, task TorrentTask) (Torren
 		sourceCleanupPolicy,
 		sourceCleanupDueAt,
 		sourceCleanupDone,
+		priority,
+		pausedFromStatus,
+		preserveDirectoryStructure,
+		seedRatioTarget,
+		seedMinMinutes,
+		seedRatio,
+		seedingSeconds,
+		seedCheckedAt,
 		createdAt,
 		updatedAt,
 	)
@@ -310,34 +393,44 @@ func (s *Store) GetTorrentTask(ctx context.Context, id uuid.UUID) (TorrentTask,
 	  id, source_type, source_url, torrent_name, info_hash, torrent_file_path, qb_torrent_hash,
 	  target_chat_id, target_parent_id, submitted_by, estimated_size, downloaded_bytes, progress,
 	  is_private, tracker_hosts_json, status, error, started_at, finished_at,
-	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done, created_at, updated_at
+	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done,
+	  priority, paused_from_status, preserve_directory_structure,
+	  seed_ratio_target, seed_min_minutes, seed_ratio, seeding_seconds, seed_checked_at, created_at, updated_at
 	FROM torrent_tasks
 	WHERE id = $1
 	`
 	var (
-		sourceTypeRaw       string
-		sourceURL           *string
-		torrentName         string
-		infoHash            string
-		torrentFilePath     string
-		qbTorrentHash       *string
-		targetChatID        string
-		targetParentID      *uuid.UUID
-		submittedBy         string
-		estimatedSize       int64
-		downloadedBytes     int64
-		progress            float64
-		isPrivate           bool
-		trackerHostsJSON    string
-		statusRaw           string
-		errMsg              *string
-		startedAt           *time.Time
-		finishedAt          *time.Time
-		sourceCleanupPolicy string
-		sourceCleanupDueAt  *time.Time
-		sourceCleanupDone   bool
-		createdAt           time.Time
-		updatedAt           time.Time
+		sourceTypeRaw              string
+		sourceURL                  *string
+		torrentName                string
+		infoHash                   string
+		torrentFilePath            string
+		qbTorrentHash              *string
+		targetChatID               string
+		targetParentID             *uuid.UUID
+		submittedBy                string
+		estimatedSize              int64
+		downloadedBytes            int64
+		progress                   float64
+		isPrivate                  bool
+		trackerHostsJSON           string
+		statusRaw                  string
+		errMsg                     *string
+		startedAt                  *time.Time
+		finishedAt                 *time.Time
+		sourceCleanupPolicy        string
+		sourceCleanupDueAt         *time.Time
+		sourceCleanupDone          bool
+		priority                   int
+		pausedFromStatus           *string
+		preserveDirectoryStructure bool
+		seedRatioTarget            float64
+		seedMinMinutes             int
+		seedRatio                  float64
+		seedingSeconds             int64
+		seedCheckedAt              *time.Time
+		createdAt                  time.Time
+		updatedAt                  time.Time
 	)
 	err := s.db.QueryRow(ctx, q, id).Scan(
 		&id,
@@ -362,6 +455,14 @@ func (s *Store) GetTorrentTask(ctx context.Context, id uuid.UUID) (TorrentTask,
 		&sourceCleanupPolicy,
 		&sourceCleanupDueAt,
 		&sourceCleanupDone,
+		&priority,
+		&pausedFromStatus,
+		&preserveDirectoryStructure,
+		&seedRatioTarget,
+		&seedMinMinutes,
+		&seedRatio,
+		&seedingSeconds,
+		&seedCheckedAt,
 		&createdAt,
 		&updatedAt,
 	)
@@ -394,6 +495,14 @@ func (s *Store) GetTorrentTask(ctx context.Context, id uuid.UUID) (TorrentTask,
 		sourceCleanupPolicy,
 		sourceCleanupDueAt,
 		sourceCleanupDone,
+		priority,
+		pausedFromStatus,
+		preserveDirectoryStructure,
+		seedRatioTarget,
+		seedMinMinutes,
+		seedRatio,
+		seedingSeconds,
+		seedCheckedAt,
 		createdAt,
 		updatedAt,
 	)
@@ -452,7 +561,9 @@ func (s *Store) ListTorrentTasks(
 	  id, source_type, source_url, torrent_name, info_hash, torrent_file_path, qb_torrent_hash,
 	  target_chat_id, target_parent_id, submitted_by, estimated_size, downloaded_bytes, progress,
 	  is_private, tracker_hosts_json, status, error, started_at, finished_at,
-	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done, created_at, updated_at
+	  source_cleanup_policy, source_cleanup_due_at, source_cleanup_done,
+	  priority, paused_from_status, preserve_directory_structure,
+	  seed_ratio_target, seed_min_minutes, seed_ratio, seeding_seconds, seed_checked_at, created_at, updated_at
 	FROM torrent_tasks
 	WHERE %s
 ORDER BY created_at DESC
@@ -468,30 +579,38 @@ LIMIT $%d OFFSET $%d
 	items := make([]TorrentTask, 0, pageSize)
 	for rows.Next() {
 		var (
-			id                  uuid.UUID
-			sourceTypeRaw       string
-			sourceURL           *string
-			torrentName         string
-			infoHash            string
-			torrentFilePath     string
-			qbTorrentHash       *string
-			targetChatID        string
-			targetParentID      *uuid.UUID
-			submittedBy         string
-			estimatedSize       int64
-			downloadedBytes     int64
-			progress            float64
-			isPrivate           bool
-			trackerHostsJSON    string
-			statusRaw           string
-			errMsg              *string
-			startedAt           *time.Time
-			finishedAt          *time.Time
-			sourceCleanupPolicy string
-			sourceCleanupDueAt  *time.Time
-			sourceCleanupDone   bool
-			createdAt           time.Time
-			updatedAt           time.Time
+			id                         uuid.UUID
+			sourceTypeRaw              string
+			sourceURL                  *string
+			torrentName                string
+			infoHash                   string
+			torrentFilePath            string
+			qbTorrentHash              *string
+			targetChatID               string
+			targetParentID             *uuid.UUID
+			submittedBy                string
+			estimatedSize              int64
+			downloadedBytes            int64
+			progress                   float64
+			isPrivate                  bool
+			trackerHostsJSON           string
+			statusRaw                  string
+			errMsg                     *string
+			startedAt                  *time.Time
+			finishedAt                 *time.Time
+			sourceCleanupPolicy        string
+			sourceCleanupDueAt         *time.Time
+			sourceCleanupDone          bool
+			priority                   int
+			pausedFromStatus           *string
+			preserveDirectoryStructure bool
+			seedRatioTarget            float64
+			seedMinMinutes             int
+			seedRatio                  float64
+			seedingSeconds             int64
+			seedCheckedAt              *time.Time
+			createdAt                  time.Time
+			updatedAt                  time.Time
 		)
 		if err := rows.Scan(
 			&id,
@@ -516,6 +635,14 @@ LIMIT $%d OFFSET $%d
 			&sourceCleanupPolicy,
 			&sourceCleanupDueAt,
 			&sourceCleanupDone,
+			&priority,
+			&pausedFromStatus,
+			&preserveDirectoryStructure,
+			&seedRatioTarget,
+			&seedMinMinutes,
+			&seedRatio,
+			&seedingSeconds,
+			&seedCheckedAt,
 			&createdAt,
 			&updatedAt,
 		); err != nil {
@@ -544,6 +671,14 @@ LIMIT $%d OFFSET $%d
 			sourceCleanupPolicy,
 			sourceCleanupDueAt,
 			sourceCleanupDone,
+			priority,
+			pausedFromStatus,
+			preserveDirectoryStructure,
+			seedRatioTarget,
+			seedMinMinutes,
+			seedRatio,
+			seedingSeconds,
+			seedCheckedAt,
 			createdAt,
 			updatedAt,
 		)
@@ -653,7 +788,8 @@ WITH picked AS (
   SELECT id
   FROM torrent_tasks
   WHERE status = ANY($1::text[])
-  ORDER BY updated_at ASC, created_at ASC
+  -- downloading 阶段仅轮询 qBittorrent 状态，需按 updated_at 轮转，不参与优先级排序
+  ORDER BY CASE WHEN status = 'downloading' THEN 0 ELSE priority END DESC, updated_at ASC, created_at ASC
   LIMIT 1
   FOR UPDATE SKIP LOCKED
 )
@@ -668,34 +804,44 @@ WHERE t.id = picked.id
 	  t.id, t.source_type, t.source_url, t.torrent_name, t.info_hash, t.torrent_file_path, t.qb_torrent_hash,
 	  t.target_chat_id, t.target_parent_id, t.submitted_by, t.estimated_size, t.downloaded_bytes, t.progress,
 	  t.is_private, t.tracker_hosts_json, t.status, t.error, t.started_at, t.finished_at,
-	  t.source_cleanup_policy, t.source_cleanup_due_at, t.source_cleanup_done, t.created_at, t.updated_at
+	  t.source_cleanup_policy, t.source_cleanup_due_at, t.source_cleanup_done,
+	  t.priority, t.paused_from_status, t.preserve_directory_structure,
+	  t.seed_ratio_target, t.seed_min_minutes, t.seed_ratio, t.seeding_seconds, t.seed_checked_at, t.created_at, t.updated_at
 	`
 
 	var (
-		id                  uuid.UUID
-		sourceTypeRaw       string
-		sourceURL           *string
-		torrentName         string
-		infoHash            string
-		torrentFilePath     string
-		qbTorrentHash       *string
-		targetChatID        string
-		targetParentID      *uuid.UUID
-		submittedBy         string
-		estimatedSize       int64
-		downloadedBytes     int64
-		progress            float64
-		isPrivate           bool
-		trackerHostsJSON    string
-		statusRaw           string
-		errMsg              *string
-		startedAt           *time.Time
-		finishedAt          *time.Time
-		sourceCleanupPolicy string
-		sourceCleanupDueAt  *time.Time
-		sourceCleanupDone   bool
-		createdAt           time.Time
-		updatedAt           time.Time
+		id                         uuid.UUID
+		sourceTypeRaw              string
+		sourceURL                  *string
+		torrentName                string
+		infoHash                   string
+		torrentFilePath            string
+		qbTorrentHash              *string
+		targetChatID               string
+		targetParentID             *uuid.UUID
+		submittedBy                string
+		estimatedSize              int64
+		downloadedBytes            int64
+		progress                   float64
+		isPrivate                  bool
+		trackerHostsJSON           string
+		statusRaw                  string
+		errMsg                     *string
+		startedAt                  *time.Time
+		finishedAt                 *time.Time
+		sourceCleanupPolicy        string
+		sourceCleanupDueAt         *time.Time
+		sourceCleanupDone          bool
+		priority                   int
+		pausedFromStatus           *string
+		preserveDirectoryStructure bool
+		seedRatioTarget            float64
+		seedMinMinutes             int
+		seedRatio                  float64
+		seedingSeconds             int64
+		seedCheckedAt              *time.Time
+		createdAt                  time.Time
+		updatedAt                  time.Time
 	)
 	if err := s.db.QueryRow(ctx, q, fromRaw, string(parsedTo), now).Scan(
 		&id,
@@ -720,6 +866,14 @@ WHERE t.id = picked.id
 		&sourceCleanupPolicy,
 		&sourceCleanupDueAt,
 		&sourceCleanupDone,
+		&priority,
+		&pausedFromStatus,
+		&preserveDirectoryStructure,
+		&seedRatioTarget,
+		&seedMinMinutes,
+		&seedRatio,
+		&seedingSeconds,
+		&seedCheckedAt,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -751,6 +905,14 @@ WHERE t.id = picked.id
 		sourceCleanupPolicy,
 		sourceCleanupDueAt,
 		sourceCleanupDone,
+		priority,
+		pausedFromStatus,
+		preserveDirectoryStructure,
+		seedRatioTarget,
+		seedMinMinutes,
+		seedRatio,
+		seedingSeconds,
+		seedCheckedAt,
 		createdAt,
 		updatedAt,
 	)
@@ -849,6 +1011,132 @@ func (s *Store) SetTorrentTaskStatus(
 	return nil
 }
 
+func (s *Store) SetTorrentTaskPriority(ctx context.Context, id uuid.UUID, priority int, now time.Time) error {
+	if priority < TorrentTaskPriorityMin || priority > TorrentTaskPriorityMax {
+		return ErrBadInput
+	}
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE torrent_tasks SET priority = $2, updated_at = $3 WHERE id = $1`,
+		id,
+		priority,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PauseTorrentTask 暂停未结束的任务，返回暂停前的状态。
+func (s *Store) PauseTorrentTask(ctx context.Context, id uuid.UUID, now time.Time) (TorrentTaskStatus, error) {
+	var fromRaw string
+	err := s.db.QueryRow(
+		ctx,
+		`UPDATE torrent_tasks
+SET paused_from_status = status,
+    status = $2,
+    updated_at = $3
+WHERE id = $1
+  AND status IN ('queued', 'downloading', 'awaiting_selection', 'uploading')
+RETURNING paused_from_status`,
+		id,
+		string(TorrentTaskStatusPaused),
+		now,
+	).Scan(&fromRaw)
+	if err == nil {
+		return parseTorrentTaskStatus(fromRaw)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	return "", s.torrentTaskTransitionMissError(ctx, id)
+}
+
+// ResumeTorrentTask 将暂停的任务恢复到暂停前状态，返回恢复后的状态。
+func (s *Store) ResumeTorrentTask(ctx context.Context, id uuid.UUID, now time.Time) (TorrentTaskStatus, error) {
+	var toRaw string
+	err := s.db.QueryRow(
+		ctx,
+		`UPDATE torrent_tasks
+SET status = COALESCE(paused_from_status, $3),
+    paused_from_status = NULL,
+    updated_at = $4
+WHERE id = $1
+  AND status = $2
+RETURNING status`,
+		id,
+		string(TorrentTaskStatusPaused),
+		string(TorrentTaskStatusQueued),
+		now,
+	).Scan(&toRaw)
+	if err == nil {
+		return parseTorrentTaskStatus(toRaw)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", err
+	}
+	return "", s.torrentTaskTransitionMissError(ctx, id)
+}
+
+// AdvanceTorrentTaskStatus 在 worker 推进阶段时使用：若任务已被暂停，则只更新恢复目标状态。
+func (s *Store) AdvanceTorrentTaskStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	from TorrentTaskStatus,
+	to TorrentTaskStatus,
+	now time.Time,
+) error {
+	parsedFrom, err := parseTorrentTaskStatus(string(from))
+	if err != nil {
+		return err
+	}
+	parsedTo, err := parseTorrentTaskStatus(string(to))
+	if err != nil {
+		return err
+	}
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE torrent_tasks
+SET status = CASE WHEN status = $4 THEN status ELSE $3 END,
+    paused_from_status = CASE WHEN status = $4 THEN $3 ELSE paused_from_status END,
+    error = NULL,
+    updated_at = $5
+WHERE id = $1
+  AND (status = $2 OR (status = $4 AND paused_from_status = $2))`,
+		id,
+		string(parsedFrom),
+		string(parsedTo),
+		string(TorrentTaskStatusPaused),
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return s.torrentTaskTransitionMissError(ctx, id)
+	}
+	return nil
+}
+
+func (s *Store) torrentTaskTransitionMissError(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	if err := s.db.QueryRow(
+		ctx,
+		`SELECT EXISTS(SELECT 1 FROM torrent_tasks WHERE id = $1)`,
+		id,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
 func (s *Store) ResetTorrentTaskForRetry(ctx context.Context, id uuid.UUID, now time.Time) error {
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
@@ -955,6 +1243,7 @@ func (s *Store) FinishTorrentTask(
 		`UPDATE torrent_tasks
 SET status = $2,
     error = $3,
+    paused_from_status = NULL,
     finished_at = $4,
     updated_at = $4
 WHERE id = $1`,
@@ -1029,7 +1318,43 @@ WHERE id = $1`,
 	return nil
 }
 
+// UpdateTorrentTaskSeedStats 记录最近一次从 qBittorrent 读取的做种数据，不刷新 updated_at。
+func (s *Store) UpdateTorrentTaskSeedStats(
+	ctx context.Context,
+	id uuid.UUID,
+	ratio float64,
+	seedingSeconds int64,
+	now time.Time,
+) error {
+	if ratio < 0 {
+		ratio = 0
+	}
+	if seedingSeconds < 0 {
+		seedingSeconds = 0
+	}
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE torrent_tasks
+SET seed_ratio = $2,
+    seeding_seconds = $3,
+    seed_checked_at = $4
+WHERE id = $1`,
+		id,
+		ratio,
+		seedingSeconds,
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (s *Store) ClaimNextDueTorrentCleanupTask(ctx context.Context, now time.Time) (TorrentTask, error) {
+	// 已记录的做种数据未达标且检查时间较新时跳过，等待下一次做种检查
 	const q = `
 WITH picked AS (
   SELECT id
@@ -1038,6 +1363,13 @@ WITH picked AS (
     AND source_cleanup_done = FALSE
     AND source_cleanup_due_at IS NOT NULL
     AND source_cleanup_due_at <= $1
+    AND (
+      (seed_ratio_target <= 0 AND seed_min_minutes <= 0)
+      OR (seed_ratio_target > 0 AND seed_ratio >= seed_ratio_target)
+      OR (seed_min_minutes > 0 AND seeding_seconds >= seed_min_minutes::BIGINT * 60)
+      OR seed_checked_at IS NULL
+      OR seed_checked_at <= $2
+    )
   ORDER BY source_cleanup_due_at ASC, created_at ASC
   LIMIT 1
   FOR UPDATE SKIP LOCKED
@@ -1050,36 +1382,46 @@ WHERE t.id = picked.id
 	  t.id, t.source_type, t.source_url, t.torrent_name, t.info_hash, t.torrent_file_path, t.qb_torrent_hash,
 	  t.target_chat_id, t.target_parent_id, t.submitted_by, t.estimated_size, t.downloaded_bytes, t.progress,
 	  t.is_private, t.tracker_hosts_json, t.status, t.error, t.started_at, t.finished_at,
-	  t.source_cleanup_policy, t.source_cleanup_due_at, t.source_cleanup_done, t.created_at, t.updated_at
+	  t.source_cleanup_policy, t.source_cleanup_due_at, t.source_cleanup_done,
+	  t.priority, t.paused_from_status, t.preserve_directory_structure,
+	  t.seed_ratio_target, t.seed_min_minutes, t.seed_ratio, t.seeding_seconds, t.seed_checked_at, t.created_at, t.updated_at
 	`
 
 	var (
-		id                  uuid.UUID
-		sourceTypeRaw       string
-		sourceURL           *string
-		torrentName         string
-		infoHash            string
-		torrentFilePath     string
-		qbTorrentHash       *string
-		targetChatID        string
-		targetParentID      *uuid.UUID
-		submittedBy         string
-		estimatedSize       int64
-		downloadedBytes     int64
-		progress            float64
-		isPrivate           bool
-		trackerHostsJSON    string
-		statusRaw           string
-		errMsg              *string
-		startedAt           *time.Time
-		finishedAt          *time.Time
-		sourceCleanupPolicy string
-		sourceCleanupDueAt  *time.Time
-		sourceCleanupDone   bool
-		createdAt           time.Time
-		updatedAt           time.Time
+		id                         uuid.UUID
+		sourceTypeRaw              string
+		sourceURL                  *string
+		torrentName                string
+		infoHash                   string
+		torrentFilePath            string
+		qbTorrentHash              *string
+		targetChatID               string
+		targetParentID             *uuid.UUID
+		submittedBy                string
+		estimatedSize              int64
+		downloadedBytes            int64
+		progress                   float64
+		isPrivate                  bool
+		trackerHostsJSON           string
+		statusRaw                  string
+		errMsg                     *string
+		startedAt                  *time.Time
+		finishedAt                 *time.Time
+		sourceCleanupPolicy        string
+		sourceCleanupDueAt         *time.Time
+		sourceCleanupDone          bool
+		priority                   int
+		pausedFromStatus           *string
+		preserveDirectoryStructure bool
+		seedRatioTarget            float64
+		seedMinMinutes             int
+		seedRatio                  float64
+		seedingSeconds             int64
+		seedCheckedAt              *time.Time
+		createdAt                  time.Time
+		updatedAt                  time.Time
 	)
-	if err := s.db.QueryRow(ctx, q, now).Scan(
+	if err := s.db.QueryRow(ctx, q, now, now.Add(-TorrentSeedCheckInterval)).Scan(
 		&id,
 		&sourceTypeRaw,
 		&sourceURL,
@@ -1102,6 +1444,14 @@ WHERE t.id = picked.id
 		&sourceCleanupPolicy,
 		&sourceCleanupDueAt,
 		&sourceCleanupDone,
+		&priority,
+		&pausedFromStatus,
+		&preserveDirectoryStructure,
+		&seedRatioTarget,
+		&seedMinMinutes,
+		&seedRatio,
+		&seedingSeconds,
+		&seedCheckedAt,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -1133,6 +1483,14 @@ WHERE t.id = picked.id
 		sourceCleanupPolicy,
 		sourceCleanupDueAt,
 		sourceCleanupDone,
+		priority,
+		pausedFromStatus,
+		preserveDirectoryStructure,
+		seedRatioTarget,
+		seedMinMinutes,
+		seedRatio,
+		seedingSeconds,
+		seedCheckedAt,
 		createdAt,
 		updatedAt,
 	)
@@ -1161,12 +1519,13 @@ func (s *Store) ReplaceTorrentTaskFiles(
 		if _, err := tx.Exec(
 			ctx,
 			`INSERT INTO torrent_task_files(
-  task_id, file_index, file_path, file_name, file_size,
+  task_id, file_index, file_path, relative_path, file_name, file_size,
   selected, uploaded, uploaded_item_id, error, created_at, updated_at
-) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)`,
+) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)`,
 			taskID,
 			file.FileIndex,
 			strings.TrimSpace(file.FilePath),
+			strings.TrimSpace(file.RelativePath),
 			strings.TrimSpace(file.FileName),
 			file.FileSize,
 			file.Selected,
@@ -1190,7 +1549,7 @@ func (s *Store) ListTorrentTaskFiles(ctx context.Context, taskID uuid.UUID) ([]T
 	rows, err := s.db.Query(
 		ctx,
 		`SELECT
-  task_id, file_index, file_path, file_name, file_size,
+  task_id, file_index, file_path, relative_path, file_name, file_size,
   selected, uploaded, uploaded_item_id, error, created_at, updated_at
 FROM torrent_task_files
 WHERE task_id = $1
@@ -1209,6 +1568,7 @@ ORDER BY file_index ASC`,
 			&file.TaskID,
 			&file.FileIndex,
 			&file.FilePath,
+			&file.RelativePath,
 			&file.FileName,
 			&file.FileSize,
 			&file.Selected,
@@ -1308,7 +1668,7 @@ func (s *Store) ListTorrentTaskPendingUploadFiles(ctx context.Context, taskID uu
 	rows, err := s.db.Query(
 		ctx,
 		`SELECT
-  task_id, file_index, file_path, file_name, file_size,
+  task_id, file_index, file_path, relative_path, file_name, file_size,
   selected, uploaded, uploaded_item_id, error, created_at, updated_at
 FROM torrent_task_files
 WHERE task_id = $1
@@ -1329,6 +1689,7 @@ ORDER BY file_index ASC`,
 			&file.TaskID,
 			&file.FileIndex,
 			&file.FilePath,
+			&file.RelativePath,
 			&file.FileName,
 			&file.FileSize,
 			&file.Selected,
@@ -1425,3 +1786,55 @@ func (s *Store) DeleteTorrentTask(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// CountTorrentTasksByStatus 按状态统计任务数，没有任务的状态不会出现在结果中。
+func (s *Store) CountTorrentTasksByStatus(ctx context.Context) (map[TorrentTaskStatus]int64, error) {
+	rows, err := s.db.Query(ctx, `SELECT status, count(*) FROM torrent_tasks GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[TorrentTaskStatus]int64)
+	for rows.Next() {
+		var status TorrentTaskStatus
+		var count int64
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		out[status] = count
+	}
+	return out, rows.Err()
+}
+
+// SetTorrentTaskTraceParent 记录提交任务时请求所在链路的 W3C traceparent，供后台处理接续同一条链路。
+func (s *Store) SetTorrentTaskTraceParent(ctx context.Context, id uuid.UUID, traceParent string) error {
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE torrent_tasks SET trace_parent = NULLIF($2, '') WHERE id = $1`,
+		id,
+		strings.TrimSpace(traceParent),
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetTorrentTaskTraceParent 返回任务记录的 traceparent，未记录时为空字符串。
+func (s *Store) GetTorrentTaskTraceParent(ctx context.Context, id uuid.UUID) (string, error) {
+	var traceParent *string
+	if err := s.db.QueryRow(ctx, `SELECT trace_parent FROM torrent_tasks WHERE id = $1`, id).Scan(&traceParent); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if traceParent == nil {
+		return "", nil
+	}
+	return *traceParent, nil
+}
@@ -239,6 +239,93 @@ type transferJobScanner interface {
 	Scan(dest ...any) error
 }
 
+// FailRunningTransferJobsBySourceKind 将进程内执行、且 staleBefore 之后没有心跳的运行中任务标记为失败：执行它的进程已退出，任务无法恢复。
+func (s *Store) FailRunningTransferJobsBySourceKind(
+	ctx context.Context,
+	sourceKind TransferSourceKind,
+	lastError string,
+	staleBefore time.Time,
+	now time.Time,
+) (int64, error) {
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE transfer_jobs
+SET status = $2,
+    last_error = $3,
+    finished_at = $4,
+    updated_at = $4
+WHERE source_kind = $1 AND status = $5 AND updated_at < $6`,
+		string(sourceKind),
+		string(TransferJobStatusError),
+		lastError,
+		now,
+		string(TransferJobStatusRunning),
+		staleBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// TouchRunningTransferJob 刷新运行中任务的 updated_at，作为执行进程仍存活的心跳；
+// 同时返回其他副本记录的取消请求。任务已不在运行时返回零值。
+func (s *Store) TouchRunningTransferJob(ctx context.Context, id uuid.UUID, now time.Time) (TransferJobCancelRequest, error) {
+	var out TransferJobCancelRequest
+	err := s.db.QueryRow(
+		ctx,
+		`UPDATE transfer_jobs SET updated_at = $2 WHERE id = $1 AND status = $3
+RETURNING cancel_requested_at IS NOT NULL, delete_on_cancel`,
+		id,
+		now,
+		string(TransferJobStatusRunning),
+	).Scan(&out.Requested, &out.Delete)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return TransferJobCancelRequest{}, err
+	}
+	return out, nil
+}
+
+// RequestTransferJobCancel 在运行中的任务上记录取消请求，由执行任务的副本在心跳时处理；
+// deleteAfter 只会由 false 变为 true。任务已不在运行时返回 ErrNotFound。
+func (s *Store) RequestTransferJobCancel(ctx context.Context, id uuid.UUID, deleteAfter bool, now time.Time) error {
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE transfer_jobs
+SET cancel_requested_at = COALESCE(cancel_requested_at, $2),
+    delete_on_cancel = delete_on_cancel OR $3
+WHERE id = $1 AND status = $4`,
+		id,
+		now,
+		deleteAfter,
+		string(TransferJobStatusRunning),
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetTransferJobCancelRequest 读取任务上记录的取消请求，不限任务状态。
+func (s *Store) GetTransferJobCancelRequest(ctx context.Context, id uuid.UUID) (TransferJobCancelRequest, error) {
+	var out TransferJobCancelRequest
+	err := s.db.QueryRow(
+		ctx,
+		`SELECT cancel_requested_at IS NOT NULL, delete_on_cancel FROM transfer_jobs WHERE id = $1`,
+		id,
+	).Scan(&out.Requested, &out.Delete)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return TransferJobCancelRequest{}, ErrNotFound
+		}
+		return TransferJobCancelRequest{}, err
+	}
+	return out, nil
+}
+
 func scanTransferJob(scanner transferJobScanner) (TransferJob, error) {
 	var (
 		out         TransferJob
@@ -67,6 +67,7 @@ type UploadSessionStatus string
 
 const (
 	UploadSessionStatusUploading UploadSessionStatus = "uploading"
+	UploadSessionStatusPaused    UploadSessionStatus = "paused"
 	UploadSessionStatusCompleted UploadSessionStatus = "completed"
 	UploadSessionStatusFailed    UploadSessionStatus = "failed"
 )
@@ -159,10 +160,13 @@ const (
 type TransferSourceKind string
 
 const (
-	TransferSourceKindUploadSession TransferSourceKind = "upload_session"
-	TransferSourceKindUploadBatch   TransferSourceKind = "upload_batch"
-	TransferSourceKindTorrentTask   TransferSourceKind = "torrent_task"
-	TransferSourceKindDownloadTask  TransferSourceKind = "download_task"
+	TransferSourceKindUploadSession  TransferSourceKind = "upload_session"
+	TransferSourceKindUploadBatch    TransferSourceKind = "upload_batch"
+	TransferSourceKindTorrentTask    TransferSourceKind = "torrent_task"
+	TransferSourceKindDownloadTask   TransferSourceKind = "download_task"
+	TransferSourceKindArchiveExtract TransferSourceKind = "archive_extract"
+	TransferSourceKindArchiveCreate  TransferSourceKind = "archive_create"
+	TransferSourceKindTorrentPublish TransferSourceKind = "torrent_publish"
 )
 
 type TransferUnitKind string
@@ -202,6 +206,13 @@ type TransferJob struct {
 	UpdatedAt      time.Time
 }
 
+// TransferJobCancelRequest 为其他副本记录在任务行上的取消请求。
+type TransferJobCancelRequest struct {
+	Requested bool
+	// Delete 表示任务结束后清理产物并删除任务记录。
+	Delete bool
+}
+
 type TorrentSourceType string
 
 const (
@@ -216,41 +227,51 @@ const (
 	TorrentTaskStatusDownloading       TorrentTaskStatus = "downloading"
 	TorrentTaskStatusAwaitingSelection TorrentTaskStatus = "awaiting_selection"
 	TorrentTaskStatusUploading         TorrentTaskStatus = "uploading"
+	TorrentTaskStatusPaused            TorrentTaskStatus = "paused"
 	TorrentTaskStatusCompleted         TorrentTaskStatus = "completed"
 	TorrentTaskStatusError             TorrentTaskStatus = "error"
 )
 
 type TorrentTask struct {
-	ID                  uuid.UUID
-	SourceType          TorrentSourceType
-	SourceURL           *string
-	TorrentName         string
-	InfoHash            string
-	TorrentFilePath     string
-	QBTorrentHash       *string
-	TargetChatID        string
-	TargetParentID      *uuid.UUID
-	SubmittedBy         string
-	EstimatedSize       int64
-	DownloadedBytes     int64
-	Progress            float64
-	IsPrivate           bool
-	TrackerHosts        []string
-	Status              TorrentTaskStatus
-	Error               *string
-	StartedAt           *time.Time
-	FinishedAt          *time.Time
-	SourceCleanupPolicy string
-	SourceCleanupDueAt  *time.Time
-	SourceCleanupDone   bool
-	CreatedAt           time.Time
-	UpdatedAt           time.Time
+	ID                         uuid.UUID
+	SourceType                 TorrentSourceType
+	SourceURL                  *string
+	TorrentName                string
+	InfoHash                   string
+	TorrentFilePath            string
+	QBTorrentHash              *string
+	TargetChatID               string
+	TargetParentID             *uuid.UUID
+	SubmittedBy                string
+	EstimatedSize              int64
+	DownloadedBytes            int64
+	Progress                   float64
+	IsPrivate                  bool
+	TrackerHosts               []string
+	Status                     TorrentTaskStatus
+	Error                      *string
+	StartedAt                  *time.Time
+	FinishedAt                 *time.Time
+	SourceCleanupPolicy        string
+	SourceCleanupDueAt         *time.Time
+	SourceCleanupDone          bool
+	Priority                   int
+	PausedFromStatus           *TorrentTaskStatus
+	PreserveDirectoryStructure bool
+	SeedRatioTarget            float64
+	SeedMinMinutes             int
+	SeedRatio                  float64
+	SeedingSeconds             int64
+	SeedCheckedAt              *time.Time
+	CreatedAt                  time.Time
+	UpdatedAt                  time.Time
 }
 
 type TorrentTaskFile struct {
 	TaskID         uuid.UUID
 	FileIndex      int
 	FilePath       string
+	RelativePath   string
 	FileName       string
 	FileSize       int64
 	Selected       bool
@@ -272,9 +293,20 @@ type TelegramDeleteFailure struct {
 	Resolved    bool
 	FailedAt    time.Time
 	LastRetryAt time.Time
+	NextRetryAt *time.Time
 	ResolvedAt  *time.Time
+	Resolution  *TelegramDeleteResolution
 }
 
+// TelegramDeleteResolution 记录失败项的结束方式：重试删除成功、替换为占位内容，或人工忽略。
+type TelegramDeleteResolution string
+
+const (
+	TelegramDeleteResolutionDeleted   TelegramDeleteResolution = "deleted"
+	TelegramDeleteResolutionReplaced  TelegramDeleteResolution = "replaced"
+	TelegramDeleteResolutionDismissed TelegramDeleteResolution = "dismissed"
+)
+
 type View string
 
 const (
@@ -297,6 +329,10 @@ const (
 	SortByDate SortBy = "date"
 	SortBySize SortBy = "size"
 	SortByType SortBy = "type"
+
+	SortByDuration   SortBy = "duration"
+	SortByResolution SortBy = "resolution"
+	SortByTakenAt    SortBy = "takenAt"
 )
 
 type SortOrder string
@@ -314,4 +350,18 @@ type ListParams struct {
 	SortOrder SortOrder
 	Page      int
 	PageSize  int
+	Metadata  MetadataFilter
+}
+
+// MetadataFilter 按 item_metadata 过滤，零值字段不生效。
+type MetadataFilter struct {
+	MinDurationSeconds *float64
+	MaxDurationSeconds *float64
+	MinWidth           *int
+	MinHeight          *int
+	VideoCodec         string
+	Camera             string
+	TakenFrom          *time.Time
+	TakenTo            *time.Time
+	HasGPS             *bool
 }
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TryAcquireUploadChunkLock 尝试以 holder 身份持有分片锁 lease 时长；锁被他人持有且未过期时返回 false。
+// 过期判断与到期时间都以数据库时钟为准，避免副本之间的时钟偏差导致提前接管。
+func (s *Store) TryAcquireUploadChunkLock(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	chunkIndex int,
+	holder uuid.UUID,
+	lease time.Duration,
+) (bool, error) {
+	var got uuid.UUID
+	err := s.db.QueryRow(ctx, `
+INSERT INTO upload_chunk_locks(session_id, chunk_index, holder, expires_at, acquired_at)
+VALUES ($1, $2, $3, now() + $4 * interval '1 millisecond', now())
+ON CONFLICT (session_id, chunk_index) DO UPDATE
+SET holder = EXCLUDED.holder,
+    expires_at = EXCLUDED.expires_at,
+    acquired_at = EXCLUDED.acquired_at
+WHERE upload_chunk_locks.expires_at <= now()
+RETURNING holder`, sessionID, chunkIndex, holder, lease.Milliseconds()).Scan(&got)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return got == holder, nil
+}
+
+// ExtendUploadChunkLock 把租约续到数据库当前时间之后 lease；锁已不属于 holder 或已过期时返回 ErrNotFound。
+func (s *Store) ExtendUploadChunkLock(
+	ctx context.Context,
+	sessionID uuid.UUID,
+	chunkIndex int,
+	holder uuid.UUID,
+	lease time.Duration,
+) error {
+	ct, err := s.db.Exec(ctx, `
+UPDATE upload_chunk_locks
+SET expires_at = now() + $4 * interval '1 millisecond'
+WHERE session_id = $1 AND chunk_index = $2 AND holder = $3 AND expires_at > now()`, sessionID, chunkIndex, holder, lease.Milliseconds())
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) ReleaseUploadChunkLock(ctx context.Context, sessionID uuid.UUID, chunkIndex int, holder uuid.UUID) error {
+	_, err := s.db.Exec(ctx, `
+DELETE FROM upload_chunk_locks
+WHERE session_id = $1 AND chunk_index = $2 AND holder = $3`, sessionID, chunkIndex, holder)
+	return err
+}
+
+// PurgeExpiredUploadChunkLocks 删除已过期的锁记录（持有者异常退出后遗留）。
+func (s *Store) PurgeExpiredUploadChunkLocks(ctx context.Context) (int64, error) {
+	ct, err := s.db.Exec(ctx, `DELETE FROM upload_chunk_locks WHERE expires_at < now()`)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
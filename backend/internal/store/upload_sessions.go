@@ -111,6 +111,60 @@ func (s *Store) SetUploadSessionStatus(ctx context.Context, id uuid.UUID, status
 	return nil
 }
 
+// TouchUploadSessionUploading 记录分片活动；分片上传期间被暂停的会话保持暂停。
+func (s *Store) TouchUploadSessionUploading(ctx context.Context, id uuid.UUID, now time.Time) error {
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE upload_sessions
+SET status = CASE WHEN status = $2 THEN status ELSE $3 END,
+    updated_at = $4
+WHERE id = $1`,
+		id,
+		string(UploadSessionStatusPaused),
+		string(UploadSessionStatusUploading),
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// TransitionUploadSessionStatus 仅当会话处于 from 状态时切换到 to。
+func (s *Store) TransitionUploadSessionStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	from UploadSessionStatus,
+	to UploadSessionStatus,
+	now time.Time,
+) error {
+	ct, err := s.db.Exec(
+		ctx,
+		`UPDATE upload_sessions SET status = $3, updated_at = $4 WHERE id = $1 AND status = $2`,
+		id,
+		string(from),
+		string(to),
+		now,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM upload_sessions WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
 func (s *Store) SetUploadSessionProgress(ctx context.Context, id uuid.UUID, uploadedChunks int, now time.Time) error {
 	ct, err := s.db.Exec(
 		ctx,
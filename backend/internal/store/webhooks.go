@@ -0,0 +1,433 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+type WebhookSubscription struct {
+	ID        uuid.UUID
+	Name      string
+	URL       string
+	Secret    string
+	Events    []string
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WebhookSubscriptionPatch struct {
+	Name    *string
+	URL     *string
+	Secret  *string
+	Events  []string
+	Enabled *bool
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID
+	SubscriptionID uuid.UUID
+	EventID        uuid.UUID
+	EventType      string
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode *int
+	LastError      *string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// WebhookDeliveryAttempt 描述一次投递尝试的结果；NextAttemptAt 仅在 Status 为 pending 时有意义。
+type WebhookDeliveryAttempt struct {
+	Status        WebhookDeliveryStatus
+	StatusCode    *int
+	Error         string
+	NextAttemptAt *time.Time
+	At            time.Time
+}
+
+type WebhookDeliveryListParams struct {
+	SubscriptionID *uuid.UUID
+	Status         *WebhookDeliveryStatus
+	EventType      string
+	Page           int
+	PageSize       int
+}
+
+const webhookSubscriptionColumns = `
+  id,
+  name,
+  url,
+  secret,
+  events,
+  enabled,
+  created_at,
+  updated_at`
+
+const webhookDeliveryColumns = `
+  id,
+  subscription_id,
+  event_id,
+  event_type,
+  payload,
+  status,
+  attempts,
+  next_attempt_at,
+  last_status_code,
+  last_error,
+  created_at,
+  updated_at,
+  delivered_at`
+
+func scanWebhookSubscription(row pgx.Row) (WebhookSubscription, error) {
+	var out WebhookSubscription
+	if err := row.Scan(
+		&out.ID,
+		&out.Name,
+		&out.URL,
+		&out.Secret,
+		&out.Events,
+		&out.Enabled,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WebhookSubscription{}, ErrNotFound
+		}
+		return WebhookSubscription{}, err
+	}
+	if out.Events == nil {
+		out.Events = []string{}
+	}
+	return out, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (WebhookDelivery, error) {
+	var (
+		out    WebhookDelivery
+		status string
+	)
+	if err := row.Scan(
+		&out.ID,
+		&out.SubscriptionID,
+		&out.EventID,
+		&out.EventType,
+		&out.Payload,
+		&status,
+		&out.Attempts,
+		&out.NextAttemptAt,
+		&out.LastStatusCode,
+		&out.LastError,
+		&out.CreatedAt,
+		&out.UpdatedAt,
+		&out.DeliveredAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WebhookDelivery{}, ErrNotFound
+		}
+		return WebhookDelivery{}, err
+	}
+	out.Status = WebhookDeliveryStatus(status)
+	return out, nil
+}
+
+func (s *Store) CreateWebhookSubscription(ctx context.Context, sub WebhookSubscription) (WebhookSubscription, error) {
+	sub.Name = strings.TrimSpace(sub.Name)
+	sub.URL = strings.TrimSpace(sub.URL)
+	if sub.URL == "" || sub.Secret == "" || len(sub.Events) == 0 {
+		return WebhookSubscription{}, ErrBadInput
+	}
+	if sub.ID == uuid.Nil {
+		sub.ID = uuid.New()
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+	return scanWebhookSubscription(s.db.QueryRow(ctx, `
+INSERT INTO webhook_subscriptions (id, name, url, secret, events, enabled, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+RETURNING`+webhookSubscriptionColumns,
+		sub.ID,
+		sub.Name,
+		sub.URL,
+		sub.Secret,
+		sub.Events,
+		sub.Enabled,
+		sub.CreatedAt,
+	))
+}
+
+func (s *Store) GetWebhookSubscription(ctx context.Context, id uuid.UUID) (WebhookSubscription, error) {
+	return scanWebhookSubscription(s.db.QueryRow(ctx, `SELECT`+webhookSubscriptionColumns+`
+FROM webhook_subscriptions
+WHERE id = $1`, id))
+}
+
+func (s *Store) ListWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	return s.queryWebhookSubscriptions(ctx, `SELECT`+webhookSubscriptionColumns+`
+FROM webhook_subscriptions
+ORDER BY created_at ASC, id ASC`)
+}
+
+// ListWebhookSubscriptionsForEvent 返回订阅了指定事件且已启用的订阅。
+func (s *Store) ListWebhookSubscriptionsForEvent(ctx context.Context, eventType string) ([]WebhookSubscription, error) {
+	return s.queryWebhookSubscriptions(ctx, `SELECT`+webhookSubscriptionColumns+`
+FROM webhook_subscriptions
+WHERE enabled = TRUE AND $1 = ANY(events)
+ORDER BY created_at ASC, id ASC`, eventType)
+}
+
+func (s *Store) queryWebhookSubscriptions(ctx context.Context, query string, args ...any) ([]WebhookSubscription, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]WebhookSubscription, 0)
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, rows.Err()
+}
+
+// UpdateWebhookSubscription 按 patch 中非空字段更新订阅；Events 为 nil 表示不修改。
+func (s *Store) UpdateWebhookSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	patch WebhookSubscriptionPatch,
+	now time.Time,
+) (WebhookSubscription, error) {
+	if patch.Events != nil && len(patch.Events) == 0 {
+		return WebhookSubscription{}, ErrBadInput
+	}
+	return scanWebhookSubscription(s.db.QueryRow(ctx, `
+UPDATE webhook_subscriptions
+SET name = COALESCE($2, name),
+    url = COALESCE($3, url),
+    secret = COALESCE($4, secret),
+    events = COALESCE($5, events),
+    enabled = COALESCE($6, enabled),
+    updated_at = $7
+WHERE id = $1
+RETURNING`+webhookSubscriptionColumns,
+		id,
+		patch.Name,
+		patch.URL,
+		patch.Secret,
+		patch.Events,
+		patch.Enabled,
+		now,
+	))
+}
+
+// DeleteWebhookSubscription 删除订阅及其全部投递记录。
+func (s *Store) DeleteWebhookSubscription(ctx context.Context, id uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) InsertWebhookDeliveries(ctx context.Context, deliveries []WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	batch := &pgx.Batch{}
+	for _, d := range deliveries {
+		if d.ID == uuid.Nil {
+			d.ID = uuid.New()
+		}
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = time.Now()
+		}
+		if d.Status == "" {
+			d.Status = WebhookDeliveryStatusPending
+		}
+		nextAttemptAt := d.NextAttemptAt
+		if nextAttemptAt == nil && d.Status == WebhookDeliveryStatusPending {
+			nextAttemptAt = &d.CreatedAt
+		}
+		batch.Queue(`
+INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $8)`,
+			d.ID,
+			d.SubscriptionID,
+			d.EventID,
+			d.EventType,
+			d.Payload,
+			string(d.Status),
+			nextAttemptAt,
+			d.CreatedAt,
+		)
+	}
+	return s.db.SendBatch(ctx, batch).Close()
+}
+
+// ClaimDueWebhookDeliveries 领取到期的待投递记录，并把下次尝试时间推后 lease，避免并发的投递循环重复发送。
+func (s *Store) ClaimDueWebhookDeliveries(
+	ctx context.Context,
+	now time.Time,
+	lease time.Duration,
+	limit int,
+) ([]WebhookDelivery, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.Query(ctx, `
+UPDATE webhook_deliveries
+SET next_attempt_at = $2
+WHERE id IN (
+  SELECT id
+  FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= $1
+  ORDER BY next_attempt_at ASC, id ASC
+  LIMIT $3
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING`+webhookDeliveryColumns, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, delivery)
+	}
+	return out, rows.Err()
+}
+
+// RecordWebhookDeliveryAttempt 记录一次投递结果并累加尝试次数。
+func (s *Store) RecordWebhookDeliveryAttempt(
+	ctx context.Context,
+	id uuid.UUID,
+	attempt WebhookDeliveryAttempt,
+) (WebhookDelivery, error) {
+	var (
+		lastError   *string
+		deliveredAt *time.Time
+	)
+	if msg := strings.TrimSpace(attempt.Error); msg != "" {
+		lastError = &msg
+	}
+	if attempt.Status == WebhookDeliveryStatusSucceeded {
+		deliveredAt = &attempt.At
+	}
+	nextAttemptAt := attempt.NextAttemptAt
+	if attempt.Status != WebhookDeliveryStatusPending {
+		nextAttemptAt = nil
+	}
+	return scanWebhookDelivery(s.db.QueryRow(ctx, `
+UPDATE webhook_deliveries
+SET status = $2,
+    attempts = attempts + 1,
+    next_attempt_at = $3,
+    last_status_code = $4,
+    last_error = $5,
+    delivered_at = $6,
+    updated_at = $7
+WHERE id = $1
+RETURNING`+webhookDeliveryColumns,
+		id,
+		string(attempt.Status),
+		nextAttemptAt,
+		attempt.StatusCode,
+		lastError,
+		deliveredAt,
+		attempt.At,
+	))
+}
+
+func (s *Store) GetWebhookDelivery(ctx context.Context, id uuid.UUID) (WebhookDelivery, error) {
+	return scanWebhookDelivery(s.db.QueryRow(ctx, `SELECT`+webhookDeliveryColumns+`
+FROM webhook_deliveries
+WHERE id = $1`, id))
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, params WebhookDeliveryListParams) ([]WebhookDelivery, int64, error) {
+	page := normalizeTransferQueryPage(params.Page)
+	pageSize := normalizeTransferQueryPageSize(params.PageSize)
+
+	where := []string{"1=1"}
+	args := make([]any, 0, 5)
+	addArg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if params.SubscriptionID != nil {
+		where = append(where, "subscription_id = "+addArg(*params.SubscriptionID))
+	}
+	if params.Status != nil {
+		where = append(where, "status = "+addArg(string(*params.Status)))
+	}
+	if eventType := strings.TrimSpace(params.EventType); eventType != "" {
+		where = append(where, "event_type = "+addArg(eventType))
+	}
+	whereSQL := strings.Join(where, " AND ")
+
+	var total int64
+	if err := s.db.QueryRow(ctx, `SELECT count(*) FROM webhook_deliveries WHERE `+whereSQL, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limitArg := addArg(pageSize)
+	offsetArg := addArg((page - 1) * pageSize)
+	rows, err := s.db.Query(ctx, `SELECT`+webhookDeliveryColumns+`
+FROM webhook_deliveries
+WHERE `+whereSQL+`
+ORDER BY created_at DESC, id DESC
+LIMIT `+limitArg+` OFFSET `+offsetArg, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		out = append(out, delivery)
+	}
+	return out, total, rows.Err()
+}
+
+// PurgeWebhookDeliveriesBefore 删除早于 cutoff 的已结束投递记录，待投递的记录不受影响。
+func (s *Store) PurgeWebhookDeliveriesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := s.db.Exec(ctx, `DELETE FROM webhook_deliveries WHERE status <> 'pending' AND updated_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -16,12 +16,15 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"tg-cloud-drive-api/internal/tracing"
 )
 
 type Client struct {
-	token   string
-	http    *http.Client
-	baseURL string
+	token    string
+	http     *http.Client
+	baseURL  string
+	observer CallObserver
 }
 
 type ClientOption func(*Client)
@@ -51,6 +54,25 @@ func WithBaseURL(raw string) ClientOption {
 	}
 }
 
+// CallStats 描述一次 Bot API 调用的结果；Err 仅在网络或解析失败时非空。
+type CallStats struct {
+	Method     string
+	OK         bool
+	ErrorCode  int
+	RetryAfter time.Duration
+	Duration   time.Duration
+	Err        error
+}
+
+// CallObserver 接收每次 Bot API 调用的结果，用于指标统计；会在调用方的 goroutine 中同步执行。
+type CallObserver func(CallStats)
+
+func WithCallObserver(observer CallObserver) ClientOption {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
 func (c *Client) apiURL(method string) string {
 	return SafeJoinURL(c.baseURL, path.Join("bot"+c.token, method))
 }
@@ -798,16 +820,34 @@ func (c *Client) doJSON(ctx context.Context, method string, endpoint string, bod
 }
 
 func (c *Client) doHTTP(req *http.Request, out any) error {
+	method := path.Base(req.URL.Path)
+	// 请求地址包含 bot token，span 名称只用方法名；传输错误经 RecordError 去掉请求地址后再记录。
+	_, span := tracing.StartChild(req.Context(), tracing.KindClient, "telegram "+method, tracing.String("telegram.method", method))
+	startedAt := time.Now()
+	b, err := c.readResponse(req)
+	if err == nil {
+		err = decodeResponse(b, out)
+	}
+	if c.observer != nil || span != nil {
+		stats := newCallStats(method, b, time.Since(startedAt), err)
+		if c.observer != nil {
+			c.observer(stats)
+		}
+		endCallSpan(span, stats)
+	}
+	return err
+}
+
+func (c *Client) readResponse(req *http.Request) ([]byte, error) {
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+}
 
-	b, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
-	if err != nil {
-		return err
-	}
+func decodeResponse(b []byte, out any) error {
 	if err := json.Unmarshal(b, out); err != nil {
 		// 保留原始错误上下文，便于排障
 		return fmt.Errorf("解析 Telegram 响应失败: %w", err)
@@ -815,6 +855,33 @@ func (c *Client) doHTTP(req *http.Request, out any) error {
 	return nil
 }
 
+func newCallStats(method string, body []byte, duration time.Duration, err error) CallStats {
+	stats := CallStats{Method: method, Duration: duration, Err: err}
+	if err == nil {
+		var status apiResponse[json.RawMessage]
+		if json.Unmarshal(body, &status) == nil {
+			stats.OK = status.OK
+			stats.ErrorCode = status.ErrorCode
+			stats.RetryAfter = time.Duration(status.Parameters.RetryAfter) * time.Second
+		}
+	}
+	return stats
+}
+
+func endCallSpan(span *tracing.Span, stats CallStats) {
+	if span == nil {
+		return
+	}
+	switch {
+	case stats.Err != nil:
+		span.RecordError(stats.Err)
+	case !stats.OK:
+		span.SetAttributes(tracing.Int("telegram.error_code", stats.ErrorCode))
+		span.SetError(fmt.Sprintf("telegram error_code=%d", stats.ErrorCode))
+	}
+	span.End()
+}
+
 func SafeJoinURL(base string, p string) string {
 	u, err := url.Parse(base)
 	if err != nil {
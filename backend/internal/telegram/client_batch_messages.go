@@ -0,0 +1,80 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type messageIDResult struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// ForwardMessages 批量转发（最多 100 条），返回新消息的 ID；不存在或不可转发的消息会被 Telegram 直接跳过，
+// 因此返回数量可能少于请求数量。全部不存在时返回空切片。
+func (c *Client) ForwardMessages(ctx context.Context, toChatID string, fromChatID string, messageIDs []int64) ([]int64, error) {
+	if len(messageIDs) == 0 {
+		return []int64{}, nil
+	}
+	body := map[string]any{
+		"chat_id":              toChatID,
+		"from_chat_id":         fromChatID,
+		"message_ids":          messageIDs,
+		"disable_notification": true,
+	}
+	var out apiResponse[[]messageIDResult]
+	if err := c.doJSON(ctx, http.MethodPost, c.apiURL("forwardMessages"), body, &out); err != nil {
+		return nil, err
+	}
+	if !out.OK {
+		if out.ErrorCode == 429 && out.Parameters.RetryAfter > 0 {
+			return nil, RetryAfterError{After: time.Duration(out.Parameters.RetryAfter) * time.Second, Message: out.Description}
+		}
+		if out.ErrorCode == 400 && isMissingForwardMessageError(out.Description) {
+			return []int64{}, nil
+		}
+		return nil, fmt.Errorf("forwardMessages 失败: %s", out.Description)
+	}
+	ids := make([]int64, 0, len(out.Result))
+	for _, item := range out.Result {
+		ids = append(ids, item.MessageID)
+	}
+	return ids, nil
+}
+
+// DeleteMessages 批量删除（最多 100 条），已不存在的消息会被跳过。
+func (c *Client) DeleteMessages(ctx context.Context, chatID string, messageIDs []int64) error {
+	if len(messageIDs) == 0 {
+		return nil
+	}
+	body := map[string]any{
+		"chat_id":     chatID,
+		"message_ids": messageIDs,
+	}
+	var out apiResponse[bool]
+	if err := c.doJSON(ctx, http.MethodPost, c.apiURL("deleteMessages"), body, &out); err != nil {
+		return err
+	}
+	if !out.OK {
+		if out.ErrorCode == 429 && out.Parameters.RetryAfter > 0 {
+			return RetryAfterError{After: time.Duration(out.Parameters.RetryAfter) * time.Second, Message: out.Description}
+		}
+		if out.ErrorCode == 400 && isIgnorableDeleteMessageError(out.Description) {
+			return nil
+		}
+		return fmt.Errorf("deleteMessages 失败: %s", out.Description)
+	}
+	return nil
+}
+
+func isMissingForwardMessageError(desc string) bool {
+	desc = strings.ToLower(strings.TrimSpace(desc))
+	if desc == "" {
+		return false
+	}
+	return strings.Contains(desc, "message to forward not found") ||
+		strings.Contains(desc, "there are no messages to forward") ||
+		strings.Contains(desc, "message_id_invalid")
+}
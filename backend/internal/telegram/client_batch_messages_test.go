@@ -0,0 +1,45 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestForwardMessages_ReturnsNewMessageIDs(t *testing.T) {
+	t.Parallel()
+
+	client := testTelegramClient(t, `{"ok":true,"result":[{"message_id":501},{"message_id":502}]}`)
+	ids, err := client.ForwardMessages(context.Background(), "-100123", "-100123", []int64{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{501, 502}) {
+		t.Fatalf("ids = %v", ids)
+	}
+}
+
+func TestForwardMessages_TreatsMissingMessagesAsEmpty(t *testing.T) {
+	t.Parallel()
+
+	client := testTelegramClient(t, `{"ok":false,"error_code":400,"description":"Bad Request: message to forward not found"}`)
+	ids, err := client.ForwardMessages(context.Background(), "-100123", "-100123", []int64{7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("ids = %v, want empty", ids)
+	}
+}
+
+func TestDeleteMessages_ReturnsRetryAfterErrorOnRateLimit(t *testing.T) {
+	t.Parallel()
+
+	client := testTelegramClient(t, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":3}}`)
+	err := client.DeleteMessages(context.Background(), "-100123", []int64{1, 2})
+	var retryErr RetryAfterError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected RetryAfterError, got %T (%v)", err, err)
+	}
+}
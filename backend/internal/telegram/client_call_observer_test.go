@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallObserver_ReportsMethodAndRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Body:       io.NopCloser(strings.NewReader(`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":7}}`)),
+				Header:     make(http.Header),
+				Request:    req,
+			}, nil
+		}),
+	}
+	var got []CallStats
+	client := NewClient("test-token", httpClient, WithCallObserver(func(stats CallStats) {
+		got = append(got, stats)
+	}))
+
+	if err := client.DeleteMessage(context.Background(), "-100123", 1); err == nil {
+		t.Fatalf("expected rate limit error")
+	}
+	if len(got) != 1 {
+		t.Fatalf("observed calls = %d, want 1", len(got))
+	}
+	stats := got[0]
+	if stats.Method != "deleteMessage" || stats.OK || stats.ErrorCode != 429 || stats.RetryAfter != 7*time.Second || stats.Err != nil {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,40 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SendMessageOptions 控制文本消息的格式与提醒方式；ParseMode 为空时按纯文本发送。
+type SendMessageOptions struct {
+	ParseMode           string
+	DisableNotification bool
+}
+
+// SendMessage 向会话发送一条文本消息（最长 4096 个字符），遇到限流时返回 RetryAfterError。
+func (c *Client) SendMessage(ctx context.Context, chatID string, text string, options SendMessageOptions) (Message, error) {
+	body := map[string]any{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	if options.ParseMode != "" {
+		body["parse_mode"] = options.ParseMode
+	}
+	if options.DisableNotification {
+		body["disable_notification"] = true
+	}
+	var out apiResponse[Message]
+	if err := c.doJSON(ctx, http.MethodPost, c.apiURL("sendMessage"), body, &out); err != nil {
+		return Message{}, err
+	}
+	if !out.OK {
+		if out.ErrorCode == 429 && out.Parameters.RetryAfter > 0 {
+			return Message{}, RetryAfterError{After: time.Duration(out.Parameters.RetryAfter) * time.Second, Message: out.Description}
+		}
+		return Message{}, fmt.Errorf("sendMessage 失败: %s", out.Description)
+	}
+	return out.Result, nil
+}
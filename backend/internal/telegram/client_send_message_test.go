@@ -0,0 +1,55 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSendMessage_PostsTextAndOptions(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !strings.HasSuffix(req.URL.Path, "/sendMessage") {
+				t.Errorf("path = %s", req.URL.Path)
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Errorf("decode body: %v", err)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"ok":true,"result":{"message_id":42}}`)),
+				Header:     make(http.Header),
+				Request:    req,
+			}, nil
+		}),
+	}
+	client := NewClient("test-token", httpClient)
+	msg, err := client.SendMessage(context.Background(), "12345", "<b>hi</b>", SendMessageOptions{ParseMode: "HTML", DisableNotification: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.MessageID != 42 {
+		t.Fatalf("message id = %d", msg.MessageID)
+	}
+	if body["chat_id"] != "12345" || body["text"] != "<b>hi</b>" || body["parse_mode"] != "HTML" || body["disable_notification"] != true {
+		t.Fatalf("unexpected body: %#v", body)
+	}
+}
+
+func TestSendMessage_ReturnsRetryAfterErrorOnRateLimit(t *testing.T) {
+	t.Parallel()
+
+	client := testTelegramClient(t, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":5}}`)
+	_, err := client.SendMessage(context.Background(), "12345", "hi", SendMessageOptions{})
+	var retryErr RetryAfterError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected RetryAfterError, got %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"tg-cloud-drive-api/internal/tracing"
+)
+
+// TestDoHTTP_SpanErrorOmitsBotToken 会修改全局 tracer，因此不并行执行。
+func TestDoHTTP_SpanErrorOmitsBotToken(t *testing.T) {
+	const token = "123456:secret-bot-token"
+
+	var (
+		mu       sync.Mutex
+		messages []string
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						Name   string `json:"name"`
+						Status *struct {
+							Message string `json:"message"`
+						} `json:"status"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					if span.Status != nil {
+						messages = append(messages, span.Status.Message)
+					}
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	shutdown := tracing.Init(tracing.Config{Endpoint: collector.URL, SampleRatio: 1})
+	httpClient := &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset by peer")
+		}),
+	}
+	client := NewClient(token, httpClient)
+
+	ctx, root := tracing.Start(context.Background(), tracing.KindServer, "DELETE /api/items")
+	err := client.DeleteMessage(ctx, "-100123", 1)
+	root.End()
+	if shutdownErr := shutdown(context.Background()); shutdownErr != nil {
+		t.Fatalf("shutdown: %v", shutdownErr)
+	}
+	if err == nil {
+		t.Fatalf("expected transport error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(messages) == 0 {
+		t.Fatalf("no span status exported")
+	}
+	for _, msg := range messages {
+		if strings.Contains(msg, token) || strings.Contains(msg, "secret-bot-token") {
+			t.Fatalf("span status message leaks bot token: %q", msg)
+		}
+	}
+	found := false
+	for _, msg := range messages {
+		if strings.Contains(msg, "connection reset by peer") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("span status messages = %q, want transport error", messages)
+	}
+}
@@ -0,0 +1,95 @@
+package torrent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// EncodeBencode 将值编码为 bencode。
+// 支持 int/int64、string、[]byte、[]string、[]any、map[string]any；字典键按字节序排序。
+func EncodeBencode(w io.Writer, v any) error {
+	return encodeBencodeValue(w, v, 0)
+}
+
+func MarshalBencode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := EncodeBencode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeBencodeValue(w io.Writer, v any, depth int) error {
+	if depth > maxBencodeDepth {
+		return fmt.Errorf("bencode 嵌套层级过深")
+	}
+	switch val := v.(type) {
+	case int:
+		return writeBencodeInt(w, int64(val))
+	case int64:
+		return writeBencodeInt(w, val)
+	case string:
+		return writeBencodeBytes(w, []byte(val))
+	case []byte:
+		return writeBencodeBytes(w, val)
+	case []string:
+		if _, err := io.WriteString(w, "l"); err != nil {
+			return err
+		}
+		for _, item := range val {
+			if err := writeBencodeBytes(w, []byte(item)); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "e")
+		return err
+	case []any:
+		if _, err := io.WriteString(w, "l"); err != nil {
+			return err
+		}
+		for _, item := range val {
+			if err := encodeBencodeValue(w, item, depth+1); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "e")
+		return err
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if _, err := io.WriteString(w, "d"); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := writeBencodeBytes(w, []byte(key)); err != nil {
+				return err
+			}
+			if err := encodeBencodeValue(w, val[key], depth+1); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "e")
+		return err
+	default:
+		return fmt.Errorf("bencode 不支持的类型: %T", v)
+	}
+}
+
+func writeBencodeInt(w io.Writer, v int64) error {
+	_, err := io.WriteString(w, "i"+strconv.FormatInt(v, 10)+"e")
+	return err
+}
+
+func writeBencodeBytes(w io.Writer, b []byte) error {
+	if _, err := io.WriteString(w, strconv.Itoa(len(b))+":"); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,195 @@
+package torrent
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strings"
+	"time"
+)
+
+const (
+	minPieceLength    int64 = 256 << 10
+	maxPieceLength    int64 = 16 << 20
+	targetPieceCount  int64 = 1500
+	defaultCreatedBy        = "tg-cloud-drive"
+	sha1PieceHashSize       = sha1.Size
+)
+
+// ChoosePieceLength 按总大小选择 2 的幂分片长度，使分片数量接近 targetPieceCount。
+func ChoosePieceLength(totalSize int64) int64 {
+	length := minPieceLength
+	for length < maxPieceLength && totalSize/length > targetPieceCount {
+		length *= 2
+	}
+	return length
+}
+
+// PieceHasher 以 io.Writer 形式接收连续内容（多文件按顺序拼接），按固定分片长度计算 SHA-1。
+type PieceHasher struct {
+	pieceLength int64
+	current     hash.Hash
+	filled      int64
+	written     int64
+	pieces      []byte
+}
+
+func NewPieceHasher(pieceLength int64) (*PieceHasher, error) {
+	if pieceLength <= 0 {
+		return nil, errors.New("分片长度必须大于 0")
+	}
+	return &PieceHasher{
+		pieceLength: pieceLength,
+		current:     sha1.New(),
+	}, nil
+}
+
+func (h *PieceHasher) Write(p []byte) (int, error) {
+	total := len(p)
+	for len(p) > 0 {
+		need := h.pieceLength - h.filled
+		n := int64(len(p))
+		if n > need {
+			n = need
+		}
+		_, _ = h.current.Write(p[:n])
+		h.filled += n
+		h.written += n
+		p = p[n:]
+		if h.filled == h.pieceLength {
+			h.pieces = h.current.Sum(h.pieces)
+			h.current.Reset()
+			h.filled = 0
+		}
+	}
+	return total, nil
+}
+
+// Written 返回已写入的字节数。
+func (h *PieceHasher) Written() int64 {
+	return h.written
+}
+
+// Pieces 返回全部分片哈希（包含末尾不足一个分片的部分）。
+func (h *PieceHasher) Pieces() []byte {
+	out := make([]byte, len(h.pieces), len(h.pieces)+sha1PieceHashSize)
+	copy(out, h.pieces)
+	if h.filled > 0 {
+		out = h.current.Sum(out)
+	}
+	return out
+}
+
+type CreateFileEntry struct {
+	// Path 为相对路径片段；单文件 torrent 时忽略
+	Path   []string
+	Length int64
+}
+
+type CreateOptions struct {
+	Name         string
+	PieceLength  int64
+	Pieces       []byte
+	Files        []CreateFileEntry
+	MultiFile    bool
+	Announce     []string
+	URLList      []string
+	Private      bool
+	Comment      string
+	CreatedBy    string
+	CreationDate time.Time
+}
+
+// BuildTorrent 生成 .torrent 内容并返回 v1 info hash（小写十六进制）。
+func BuildTorrent(opts CreateOptions) ([]byte, string, error) {
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		return nil, "", errors.New("torrent 名称不能为空")
+	}
+	if opts.PieceLength <= 0 {
+		return nil, "", errors.New("分片长度必须大于 0")
+	}
+	if len(opts.Files) == 0 {
+		return nil, "", errors.New("torrent 至少需要一个文件")
+	}
+	if !opts.MultiFile && len(opts.Files) != 1 {
+		return nil, "", errors.New("单文件 torrent 只能包含一个文件")
+	}
+
+	var total int64
+	for _, file := range opts.Files {
+		if file.Length < 0 {
+			return nil, "", errors.New("文件大小非法")
+		}
+		total += file.Length
+	}
+	expectedPieces := (total + opts.PieceLength - 1) / opts.PieceLength
+	if int64(len(opts.Pieces)) != expectedPieces*sha1PieceHashSize {
+		return nil, "", errors.New("分片哈希数量与文件大小不一致")
+	}
+
+	info := map[string]any{
+		"name":         name,
+		"piece length": opts.PieceLength,
+		"pieces":       opts.Pieces,
+	}
+	if opts.Private {
+		info["private"] = 1
+	}
+	if opts.MultiFile {
+		files := make([]any, 0, len(opts.Files))
+		for _, file := range opts.Files {
+			if len(file.Path) == 0 {
+				return nil, "", errors.New("多文件 torrent 的文件路径不能为空")
+			}
+			files = append(files, map[string]any{
+				"length": file.Length,
+				"path":   file.Path,
+			})
+		}
+		info["files"] = files
+	} else {
+		info["length"] = opts.Files[0].Length
+	}
+
+	infoBytes, err := MarshalBencode(info)
+	if err != nil {
+		return nil, "", err
+	}
+	infoHash := sha1.Sum(infoBytes)
+
+	root := map[string]any{
+		"info": info,
+	}
+	if len(opts.Announce) > 0 {
+		root["announce"] = opts.Announce[0]
+		if len(opts.Announce) > 1 {
+			tiers := make([]any, 0, len(opts.Announce))
+			for _, announce := range opts.Announce {
+				tiers = append(tiers, []string{announce})
+			}
+			root["announce-list"] = tiers
+		}
+	}
+	if len(opts.URLList) > 0 {
+		root["url-list"] = opts.URLList
+	}
+	if comment := strings.TrimSpace(opts.Comment); comment != "" {
+		root["comment"] = comment
+	}
+	createdBy := strings.TrimSpace(opts.CreatedBy)
+	if createdBy == "" {
+		createdBy = defaultCreatedBy
+	}
+	root["created by"] = createdBy
+	if !opts.CreationDate.IsZero() {
+		root["creation date"] = opts.CreationDate.Unix()
+	}
+
+	data, err := MarshalBencode(root)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, hex.EncodeToString(infoHash[:]), nil
+}
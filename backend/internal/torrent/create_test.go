@@ -0,0 +1,105 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"testing"
+)
+
+func TestMarshalBencode_SortsDictKeys(t *testing.T) {
+	got, err := MarshalBencode(map[string]any{
+		"b":    1,
+		"a":    "x",
+		"list": []any{int64(-2), []byte("yz")},
+	})
+	if err != nil {
+		t.Fatalf("MarshalBencode() error = %v", err)
+	}
+	want := "d1:a1:x1:bi1e4:listli-2e2:yzee"
+	if string(got) != want {
+		t.Fatalf("MarshalBencode() = %q, want %q", got, want)
+	}
+}
+
+func TestPieceHasher_SplitsAcrossWrites(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 7)
+	hasher, err := NewPieceHasher(16)
+	if err != nil {
+		t.Fatalf("NewPieceHasher() error = %v", err)
+	}
+	_, _ = hasher.Write(data[:5])
+	_, _ = hasher.Write(data[5:40])
+	_, _ = hasher.Write(data[40:])
+
+	var want []byte
+	for start := 0; start < len(data); start += 16 {
+		end := start + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		sum := sha1.Sum(data[start:end])
+		want = append(want, sum[:]...)
+	}
+	if !bytes.Equal(hasher.Pieces(), want) {
+		t.Fatalf("Pieces() mismatch")
+	}
+	if hasher.Written() != int64(len(data)) {
+		t.Fatalf("Written() = %d, want %d", hasher.Written(), len(data))
+	}
+}
+
+func TestBuildTorrent_RoundTripMultiFile(t *testing.T) {
+	hasher, _ := NewPieceHasher(minPieceLength)
+	_, _ = hasher.Write(bytes.Repeat([]byte{1}, 300<<10))
+	_, _ = hasher.Write(bytes.Repeat([]byte{2}, 10))
+
+	data, infoHash, err := BuildTorrent(CreateOptions{
+		Name:        "album",
+		PieceLength: minPieceLength,
+		Pieces:      hasher.Pieces(),
+		MultiFile:   true,
+		Files: []CreateFileEntry{
+			{Path: []string{"disc1", "01.flac"}, Length: 300 << 10},
+			{Path: []string{"cover.jpg"}, Length: 10},
+		},
+		URLList: []string{"https://example.com/ws/token/"},
+	})
+	if err != nil {
+		t.Fatalf("BuildTorrent() error = %v", err)
+	}
+
+	meta, err := ParseMetaInfo(data)
+	if err != nil {
+		t.Fatalf("ParseMetaInfo() error = %v", err)
+	}
+	if meta.InfoHash != infoHash {
+		t.Fatalf("info hash = %s, want %s", meta.InfoHash, infoHash)
+	}
+	if meta.Name != "album" || len(meta.Files) != 2 || meta.Files[0].Path != "disc1/01.flac" {
+		t.Fatalf("unexpected meta: %+v", meta)
+	}
+	if meta.TotalSize != 300<<10+10 {
+		t.Fatalf("total size = %d", meta.TotalSize)
+	}
+}
+
+func TestBuildTorrent_RejectsPieceCountMismatch(t *testing.T) {
+	_, _, err := BuildTorrent(CreateOptions{
+		Name:        "a.bin",
+		PieceLength: 16,
+		Pieces:      make([]byte, sha1.Size),
+		Files:       []CreateFileEntry{{Length: 40}},
+	})
+	if err == nil {
+		t.Fatalf("BuildTorrent() expected error")
+	}
+}
+
+func TestChoosePieceLength(t *testing.T) {
+	if got := ChoosePieceLength(10 << 20); got != minPieceLength {
+		t.Fatalf("ChoosePieceLength(10MB) = %d", got)
+	}
+	if got := ChoosePieceLength(1 << 40); got != maxPieceLength {
+		t.Fatalf("ChoosePieceLength(1TB) = %d", got)
+	}
+}
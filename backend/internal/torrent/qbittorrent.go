@@ -15,6 +15,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"tg-cloud-drive-api/internal/tracing"
 )
 
 type QBittorrentClient struct {
@@ -34,6 +36,8 @@ type QBittorrentTorrentInfo struct {
 	TotalSize   int64   `json:"total_size"`
 	Completed   int64   `json:"completed"`
 	AmountLeft  int64   `json:"amount_left"`
+	Ratio       float64 `json:"ratio"`
+	SeedingTime int64   `json:"seeding_time"`
 }
 
 type QBittorrentTorrentFile struct {
@@ -349,6 +353,54 @@ func (c *QBittorrentClient) DeleteTorrent(ctx context.Context, hash string, dele
 	return nil
 }
 
+// PauseTorrent 暂停下载；qBittorrent 5.x 将 pause 更名为 stop，旧版本回退到 pause。
+func (c *QBittorrentClient) PauseTorrent(ctx context.Context, hash string) error {
+	return c.postTorrentStateChange(ctx, hash, "/api/v2/torrents/stop", "/api/v2/torrents/pause", "暂停")
+}
+
+// ResumeTorrent 恢复下载；qBittorrent 5.x 将 resume 更名为 start，旧版本回退到 resume。
+func (c *QBittorrentClient) ResumeTorrent(ctx context.Context, hash string) error {
+	return c.postTorrentStateChange(ctx, hash, "/api/v2/torrents/start", "/api/v2/torrents/resume", "恢复")
+}
+
+func (c *QBittorrentClient) postTorrentStateChange(
+	ctx context.Context,
+	hash string,
+	endpoint string,
+	legacyEndpoint string,
+	action string,
+) error {
+	hash = strings.TrimSpace(strings.ToLower(hash))
+	if hash == "" {
+		return errors.New("torrent hash 不能为空")
+	}
+	form := url.Values{}
+	form.Set("hashes", hash)
+
+	for _, target := range []string{endpoint, legacyEndpoint} {
+		resp, err := c.doRequest(
+			ctx,
+			http.MethodPost,
+			target,
+			strings.NewReader(form.Encode()),
+			map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s torrent 失败: http %d", action, resp.StatusCode)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s torrent 失败: http %d", action, http.StatusNotFound)
+}
+
 func (c *QBittorrentClient) doRequest(
 	ctx context.Context,
 	method string,
@@ -366,7 +418,25 @@ func (c *QBittorrentClient) doRequest(
 		}
 		req.Header.Set(k, v)
 	}
-	return c.http.Do(req)
+	// 响应体由调用方读取，span 只覆盖到拿到响应头为止。
+	_, span := tracing.StartChild(
+		ctx,
+		tracing.KindClient,
+		"qbittorrent "+req.URL.Path,
+		tracing.String("http.request.method", method),
+		tracing.String("url.path", req.URL.Path),
+	)
+	defer span.End()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	span.SetAttributes(tracing.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetError(resp.Status)
+	}
+	return resp, nil
 }
 
 func (c *QBittorrentClient) joinURL(endpoint string) string {
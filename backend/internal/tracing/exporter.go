@@ -0,0 +1,290 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	exportQueueSize   = 4096
+	exportBatchSize   = 512
+	exportInterval    = 5 * time.Second
+	exportTimeout     = 10 * time.Second
+	scopeName         = "tg-cloud-drive-api"
+	statusCodeError   = 2
+	maxAttrValueBytes = 2048
+)
+
+type spanData struct {
+	sc       SpanContext
+	parent   SpanID
+	kind     Kind
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attr
+	failed   bool
+	errorMsg string
+}
+
+// exporter 在后台 goroutine 中攒批上报；队列满时直接丢弃，不阻塞业务代码。
+type exporter struct {
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	client      *http.Client
+	logger      *slog.Logger
+
+	queue    chan spanData
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	droppedMu sync.Mutex
+	dropped   int64
+}
+
+func newExporter(cfg Config) *exporter {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	serviceName := cfg.ServiceName
+	if serviceName == "" {
+		serviceName = scopeName
+	}
+	return &exporter{
+		endpoint:    cfg.Endpoint,
+		headers:     cfg.Headers,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: exportTimeout},
+		logger:      logger,
+		queue:       make(chan spanData, exportQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (e *exporter) enqueue(data spanData) {
+	select {
+	case e.queue <- data:
+	default:
+		e.droppedMu.Lock()
+		e.dropped++
+		e.droppedMu.Unlock()
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]spanData, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		e.export(batch)
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case data := <-e.queue:
+			batch = append(batch, data)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+			e.reportDropped()
+		case <-e.stop:
+			for {
+				select {
+				case data := <-e.queue:
+					batch = append(batch, data)
+					if len(batch) >= exportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					e.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) shutdown(ctx context.Context) error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *exporter) reportDropped() {
+	e.droppedMu.Lock()
+	dropped := e.dropped
+	e.dropped = 0
+	e.droppedMu.Unlock()
+	if dropped > 0 {
+		e.logger.Warn("trace export queue full, spans dropped", "dropped", dropped)
+	}
+}
+
+func (e *exporter) export(batch []spanData) {
+	body, err := json.Marshal(buildExportRequest(e.serviceName, batch))
+	if err != nil {
+		e.logger.Warn("encode trace export failed", "error", err.Error())
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		e.logger.Warn("build trace export request failed", "error", err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		e.logger.Warn("trace export failed", "spans", len(batch), "error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		e.logger.Warn("trace export rejected", "spans", len(batch), "status", resp.StatusCode)
+	}
+}
+
+// 以下类型对应 OTLP/HTTP JSON 编码（ExportTraceServiceRequest）；按规范 trace/span id 用十六进制，64 位整数用字符串。
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func buildExportRequest(serviceName string, batch []spanData) otlpExportRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, data := range batch {
+		span := otlpSpan{
+			TraceID:           data.sc.TraceID.String(),
+			SpanID:            data.sc.SpanID.String(),
+			Name:              data.name,
+			Kind:              int(data.kind),
+			StartTimeUnixNano: strconv.FormatInt(data.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(data.end.UnixNano(), 10),
+		}
+		if data.parent != (SpanID{}) {
+			span.ParentSpanID = data.parent.String()
+		}
+		for _, attr := range data.attrs {
+			span.Attributes = append(span.Attributes, otlpKeyValue{Key: attr.Key, Value: toAnyValue(attr.Value)})
+		}
+		if data.failed {
+			span.Status = &otlpStatus{Code: statusCodeError, Message: truncate(data.errorMsg)}
+		}
+		spans = append(spans, span)
+	}
+	return otlpExportRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: toAnyValue(serviceName)},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: spans}},
+	}}}
+}
+
+func toAnyValue(v any) otlpAnyValue {
+	switch typed := v.(type) {
+	case string:
+		s := truncate(typed)
+		return otlpAnyValue{StringValue: &s}
+	case int64:
+		s := strconv.FormatInt(typed, 10)
+		return otlpAnyValue{IntValue: &s}
+	case bool:
+		return otlpAnyValue{BoolValue: &typed}
+	case float64:
+		if math.IsNaN(typed) || math.IsInf(typed, 0) {
+			s := strconv.FormatFloat(typed, 'g', -1, 64)
+			return otlpAnyValue{StringValue: &s}
+		}
+		return otlpAnyValue{DoubleValue: &typed}
+	default:
+		s := truncate(fmt.Sprint(typed))
+		return otlpAnyValue{StringValue: &s}
+	}
+}
+
+func truncate(v string) string {
+	if len(v) <= maxAttrValueBytes {
+		return v
+	}
+	cut := maxAttrValueBytes
+	for cut > 0 && v[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return v[:cut]
+}
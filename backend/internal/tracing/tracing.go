@@ -0,0 +1,293 @@
+// Package tracing 实现可选的链路追踪：W3C traceparent 传播、按比例采样，并以 OTLP/HTTP JSON 批量导出 span。
+// 未调用 Init（或未配置导出地址）时所有入口都是空操作，返回的 *Span 为 nil 且方法可安全调用。
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Kind int
+
+// 取值与 OTLP SpanKind 一致。
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext 是跨进程、跨 goroutine 传播的链路标识。
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key string, value string) Attr { return Attr{Key: key, Value: value} }
+
+func Int(key string, value int) Attr { return Attr{Key: key, Value: int64(value)} }
+
+func Int64(key string, value int64) Attr { return Attr{Key: key, Value: value} }
+
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+type Config struct {
+	// Endpoint 为 OTLP/HTTP traces 完整地址（如 http://otel-collector:4318/v1/traces），为空时不启用。
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	// SampleRatio 只作用于根 span，子 span 跟随父级的采样决定。
+	SampleRatio float64
+	Logger      *slog.Logger
+}
+
+type tracer struct {
+	exporter    *exporter
+	sampleRatio float64
+}
+
+var active atomic.Pointer[tracer]
+
+// Init 启用全局追踪并返回关闭函数；关闭时会导出缓冲中的 span。
+func Init(cfg Config) func(context.Context) error {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }
+	}
+	ratio := cfg.SampleRatio
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	exp := newExporter(cfg)
+	t := &tracer{exporter: exp, sampleRatio: ratio}
+	active.Store(t)
+	go exp.run()
+	return func(ctx context.Context) error {
+		active.CompareAndSwap(t, nil)
+		return exp.shutdown(ctx)
+	}
+}
+
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// shouldSample 基于 trace id 低 8 字节做确定性采样，同一条链路在各处的决定一致。
+func (t *tracer) shouldSample(id TraceID) bool {
+	switch {
+	case t.sampleRatio >= 1:
+		return true
+	case t.sampleRatio <= 0:
+		return false
+	}
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(id[8:])>>1 < bound
+}
+
+type spanContextKey struct{}
+
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// Detach 返回只携带链路标识的新 context，用于把请求内启动的后台任务挂到同一条链路上，
+// 而不继承请求的取消与超时。
+func Detach(ctx context.Context) context.Context {
+	return ContextWithSpanContext(context.Background(), SpanContextFromContext(ctx))
+}
+
+// TraceIDFromContext 返回 ctx 所在链路的 trace id，未追踪时为空字符串，便于写入日志。
+func TraceIDFromContext(ctx context.Context) string {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID.String()
+}
+
+type Span struct {
+	tracer *tracer
+	sc     SpanContext
+	parent SpanID
+	kind   Kind
+	start  time.Time
+
+	mu       sync.Mutex
+	name     string
+	attrs    []Attr
+	errorMsg string
+	failed   bool
+	ended    bool
+}
+
+// Start 创建 span；ctx 中已有链路时作为子 span，否则开启新链路并按比例采样。
+func Start(ctx context.Context, kind Kind, name string, attrs ...Attr) (context.Context, *Span) {
+	t := active.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	return t.start(ctx, SpanContextFromContext(ctx), kind, name, attrs)
+}
+
+// StartChild 只在 ctx 已处于某条链路时创建 span，适合数据库、外部 API 等底层调用，避免后台轮询产生大量孤立链路。
+func StartChild(ctx context.Context, kind Kind, name string, attrs ...Attr) (context.Context, *Span) {
+	t := active.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	parent := SpanContextFromContext(ctx)
+	if !parent.IsValid() {
+		return ctx, nil
+	}
+	return t.start(ctx, parent, kind, name, attrs)
+}
+
+func (t *tracer) start(ctx context.Context, parent SpanContext, kind Kind, name string, attrs []Attr) (context.Context, *Span) {
+	span := &Span{tracer: t, kind: kind, name: name, start: time.Now()}
+	span.sc.SpanID = newSpanID()
+	if parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+		span.sc.Sampled = t.shouldSample(span.sc.TraceID)
+	}
+	if len(attrs) > 0 {
+		span.attrs = append(span.attrs, attrs...)
+	}
+	return context.WithValue(ctx, spanContextKey{}, span.sc), span
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// RecordError 把 span 状态标记为错误；err 为 nil 时不做任何事。
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.SetError(errorMessage(err))
+}
+
+// errorMessage 对 *url.Error 只保留操作与底层错误：其文本包含完整请求地址，
+// 而 Telegram 等地址路径中带有 token，不能导出到采集端。
+func errorMessage(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Err != nil {
+		return urlErr.Op + ": " + errorMessage(urlErr.Err)
+	}
+	return err.Error()
+}
+
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.failed = true
+	s.errorMsg = message
+	s.mu.Unlock()
+}
+
+// End 结束 span 并交给导出器；重复调用只生效一次。
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := spanData{
+		sc:       s.sc,
+		parent:   s.parent,
+		kind:     s.kind,
+		name:     s.name,
+		start:    s.start,
+		end:      end,
+		attrs:    s.attrs,
+		failed:   s.failed,
+		errorMsg: s.errorMsg,
+	}
+	s.mu.Unlock()
+	if s.sc.Sampled {
+		s.tracer.exporter.enqueue(data)
+	}
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for id == (TraceID{}) {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for id == (SpanID{}) {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,206 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		raw         string
+		wantOK      bool
+		wantSampled bool
+	}{
+		{name: "sampled", raw: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantOK: true, wantSampled: true},
+		{name: "not sampled", raw: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", wantOK: true},
+		{name: "future version extra field", raw: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantOK: true, wantSampled: true},
+		{name: "version 00 extra field", raw: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+		{name: "invalid version", raw: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "zero trace id", raw: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", raw: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "upper case", raw: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "short", raw: "00-4bf92f35-00f067aa0ba902b7-01"},
+		{name: "empty", raw: ""},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			sc, ok := ParseTraceParent(tc.raw)
+			if ok != tc.wantOK {
+				t.Fatalf("ParseTraceParent(%q) ok = %v, want %v", tc.raw, ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if sc.Sampled != tc.wantSampled {
+				t.Fatalf("sampled = %v, want %v", sc.Sampled, tc.wantSampled)
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+				t.Fatalf("span context = %s/%s", sc.TraceID, sc.SpanID)
+			}
+		})
+	}
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	sc := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	parsed, ok := ParseTraceParent(sc.TraceParent())
+	if !ok || parsed != sc {
+		t.Fatalf("round trip = %+v (%v), want %+v", parsed, ok, sc)
+	}
+	if (SpanContext{}).TraceParent() != "" {
+		t.Fatalf("invalid span context should format as empty string")
+	}
+}
+
+func TestShouldSample(t *testing.T) {
+	t.Parallel()
+
+	low := TraceID{}
+	high := TraceID{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+	tests := []struct {
+		name  string
+		ratio float64
+		id    TraceID
+		want  bool
+	}{
+		{name: "always", ratio: 1, id: high, want: true},
+		{name: "never", ratio: 0, id: low, want: false},
+		{name: "half low", ratio: 0.5, id: low, want: true},
+		{name: "half high", ratio: 0.5, id: high, want: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tr := &tracer{sampleRatio: tc.ratio}
+			if got := tr.shouldSample(tc.id); got != tc.want {
+				t.Fatalf("shouldSample() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildExportRequest(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 5)
+	data := spanData{
+		sc:       SpanContext{TraceID: TraceID{15: 1}, SpanID: SpanID{7: 2}, Sampled: true},
+		parent:   SpanID{7: 3},
+		kind:     KindClient,
+		name:     "telegram getFile",
+		start:    start,
+		end:      start.Add(time.Second),
+		attrs:    []Attr{String("telegram.method", "getFile"), Int("telegram.error_code", 400), Bool("ok", false)},
+		failed:   true,
+		errorMsg: "telegram error_code=400",
+	}
+	body, err := json.Marshal(buildExportRequest("tgcd-test", []spanData{data}))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	want := `{"resourceSpans":[{"resource":{"attributes":[{"key":"service.name","value":{"stringValue":"tgcd-test"}}]},` +
+		`"scopeSpans":[{"scope":{"name":"tg-cloud-drive-api"},"spans":[{` +
+		`"traceId":"00000000000000000000000000000001","spanId":"0000000000000002","parentSpanId":"0000000000000003",` +
+		`"name":"telegram getFile","kind":3,"startTimeUnixNano":"1700000000000000005","endTimeUnixNano":"1700000001000000005",` +
+		`"attributes":[{"key":"telegram.method","value":{"stringValue":"getFile"}},{"key":"telegram.error_code","value":{"intValue":"400"}},{"key":"ok","value":{"boolValue":false}}],` +
+		`"status":{"code":2,"message":"telegram error_code=400"}}]}]}]}`
+	if string(body) != want {
+		t.Fatalf("export body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestRecordErrorOmitsRequestURL(t *testing.T) {
+	t.Parallel()
+
+	span := &Span{}
+	err := &url.Error{
+		Op:  "Post",
+		URL: "https://api.telegram.org/bot123456:secret-bot-token/getFile",
+		Err: errors.New("connection reset by peer"),
+	}
+	span.RecordError(fmt.Errorf("telegram getFile: %w", err))
+	if !span.failed || span.errorMsg != "Post: connection reset by peer" {
+		t.Fatalf("errorMsg = %q", span.errorMsg)
+	}
+	if strings.Contains(span.errorMsg, "secret-bot-token") {
+		t.Fatalf("errorMsg leaks token: %q", span.errorMsg)
+	}
+}
+
+// TestInitExportsSpans 会修改全局 tracer，因此不并行执行。
+func TestInitExportsSpans(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received otlpExportRequest
+		auth     string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		_ = json.Unmarshal(body, &received)
+	}))
+	defer srv.Close()
+
+	shutdown := Init(Config{Endpoint: srv.URL, Headers: map[string]string{"Authorization": "Bearer t"}, SampleRatio: 1})
+	if !Enabled() {
+		t.Fatalf("tracing should be enabled after Init")
+	}
+	if _, span := StartChild(context.Background(), KindClient, "orphan"); span != nil {
+		t.Fatalf("StartChild without a parent should not create a span")
+	}
+
+	remote, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := Start(ContextWithSpanContext(context.Background(), remote), KindServer, "GET /api/items")
+	_, child := StartChild(ctx, KindClient, "db SELECT")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	root.End()
+	root.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	if Enabled() {
+		t.Fatalf("tracing should be disabled after shutdown")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if auth != "Bearer t" {
+		t.Fatalf("Authorization = %q", auth)
+	}
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("received = %+v", received)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("exported spans = %d, want 2", len(spans))
+	}
+	dbSpan, httpSpan := spans[0], spans[1]
+	if httpSpan.TraceID != remote.TraceID.String() || httpSpan.ParentSpanID != remote.SpanID.String() {
+		t.Fatalf("server span should continue the remote trace: %+v", httpSpan)
+	}
+	if dbSpan.ParentSpanID != httpSpan.SpanID || dbSpan.Status == nil || dbSpan.Status.Message != "boom" {
+		t.Fatalf("db span = %+v", dbSpan)
+	}
+}